@@ -0,0 +1,184 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bojanz/currency"
+	"github.com/go-chi/chi"
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/balance"
+	"github.com/runbilliam/billiam/internal/customer"
+	"github.com/runbilliam/billiam/internal/dunning"
+	"github.com/runbilliam/billiam/internal/invoice"
+	"github.com/runbilliam/billiam/pkg/database"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+type balanceResponse struct {
+	Balances     []balance.Balance     `json:"balances"`
+	Transactions []balance.Transaction `json:"transactions"`
+}
+
+type createBalanceTransactionRequest struct {
+	// Amount is positive for credits, and negative for debits.
+	Amount struct {
+		// Number is a decimal number, e.g. "9.99".
+		Number   string `json:"number"`
+		Currency string `json:"currency"`
+	} `json:"amount"`
+	Description string `json:"description"`
+}
+
+type createPaymentRequest struct {
+	// Amount is a decimal number in the invoice currency, e.g. "9.99".
+	Amount string `json:"amount"`
+}
+
+// GetCustomerBalance returns the balances of a customer in each
+// currency, along with the transactions that made them.
+func (h *Handler) GetCustomerBalance(w http.ResponseWriter, r *http.Request) {
+	id, err := ulid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Customer not found."})
+		return
+	}
+	ctx := r.Context()
+	if _, err := customer.NewRepository(h.db).Get(ctx, id); err != nil {
+		if errors.Is(err, customer.ErrNotFound) {
+			h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Customer not found."})
+		} else {
+			h.handleError(w, err)
+		}
+		return
+	}
+	repo := balance.NewRepository(h.db)
+	balances, err := repo.List(ctx, id)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	transactions, err := repo.ListTransactions(ctx, id)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	if balances == nil {
+		balances = []balance.Balance{}
+	}
+	if transactions == nil {
+		transactions = []balance.Transaction{}
+	}
+	h.writeJSON(w, http.StatusOK, balanceResponse{balances, transactions})
+}
+
+// CreateBalanceTransaction manually credits or debits a customer's balance.
+//
+// Debits can't exceed the balance.
+func (h *Handler) CreateBalanceTransaction(w http.ResponseWriter, r *http.Request) {
+	id, err := ulid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Customer not found."})
+		return
+	}
+	var req createBalanceTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, errorResponse{"invalid_json", "The request body is not valid JSON."})
+		return
+	}
+	ctx := r.Context()
+	if _, err := customer.NewRepository(h.db).Get(ctx, id); err != nil {
+		if errors.Is(err, customer.ErrNotFound) {
+			h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Customer not found."})
+		} else {
+			h.handleError(w, err)
+		}
+		return
+	}
+
+	errs := validation.Errors{}
+	amount, err := currency.NewAmount(req.Amount.Number, req.Amount.Currency)
+	if err != nil {
+		errs.Add("amount", validation.InvalidValue("Invalid amount."))
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	t := balance.NewTransaction(id, balance.TransactionAdjustment, amount.Round())
+	t.Description = req.Description
+	if errs := t.Validate(); !errs.IsEmpty() {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	err = database.WithTx(ctx, h.db, func(tx pgx.Tx) error {
+		return balance.NewRepository(tx).Create(ctx, t)
+	})
+	switch {
+	case errors.Is(err, balance.ErrInsufficientBalance):
+		errs.Add("amount", validation.InvalidValue("Debit can't exceed the balance."))
+		h.writeValidationErrors(w, errs)
+	case err != nil:
+		h.handleError(w, err)
+	default:
+		h.writeJSON(w, http.StatusCreated, t)
+	}
+}
+
+// CreatePayment records a payment for an invoice received outside of
+// the gateways, such as a bank transfer, and marks the invoice as paid.
+//
+// Any amount above the amount due is credited to the customer's balance.
+func (h *Handler) CreatePayment(w http.ResponseWriter, r *http.Request) {
+	id, err := ulid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Invoice not found."})
+		return
+	}
+	var req createPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, errorResponse{"invalid_json", "The request body is not valid JSON."})
+		return
+	}
+
+	ctx := r.Context()
+	var inv invoice.Invoice
+	errs := validation.Errors{}
+	notPayable := false
+	err = database.WithTx(ctx, h.db, func(tx pgx.Tx) error {
+		inv, err = invoice.NewRepository(tx).GetForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !inv.Status.CanTransitionTo(invoice.StatusPaid) {
+			notPayable = true
+			return nil
+		}
+		amount, err := currency.NewAmount(req.Amount, inv.Currency)
+		if err != nil {
+			errs.Add("amount", validation.InvalidValue("Invalid amount."))
+			return nil
+		}
+		if _, errs = dunning.CheckPayment(inv, amount); !errs.IsEmpty() {
+			return nil
+		}
+		return dunning.RecordPayment(ctx, tx, &inv, amount, time.Now())
+	})
+	switch {
+	case errors.Is(err, invoice.ErrNotFound):
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Invoice not found."})
+	case err != nil:
+		h.handleError(w, err)
+	case notPayable:
+		h.writeJSON(w, http.StatusConflict, errorResponse{"invoice_not_payable", "Only open and uncollectible invoices can be paid."})
+	case !errs.IsEmpty():
+		h.writeValidationErrors(w, errs)
+	default:
+		h.writeJSON(w, http.StatusCreated, inv)
+	}
+}
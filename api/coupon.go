@@ -0,0 +1,210 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bojanz/currency"
+	"github.com/go-chi/chi"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/catalog"
+	"github.com/runbilliam/billiam/internal/coupon"
+	"github.com/runbilliam/billiam/internal/subscription"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+type createCouponRequest struct {
+	Code       string      `json:"code"`
+	Name       string      `json:"name"`
+	Type       coupon.Type `json:"type"`
+	PercentOff string      `json:"percent_off"`
+	AmountsOff []struct {
+		// Number is a decimal number, e.g. "9.99".
+		Number   string `json:"number"`
+		Currency string `json:"currency"`
+	} `json:"amounts_off"`
+	Duration        coupon.Duration `json:"duration"`
+	DurationPeriods int             `json:"duration_periods"`
+	MaxRedemptions  int             `json:"max_redemptions"`
+	ProductIDs      []string        `json:"product_ids"`
+	ExpiresAt       time.Time       `json:"expires_at"`
+}
+
+type redeemCouponRequest struct {
+	Code string `json:"code"`
+}
+
+// ListCoupons returns all coupons.
+func (h *Handler) ListCoupons(w http.ResponseWriter, r *http.Request) {
+	coupons, err := coupon.NewRepository(h.db).List(r.Context())
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	if coupons == nil {
+		coupons = []coupon.Coupon{}
+	}
+	h.writeJSON(w, http.StatusOK, coupons)
+}
+
+// CreateCoupon creates a coupon.
+func (h *Handler) CreateCoupon(w http.ResponseWriter, r *http.Request) {
+	var req createCouponRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, errorResponse{"invalid_json", "The request body is not valid JSON."})
+		return
+	}
+
+	ctx := r.Context()
+	c := coupon.New()
+	c.Code = req.Code
+	c.Name = req.Name
+	c.Type = req.Type
+	c.PercentOff = req.PercentOff
+	c.Duration = req.Duration
+	c.DurationPeriods = req.DurationPeriods
+	c.MaxRedemptions = req.MaxRedemptions
+	c.ExpiresAt = req.ExpiresAt.UTC()
+	errs := validation.Errors{}
+	for i, a := range req.AmountsOff {
+		amount, err := currency.NewAmount(a.Number, a.Currency)
+		if err != nil {
+			errs.Add("amounts_off."+strconv.Itoa(i), validation.InvalidValue("Invalid amount."))
+			continue
+		}
+		c.AmountsOff = append(c.AmountsOff, amount)
+	}
+	catalogRepo := catalog.NewRepository(h.db)
+	for i, productID := range req.ProductIDs {
+		path := "product_ids." + strconv.Itoa(i)
+		id, err := ulid.Parse(productID)
+		if err != nil {
+			errs.Add(path, validation.InvalidChoice("Invalid product."))
+			continue
+		}
+		if _, err := catalogRepo.GetProduct(ctx, id); err != nil {
+			if !errors.Is(err, catalog.ErrProductNotFound) {
+				h.handleError(w, err)
+				return
+			}
+			errs.Add(path, validation.InvalidChoice("Invalid product."))
+			continue
+		}
+		c.ProductIDs = append(c.ProductIDs, id)
+	}
+	if !errs.IsEmpty() {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	if errs := c.Validate(); !errs.IsEmpty() {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	if err := coupon.NewRepository(h.db).Create(ctx, c); err != nil {
+		if errors.Is(err, coupon.ErrCodeInUse) {
+			errs.Add("code", validation.NotUnique("Code is already in use."))
+			h.writeValidationErrors(w, errs)
+		} else {
+			h.handleError(w, err)
+		}
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, c)
+}
+
+// GetCoupon returns a coupon.
+func (h *Handler) GetCoupon(w http.ResponseWriter, r *http.Request) {
+	id, err := ulid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Coupon not found."})
+		return
+	}
+	c, err := coupon.NewRepository(h.db).Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, coupon.ErrNotFound) {
+			h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Coupon not found."})
+		} else {
+			h.handleError(w, err)
+		}
+		return
+	}
+	h.writeJSON(w, http.StatusOK, c)
+}
+
+// RedeemCoupon redeems a coupon code on a subscription.
+//
+// The discount is applied to the subscription's next invoices.
+func (h *Handler) RedeemCoupon(w http.ResponseWriter, r *http.Request) {
+	id, err := ulid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Subscription not found."})
+		return
+	}
+	var req redeemCouponRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, errorResponse{"invalid_json", "The request body is not valid JSON."})
+		return
+	}
+
+	ctx := r.Context()
+	sub, err := subscription.NewRepository(h.db).Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, subscription.ErrNotFound) {
+			h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Subscription not found."})
+		} else {
+			h.handleError(w, err)
+		}
+		return
+	}
+	if sub.Status == subscription.StatusCanceled {
+		h.writeJSON(w, http.StatusConflict, errorResponse{"subscription_canceled", "Canceled subscriptions can't be changed."})
+		return
+	}
+	errs := validation.Errors{}
+	if req.Code == "" {
+		errs.Add("code", validation.Required("Code is required."))
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	repo := coupon.NewRepository(h.db)
+	c, err := repo.GetByCode(ctx, req.Code)
+	if err != nil {
+		if errors.Is(err, coupon.ErrNotFound) {
+			errs.Add("code", validation.InvalidValue("Invalid coupon code."))
+			h.writeValidationErrors(w, errs)
+		} else {
+			h.handleError(w, err)
+		}
+		return
+	}
+	price, err := catalog.NewRepository(h.db).GetPrice(ctx, sub.PriceID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	if errs := c.CheckRedeemable(price.ProductID, price.Amount.CurrencyCode(), time.Now()); !errs.IsEmpty() {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+
+	rd := coupon.NewRedemption(c.ID, sub.CustomerID, sub.ID)
+	switch err := repo.Redeem(ctx, &c, rd); {
+	case errors.Is(err, coupon.ErrLimitReached):
+		errs.Add("code", validation.LimitReached("Coupon has been fully redeemed."))
+		h.writeValidationErrors(w, errs)
+	case errors.Is(err, coupon.ErrAlreadyRedeemed):
+		errs.Add("code", validation.NotUnique("Subscription already has a coupon."))
+		h.writeValidationErrors(w, errs)
+	case err != nil:
+		h.handleError(w, err)
+	default:
+		h.writeJSON(w, http.StatusCreated, rd)
+	}
+}
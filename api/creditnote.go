@@ -0,0 +1,171 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bojanz/currency"
+	"github.com/go-chi/chi"
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/creditnote"
+	"github.com/runbilliam/billiam/internal/invoice"
+	"github.com/runbilliam/billiam/pkg/database"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+type createCreditNoteRequest struct {
+	Method creditnote.Method `json:"method"`
+	Memo   string            `json:"memo"`
+	Lines  []struct {
+		InvoiceLineID string `json:"invoice_line_id"`
+		// Amount is a decimal number in the invoice currency, e.g. "9.99".
+		Amount string `json:"amount"`
+	} `json:"lines"`
+}
+
+// ListCreditNotes returns the credit notes of an invoice.
+func (h *Handler) ListCreditNotes(w http.ResponseWriter, r *http.Request) {
+	inv, ok := h.loadInvoice(w, r)
+	if !ok {
+		return
+	}
+	creditNotes, err := creditnote.NewRepository(h.db).ListByInvoice(r.Context(), inv.ID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	if creditNotes == nil {
+		creditNotes = []creditnote.CreditNote{}
+	}
+	h.writeJSON(w, http.StatusOK, creditNotes)
+}
+
+// CreateCreditNote issues a credit note for an invoice.
+func (h *Handler) CreateCreditNote(w http.ResponseWriter, r *http.Request) {
+	id, err := ulid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Invoice not found."})
+		return
+	}
+	var req createCreditNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, errorResponse{"invalid_json", "The request body is not valid JSON."})
+		return
+	}
+
+	ctx := r.Context()
+	var cn creditnote.CreditNote
+	errs := validation.Errors{}
+	err = database.WithTx(ctx, h.db, func(tx pgx.Tx) error {
+		inv, err := invoice.NewRepository(tx).GetForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		items := make([]creditnote.Item, 0, len(req.Lines))
+		for i, line := range req.Lines {
+			path := "lines." + strconv.Itoa(i)
+			var item creditnote.Item
+			if item.InvoiceLineID, err = ulid.Parse(line.InvoiceLineID); err != nil {
+				errs.Add(path+".invoice_line_id", validation.InvalidChoice("Invalid invoice line."))
+			}
+			if line.Amount != "" {
+				if item.Amount, err = currency.NewAmount(line.Amount, inv.Currency); err != nil {
+					errs.Add(path+".amount", validation.InvalidValue("Invalid amount."))
+				}
+			}
+			items = append(items, item)
+		}
+		if !errs.IsEmpty() {
+			return nil
+		}
+		repo := creditnote.NewRepository(tx)
+		previous, err := repo.ListByInvoice(ctx, inv.ID)
+		if err != nil {
+			return err
+		}
+		if cn, errs = creditnote.Build(inv, previous, req.Method, items); !errs.IsEmpty() {
+			return nil
+		}
+		cn.Memo = req.Memo
+		return creditnote.Issue(ctx, tx, &cn, time.Now())
+	})
+	switch {
+	case errors.Is(err, invoice.ErrNotFound):
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Invoice not found."})
+	case err != nil:
+		h.handleRefundError(w, err)
+	case !errs.IsEmpty():
+		h.writeValidationErrors(w, errs)
+	default:
+		h.refund(ctx, &cn)
+		h.writeJSON(w, http.StatusCreated, cn)
+	}
+}
+
+// handleRefundError writes the response for an error returned by creditnote.Issue.
+//
+// Invoices without a refundable payment are reported as conflicts,
+// other errors are handled by handleError.
+func (h *Handler) handleRefundError(w http.ResponseWriter, err error) {
+	if errors.Is(err, creditnote.ErrNotRefundable) {
+		h.writeJSON(w, http.StatusConflict, errorResponse{"not_refundable", "The invoice has no refundable payment."})
+		return
+	}
+	h.handleError(w, err)
+}
+
+// refund makes the pending refund of the given issued credit note.
+//
+// The credit note is already stored, so errors are only logged,
+// and the refund is left pending, to be retried by the worker.
+// The caller reports the refund status from the credit note.
+func (h *Handler) refund(ctx context.Context, cn *creditnote.CreditNote) {
+	if cn.RefundStatus != creditnote.RefundPending {
+		return
+	}
+	err := database.WithTx(ctx, h.db, func(tx pgx.Tx) error {
+		locked, err := creditnote.NewRepository(tx).GetForUpdate(ctx, cn.ID)
+		if err != nil {
+			return err
+		}
+		if err := creditnote.Refund(ctx, tx, h.gateways, &locked); err != nil {
+			return err
+		}
+		*cn = locked
+		return nil
+	})
+	if err != nil {
+		h.logger.Error().
+			Err(err).
+			Str("credit_note_id", cn.ID.String()).
+			Msg("Refund failed")
+	}
+}
+
+// GetCreditNote returns a credit note, including its lines.
+func (h *Handler) GetCreditNote(w http.ResponseWriter, r *http.Request) {
+	id, err := ulid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Credit note not found."})
+		return
+	}
+	cn, err := creditnote.NewRepository(h.db).Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, creditnote.ErrNotFound) {
+			h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Credit note not found."})
+		} else {
+			h.handleError(w, err)
+		}
+		return
+	}
+	h.writeJSON(w, http.StatusOK, cn)
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package api handles the JSON API.
+//
+// All routes require a logged in user, see auth.RequireUser.
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"github.com/rs/zerolog"
+
+	"github.com/runbilliam/billiam/internal/customer"
+	"github.com/runbilliam/billiam/internal/gateway"
+	"github.com/runbilliam/billiam/internal/invoice"
+	"github.com/runbilliam/billiam/internal/invoice/pdf"
+	"github.com/runbilliam/billiam/internal/settings"
+	"github.com/runbilliam/billiam/internal/subscription"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type validationErrorResponse struct {
+	Code    string                     `json:"code"`
+	Message string                     `json:"message"`
+	Errors  map[string][]errorResponse `json:"errors"`
+}
+
+// Handler handles API routes.
+type Handler struct {
+	logger   *zerolog.Logger
+	db       *pgxpool.Pool
+	gateways *gateway.Registry
+	renderer *pdf.Renderer
+}
+
+// NewHandler creates a new API handler.
+func NewHandler(logger *zerolog.Logger, db *pgxpool.Pool, gateways *gateway.Registry, renderer *pdf.Renderer) *Handler {
+	h := Handler{
+		logger:   logger,
+		db:       db,
+		gateways: gateways,
+		renderer: renderer,
+	}
+	return &h
+}
+
+// Routes attaches API routes to the router.
+func (h *Handler) Routes(r chi.Router) {
+	r.Get("/settings", h.GetSettings)
+	r.Post("/settings", h.UpdateSettings)
+	r.Get("/invoices/{id}", h.GetInvoice)
+	r.Get("/invoices/{id}/pdf", h.GetInvoicePDF)
+	r.Post("/invoices/{id}/payments", h.CreatePayment)
+	r.Get("/invoices/{id}/credit_notes", h.ListCreditNotes)
+	r.Post("/invoices/{id}/credit_notes", h.CreateCreditNote)
+	r.Get("/credit_notes/{id}", h.GetCreditNote)
+	r.Get("/coupons", h.ListCoupons)
+	r.Post("/coupons", h.CreateCoupon)
+	r.Get("/coupons/{id}", h.GetCoupon)
+	r.Get("/meters", h.ListMeters)
+	r.Post("/meters", h.CreateMeter)
+	r.Post("/usage_events", h.CreateUsageEvent)
+	r.Post("/subscriptions", h.CreateSubscription)
+	r.Get("/subscriptions/{id}", h.GetSubscription)
+	r.Post("/subscriptions/{id}/change", h.ChangeSubscription)
+	r.Post("/subscriptions/{id}/change/preview", h.PreviewSubscriptionChange)
+	r.Get("/subscriptions/{id}/quantity_changes", h.ListQuantityChanges)
+	r.Post("/subscriptions/{id}/pause", h.PauseSubscription)
+	r.Post("/subscriptions/{id}/resume", h.ResumeSubscription)
+	r.Post("/subscriptions/{id}/cancel", h.CancelSubscription)
+	r.Post("/subscriptions/{id}/reactivate", h.ReactivateSubscription)
+	r.Post("/subscriptions/{id}/schedule", h.CreateSubscriptionSchedule)
+	r.Delete("/subscriptions/{id}/schedule", h.CancelSubscriptionSchedule)
+	r.Post("/subscriptions/{id}/coupon", h.RedeemCoupon)
+	r.Get("/customers/{id}/events", h.ListCustomerEvents)
+	r.Get("/customers/{id}/balance", h.GetCustomerBalance)
+	r.Post("/customers/{id}/balance_transactions", h.CreateBalanceTransaction)
+	r.Get("/customers/{id}/payment_methods", h.ListPaymentMethods)
+	r.Post("/customers/{id}/payment_methods", h.CreatePaymentMethod)
+	r.Post("/customers/{id}/payment_methods/{method_id}/default", h.SetDefaultPaymentMethod)
+}
+
+// GetInvoice returns an invoice, including its lines.
+func (h *Handler) GetInvoice(w http.ResponseWriter, r *http.Request) {
+	inv, ok := h.loadInvoice(w, r)
+	if !ok {
+		return
+	}
+	h.writeJSON(w, http.StatusOK, inv)
+}
+
+// GetInvoicePDF returns a finalized invoice as a PDF document.
+func (h *Handler) GetInvoicePDF(w http.ResponseWriter, r *http.Request) {
+	inv, ok := h.loadInvoice(w, r)
+	if !ok {
+		return
+	}
+	if !inv.IsFinalized() {
+		h.writeJSON(w, http.StatusConflict, errorResponse{"invoice_draft", "Draft invoices can't be rendered."})
+		return
+	}
+	ctx := r.Context()
+	cust, err := customer.NewRepository(h.db).Get(ctx, inv.CustomerID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	st, err := settings.NewStore(h.db).Get(ctx)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	var buf bytes.Buffer
+	if err := h.renderer.Render(&buf, inv, cust, st); err != nil {
+		h.handleError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `inline; filename="`+inv.Number+`.pdf"`)
+	w.Write(buf.Bytes())
+}
+
+// loadInvoice loads the invoice identified by the "id" URL parameter.
+//
+// Writes an error response and returns false if the invoice could not be loaded.
+func (h *Handler) loadInvoice(w http.ResponseWriter, r *http.Request) (invoice.Invoice, bool) {
+	id, err := ulid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Invoice not found."})
+		return invoice.Invoice{}, false
+	}
+	inv, err := invoice.NewRepository(h.db).Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, invoice.ErrNotFound) {
+			h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Invoice not found."})
+		} else {
+			h.handleError(w, err)
+		}
+		return invoice.Invoice{}, false
+	}
+
+	return inv, true
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b)
+}
+
+// writeValidationErrors writes the given validation errors.
+func (h *Handler) writeValidationErrors(w http.ResponseWriter, errs validation.Errors) {
+	resp := validationErrorResponse{
+		Code:    "invalid_request",
+		Message: "The request is invalid.",
+		Errors:  make(map[string][]errorResponse, len(errs)),
+	}
+	for path, pathErrs := range errs {
+		for _, err := range pathErrs {
+			e := errorResponse{Code: validation.CodeInvalidValue, Message: err.Error()}
+			if verr, ok := err.(validation.Error); ok {
+				e.Code = verr.Code
+			}
+			resp.Errors[path] = append(resp.Errors[path], e)
+		}
+	}
+	h.writeJSON(w, http.StatusUnprocessableEntity, resp)
+}
+
+// handleError writes the response for an unexpected error.
+//
+// Subscriptions modified by another request in the meantime result
+// in a conflict, allowing the client to reload and retry.
+func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	var conflictErr *subscription.ConflictError
+	if errors.As(err, &conflictErr) {
+		h.writeJSON(w, http.StatusConflict, errorResponse{"conflict", "The subscription was modified in the meantime."})
+		return
+	}
+	h.logger.Error().Msg(err.Error())
+	http.Error(w, "Internal Server Error", 500)
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/customer"
+	"github.com/runbilliam/billiam/internal/meter"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+type createMeterRequest struct {
+	Key         string            `json:"key"`
+	Name        string            `json:"name"`
+	Aggregation meter.Aggregation `json:"aggregation"`
+}
+
+type createUsageEventRequest struct {
+	CustomerID string `json:"customer_id"`
+	// Meter is the meter key, e.g. "api_calls".
+	Meter    string `json:"meter"`
+	Quantity int64  `json:"quantity"`
+	// Timestamp defaults to the current time.
+	Timestamp      time.Time `json:"timestamp"`
+	IdempotencyKey string    `json:"idempotency_key"`
+}
+
+// ListMeters returns all meters.
+func (h *Handler) ListMeters(w http.ResponseWriter, r *http.Request) {
+	meters, err := meter.NewRepository(h.db).List(r.Context())
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	if meters == nil {
+		meters = []meter.Meter{}
+	}
+	h.writeJSON(w, http.StatusOK, meters)
+}
+
+// CreateMeter creates a meter.
+func (h *Handler) CreateMeter(w http.ResponseWriter, r *http.Request) {
+	var req createMeterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, errorResponse{"invalid_json", "The request body is not valid JSON."})
+		return
+	}
+	m := meter.New()
+	m.Key = req.Key
+	m.Name = req.Name
+	m.Aggregation = req.Aggregation
+	if errs := m.Validate(); !errs.IsEmpty() {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	if err := meter.NewRepository(h.db).Create(r.Context(), m); err != nil {
+		if errors.Is(err, meter.ErrKeyInUse) {
+			errs := validation.Errors{}
+			errs.Add("key", validation.NotUnique("Key is already in use."))
+			h.writeValidationErrors(w, errs)
+		} else {
+			h.handleError(w, err)
+		}
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, m)
+}
+
+// CreateUsageEvent records a usage event.
+//
+// Events are deduplicated by idempotency key: reporting an event again
+// returns the previously stored event with a 200 status, instead of 201.
+func (h *Handler) CreateUsageEvent(w http.ResponseWriter, r *http.Request) {
+	var req createUsageEventRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, errorResponse{"invalid_json", "The request body is not valid JSON."})
+		return
+	}
+	errs := validation.Errors{}
+	customerID, err := ulid.Parse(req.CustomerID)
+	if err != nil {
+		errs.Add("customer_id", validation.InvalidChoice("Invalid customer."))
+	}
+	if req.Meter == "" {
+		errs.Add("meter", validation.Required("Meter is required."))
+	}
+	if !errs.IsEmpty() {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+
+	ctx := r.Context()
+	if _, err := customer.NewRepository(h.db).Get(ctx, customerID); err != nil {
+		if !errors.Is(err, customer.ErrNotFound) {
+			h.handleError(w, err)
+			return
+		}
+		errs.Add("customer_id", validation.InvalidChoice("Invalid customer."))
+	}
+	repo := meter.NewRepository(h.db)
+	m, err := repo.GetByKey(ctx, req.Meter)
+	if err != nil {
+		if !errors.Is(err, meter.ErrNotFound) {
+			h.handleError(w, err)
+			return
+		}
+		errs.Add("meter", validation.InvalidChoice("Invalid meter."))
+	}
+	if !errs.IsEmpty() {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	now := time.Now()
+	timestamp := req.Timestamp
+	if timestamp.IsZero() {
+		timestamp = now
+	}
+	e := meter.NewEvent(m.ID, customerID, req.Quantity, timestamp, req.IdempotencyKey)
+	if errs := e.Validate(now); !errs.IsEmpty() {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	stored, err := repo.Ingest(ctx, &e)
+	if err != nil {
+		if errors.Is(err, meter.ErrPeriodInvoiced) {
+			errs.Add("timestamp", validation.Expired("Timestamp falls into an already invoiced period."))
+			h.writeValidationErrors(w, errs)
+		} else {
+			h.handleError(w, err)
+		}
+		return
+	}
+	if stored {
+		h.writeJSON(w, http.StatusCreated, e)
+	} else {
+		h.writeJSON(w, http.StatusOK, e)
+	}
+}
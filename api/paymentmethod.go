@@ -0,0 +1,134 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/customer"
+	"github.com/runbilliam/billiam/internal/gateway"
+	"github.com/runbilliam/billiam/internal/payment"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+type createPaymentMethodRequest struct {
+	// Token is the gateway specific payment method token, usually
+	// created by the gateway's JavaScript library.
+	Token string `json:"token"`
+}
+
+// ListPaymentMethods returns the payment methods of a customer.
+func (h *Handler) ListPaymentMethods(w http.ResponseWriter, r *http.Request) {
+	cust, ok := h.loadCustomer(w, r)
+	if !ok {
+		return
+	}
+	methods, err := payment.NewRepository(h.db).ListMethods(r.Context(), cust.ID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	if methods == nil {
+		methods = []payment.Method{}
+	}
+	h.writeJSON(w, http.StatusOK, methods)
+}
+
+// CreatePaymentMethod saves a payment method token with the default
+// gateway, and makes it the customer's default payment method.
+func (h *Handler) CreatePaymentMethod(w http.ResponseWriter, r *http.Request) {
+	cust, ok := h.loadCustomer(w, r)
+	if !ok {
+		return
+	}
+	var req createPaymentMethodRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, errorResponse{"invalid_json", "The request body is not valid JSON."})
+		return
+	}
+	if req.Token == "" {
+		errs := validation.Errors{}
+		errs.Add("token", validation.Required("Token is required."))
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	m, err := payment.SaveMethod(r.Context(), h.db, h.gateways, cust, req.Token)
+	if err != nil {
+		h.handlePaymentMethodError(w, err, "token")
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, m)
+}
+
+// SetDefaultPaymentMethod makes a payment method the customer's default.
+func (h *Handler) SetDefaultPaymentMethod(w http.ResponseWriter, r *http.Request) {
+	cust, ok := h.loadCustomer(w, r)
+	if !ok {
+		return
+	}
+	id, err := ulid.Parse(chi.URLParam(r, "method_id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Payment method not found."})
+		return
+	}
+	ctx := r.Context()
+	repo := payment.NewRepository(h.db)
+	if err := repo.SetDefaultMethod(ctx, cust.ID, id); err != nil {
+		if errors.Is(err, payment.ErrNotFound) {
+			h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Payment method not found."})
+		} else {
+			h.handleError(w, err)
+		}
+		return
+	}
+	m, err := repo.GetMethod(ctx, id)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, m)
+}
+
+// loadCustomer loads the customer identified by the "id" URL parameter.
+//
+// Writes an error response and returns false if the customer could not be loaded.
+func (h *Handler) loadCustomer(w http.ResponseWriter, r *http.Request) (customer.Customer, bool) {
+	id, err := ulid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Customer not found."})
+		return customer.Customer{}, false
+	}
+	cust, err := customer.NewRepository(h.db).Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, customer.ErrNotFound) {
+			h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Customer not found."})
+		} else {
+			h.handleError(w, err)
+		}
+		return customer.Customer{}, false
+	}
+
+	return cust, true
+}
+
+// handlePaymentMethodError writes the response for an error returned
+// by payment.SaveMethod. Declined tokens are reported under the given path.
+func (h *Handler) handlePaymentMethodError(w http.ResponseWriter, err error, path string) {
+	var declineErr *gateway.DeclineError
+	switch {
+	case errors.As(err, &declineErr):
+		errs := validation.Errors{}
+		errs.Add(path, validation.InvalidValue(declineErr.Message))
+		h.writeValidationErrors(w, errs)
+	case errors.Is(err, gateway.ErrNotFound):
+		h.writeJSON(w, http.StatusConflict, errorResponse{"no_gateway", "No payment gateway is enabled."})
+	default:
+		h.handleError(w, err)
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/catalog"
+	"github.com/runbilliam/billiam/internal/coupon"
+	"github.com/runbilliam/billiam/internal/customer"
+	"github.com/runbilliam/billiam/internal/period"
+	"github.com/runbilliam/billiam/internal/schedule"
+	"github.com/runbilliam/billiam/internal/settings"
+	"github.com/runbilliam/billiam/internal/subscription"
+	"github.com/runbilliam/billiam/pkg/database"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+type createScheduleRequest struct {
+	Phases []schedulePhaseRequest `json:"phases"`
+}
+
+type schedulePhaseRequest struct {
+	PriceID string `json:"price_id"`
+	// Quantity defaults to the price's minimum quantity, or 1.
+	Quantity *int64 `json:"quantity"`
+	// CouponCode is optional.
+	CouponCode string `json:"coupon_code"`
+	// Iterations is the number of billing periods the phase lasts.
+	// Must be omitted for the last phase.
+	Iterations int `json:"iterations"`
+}
+
+// CreateSubscriptionSchedule creates a schedule for a subscription.
+//
+// The first phase starts at the end of the current period, and each
+// phase is applied as its period starts, see invoice.Generator.
+func (h *Handler) CreateSubscriptionSchedule(w http.ResponseWriter, r *http.Request) {
+	id, err := ulid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Subscription not found."})
+		return
+	}
+	var req createScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, errorResponse{"invalid_json", "The request body is not valid JSON."})
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now()
+	var s schedule.Schedule
+	errs := validation.Errors{}
+	notFound, canceled, scheduled := false, false, false
+	err = database.WithTx(ctx, h.db, func(tx pgx.Tx) error {
+		sub, err := subscription.NewRepository(tx).Get(ctx, id)
+		if errors.Is(err, subscription.ErrNotFound) {
+			notFound = true
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if sub.Status == subscription.StatusCanceled {
+			canceled = true
+			return nil
+		}
+		current, err := catalog.NewRepository(tx).GetPrice(ctx, sub.PriceID)
+		if err != nil {
+			return err
+		}
+		s = schedule.New(sub.ID)
+		for i, pr := range req.Phases {
+			phase, err := loadPhase(ctx, tx, pr, current, "phases."+strconv.Itoa(i), errs, now)
+			if err != nil {
+				return err
+			}
+			s.Phases = append(s.Phases, phase)
+		}
+		errs.Merge("", s.Validate())
+		if !errs.IsEmpty() {
+			return nil
+		}
+		cust, err := customer.NewRepository(tx).Get(ctx, sub.CustomerID)
+		if err != nil {
+			return err
+		}
+		st, err := settings.NewStore(tx).Get(ctx)
+		if errors.Is(err, settings.ErrNotFound) {
+			st = settings.New()
+		} else if err != nil {
+			return err
+		}
+		loc, err := period.Location(cust.Timezone, st.Timezone)
+		if err != nil {
+			return err
+		}
+		s.SetStartDates(sub.Schedule(current.Interval, loc), sub.CurrentPeriodEnd)
+
+		err = schedule.NewRepository(tx).Create(ctx, s)
+		if errors.Is(err, schedule.ErrAlreadyScheduled) {
+			scheduled = true
+			return nil
+		}
+		return err
+	})
+	switch {
+	case err != nil:
+		h.handleError(w, err)
+	case notFound:
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Subscription not found."})
+	case canceled:
+		h.writeJSON(w, http.StatusConflict, errorResponse{"subscription_canceled", "Canceled subscriptions can't be scheduled."})
+	case scheduled:
+		h.writeJSON(w, http.StatusConflict, errorResponse{"already_scheduled", "Subscription already has a schedule."})
+	case !errs.IsEmpty():
+		h.writeValidationErrors(w, errs)
+	default:
+		h.writeJSON(w, http.StatusCreated, s)
+	}
+}
+
+// loadPhase parses and validates the given schedule phase.
+//
+// The phase's price must match the currency and interval of the
+// subscription's current price. Validation errors are added to errs,
+// prefixed by the given path.
+func loadPhase(ctx context.Context, tx pgx.Tx, pr schedulePhaseRequest, current catalog.Price, path string, errs validation.Errors, now time.Time) (schedule.Phase, error) {
+	phase := schedule.Phase{Iterations: pr.Iterations}
+	priceID, err := ulid.Parse(pr.PriceID)
+	if err != nil {
+		errs.Add(path+".price_id", validation.InvalidChoice("Invalid price."))
+		return phase, nil
+	}
+	catalogRepo := catalog.NewRepository(tx)
+	price, err := catalogRepo.GetPrice(ctx, priceID)
+	if errors.Is(err, catalog.ErrPriceNotFound) || price.IsArchived() || price.IsMetered() {
+		errs.Add(path+".price_id", validation.InvalidChoice("Invalid price."))
+		return phase, nil
+	} else if err != nil {
+		return phase, err
+	}
+	if price.Amount.CurrencyCode() != current.Amount.CurrencyCode() || price.Interval != current.Interval {
+		errs.Add(path+".price_id", validation.InvalidValue("Price must match the currency and interval of the current price."))
+	}
+	phase.PriceID = price.ID
+	phase.Quantity = price.MinQuantity
+	if pr.Quantity != nil {
+		phase.Quantity = *pr.Quantity
+	} else if phase.Quantity < 1 {
+		phase.Quantity = 1
+	}
+	errs.Merge(path, price.CheckQuantity(phase.Quantity))
+
+	if pr.CouponCode != "" {
+		c, err := coupon.NewRepository(tx).GetByCode(ctx, pr.CouponCode)
+		if errors.Is(err, coupon.ErrNotFound) {
+			errs.Add(path+".coupon_code", validation.InvalidValue("Invalid coupon code."))
+			return phase, nil
+		} else if err != nil {
+			return phase, err
+		}
+		for _, err := range c.CheckRedeemable(price.ProductID, price.Amount.CurrencyCode(), now)["code"] {
+			errs.Add(path+".coupon_code", err)
+		}
+		phase.CouponID = c.ID
+	}
+
+	return phase, nil
+}
+
+// CancelSubscriptionSchedule cancels the active schedule of a subscription.
+//
+// Phases which were already applied are kept.
+func (h *Handler) CancelSubscriptionSchedule(w http.ResponseWriter, r *http.Request) {
+	id, err := ulid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Schedule not found."})
+		return
+	}
+
+	ctx := r.Context()
+	repo := schedule.NewRepository(h.db)
+	s, err := repo.GetActive(ctx, id)
+	if err != nil {
+		if errors.Is(err, schedule.ErrNotFound) {
+			h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Schedule not found."})
+		} else {
+			h.handleError(w, err)
+		}
+		return
+	}
+	s.Cancel(time.Now())
+	if err := repo.Update(ctx, &s); err != nil {
+		h.handleError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, s)
+}
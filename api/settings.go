@@ -0,0 +1,93 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/runbilliam/billiam/internal/sequence"
+	"github.com/runbilliam/billiam/internal/settings"
+	"github.com/runbilliam/billiam/internal/subscription"
+)
+
+type updateSettingsRequest struct {
+	// Version must match the version of the current settings.
+	Version int `json:"version"`
+	// Fields which are not set keep their current values.
+	InvoiceNumberPattern    string              `json:"invoice_number_pattern"`
+	InvoiceNumberReset      sequence.Reset      `json:"invoice_number_reset"`
+	CreditNoteNumberPattern string              `json:"credit_note_number_pattern"`
+	CreditNoteNumberReset   sequence.Reset      `json:"credit_note_number_reset"`
+	DunningSchedule         []int               `json:"dunning_schedule"`
+	DunningFinalStatus      subscription.Status `json:"dunning_final_status"`
+}
+
+// GetSettings returns the settings.
+func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	st, err := settings.NewStore(h.db).Get(r.Context())
+	if err != nil {
+		if errors.Is(err, settings.ErrNotFound) {
+			h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Settings not found."})
+		} else {
+			h.handleError(w, err)
+		}
+		return
+	}
+	h.writeJSON(w, http.StatusOK, st)
+}
+
+// UpdateSettings updates the settings.
+func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	var req updateSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, errorResponse{"invalid_json", "The request body is not valid JSON."})
+		return
+	}
+	ctx := r.Context()
+	store := settings.NewStore(h.db)
+	st, err := store.Get(ctx)
+	if err != nil {
+		if errors.Is(err, settings.ErrNotFound) {
+			h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Settings not found."})
+		} else {
+			h.handleError(w, err)
+		}
+		return
+	}
+	st.Version = req.Version
+	if req.InvoiceNumberPattern != "" {
+		st.InvoiceNumberPattern = req.InvoiceNumberPattern
+	}
+	if req.InvoiceNumberReset != "" {
+		st.InvoiceNumberReset = req.InvoiceNumberReset
+	}
+	if req.CreditNoteNumberPattern != "" {
+		st.CreditNoteNumberPattern = req.CreditNoteNumberPattern
+	}
+	if req.CreditNoteNumberReset != "" {
+		st.CreditNoteNumberReset = req.CreditNoteNumberReset
+	}
+	if req.DunningSchedule != nil {
+		st.DunningSchedule = req.DunningSchedule
+	}
+	if req.DunningFinalStatus != "" {
+		st.DunningFinalStatus = req.DunningFinalStatus
+	}
+	if errs := st.Validate(); !errs.IsEmpty() {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	if err := store.Update(ctx, &st); err != nil {
+		var conflictErr *settings.ConflictError
+		if errors.As(err, &conflictErr) {
+			h.writeJSON(w, http.StatusConflict, errorResponse{"conflict", "The settings were modified in the meantime."})
+		} else {
+			h.handleError(w, err)
+		}
+		return
+	}
+	h.writeJSON(w, http.StatusOK, st)
+}
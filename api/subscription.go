@@ -0,0 +1,872 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/auth"
+	"github.com/runbilliam/billiam/internal/catalog"
+	"github.com/runbilliam/billiam/internal/creditnote"
+	"github.com/runbilliam/billiam/internal/customer"
+	"github.com/runbilliam/billiam/internal/event"
+	"github.com/runbilliam/billiam/internal/gateway"
+	"github.com/runbilliam/billiam/internal/invoice"
+	"github.com/runbilliam/billiam/internal/payment"
+	"github.com/runbilliam/billiam/internal/period"
+	"github.com/runbilliam/billiam/internal/schedule"
+	"github.com/runbilliam/billiam/internal/settings"
+	"github.com/runbilliam/billiam/internal/subscription"
+	"github.com/runbilliam/billiam/pkg/database"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+// maxEvents is the maximum number of listed events.
+const maxEvents = 100
+
+type createSubscriptionRequest struct {
+	CustomerID string `json:"customer_id"`
+	PriceID    string `json:"price_id"`
+	// TrialDays overrides the price's trial days, if set.
+	// Use 0 to start the subscription without a trial.
+	TrialDays *int `json:"trial_days"`
+	// Quantity defaults to the price's minimum quantity, or 1.
+	Quantity *int64 `json:"quantity"`
+	// MeteredPriceIDs lists the metered prices whose usage is billed
+	// in arrears, at the end of each period.
+	MeteredPriceIDs []string `json:"metered_price_ids"`
+	// PaymentMethod is the ID of one of the customer's payment methods,
+	// or a gateway token to save, see CreatePaymentMethod. It becomes
+	// the customer's default payment method, charged once the trial ends.
+	PaymentMethod string `json:"payment_method"`
+}
+
+type changeSubscriptionRequest struct {
+	// PriceID and Quantity default to the current ones.
+	PriceID  string `json:"price_id"`
+	Quantity *int64 `json:"quantity"`
+	// Proration defaults to next_invoice.
+	Proration invoice.ProrationBehavior `json:"proration"`
+	// ProrationDate defaults to the current time. Pass the date
+	// returned by the preview to get the previewed amounts.
+	ProrationDate time.Time `json:"proration_date"`
+}
+
+type pauseSubscriptionRequest struct {
+	// Behavior defaults to void.
+	Behavior subscription.PauseBehavior `json:"behavior"`
+	// ResumesOn is the date on which the subscription is resumed
+	// automatically, at midnight in the customer's timezone.
+	// The subscription is paused indefinitely if empty.
+	ResumesOn string `json:"resumes_on"`
+	// ResumeBehavior defaults to keep_cycle.
+	ResumeBehavior subscription.ResumeBehavior `json:"resume_behavior"`
+}
+
+type resumeSubscriptionRequest struct {
+	// Behavior defaults to the one chosen when pausing.
+	Behavior subscription.ResumeBehavior `json:"behavior"`
+}
+
+// Cancellation modes.
+const (
+	cancelImmediately = "immediately"
+	cancelAtPeriodEnd = "at_period_end"
+)
+
+type cancelSubscriptionRequest struct {
+	// Mode is either "immediately" or "at_period_end", the default.
+	Mode     string                    `json:"mode"`
+	Reason   subscription.CancelReason `json:"reason"`
+	Feedback string                    `json:"feedback"`
+	// Refund credits the unused time of the current period.
+	// Only available for immediate cancellations.
+	Refund bool `json:"refund"`
+	// RefundMethod defaults to refund.
+	RefundMethod creditnote.Method `json:"refund_method"`
+}
+
+type cancelSubscriptionResponse struct {
+	Subscription subscription.Subscription `json:"subscription"`
+	// Invoice is set if a final invoice was generated.
+	Invoice *invoice.Invoice `json:"invoice"`
+	// CreditNote is set if the unused time was refunded.
+	CreditNote *creditnote.CreditNote `json:"credit_note"`
+}
+
+type subscriptionResponse struct {
+	subscription.Subscription
+	// Schedule is the subscription's pending schedule, if any.
+	Schedule *schedule.Schedule `json:"schedule"`
+}
+
+type subscriptionChangePreview struct {
+	ProrationDate time.Time `json:"proration_date"`
+	// Invoice is an unsaved draft containing the proration lines.
+	Invoice invoice.Invoice `json:"invoice"`
+}
+
+type subscriptionChangeResponse struct {
+	Subscription subscription.Subscription `json:"subscription"`
+	// Invoice is set if the proration was invoiced immediately.
+	Invoice *invoice.Invoice `json:"invoice"`
+}
+
+// CreateSubscription creates a subscription.
+//
+// Subscriptions with a trial start out as trialing, and are converted
+// at the end of the trial if the customer has a payment method, see
+// invoice.Generator. Other subscriptions
+// start out as active, and their first period is invoiced immediately.
+func (h *Handler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var req createSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, errorResponse{"invalid_json", "The request body is not valid JSON."})
+		return
+	}
+	errs := validation.Errors{}
+	customerID, err := ulid.Parse(req.CustomerID)
+	if err != nil {
+		errs.Add("customer_id", validation.InvalidChoice("Invalid customer."))
+	}
+	priceID, err := ulid.Parse(req.PriceID)
+	if err != nil {
+		errs.Add("price_id", validation.InvalidChoice("Invalid price."))
+	}
+	if !errs.IsEmpty() {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now()
+	var sub subscription.Subscription
+	err = database.WithTx(ctx, h.db, func(tx pgx.Tx) error {
+		cust, err := customer.NewRepository(tx).Get(ctx, customerID)
+		if errors.Is(err, customer.ErrNotFound) {
+			errs.Add("customer_id", validation.InvalidChoice("Invalid customer."))
+			return nil
+		} else if err != nil {
+			return err
+		}
+		price, err := catalog.NewRepository(tx).GetPrice(ctx, priceID)
+		if errors.Is(err, catalog.ErrPriceNotFound) || price.IsArchived() {
+			errs.Add("price_id", validation.InvalidChoice("Invalid price."))
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if price.IsMetered() {
+			errs.Add("price_id", validation.InvalidChoice("Metered prices must be listed in metered_price_ids."))
+		}
+		quantity := price.MinQuantity
+		if req.Quantity != nil {
+			quantity = *req.Quantity
+		} else if quantity < 1 {
+			quantity = 1
+		}
+		errs.Merge("", price.CheckQuantity(quantity))
+		if cust.Currency != "" && cust.Currency != price.Amount.CurrencyCode() {
+			errs.Add("price_id", validation.InvalidValue("Price currency must match the customer currency."))
+		}
+		meteredPriceIDs, err := loadMeteredPrices(ctx, tx, req.MeteredPriceIDs, price, errs)
+		if err != nil {
+			return err
+		}
+		trialDays := price.TrialDays
+		if req.TrialDays != nil {
+			trialDays = *req.TrialDays
+		}
+		if trialDays < 0 || trialDays > catalog.MaxTrialDays {
+			errs.Add("trial_days", validation.InvalidValue(fmt.Sprintf("Trial days must be between 0 and %d.", catalog.MaxTrialDays)))
+		}
+		if !errs.IsEmpty() {
+			return nil
+		}
+		if req.PaymentMethod != "" {
+			if err := setPaymentMethod(ctx, tx, h.gateways, cust, req.PaymentMethod, errs); err != nil || !errs.IsEmpty() {
+				return err
+			}
+		}
+		st, err := settings.NewStore(tx).Get(ctx)
+		if errors.Is(err, settings.ErrNotFound) {
+			st = settings.New()
+		} else if err != nil {
+			return err
+		}
+		loc, err := period.Location(cust.Timezone, st.Timezone)
+		if err != nil {
+			return err
+		}
+
+		if trialDays > 0 {
+			sub = subscription.New(cust.ID, price.ID, subscription.StatusTrialing)
+			sub.StartTrial(now, trialDays, loc)
+		} else {
+			sub = subscription.New(cust.ID, price.ID, subscription.StatusActive)
+			sub.SetSchedule(period.NewSchedule(now, price.Interval, loc), now)
+		}
+		sub.Quantity = quantity
+		sub.MeteredPriceIDs = meteredPriceIDs
+		if errs := sub.Validate(); !errs.IsEmpty() {
+			return fmt.Errorf("invalid subscription: %v", errs)
+		}
+		if err := subscription.NewRepository(tx).Create(ctx, &sub); err != nil {
+			return err
+		}
+		if sub.Status == subscription.StatusActive {
+			_, err = invoice.InvoiceCurrentPeriod(ctx, tx, sub, now)
+		}
+		return err
+	})
+	switch {
+	case err != nil:
+		h.handleError(w, err)
+	case !errs.IsEmpty():
+		h.writeValidationErrors(w, errs)
+	default:
+		h.writeJSON(w, http.StatusCreated, sub)
+	}
+}
+
+// loadMeteredPrices parses and validates the given metered price IDs.
+//
+// Metered prices must match the currency and interval of the
+// subscription's price. Validation errors are added to errs.
+func loadMeteredPrices(ctx context.Context, tx pgx.Tx, ids []string, price catalog.Price, errs validation.Errors) ([]ulid.ULID, error) {
+	var meteredPriceIDs []ulid.ULID
+	catalogRepo := catalog.NewRepository(tx)
+	for i, priceID := range ids {
+		path := "metered_price_ids." + strconv.Itoa(i)
+		id, err := ulid.Parse(priceID)
+		if err != nil {
+			errs.Add(path, validation.InvalidChoice("Invalid price."))
+			continue
+		}
+		metered, err := catalogRepo.GetPrice(ctx, id)
+		if errors.Is(err, catalog.ErrPriceNotFound) || metered.IsArchived() || !metered.IsMetered() {
+			errs.Add(path, validation.InvalidChoice("Invalid price."))
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if metered.Amount.CurrencyCode() != price.Amount.CurrencyCode() || metered.Interval != price.Interval {
+			errs.Add(path, validation.InvalidValue("Metered prices must match the currency and interval of the price."))
+			continue
+		}
+		meteredPriceIDs = append(meteredPriceIDs, id)
+	}
+
+	return meteredPriceIDs, nil
+}
+
+// setPaymentMethod makes the given payment method the customer's default.
+//
+// The payment method is either the ID of one of the customer's payment
+// methods, or a token saved with the default gateway.
+// Validation errors are added to errs.
+func setPaymentMethod(ctx context.Context, tx pgx.Tx, gateways *gateway.Registry, cust customer.Customer, paymentMethod string, errs validation.Errors) error {
+	if id, err := ulid.Parse(paymentMethod); err == nil {
+		err = payment.NewRepository(tx).SetDefaultMethod(ctx, cust.ID, id)
+		if errors.Is(err, payment.ErrNotFound) {
+			errs.Add("payment_method", validation.InvalidChoice("Invalid payment method."))
+			return nil
+		}
+		return err
+	}
+	_, err := payment.SaveMethod(ctx, tx, gateways, cust, paymentMethod)
+	var declineErr *gateway.DeclineError
+	switch {
+	case errors.As(err, &declineErr):
+		errs.Add("payment_method", validation.InvalidValue(declineErr.Message))
+	case errors.Is(err, gateway.ErrNotFound):
+		errs.Add("payment_method", validation.InvalidValue("No payment gateway is enabled."))
+	default:
+		return err
+	}
+
+	return nil
+}
+
+// GetSubscription returns a subscription, along with its pending schedule.
+func (h *Handler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := ulid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Subscription not found."})
+		return
+	}
+	ctx := r.Context()
+	sub, err := subscription.NewRepository(h.db).Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, subscription.ErrNotFound) {
+			h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Subscription not found."})
+		} else {
+			h.handleError(w, err)
+		}
+		return
+	}
+	resp := subscriptionResponse{Subscription: sub}
+	s, err := schedule.NewRepository(h.db).GetActive(ctx, sub.ID)
+	if err == nil {
+		resp.Schedule = &s
+	} else if !errors.Is(err, schedule.ErrNotFound) {
+		h.handleError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+// ChangeSubscription changes the price or quantity of a subscription mid-period.
+//
+// The change is prorated to the second, see invoice.ApplyChange.
+// Quantity changes are recorded along with the current user.
+func (h *Handler) ChangeSubscription(w http.ResponseWriter, r *http.Request) {
+	h.changeSubscription(w, r, false)
+}
+
+// PreviewSubscriptionChange returns the proration for changing the
+// price or quantity of a subscription, without changing it.
+func (h *Handler) PreviewSubscriptionChange(w http.ResponseWriter, r *http.Request) {
+	h.changeSubscription(w, r, true)
+}
+
+// changeSubscription changes a subscription, or previews the change.
+func (h *Handler) changeSubscription(w http.ResponseWriter, r *http.Request, preview bool) {
+	id, err := ulid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Subscription not found."})
+		return
+	}
+	var req changeSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, errorResponse{"invalid_json", "The request body is not valid JSON."})
+		return
+	}
+	errs := validation.Errors{}
+	var priceID ulid.ULID
+	if req.PriceID != "" {
+		if priceID, err = ulid.Parse(req.PriceID); err != nil {
+			errs.Add("price_id", validation.InvalidChoice("Invalid price."))
+		}
+	}
+	if req.Proration == "" {
+		req.Proration = invoice.ProrateNextInvoice
+	} else if !req.Proration.IsValid() {
+		errs.Add("proration", validation.InvalidChoice("Invalid proration."))
+	}
+	if !errs.IsEmpty() {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now()
+	at := req.ProrationDate
+	if at.IsZero() {
+		at = now
+	}
+	var sub subscription.Subscription
+	var inv invoice.Invoice
+	notFound, canceled := false, false
+	err = database.WithTx(ctx, h.db, func(tx pgx.Tx) error {
+		var err error
+		sub, err = subscription.NewRepository(tx).Get(ctx, id)
+		if errors.Is(err, subscription.ErrNotFound) {
+			notFound = true
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if sub.Status == subscription.StatusCanceled {
+			canceled = true
+			return nil
+		}
+		catalogRepo := catalog.NewRepository(tx)
+		current, err := catalogRepo.GetPrice(ctx, sub.PriceID)
+		if err != nil {
+			return err
+		}
+		price := current
+		if priceID != (ulid.ULID{}) && priceID != current.ID {
+			price, err = catalogRepo.GetPrice(ctx, priceID)
+			if errors.Is(err, catalog.ErrPriceNotFound) || price.IsArchived() || price.IsMetered() {
+				errs.Add("price_id", validation.InvalidChoice("Invalid price."))
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if price.Amount.CurrencyCode() != current.Amount.CurrencyCode() || price.Interval != current.Interval {
+				errs.Add("price_id", validation.InvalidValue("Price must match the currency and interval of the current price."))
+			}
+		}
+		quantity := sub.Quantity
+		if req.Quantity != nil {
+			quantity = *req.Quantity
+		}
+		if price.ID == sub.PriceID && quantity == sub.Quantity {
+			errs.Add("price_id", validation.InvalidValue("Subscription already uses this price and quantity."))
+		}
+		errs.Merge("", price.CheckQuantity(quantity))
+		if at.After(now) || at.Before(sub.CurrentPeriodStart) {
+			errs.Add("proration_date", validation.InvalidValue("Proration date must be within the current period, and not in the future."))
+		}
+		if !errs.IsEmpty() {
+			return nil
+		}
+
+		u, _ := auth.UserFromContext(ctx)
+		c := invoice.Change{
+			Price:         price,
+			Quantity:      quantity,
+			ChangedBy:     u.ID,
+			ProrationDate: at,
+			Proration:     req.Proration,
+		}
+		if preview {
+			lines, err := invoice.PreviewChange(ctx, tx, sub, c)
+			if err != nil {
+				return err
+			}
+			inv = invoice.New(sub.CustomerID, price.Amount.CurrencyCode())
+			inv.SubscriptionID = sub.ID
+			for _, line := range lines {
+				if err := inv.AddLine(line); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		inv, err = invoice.ApplyChange(ctx, tx, &sub, c, now)
+		return err
+	})
+	switch {
+	case err != nil:
+		h.handleError(w, err)
+	case notFound:
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Subscription not found."})
+	case canceled:
+		h.writeJSON(w, http.StatusConflict, errorResponse{"subscription_canceled", "Canceled subscriptions can't be changed."})
+	case !errs.IsEmpty():
+		h.writeValidationErrors(w, errs)
+	case preview:
+		h.writeJSON(w, http.StatusOK, subscriptionChangePreview{ProrationDate: at.UTC(), Invoice: inv})
+	default:
+		resp := subscriptionChangeResponse{Subscription: sub}
+		if inv.ID != (ulid.ULID{}) {
+			resp.Invoice = &inv
+		}
+		h.writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// PauseSubscription pauses a subscription.
+//
+// The subscription keeps being renewed while paused, with its invoices
+// settled according to the pause behavior, see invoice.Generator.
+func (h *Handler) PauseSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := ulid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Subscription not found."})
+		return
+	}
+	var req pauseSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, errorResponse{"invalid_json", "The request body is not valid JSON."})
+		return
+	}
+	errs := validation.Errors{}
+	if req.Behavior == "" {
+		req.Behavior = subscription.PauseVoid
+	} else if !req.Behavior.IsValid() {
+		errs.Add("behavior", validation.InvalidChoice("Invalid behavior."))
+	}
+	if req.ResumeBehavior == "" {
+		req.ResumeBehavior = subscription.ResumeKeepCycle
+	} else if !req.ResumeBehavior.IsValid() {
+		errs.Add("resume_behavior", validation.InvalidChoice("Invalid resume behavior."))
+	}
+	var resumesOn time.Time
+	if req.ResumesOn != "" {
+		if resumesOn, err = time.Parse("2006-01-02", req.ResumesOn); err != nil {
+			errs.Add("resumes_on", validation.InvalidValue("Resume date must be in the YYYY-MM-DD format."))
+		}
+	}
+	if !errs.IsEmpty() {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now()
+	var sub subscription.Subscription
+	notFound, notPausable := false, false
+	err = database.WithTx(ctx, h.db, func(tx pgx.Tx) error {
+		var err error
+		sub, err = subscription.NewRepository(tx).Get(ctx, id)
+		if errors.Is(err, subscription.ErrNotFound) {
+			notFound = true
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if sub.Status != subscription.StatusActive && sub.Status != subscription.StatusPastDue {
+			notPausable = true
+			return nil
+		}
+		var resumesAt time.Time
+		if !resumesOn.IsZero() {
+			cust, err := customer.NewRepository(tx).Get(ctx, sub.CustomerID)
+			if err != nil {
+				return err
+			}
+			st, err := settings.NewStore(tx).Get(ctx)
+			if errors.Is(err, settings.ErrNotFound) {
+				st = settings.New()
+			} else if err != nil {
+				return err
+			}
+			loc, err := period.Location(cust.Timezone, st.Timezone)
+			if err != nil {
+				return err
+			}
+			resumesAt = time.Date(resumesOn.Year(), resumesOn.Month(), resumesOn.Day(), 0, 0, 0, 0, loc)
+		}
+		if !resumesAt.IsZero() && !resumesAt.After(now) {
+			errs.Add("resumes_on", validation.InvalidValue("Resume date must be in the future."))
+			return nil
+		}
+		if errs := sub.Pause(req.Behavior, resumesAt, req.ResumeBehavior, now); !errs.IsEmpty() {
+			return fmt.Errorf("pause subscription %v: %v", sub.ID, errs)
+		}
+		return subscription.NewRepository(tx).Update(ctx, &sub)
+	})
+	switch {
+	case err != nil:
+		h.handleError(w, err)
+	case notFound:
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Subscription not found."})
+	case notPausable:
+		h.writeJSON(w, http.StatusConflict, errorResponse{"invalid_status", "Only active and past due subscriptions can be paused."})
+	case !errs.IsEmpty():
+		h.writeValidationErrors(w, errs)
+	default:
+		h.writeJSON(w, http.StatusOK, sub)
+	}
+}
+
+// ResumeSubscription resumes a paused subscription, see invoice.Resume.
+func (h *Handler) ResumeSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := ulid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Subscription not found."})
+		return
+	}
+	var req resumeSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, errorResponse{"invalid_json", "The request body is not valid JSON."})
+		return
+	}
+	if req.Behavior != "" && !req.Behavior.IsValid() {
+		errs := validation.Errors{}
+		errs.Add("behavior", validation.InvalidChoice("Invalid behavior."))
+		h.writeValidationErrors(w, errs)
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now()
+	var sub subscription.Subscription
+	var inv invoice.Invoice
+	notFound, notPaused := false, false
+	err = database.WithTx(ctx, h.db, func(tx pgx.Tx) error {
+		var err error
+		sub, err = subscription.NewRepository(tx).Get(ctx, id)
+		if errors.Is(err, subscription.ErrNotFound) {
+			notFound = true
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if sub.Status != subscription.StatusPaused {
+			notPaused = true
+			return nil
+		}
+		inv, err = invoice.Resume(ctx, tx, &sub, req.Behavior, now)
+		return err
+	})
+	switch {
+	case err != nil:
+		h.handleError(w, err)
+	case notFound:
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Subscription not found."})
+	case notPaused:
+		h.writeJSON(w, http.StatusConflict, errorResponse{"invalid_status", "Only paused subscriptions can be resumed."})
+	default:
+		resp := subscriptionChangeResponse{Subscription: sub}
+		if inv.ID != (ulid.ULID{}) {
+			resp.Invoice = &inv
+		}
+		h.writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// CancelSubscription cancels a subscription, immediately or at the end
+// of the current period, recording the reason for churn reporting.
+//
+// Immediate cancellations can refund the unused time of the current
+// period, see creditnote.UnusedItems. Cancellations at period end
+// can be undone until the period ends, see ReactivateSubscription.
+func (h *Handler) CancelSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := ulid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Subscription not found."})
+		return
+	}
+	var req cancelSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, errorResponse{"invalid_json", "The request body is not valid JSON."})
+		return
+	}
+	c := subscription.Cancellation{Reason: req.Reason, Feedback: req.Feedback}
+	errs := c.Validate()
+	if req.Mode == "" {
+		req.Mode = cancelAtPeriodEnd
+	} else if req.Mode != cancelImmediately && req.Mode != cancelAtPeriodEnd {
+		errs.Add("mode", validation.InvalidChoice("Invalid mode."))
+	}
+	if req.Refund && req.Mode != cancelImmediately {
+		errs.Add("refund", validation.InvalidValue("Refunds are only available for immediate cancellations."))
+	}
+	if req.RefundMethod == "" {
+		req.RefundMethod = creditnote.MethodRefund
+	} else if !req.RefundMethod.IsValid() {
+		errs.Add("refund_method", validation.InvalidChoice("Invalid refund method."))
+	}
+	if !errs.IsEmpty() {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now()
+	var sub subscription.Subscription
+	var inv invoice.Invoice
+	var cn creditnote.CreditNote
+	notFound, canceled := false, false
+	err = database.WithTx(ctx, h.db, func(tx pgx.Tx) error {
+		var err error
+		sub, err = subscription.NewRepository(tx).Get(ctx, id)
+		if errors.Is(err, subscription.ErrNotFound) {
+			notFound = true
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if sub.Status == subscription.StatusCanceled {
+			canceled = true
+			return nil
+		}
+		if req.Mode == cancelAtPeriodEnd {
+			if sub.Status == subscription.StatusIncomplete || sub.Status == subscription.StatusUnpaid {
+				errs.Add("mode", validation.InvalidValue("Incomplete and unpaid subscriptions can only be canceled immediately."))
+				return nil
+			}
+			if errs := sub.CancelAtEnd(c, now); !errs.IsEmpty() {
+				return fmt.Errorf("cancel subscription %v: %v", sub.ID, errs)
+			}
+			return subscription.NewRepository(tx).Update(ctx, &sub)
+		}
+
+		// The refund is built before canceling, and issued after,
+		// so that a non-refundable invoice rolls back the cancellation.
+		// The refund itself is made once the cancellation is committed.
+		if req.Refund {
+			if cn, errs, err = buildRefund(ctx, tx, sub, req.RefundMethod, now); err != nil || !errs.IsEmpty() {
+				return err
+			}
+		}
+		if inv, err = invoice.Cancel(ctx, tx, &sub, c, now); err != nil {
+			return err
+		}
+		if req.Refund {
+			return creditnote.Issue(ctx, tx, &cn, now)
+		}
+		return nil
+	})
+	switch {
+	case err != nil:
+		h.handleRefundError(w, err)
+	case notFound:
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Subscription not found."})
+	case canceled:
+		h.writeJSON(w, http.StatusConflict, errorResponse{"subscription_canceled", "Subscription is already canceled."})
+	case !errs.IsEmpty():
+		h.writeValidationErrors(w, errs)
+	default:
+		resp := cancelSubscriptionResponse{Subscription: sub}
+		if inv.ID != (ulid.ULID{}) {
+			resp.Invoice = &inv
+		}
+		if cn.ID != (ulid.ULID{}) {
+			h.refund(ctx, &cn)
+			resp.CreditNote = &cn
+		}
+		h.writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// buildRefund builds a credit note for the unused time of the
+// subscription's current period, as of the given time.
+//
+// The invoice of the current period is locked until the end of the
+// transaction. Validation errors are reported on the "refund" path.
+func buildRefund(ctx context.Context, tx pgx.Tx, sub subscription.Subscription, method creditnote.Method, now time.Time) (creditnote.CreditNote, validation.Errors, error) {
+	errs := validation.Errors{}
+	invoiceRepo := invoice.NewRepository(tx)
+	invoices, err := invoiceRepo.ListBySubscription(ctx, sub.ID)
+	if err != nil {
+		return creditnote.CreditNote{}, nil, err
+	}
+	var invoiceID ulid.ULID
+	for _, inv := range invoices {
+		if inv.IsFinalized() && inv.Status != invoice.StatusVoid &&
+			inv.PeriodStart.Equal(sub.CurrentPeriodStart) && inv.PeriodEnd.Equal(sub.CurrentPeriodEnd) {
+			invoiceID = inv.ID
+			break
+		}
+	}
+	if invoiceID == (ulid.ULID{}) {
+		errs.Add("refund", validation.InvalidValue("The current period has no invoice to refund."))
+		return creditnote.CreditNote{}, errs, nil
+	}
+	inv, err := invoiceRepo.GetForUpdate(ctx, invoiceID)
+	if err != nil {
+		return creditnote.CreditNote{}, nil, err
+	}
+	items, err := creditnote.UnusedItems(inv, now)
+	if err != nil {
+		return creditnote.CreditNote{}, nil, err
+	}
+	if len(items) == 0 {
+		errs.Add("refund", validation.InvalidValue("The current period has no unused time to refund."))
+		return creditnote.CreditNote{}, errs, nil
+	}
+	previous, err := creditnote.NewRepository(tx).ListByInvoice(ctx, inv.ID)
+	if err != nil {
+		return creditnote.CreditNote{}, nil, err
+	}
+	cn, buildErrs := creditnote.Build(inv, previous, method, items)
+	errs.Merge("refund", buildErrs)
+	cn.Memo = "Unused time after cancellation"
+
+	return cn, errs, nil
+}
+
+// ReactivateSubscription undoes a cancellation scheduled for the end
+// of the current period. Only possible until the period ends.
+func (h *Handler) ReactivateSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := ulid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Subscription not found."})
+		return
+	}
+
+	ctx := r.Context()
+	var sub subscription.Subscription
+	notFound, notReactivatable := false, false
+	err = database.WithTx(ctx, h.db, func(tx pgx.Tx) error {
+		var err error
+		sub, err = subscription.NewRepository(tx).Get(ctx, id)
+		if errors.Is(err, subscription.ErrNotFound) {
+			notFound = true
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !sub.CurrentPeriodEnd.After(time.Now()) {
+			notReactivatable = true
+			return nil
+		}
+		if errs := sub.Reactivate(); !errs.IsEmpty() {
+			notReactivatable = true
+			return nil
+		}
+		return subscription.NewRepository(tx).Update(ctx, &sub)
+	})
+	switch {
+	case err != nil:
+		h.handleError(w, err)
+	case notFound:
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Subscription not found."})
+	case notReactivatable:
+		h.writeJSON(w, http.StatusConflict, errorResponse{"not_reactivatable", "Only subscriptions scheduled for cancellation can be reactivated, until their period ends."})
+	default:
+		h.writeJSON(w, http.StatusOK, sub)
+	}
+}
+
+// ListQuantityChanges returns the quantity changes of a subscription, oldest first.
+func (h *Handler) ListQuantityChanges(w http.ResponseWriter, r *http.Request) {
+	id, err := ulid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Subscription not found."})
+		return
+	}
+	ctx := r.Context()
+	repo := subscription.NewRepository(h.db)
+	if _, err := repo.Get(ctx, id); err != nil {
+		if errors.Is(err, subscription.ErrNotFound) {
+			h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Subscription not found."})
+		} else {
+			h.handleError(w, err)
+		}
+		return
+	}
+	changes, err := repo.ListQuantityChanges(ctx, id)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	if changes == nil {
+		changes = []subscription.QuantityChange{}
+	}
+	h.writeJSON(w, http.StatusOK, changes)
+}
+
+// ListCustomerEvents returns the latest events of a customer.
+func (h *Handler) ListCustomerEvents(w http.ResponseWriter, r *http.Request) {
+	id, err := ulid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Customer not found."})
+		return
+	}
+	ctx := r.Context()
+	if _, err := customer.NewRepository(h.db).Get(ctx, id); err != nil {
+		if errors.Is(err, customer.ErrNotFound) {
+			h.writeJSON(w, http.StatusNotFound, errorResponse{"not_found", "Customer not found."})
+		} else {
+			h.handleError(w, err)
+		}
+		return
+	}
+	events, err := event.NewRepository(h.db).ListByCustomer(ctx, id, maxEvents)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	if events == nil {
+		events = []event.Event{}
+	}
+	h.writeJSON(w, http.StatusOK, events)
+}
@@ -0,0 +1,208 @@
+// Code generated by vfsgen; DO NOT EDIT.
+
+// +build !dev
+
+package auth
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	pathpkg "path"
+	"time"
+)
+
+// Assets are auth assets, embedded by vfsgen.
+var Assets = func() http.FileSystem {
+	fs := vfsgen۰FS{
+		"/": &vfsgen۰DirInfo{
+			name:    "/",
+			modTime: time.Date(2026, 10, 17, 4, 19, 14, 141979988, time.UTC),
+		},
+		"/css": &vfsgen۰DirInfo{
+			name:    "css",
+			modTime: time.Date(2026, 10, 17, 4, 19, 14, 145752284, time.UTC),
+		},
+		"/css/style.css": &vfsgen۰CompressedFileInfo{
+			name:             "style.css",
+			modTime:          time.Date(2026, 10, 17, 4, 19, 14, 145752284, time.UTC),
+			uncompressedSize: 12050,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xec\x3a\x7f\x6f\xe3\x36\xb2\x5f\x45\xaf\x8b\x20\x49\x1f\xa9\x48\xb2\xe5\x38\x32\x02\xb4\x5d\xf4\xe1\x15\xe8\xf6\x01\x6f\x7b\x7f\x1c\xb6\xfb\x07\x25\x8d\x2c\x36\x94\xa8\xa3\xa8\xd8\x5e\x55\xdf\xfd\x40\x52\x92\x25\x5b\xca\x8f\xdb\xdb\xde\x5d\x51\x2c\xd6\x31\xe7\x27\x39\x9c\x19\x0e\x39\xbe\xf9\xfa\xbf\xac\x9c\x8b\x8c\x30\xfa\x09\xec\xa8\x2c\xad\xc7\xb5\xed\xd8\xae\xf5\x9b\xf5\xee\x87\x9f\xad\x1f\x69\x04\x79\x09\xd6\x6f\xd6\x96\xca\xb4\x0a\xed\x88\x67\x37\x39\x44\x9c\x91\xf2\x66\xcc\xf7\xf5\x4d\x2a\x33\x56\x33\x9a\x03\x4e\x81\x6e\x53\x19\xb8\xb6\xeb\x6f\xf0\x0e\xc2\x07\x2a\xb1\x84\xbd\xc4\x25\xfd\x04\x98\xc4\xbf\x56\xa5\x0c\x5c\xc7\xb9\x68\x42\x1e\x1f\xea\x8c\x88\x2d\xcd\x03\xa7\xc9\x08\xcd\xeb\x98\x96\x05\x23\x87\x20\x64\x3c\x7a\x68\x52\xb7\x4e\x78\x6e\x38\x03\x0f\xb2\x4d\x4b\x6c\xaf\x6e\x21\xb3\x9c\x26\x15\x75\xc8\xf7\x0a\x4d\xf3\x6d\x10\xf1\x5c\x42\x2e\x71\xc8\xf7\x9b\x76\x12\xce\x86\x3f\x82\x48\x18\xdf\x05\x8f\xb4\xa4\x21\x83\xa6\x10\x60\x84\x26\x24\xa3\xec\x10\x64\x3c\xe7\x65\x41\x22\x40\xfd\xb7\xcd\x51\xa9\x0b\x59\x43\xea\x90\x44\x0f\x5b\xc1\xab\x3c\xc6\x11\x67\x5c\x04\x52\x90\xbc\x2c\x88\x80\x5c\x36\x24\x0c\xc5\x07\x49\x25\x83\x8f\x75\xc8\x45\x0c\x02\x87\x5c\x4a\x9e\x05\x39\xcf\x61\xa3\x97\x1e\x43\xc4\x05\x91\x94\xe7\x41\x95\xc7\x20\x94\xa1\xc6\xc6\x99\xa2\xb0\x62\x2e\x25\xc4\x9b\xe7\x08\x9a\x10\x95\x52\xf0\x7c\x6b\x16\xb6\x33\x6b\x0f\x39\x8b\x41\x34\x11\x8f\x01\x3d\x84\x31\x2a\x49\x56\xbc\x72\xe5\x65\x46\x18\x1b\x6c\xc1\xda\xb9\x68\xca\x2a\x44\x65\x55\x0c\xa0\xb7\xfe\xc5\x66\xb8\xf3\xce\xa6\xe0\x25\xd5\x33\x15\xc0\x88\xa4\x8f\xb0\x79\x04\x21\x69\x44\x18\x26\x8c\x6e\xf3\x20\x24\x25\x28\x16\x25\xad\x6e\xad\x85\x6d\xcf\x57\x3a\xab\xa2\x96\xbc\x08\xb0\xad\x46\x34\xdb\x76\x46\x2d\xe5\x81\x81\xb6\x69\x13\x56\x52\xf2\x1c\xd1\xbc\xa8\x24\xe2\x85\x54\x7b\x53\xa0\x12\x18\x44\x12\x29\x6b\x11\x01\x64\xb4\x56\x9a\xa7\x20\xa8\x1c\xae\xcf\x71\x2e\x36\x67\x0e\xdb\x7b\xe3\x50\x45\x7d\xe6\x44\x2d\xd6\x68\xac\xf5\xfe\x68\x97\x48\xb8\x30\xdb\xde\x7c\x90\x87\x02\xee\x0d\xdd\x47\x64\x46\x02\x4a\x90\xdd\xa0\xac\xc2\x8c\xca\x8f\xc8\x90\xd4\x9d\x33\x90\xa2\x00\x22\x48\x1e\x41\x60\x30\x63\x49\x41\x80\x33\xfe\x09\x27\x3c\xaa\x4a\x4c\xf3\x1c\xc4\x48\xf6\x2c\xba\xd5\x36\x81\x37\x82\xcf\x11\xe7\x66\xdf\x14\x24\x8e\x55\xa0\x39\x27\x73\x3a\xb2\x0a\x9a\x6f\xc7\x13\x9a\xc4\x75\xb3\x39\x41\xb6\x53\x19\x43\x6b\x5e\x49\xb5\x4d\x81\x5b\xec\x5b\x7f\xb7\xbe\xd3\x84\x3f\xc3\x5e\x36\x09\x05\x16\x97\x20\xeb\x6e\x72\xf6\xc2\x87\xcc\xb2\x6f\xf5\xe7\x4a\xbb\x14\x83\x2d\xe4\xf1\x30\x55\xf4\x71\xba\xdf\x98\x80\xee\xfc\xa3\xcb\x3f\x92\x84\x0c\x36\x19\xd9\xe3\x1d\x8d\x65\x6a\xbc\xa5\x5f\xff\x66\x97\x52\x09\x58\x87\x4c\x60\x32\x61\x53\x08\xbe\x15\x50\x96\xf5\x9c\xab\xf7\x7e\xd9\x7b\x13\xa9\x24\x6f\x4d\x19\xa5\x10\x3d\x84\x7c\xdf\xbb\x0a\x89\x29\xff\x38\x33\xe5\xd3\x7d\xc8\xab\x2c\x04\xa1\x36\xb7\x75\x22\xbd\x81\xb8\x2c\x68\x8e\x5b\x4f\x9d\x21\xe4\x95\x1c\x13\xd6\x6d\x30\x0c\xa6\x56\x02\x11\x51\xfa\x71\xca\x43\xd5\x92\xf4\x06\x6c\xda\x4d\xc2\x3c\x49\x4a\x90\x01\xf6\x8a\xfd\x98\xfd\xa8\xd3\x00\x06\xf9\x6c\x4a\xb2\x8e\xa1\x23\x4f\x42\x19\xe0\xaa\x60\x9c\xc4\xf8\xb9\x80\xd1\x31\xde\x6d\x68\x13\x83\x24\x94\x95\x27\x07\x4b\x59\x65\x19\x11\x87\x1e\xca\x68\x29\x31\x95\x90\x35\x1f\x52\x1a\xc7\x90\x7f\x44\x12\xb2\x82\x11\x09\x3d\x8d\xc9\x3c\x8a\xfd\x6f\x15\x97\x80\xe2\x18\xc5\x0c\x25\x74\x5b\x09\x40\xa9\x8b\x52\x0f\xa5\x0b\x94\x2e\x51\xea\xa3\x74\x85\x52\x81\x0a\xa4\x0e\x9b\x93\x94\xf2\xe4\x61\xb2\x19\x20\x69\x46\xb6\xa3\x7c\x17\xe8\x98\x98\x88\x87\xce\xfa\x81\x5f\xec\x2d\xb5\x73\x56\x6f\x37\xc5\x81\x55\x18\x19\x5d\x7d\xb8\x20\xce\x50\xc5\xfa\xb9\x0d\x5c\xca\x20\xb4\x41\x06\x19\x57\x1f\xef\xc3\x84\xfa\x7f\x05\xe4\xd6\x7b\x92\x97\xa8\x3c\x94\x12\x32\x5c\x51\xa4\xf6\x82\x01\x36\x00\xf4\x1d\xa3\xf9\xc3\x3b\x12\xbd\xd7\xc3\xff\xe1\xb9\x44\xef\x61\xcb\xc1\xfa\xcb\x0f\xe8\xff\x79\xc8\x25\x47\xff\x0b\xec\x11\x54\xb0\x58\x3f\x41\x05\xe8\x5b\x41\x09\x43\x3f\x71\xc9\x5b\xc9\x24\x2f\x71\x09\x82\x26\xe8\x5b\x25\xd9\x7a\xab\xd6\x60\x7d\x9f\xf1\x5f\x69\x2f\xeb\x74\xf8\xfe\x90\x85\xbc\x95\x32\xa0\x3f\x49\xf5\x7e\xf3\x35\x0a\x48\x22\x41\xa0\x20\x84\x84\x0b\x98\x89\x35\xf3\x35\x70\xac\x92\x33\x1a\x5b\x6f\x62\x3f\x5e\xc5\xb7\xa6\xf2\xd0\x44\x92\x17\x5d\x8e\x28\xf6\xe7\xe7\x95\x66\x3b\xc6\xbe\x00\x7d\xf8\x74\x39\xa2\xd1\xe7\x4b\x9b\x7c\x0b\x46\x22\x48\xf5\xa9\xdd\x1f\x62\xe7\xa8\xda\x38\xcd\x1b\xe2\x10\x88\x9c\x56\x00\xce\x54\xd6\x2e\x2a\x39\x2d\x64\x12\x3d\x29\xe8\x39\x49\xaf\x11\x35\x2d\x60\x9e\xed\x83\xe0\xec\x78\x64\x9a\xbf\x75\x54\x89\x92\x8b\xa0\xe0\x34\x97\x20\x1a\x9d\x97\x3b\x0b\x47\x9c\x31\x52\x94\x10\x74\x5f\x9a\xb3\x30\x1c\x94\x29\xa3\x12\xa0\xad\x91\x5a\x58\x43\xea\xf1\x31\x70\x5a\x71\x75\x74\x2f\x2b\x3c\x8e\xe7\xc4\xd0\xe9\x3a\xd9\x23\x4d\xc7\xf2\xac\x10\x70\x5e\xa2\xbd\x83\x9c\x71\xf4\x8e\xe7\x24\xe2\xe8\x2d\xcf\x4b\x55\x7b\xa3\x1f\x69\x08\x66\x62\xd6\x3b\x9e\x2b\x44\x25\x28\x08\xeb\x27\xd8\x1d\x4b\xb9\x86\x54\x31\xe5\x28\x22\xf9\x23\x29\x11\x64\x21\xc4\x88\x26\x82\x64\x80\x68\xb6\x45\x3c\xfc\x55\xcd\xb9\x7c\xdc\xa2\x47\x1a\x03\x1f\x27\xc8\xd3\x7a\x2d\xa3\x71\xcc\x40\x79\x77\x4b\x7d\x72\x32\x0e\x4f\x0d\x5b\xd5\xe1\x84\xaa\x12\xe2\x48\xd1\x7c\x93\x41\x4c\x89\x75\x95\xd1\xbc\x65\x5c\x2d\x9d\x62\x7f\x5d\x0f\xc8\x8f\x42\x35\xae\x99\x60\xba\x5d\xad\x67\x99\x34\x6e\x8a\xc9\x75\xbc\xe5\x2c\x97\x41\x4e\xb2\x79\xeb\xf9\x19\x1a\x64\xd3\xd8\xaa\xe4\x33\x11\x31\x77\x8e\x6d\x74\x08\x9f\x02\x4f\xc7\x67\xc7\xc2\x9b\x24\x49\x36\x47\x4f\x57\x10\xf0\x60\x9d\x38\x1d\xb0\xcf\x3a\x1d\x40\x90\x98\x56\x65\x60\x7b\xbe\x80\xac\x4f\xe9\xb6\x2f\x4c\x41\xa4\x80\x83\xfa\x57\x0d\x4f\x93\xe2\x60\x35\xcf\x65\x9e\x0d\x2f\x48\x44\xe5\x21\x70\x47\x5c\x2f\xc8\x0f\x33\x9c\x9f\xc3\xfa\x5a\x86\xf1\x49\x6a\xec\xaf\xd2\x7f\x4a\x62\xbe\x0b\x1c\x4b\xfd\x5b\x14\x7b\x4b\x6c\x43\x72\xe5\x39\x1e\x72\xef\x5c\xe4\xf9\x0b\x64\x2f\xfd\xeb\x93\x4d\x21\xd1\xdd\x32\x21\xad\xf8\x3e\x07\xfc\x51\x7c\xe1\xa5\x07\xd1\x99\xa1\x5f\x73\xf8\xcc\x33\x7f\x26\xf7\x3f\xc0\xf3\xc5\x5c\x23\xab\x98\xa4\xed\x8d\xf1\x8f\xe2\x1d\x83\x35\x7d\x39\xc3\xb5\x36\x3b\x2b\x8d\x2b\xc1\xae\xbe\x8a\x89\x24\x81\x1e\xdf\x94\x8f\xdb\xff\xde\x67\x6c\x13\xa5\x44\x94\x20\xef\x2b\x99\xe0\x35\xba\x58\xbc\x2d\x1f\xb7\xd6\x3e\x63\x79\x79\x7f\x99\x4a\x59\x04\x37\x37\xbb\xdd\xce\xde\x2d\x6c\x2e\xb6\x37\x9e\xe3\x38\x8a\xf5\xd2\x7a\xa4\xb0\xfb\x8e\xef\xef\x2f\xd5\x4c\xbd\xa5\xe5\x2d\x2f\xad\x84\x32\x76\x7f\x79\xe1\x2d\x8c\xdb\x5c\x5e\x2c\xbe\xbf\x58\xbc\x2d\x88\x4c\xad\xf8\xfe\xf2\x9d\xeb\xdb\x0b\xeb\xce\x5e\x10\xd7\x72\xd5\x02\x5d\xd7\x5e\x5a\xae\xbd\x64\x78\x69\x2d\x7b\x20\x56\x50\x87\xe1\x25\x5e\x0e\x09\x15\x98\x2d\xec\x85\xb5\xb0\xbd\x3b\x6b\x61\x2f\xf0\xc2\x5e\x7c\xba\xbc\x31\x3a\xd4\x9c\x2e\x16\xdf\x7f\x75\xbd\xf9\x2c\x67\xe9\x98\x0b\x41\x73\x69\xcc\xdb\xbd\xc1\xc1\x9e\x44\x72\x33\x01\x1a\x58\x5a\x40\x01\x44\x06\x39\x6f\xbf\x7d\x69\xe7\xf3\xcc\x9f\x57\x78\xe2\x70\x46\xc7\x77\x27\x85\x6d\xa5\x44\x90\x4b\x10\x43\x32\x23\x4b\xbd\x2f\x59\xfa\x73\xe4\x67\x26\xe5\xc0\xbe\x20\x79\x7c\x92\x33\xda\xbb\x80\xbe\x10\xb5\xe5\x42\xce\xa5\xa5\x4d\x5b\xcf\xca\x18\xdd\x1f\x3b\x3e\xcd\x63\x91\x3c\xb6\xae\x14\x69\x4a\xb7\x29\x56\x65\x86\x20\xa5\x0c\x48\xa4\xde\xcc\xae\xd1\x93\x44\x4a\xdc\xf5\x48\x6b\x57\x77\x62\xbd\xfa\xc0\x58\xaf\x19\x2f\xee\x8b\x05\x69\xf7\x80\x11\xe8\x2f\x10\x7f\x66\xb8\x8e\x62\xd1\x5d\x59\xee\x6a\x10\x8b\x49\x92\x5c\xbe\x20\xa0\x4f\x62\xd5\xb7\x6f\x9d\x5b\xeb\xd6\xf6\xee\xfa\x70\x75\x54\x08\xba\x3a\x62\xdd\x25\xf3\x2c\xaf\x47\x18\xb8\xc3\x06\x21\xdb\x12\x9b\xcf\x1f\x6f\xad\xb5\xed\xaf\x57\xd6\x40\xea\x44\xe8\x8e\x0c\x36\x73\xcf\x37\xc8\xa8\x12\x0a\xa1\x6f\xac\xe7\xbe\xea\x38\x17\x96\xfa\x98\x74\x76\x7f\x0c\x3f\x0d\xd9\x39\x57\xed\x77\x4c\x3b\xab\x1e\xd5\x67\x21\xfb\xd4\xcc\xfb\x1b\xcd\x68\x95\x27\xc0\x36\xd8\x5b\x68\x73\xe2\x2d\xf5\xef\x9e\xdb\xba\x68\xa4\xb9\xce\x23\x4f\xdc\x76\x86\x36\xe5\x82\xaa\x77\x92\xc1\xbb\x40\xa7\xbb\x2a\x41\x74\xc1\x75\x9c\xf9\x04\xb4\x3c\x07\x9e\x01\x12\x06\x2a\x16\x05\xcd\x1f\x02\xa7\xbb\x54\xb9\x90\x6d\xda\x0d\x81\xac\xdd\x90\x37\x4b\xef\xee\x0e\xdc\x7f\x7a\x36\x3e\x0d\xe6\x2f\x96\x2e\x94\x56\xfe\x6f\x97\x2b\x22\x2a\x22\x06\x56\xb4\xbf\xbf\x5c\x5f\x5a\xd1\x41\xff\x11\xf7\x97\x8b\xff\xac\xc8\x36\xc6\xfd\x17\x84\xb5\x56\xfc\x67\x4c\x3f\x11\xd3\x63\xf3\x0d\xdf\x4e\xbe\x50\x98\x8f\xe2\xed\x4b\x84\x73\xb8\xc5\xba\x1b\x52\x63\x1c\x6e\x71\x7f\xad\x9a\x9b\xf3\x29\xd4\xa8\xf2\x7d\xd4\xfd\x7f\x24\xe2\x6a\x28\xea\xfa\x5a\xeb\xd8\x0a\x72\xc0\xbe\xf3\x02\x2d\x77\x09\x49\xc2\x39\x45\xcb\x3b\xe4\xf9\x0e\xf2\x7c\x77\x52\x91\x66\x80\x18\xb3\xfe\x15\xb7\x4b\x8f\x26\x3b\x6a\xd7\x3a\xe9\x25\xd8\x6a\x87\x7b\x98\x1a\x34\xb6\x69\x22\x8c\x4b\x3f\x4d\xa7\x26\x53\xeb\x2f\x31\x15\x10\xe9\x28\x8f\x38\xab\xb2\xbc\xb1\xa9\x04\x15\xb1\xba\x5c\xad\xb5\xd7\xea\x96\x44\x19\x18\x50\x63\x2b\x97\xa7\xc9\xa1\x23\xe9\x87\xa6\xef\xdd\x93\x69\xf1\xae\xd6\x12\xe8\xda\xe5\x42\x39\x41\x2e\xb1\xca\x16\x55\x36\x6a\x12\xfb\x8e\xd3\x22\x4b\xc8\xa8\x6a\x19\x8f\xd0\x2b\x85\x4e\x71\x52\x31\xd6\x75\x86\xf4\x6b\x9e\xad\x1f\x48\xf7\xe5\xe0\x79\xb5\x2d\x37\x0d\xa6\xcc\x86\x98\xf5\x10\xb5\x1f\x36\x94\xdd\xee\xd8\x61\x40\x74\xe9\xea\x9f\xfc\x86\xa0\x45\x67\x7b\x4c\x2a\xc9\xdb\x7e\x05\x66\x90\x98\xc7\xc6\xb6\x5f\xdb\x56\xbc\xe6\xf9\x31\x93\xd8\xed\x08\x25\x2f\xfa\x93\x2d\x93\x78\x39\x84\xbb\x06\x1a\x1e\xa1\x6d\x23\xba\x45\xa8\xa7\x3e\xbc\xdc\xb3\xc1\xa3\x9f\xbf\x32\x28\x9a\xe3\x14\x97\x91\x00\xc8\x6b\x3d\xe8\x4d\xf3\x98\x36\xb6\x79\x5a\xc5\xed\xab\x61\x8d\x39\x6e\x21\x09\x95\x41\x0b\xdd\x9c\x83\x1a\xbb\x6b\x0c\xe2\xd6\x7d\xba\x71\x60\xc6\x03\x82\x83\x31\xc7\x71\xdc\xae\xbd\xc0\xab\xfe\xf5\xd9\x6d\x7d\xb6\x38\x60\xaf\x03\x1a\x83\x0c\xef\x5d\xdd\xa2\x3b\xe2\x3d\x5e\xf6\xc4\xda\xcc\xee\x90\xd8\xd8\xd9\x18\xa8\x60\xd8\xf5\xc6\xb4\x0b\x8d\x30\xe9\x44\x6d\xf4\x28\xb9\x78\x4e\xb1\xb7\x3c\xd5\xa2\xc2\x7e\x97\x63\x1c\xa4\xfe\xd9\xee\x35\x72\x2c\x57\xe1\x5d\x67\x0a\xef\x2c\xaf\x5b\xef\xd1\x69\xe0\xd6\x51\x79\x40\x8f\x8f\x99\xa0\x0d\x7f\x6f\xe9\xad\xbc\x78\x33\x88\xf9\xc5\x0a\x2d\xd6\x68\xd9\x65\x96\x21\xdb\x75\x27\x56\x40\x8c\x57\x4f\x48\x05\xc7\x5b\x7a\xcb\xa1\x54\xcf\x5b\x22\x25\x79\x35\x23\x76\x67\x82\x66\xf8\x02\xde\x1d\x2f\x0f\x70\xd0\xef\xf0\xa5\xa5\x1a\xaf\xb5\xe4\xf5\xf1\xb7\x03\x82\x4b\x22\xe1\xca\x95\x95\xc8\xaf\x9b\xe6\x9b\xd7\xd0\x9e\xcb\x2f\x54\xff\xfc\xd6\xbf\x40\x23\xbe\x32\x22\x0c\xae\xbc\xeb\xfe\x0d\xcc\x19\x29\x7a\x05\xd3\x84\xc6\x8a\x95\x50\xfb\xce\x45\xdd\xd1\xd9\xfe\x58\xfa\x34\xc1\xb9\xa4\x90\x57\x79\x04\xb5\x73\x32\x0f\xfd\x8d\x11\x09\x7f\xbd\xc2\x9e\x7f\x31\x78\x1d\xc9\x69\xa6\x7b\x22\x58\xd2\x4c\x79\x64\x52\xe5\x6d\x7e\xad\x42\x1a\xe1\x10\x3e\x51\x10\x57\xf6\x1a\x39\xc8\x45\xee\xf5\xe6\xb5\x0c\x8d\x9a\xf4\xf8\x57\x1e\xaf\x55\xae\x9d\xd9\x7b\x85\xf2\x9e\x61\x64\xc3\x3f\x4d\x33\x36\x8d\x4d\xf3\x47\xc2\x68\xac\x8a\x03\x73\x6a\x0f\x0a\x84\x71\x91\x64\xe2\x78\x04\x9c\x0c\xe7\xb1\x9c\xeb\xeb\x5e\x47\x5b\x43\x3d\xab\x29\xb9\x5b\x3b\x6b\x67\x4a\xd3\xf2\x0e\xb9\xde\x5a\xff\x9f\xd6\xd5\xb7\xf3\x9d\xa7\xaa\xb3\xe5\x1a\xb9\x6b\x47\xff\x57\xd5\x59\x63\x9b\x1f\xd5\x3c\x5f\x20\xdd\xc2\x82\x24\xde\x4c\x81\xe4\x7a\x2b\xe4\xaf\x91\xb7\xf4\x26\xeb\x23\xa3\x23\x48\xd5\xc1\xf3\xbc\xa6\x55\xe4\x85\xf1\xdd\x9c\x26\x67\x8d\x96\x0b\xe4\xb9\xb7\x4f\x69\x32\x4f\x61\xbf\xc7\xa2\xea\xf9\x1b\xdd\x79\x25\x38\x51\x66\xb7\x13\x1e\x97\xd8\xce\x60\x5b\xa6\x4f\x16\x55\x14\x3f\x59\x09\x8f\x0f\x96\x8d\x9e\x98\xbe\x1d\xe2\x42\xf0\x02\x84\x3c\x04\xa7\xab\x46\xc3\xa5\x20\xf3\xa9\x2e\xc4\xfa\x57\x82\x0f\x30\x94\x11\x57\x6d\x87\xdb\x76\xfd\xf2\x64\x11\x2f\xbf\x1a\x3c\xd1\xdd\x2d\xb3\x5f\x82\xa9\x86\xf0\xe6\xb4\xd9\x3b\xdf\xeb\x1d\x8b\x78\x4d\xbf\x77\x8e\xf3\x05\x3d\xdf\x59\xd6\xb6\xef\xfb\xc4\x7c\xb3\x78\x7a\xc9\x4f\x58\x29\x8b\xa7\xb5\x3d\xdb\x07\x9f\x63\x7c\xde\x36\x73\x9c\x2f\xb0\xcd\x2c\x6b\xd7\x13\x57\x04\x93\x57\x25\x85\xd0\xd7\x13\xc1\x77\xa7\xd7\x20\xc1\x77\x86\x20\x35\xc5\xed\xe8\x37\x05\x0a\x5e\x0c\xea\xcd\xb6\xd4\x54\xe0\x1d\x76\x7f\xb9\xf1\x5a\x3b\xfb\xce\xc5\x93\xab\x66\xdb\x57\xef\x0d\xdb\x4e\xaf\xf6\xd9\xbd\x99\x63\x7c\x7e\x6f\xe6\x38\x5f\xb0\x37\xb3\xac\xf3\x7e\xdb\xf3\xee\xd9\xab\x8d\xb3\x67\xd3\xea\x9e\x35\xce\x1c\xe3\xf3\xc6\x99\xe3\x7c\x81\x71\x66\x59\x3b\xe3\xfc\x7d\x00\x27\x8d\x80\x60\x12\x2f\x00\x00"),
+		},
+		"/templates": &vfsgen۰DirInfo{
+			name:    "templates",
+			modTime: time.Date(2026, 10, 17, 4, 19, 21, 513499344, time.UTC),
+		},
+		"/templates/login.html.hbs": &vfsgen۰CompressedFileInfo{
+			name:             "login.html.hbs",
+			modTime:          time.Date(2026, 10, 17, 6, 23, 41, 96596214, time.UTC),
+			uncompressedSize: 1887,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xbc\x55\x4d\x6f\xe3\x36\x10\xbd\xfb\x57\x4c\x59\xb4\xa7\x32\x92\x83\x24\xbb\x88\x25\xb7\x68\xbb\x3d\x15\x48\x80\x4d\x0f\x3d\xd2\xe2\x48\x9c\x2e\x3f\x54\x92\xb2\x6c\x18\xfe\xef\x85\xbe\x6c\xcb\xe9\x22\x8b\x3d\x6c\x2e\xd1\x90\x33\x6f\xde\xbc\x99\xa1\xb3\xef\x7e\x7f\xfa\xed\xe5\xef\xe7\x0f\xa0\xa2\xd1\xeb\x45\xd6\xfd\x03\x2d\x6c\x95\x33\xb4\x6c\xbd\x58\x64\x0a\x85\x5c\x2f\x00\x32\x83\x51\x40\xa1\x84\x0f\x18\x73\xf6\xd7\xcb\x1f\xfc\x3d\x83\xe4\x7c\x65\x85\xc1\x9c\x6d\x09\xdb\xda\xf9\xc8\xa0\x70\x36\xa2\x8d\x39\x6b\x49\x46\x95\x4b\xdc\x52\x81\xbc\x37\x7e\x02\xb2\x14\x49\x68\x1e\x0a\xa1\x31\x5f\xde\xa4\x13\x54\xa4\xa8\x71\xfd\x2b\x69\x4d\xc2\x00\x87\x3f\x5d\x05\x64\xb3\x64\x38\xef\x3c\x34\xd9\x4f\xa0\x3c\x96\x39\x53\x31\xd6\xe1\x31\x49\x4a\x67\x63\xb8\xa9\x9c\xab\x34\x8a\x9a\xc2\x4d\xe1\x4c\x52\x84\x70\xfb\x73\x29\x0c\xe9\x7d\xfe\x54\xa3\xfd\xe1\x36\xfd\x28\x6c\x78\x6c\x2b\x15\x7f\xb9\x4b\xd3\xd5\x7d\x9a\xae\x1e\xd2\x74\xf5\x2e\x4d\x57\xef\xd3\xf4\x47\x49\xa1\xd6\x62\x9f\x87\x56\xd4\x6c\x01\x00\xe0\x51\xe7\x2c\xc4\xbd\xc6\xa0\x10\xe3\xc4\xb1\x67\xf0\xea\x6e\xa0\x94\x88\x26\xaa\x44\x84\x80\x31\x74\x14\x92\xde\xe5\xa6\x08\xa1\x8f\xce\x92\x41\xcf\x45\xb6\x71\x72\xdf\xa3\x49\xda\x42\xa1\x45\x08\x39\x2b\x35\xee\x80\x22\x9a\xc0\x0b\xb4\x11\x3d\x18\xb2\x5c\xf1\x50\x78\x44\x0b\x35\x7f\x80\x4d\xc5\x2b\x2f\xf6\xfc\x3e\x65\x5d\xf4\xeb\x78\xbe\x04\xc5\xcb\x46\x6b\x30\x62\xc7\x5b\x6e\x24\x98\x1d\x17\x4d\x74\xe0\xb6\xe8\x4b\xed\x5a\xae\x48\x4a\xb4\x1d\x56\xab\x28\x22\x78\xd7\x58\x89\x92\xeb\x0a\x82\x12\xd2\xb5\x7c\xa7\x47\xf8\xb7\x08\xfe\xd3\x84\x48\xe5\x7e\x32\x3b\x8a\x46\x3e\xd6\x7c\x79\x7b\x02\x98\x43\xb4\x3d\xb9\x8b\x4b\x80\x4c\x2d\xa7\x5b\xb3\xe1\x77\x10\x71\x17\xf9\x4e\x43\xd7\x56\x1e\xd0\xd0\xc6\x69\x39\x9c\xf6\xb5\xbf\x4b\xd3\x59\x3c\x8c\x53\x72\x09\x99\xa8\xe5\x2c\x45\xe9\xbc\x01\x51\x44\x72\x76\x6a\x92\x76\x15\x59\x06\x06\xa3\x72\x32\x67\xcf\x4f\x1f\x5f\xae\x60\x33\xb2\x75\x13\x21\xee\x6b\xcc\xd9\x20\x1a\x1b\xe7\xdc\xe2\x2e\x32\xd8\x0a\xdd\x60\xce\x0e\x87\xce\x3c\x1e\xc7\x01\x39\xff\x1d\x0e\xdf\x53\x09\xd6\x45\x2a\xf0\x78\x9c\x63\x5f\x68\xb2\xd1\xae\xf8\x04\xe7\xda\x83\xb9\xae\xf6\x70\x98\x40\xb2\x44\xd2\xf6\x3a\x4b\x42\xe5\xf1\xf8\x3f\x99\xd1\x7b\xe7\xbf\x22\xb1\x47\xc9\x1f\x86\xbc\x23\xc4\x17\xa6\xcd\xb4\xd8\xa0\x9e\xc3\x8f\xc8\x57\xda\x02\x64\xa1\x16\x76\x72\xbd\xaa\xf7\x83\x11\xa4\xb3\xa4\xf3\x78\x15\x76\xd9\x14\xec\xfc\xa6\x9e\x8c\xc6\xa9\x29\xbd\x7d\x3c\xb2\x2b\x00\x98\xd3\x6b\xc7\x6d\x89\x7c\x79\x12\xa1\x9b\x16\xde\xe7\x99\xe9\x08\x64\xb7\x42\x93\x1c\x2b\x67\xe0\xf1\xdf\x86\x3c\x4a\xe8\xf6\xab\x74\x45\x13\xae\x27\x20\x4b\x7a\x45\xd6\x6f\xaa\x64\x22\xbf\xfb\x2a\xa9\x9e\x45\x08\xad\xf3\xf2\x0b\xd4\xaa\x47\xd7\x49\xb0\x93\xfd\x0d\x14\xfa\x9c\x2e\x9f\x5f\xb8\xd0\x6c\x0c\x9d\x97\x6c\xd8\x71\xb6\x78\x9b\x68\xbd\xe3\x77\x50\xef\xf9\xed\x4c\xd4\xe1\x39\x31\x28\xa9\x31\xa0\x51\x48\xb2\x15\xbf\x87\x31\xcb\xec\xb1\x48\xba\xea\xce\x27\xb3\xd9\xbf\x30\x4e\x9f\xe3\x47\x96\x0c\x8f\x7a\xf7\xca\xf7\x3f\xa7\xff\x0d\x00\x50\xff\x44\xd3\x5f\x07\x00\x00"),
+		},
+	}
+	fs["/"].(*vfsgen۰DirInfo).entries = []os.FileInfo{
+		fs["/css"].(os.FileInfo),
+		fs["/templates"].(os.FileInfo),
+	}
+	fs["/css"].(*vfsgen۰DirInfo).entries = []os.FileInfo{
+		fs["/css/style.css"].(os.FileInfo),
+	}
+	fs["/templates"].(*vfsgen۰DirInfo).entries = []os.FileInfo{
+		fs["/templates/login.html.hbs"].(os.FileInfo),
+	}
+
+	return fs
+}()
+
+type vfsgen۰FS map[string]interface{}
+
+func (fs vfsgen۰FS) Open(path string) (http.File, error) {
+	path = pathpkg.Clean("/" + path)
+	f, ok := fs[path]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+	}
+
+	switch f := f.(type) {
+	case *vfsgen۰CompressedFileInfo:
+		gr, err := gzip.NewReader(bytes.NewReader(f.compressedContent))
+		if err != nil {
+			// This should never happen because we generate the gzip bytes such that they are always valid.
+			panic("unexpected error reading own gzip compressed bytes: " + err.Error())
+		}
+		return &vfsgen۰CompressedFile{
+			vfsgen۰CompressedFileInfo: f,
+			gr:                        gr,
+		}, nil
+	case *vfsgen۰DirInfo:
+		return &vfsgen۰Dir{
+			vfsgen۰DirInfo: f,
+		}, nil
+	default:
+		// This should never happen because we generate only the above types.
+		panic(fmt.Sprintf("unexpected type %T", f))
+	}
+}
+
+// vfsgen۰CompressedFileInfo is a static definition of a gzip compressed file.
+type vfsgen۰CompressedFileInfo struct {
+	name              string
+	modTime           time.Time
+	compressedContent []byte
+	uncompressedSize  int64
+}
+
+func (f *vfsgen۰CompressedFileInfo) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, fmt.Errorf("cannot Readdir from file %s", f.name)
+}
+func (f *vfsgen۰CompressedFileInfo) Stat() (os.FileInfo, error) { return f, nil }
+
+func (f *vfsgen۰CompressedFileInfo) GzipBytes() []byte {
+	return f.compressedContent
+}
+
+func (f *vfsgen۰CompressedFileInfo) Name() string       { return f.name }
+func (f *vfsgen۰CompressedFileInfo) Size() int64        { return f.uncompressedSize }
+func (f *vfsgen۰CompressedFileInfo) Mode() os.FileMode  { return 0444 }
+func (f *vfsgen۰CompressedFileInfo) ModTime() time.Time { return f.modTime }
+func (f *vfsgen۰CompressedFileInfo) IsDir() bool        { return false }
+func (f *vfsgen۰CompressedFileInfo) Sys() interface{}   { return nil }
+
+// vfsgen۰CompressedFile is an opened compressedFile instance.
+type vfsgen۰CompressedFile struct {
+	*vfsgen۰CompressedFileInfo
+	gr      *gzip.Reader
+	grPos   int64 // Actual gr uncompressed position.
+	seekPos int64 // Seek uncompressed position.
+}
+
+func (f *vfsgen۰CompressedFile) Read(p []byte) (n int, err error) {
+	if f.grPos > f.seekPos {
+		// Rewind to beginning.
+		err = f.gr.Reset(bytes.NewReader(f.compressedContent))
+		if err != nil {
+			return 0, err
+		}
+		f.grPos = 0
+	}
+	if f.grPos < f.seekPos {
+		// Fast-forward.
+		_, err = io.CopyN(ioutil.Discard, f.gr, f.seekPos-f.grPos)
+		if err != nil {
+			return 0, err
+		}
+		f.grPos = f.seekPos
+	}
+	n, err = f.gr.Read(p)
+	f.grPos += int64(n)
+	f.seekPos = f.grPos
+	return n, err
+}
+func (f *vfsgen۰CompressedFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		f.seekPos = 0 + offset
+	case io.SeekCurrent:
+		f.seekPos += offset
+	case io.SeekEnd:
+		f.seekPos = f.uncompressedSize + offset
+	default:
+		panic(fmt.Errorf("invalid whence value: %v", whence))
+	}
+	return f.seekPos, nil
+}
+func (f *vfsgen۰CompressedFile) Close() error {
+	return f.gr.Close()
+}
+
+// vfsgen۰DirInfo is a static definition of a directory.
+type vfsgen۰DirInfo struct {
+	name    string
+	modTime time.Time
+	entries []os.FileInfo
+}
+
+func (d *vfsgen۰DirInfo) Read([]byte) (int, error) {
+	return 0, fmt.Errorf("cannot Read from directory %s", d.name)
+}
+func (d *vfsgen۰DirInfo) Close() error               { return nil }
+func (d *vfsgen۰DirInfo) Stat() (os.FileInfo, error) { return d, nil }
+
+func (d *vfsgen۰DirInfo) Name() string       { return d.name }
+func (d *vfsgen۰DirInfo) Size() int64        { return 0 }
+func (d *vfsgen۰DirInfo) Mode() os.FileMode  { return 0755 | os.ModeDir }
+func (d *vfsgen۰DirInfo) ModTime() time.Time { return d.modTime }
+func (d *vfsgen۰DirInfo) IsDir() bool        { return true }
+func (d *vfsgen۰DirInfo) Sys() interface{}   { return nil }
+
+// vfsgen۰Dir is an opened dir instance.
+type vfsgen۰Dir struct {
+	*vfsgen۰DirInfo
+	pos int // Position within entries for Seek and Readdir.
+}
+
+func (d *vfsgen۰Dir) Seek(offset int64, whence int) (int64, error) {
+	if offset == 0 && whence == io.SeekStart {
+		d.pos = 0
+		return 0, nil
+	}
+	return 0, fmt.Errorf("unsupported Seek in directory %s", d.name)
+}
+
+func (d *vfsgen۰Dir) Readdir(count int) ([]os.FileInfo, error) {
+	if d.pos >= len(d.entries) && count > 0 {
+		return nil, io.EOF
+	}
+	if count <= 0 || count > len(d.entries)-d.pos {
+		count = len(d.entries) - d.pos
+	}
+	e := d.entries[d.pos : d.pos+count]
+	d.pos += count
+	return e, nil
+}
@@ -0,0 +1,11 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// +build dev
+
+package auth
+
+import "net/http"
+
+// Assets are auth assets, read from disk.
+var Assets http.FileSystem = http.Dir("auth/assets")
@@ -0,0 +1,288 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package auth handles logging in and out of the admin.
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aymerick/raymond"
+	"github.com/go-chi/chi"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/rs/zerolog"
+	"github.com/shurcooL/httpfs/filter"
+	"github.com/shurcooL/httpfs/vfsutil"
+
+	"github.com/runbilliam/billiam/internal/session"
+	"github.com/runbilliam/billiam/internal/user"
+)
+
+// CookieName is the name of the session cookie.
+const CookieName = "billiam_session"
+
+// SessionLifetime is the duration of inactivity after which a session expires.
+const SessionLifetime = 12 * time.Hour
+
+// LoginPath is the path of the login page.
+const LoginPath = "/auth/login"
+
+// NoticeSetupCompleted is the login page notice shown when
+// setup was completed by someone else first.
+const NoticeSetupCompleted = "setup_completed"
+
+// notices maps login page notices to their messages.
+var notices = map[string]string{
+	NoticeSetupCompleted: "Setup was already completed by someone else. Your account was not created.",
+}
+
+type data struct {
+	Email  string
+	Next   string
+	Error  string
+	Notice string
+}
+
+type credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Handler handles auth routes.
+type Handler struct {
+	logger         *zerolog.Logger
+	db             *pgxpool.Pool
+	passwordParams user.PasswordParams
+	secureCookies  bool
+}
+
+// NewHandler creates a new auth handler.
+//
+// Secure cookies should be used when serving over HTTPS.
+func NewHandler(logger *zerolog.Logger, db *pgxpool.Pool, passwordParams user.PasswordParams, secureCookies bool) *Handler {
+	h := Handler{
+		logger:         logger,
+		db:             db,
+		passwordParams: passwordParams,
+		secureCookies:  secureCookies,
+	}
+	return &h
+}
+
+// Routes attaches auth routes to the router.
+func (h *Handler) Routes(r chi.Router) {
+	r.Get("/login", h.Login)
+	r.Post("/login", h.SubmitLogin)
+	r.Post("/logout", h.Logout)
+	r.Get("/assets/*", h.ServeAssets)
+}
+
+// Login renders the login page.
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	data := data{
+		Next:   safeRedirect(r.URL.Query().Get("next")),
+		Notice: notices[r.URL.Query().Get("notice")],
+	}
+	h.render(w, "login.html.hbs", data)
+}
+
+// SubmitLogin handles the login page submit.
+//
+// Accepts both form and JSON submissions. JSON submissions
+// receive the logged in user as the response.
+func (h *Handler) SubmitLogin(w http.ResponseWriter, r *http.Request) {
+	isJSON := strings.HasPrefix(r.Header.Get("Content-Type"), "application/json")
+	var creds credentials
+	if isJSON {
+		if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+			h.writeJSON(w, http.StatusBadRequest, errorResponse{"invalid_request", "Invalid JSON body."})
+			return
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			h.handleError(w, err)
+			return
+		}
+		creds.Email = r.PostFormValue("email")
+		creds.Password = r.PostFormValue("password")
+	}
+	creds.Email = strings.TrimSpace(creds.Email)
+
+	ctx := r.Context()
+	u, err := user.NewRepository(h.db).Authenticate(ctx, creds.Email, creds.Password, h.passwordParams)
+	if err != nil {
+		if !errors.Is(err, user.ErrInvalidCredentials) {
+			h.handleError(w, err)
+			return
+		}
+		h.logger.Info().Str("email", creds.Email).Msg("Failed login")
+		if isJSON {
+			h.writeJSON(w, http.StatusUnauthorized, errorResponse{"invalid_credentials", "Invalid email or password."})
+			return
+		}
+		data := data{
+			Email: creds.Email,
+			Next:  safeRedirect(r.PostFormValue("next")),
+			Error: "Invalid email or password.",
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		h.render(w, "login.html.hbs", data)
+		return
+	}
+
+	sessions := session.NewStore(h.db)
+	sess, token, err := session.New(u.ID, SessionLifetime)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	if err := sessions.Create(ctx, sess); err != nil {
+		h.handleError(w, err)
+		return
+	}
+	// Opportunistically clean up after other users.
+	if _, err := sessions.DeleteExpired(ctx); err != nil {
+		h.logger.Warn().Msg(err.Error())
+	}
+	h.setCookie(w, token, sess.ExpiresAt)
+
+	if isJSON {
+		h.writeJSON(w, http.StatusOK, u)
+		return
+	}
+	next := safeRedirect(r.PostFormValue("next"))
+	if next == "" {
+		next = "/"
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// Logout logs out the current user.
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(CookieName); err == nil {
+		if err := session.NewStore(h.db).Delete(r.Context(), cookie.Value); err != nil {
+			h.handleError(w, err)
+			return
+		}
+	}
+	h.clearCookie(w)
+
+	if wantsJSON(r) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	http.Redirect(w, r, LoginPath, http.StatusSeeOther)
+}
+
+// CurrentUser responds with the current user.
+//
+// Must be used behind the RequireUser middleware.
+func (h *Handler) CurrentUser(w http.ResponseWriter, r *http.Request) {
+	u, ok := UserFromContext(r.Context())
+	if !ok {
+		h.writeJSON(w, http.StatusUnauthorized, errorResponse{"unauthorized", "Authentication required."})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, u)
+}
+
+// ServeAssets serves assets.
+func (h *Handler) ServeAssets(w http.ResponseWriter, r *http.Request) {
+	// Skip directory listings and templates.
+	fs := filter.Skip(Assets, func(path string, fi os.FileInfo) bool {
+		return fi.IsDir() || strings.HasPrefix(path, "/templates/")
+	})
+	rctx := chi.RouteContext(r.Context())
+	pathPrefix := strings.TrimSuffix(rctx.RoutePattern(), "/*")
+	fsHandler := http.StripPrefix(pathPrefix, http.FileServer(fs))
+	fsHandler.ServeHTTP(w, r)
+}
+
+func (h *Handler) setCookie(w http.ResponseWriter, token string, expiresAt time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expiresAt,
+		Secure:   h.secureCookies,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (h *Handler) clearCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   h.secureCookies,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (h *Handler) render(w http.ResponseWriter, filename string, data interface{}) {
+	b, err := vfsutil.ReadFile(Assets, "templates/"+filename)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	tpl, err := raymond.Parse(string(b))
+	if err != nil {
+		err := fmt.Errorf("%v: %w", filename, err)
+		h.handleError(w, err)
+		return
+	}
+	result, err := tpl.Exec(data)
+	if err != nil {
+		err := fmt.Errorf("%v: %w", filename, err)
+		h.handleError(w, err)
+		return
+	}
+	w.Write([]byte(result))
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b)
+}
+
+func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	h.logger.Error().Msg(err.Error())
+	http.Error(w, "Internal Server Error", 500)
+}
+
+// safeRedirect returns the given path if it is safe to redirect to.
+//
+// Only local paths are allowed, to prevent open redirects.
+func safeRedirect(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return ""
+	}
+	return path
+}
+
+// wantsJSON returns whether the client expects a JSON response.
+func wantsJSON(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/api/") ||
+		strings.Contains(r.Header.Get("Accept"), "application/json") ||
+		strings.HasPrefix(r.Header.Get("Content-Type"), "application/json")
+}
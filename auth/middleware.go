@@ -0,0 +1,89 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/runbilliam/billiam/internal/session"
+	"github.com/runbilliam/billiam/internal/user"
+)
+
+type contextKey struct{}
+
+// NewContext returns a new context that carries the given user.
+func NewContext(ctx context.Context, u user.User) context.Context {
+	return context.WithValue(ctx, contextKey{}, u)
+}
+
+// UserFromContext returns the user stored in the given context, if any.
+func UserFromContext(ctx context.Context) (user.User, bool) {
+	u, ok := ctx.Value(contextKey{}).(user.User)
+	return u, ok
+}
+
+// RequireUser is a middleware that only allows logged in users through.
+//
+// Anonymous users are redirected to the login page, or receive a 401
+// response if they expect JSON. The session is extended on activity.
+func (h *Handler) RequireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(CookieName)
+		if err != nil {
+			h.unauthorized(w, r)
+			return
+		}
+		ctx := r.Context()
+		sessions := session.NewStore(h.db)
+		sess, err := sessions.Get(ctx, cookie.Value)
+		if err != nil {
+			if errors.Is(err, session.ErrNotFound) {
+				h.clearCookie(w)
+				h.unauthorized(w, r)
+				return
+			}
+			h.handleError(w, err)
+			return
+		}
+		u, err := user.NewRepository(h.db).Get(ctx, sess.UserID)
+		if err != nil && !errors.Is(err, user.ErrNotFound) {
+			h.handleError(w, err)
+			return
+		}
+		if err != nil || !u.Active {
+			if err := sessions.Delete(ctx, cookie.Value); err != nil {
+				h.handleError(w, err)
+				return
+			}
+			h.clearCookie(w)
+			h.unauthorized(w, r)
+			return
+		}
+		// Extend the session once half of its lifetime has passed,
+		// to avoid writing to the database on every request.
+		if time.Until(sess.ExpiresAt) < SessionLifetime/2 {
+			expiresAt := time.Now().UTC().Add(SessionLifetime)
+			if err := sessions.Extend(ctx, sess.ID, expiresAt); err != nil {
+				h.handleError(w, err)
+				return
+			}
+			h.setCookie(w, cookie.Value, expiresAt)
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(ctx, u)))
+	})
+}
+
+// unauthorized responds to an anonymous user.
+func (h *Handler) unauthorized(w http.ResponseWriter, r *http.Request) {
+	if wantsJSON(r) {
+		h.writeJSON(w, http.StatusUnauthorized, errorResponse{"unauthorized", "Authentication required."})
+		return
+	}
+	http.Redirect(w, r, LoginPath+"?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+}
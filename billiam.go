@@ -27,6 +27,16 @@ import (
 	"github.com/shurcooL/httpfs/vfsutil"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/runbilliam/billiam/api"
+	"github.com/runbilliam/billiam/auth"
+	"github.com/runbilliam/billiam/internal/creditnote"
+	"github.com/runbilliam/billiam/internal/dunning"
+	"github.com/runbilliam/billiam/internal/gateway"
+	"github.com/runbilliam/billiam/internal/invoice"
+	"github.com/runbilliam/billiam/internal/invoice/pdf"
+	"github.com/runbilliam/billiam/internal/meter"
+	"github.com/runbilliam/billiam/internal/subscription"
+	"github.com/runbilliam/billiam/internal/worker"
 	"github.com/runbilliam/billiam/pkg/log"
 	"github.com/runbilliam/billiam/setup"
 )
@@ -44,10 +54,17 @@ type Application struct {
 	db             *pgxpool.Pool
 	mainServer     *httpx.Server
 	redirectServer *httpx.Server
+	gateways       *gateway.Registry
+	// stopWorker stops the background worker, once started.
+	stopWorker context.CancelFunc
 }
 
 // New creates a new application.
 func New(cfg *Config, logger *zerolog.Logger, db *pgxpool.Pool) (*Application, error) {
+	gateways, err := cfg.gateways()
+	if err != nil {
+		return nil, err
+	}
 	// Initialize the HTTP servers.
 	var mainServer, redirectServer *httpx.Server
 	httpAddr := toAddr(cfg.Server.Listen)
@@ -73,6 +90,7 @@ func New(cfg *Config, logger *zerolog.Logger, db *pgxpool.Pool) (*Application, e
 		db:             db,
 		mainServer:     mainServer,
 		redirectServer: redirectServer,
+		gateways:       gateways,
 	}
 
 	return app, nil
@@ -86,8 +104,15 @@ func (app *Application) Start() error {
 		return err
 	}
 	app.mainServer.Handler = app.buildRouter()
+	w := app.buildWorker()
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	app.stopWorker = stopWorker
 
 	g, ctx := errgroup.WithContext(context.Background())
+	g.Go(func() error {
+		w.Start(workerCtx)
+		return nil
+	})
 	g.Go(func() error {
 		proto := "HTTP"
 		if app.mainServer.IsTLS() {
@@ -112,6 +137,7 @@ func (app *Application) Start() error {
 		// The context is closed if both servers finish, or one of them
 		// errors out, in which case we want to close the other and return.
 		<-ctx.Done()
+		stopWorker()
 		app.mainServer.Close()
 		if app.redirectServer != nil {
 			app.redirectServer.Close()
@@ -124,6 +150,9 @@ func (app *Application) Start() error {
 // Shutdown shuts down the application.
 func (app *Application) Shutdown() error {
 	app.logger.Info().Msgf("Shutting down")
+	if app.stopWorker != nil {
+		app.stopWorker()
+	}
 
 	if app.redirectServer != nil {
 		redirectTimeout := 1 * time.Second
@@ -147,6 +176,56 @@ func (app *Application) Shutdown() error {
 	return nil
 }
 
+// buildWorker builds the background worker and its jobs.
+func (app *Application) buildWorker() *worker.Worker {
+	w := worker.New(app.db, app.logger)
+	generator := invoice.NewGenerator(app.db, app.logger)
+	w.Add(worker.Job{
+		Name:     "generate_invoices",
+		Interval: time.Minute,
+		Run: func(ctx context.Context) error {
+			_, err := generator.Run(ctx, time.Now())
+			return err
+		},
+	})
+	collector := dunning.NewCollector(app.db, app.gateways, app.logger)
+	w.Add(worker.Job{
+		Name:     "collect_payments",
+		Interval: time.Minute,
+		Run: func(ctx context.Context) error {
+			_, err := collector.Run(ctx, time.Now())
+			return err
+		},
+	})
+	notifier := subscription.NewTrialNotifier(app.db, app.logger)
+	w.Add(worker.Job{
+		Name:     "notify_trials",
+		Interval: time.Minute,
+		Run: func(ctx context.Context) error {
+			_, err := notifier.Run(ctx, time.Now())
+			return err
+		},
+	})
+	refunder := creditnote.NewRefunder(app.db, app.gateways, app.logger)
+	w.Add(worker.Job{
+		Name:     "refund_credit_notes",
+		Interval: time.Minute,
+		Run: func(ctx context.Context) error {
+			_, err := refunder.Run(ctx)
+			return err
+		},
+	})
+	w.Add(worker.Job{
+		Name:     "create_usage_partitions",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			return meter.EnsurePartitions(ctx, app.db, time.Now())
+		},
+	})
+
+	return w
+}
+
 // UpdateDB applies database schema updates.
 func (app *Application) UpdateDB() error {
 	ctx := context.Background()
@@ -194,12 +273,21 @@ func (app *Application) buildRouter() *chi.Mux {
 	// Log only responses (default is request&response).
 	httplog.DefaultOptions.Concise = true
 
-	setupHandler := setup.NewHandler(app.logger)
+	setupHandler := setup.NewHandler(app.logger, app.db, app.cfg.passwordParams(), app.cfg.passwordPolicy())
+	authHandler := auth.NewHandler(app.logger, app.db, app.cfg.passwordParams(), app.mainServer.IsTLS())
+	// The site directory is the current directory, see cmd/billiam.
+	apiHandler := api.NewHandler(app.logger, app.db, app.gateways, pdf.NewRenderer("."))
 
 	r := chi.NewRouter()
 	r.Use(httplog.RequestLogger(*app.logger))
 	r.Use(middleware.Heartbeat("/health"))
 	r.Route("/setup", setupHandler.Routes)
+	r.Route("/auth", authHandler.Routes)
+	r.Route("/api", func(r chi.Router) {
+		r.Use(authHandler.RequireUser)
+		r.Get("/me", authHandler.CurrentUser)
+		apiHandler.Routes(r)
+	})
 
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("Hello World"))
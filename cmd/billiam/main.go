@@ -30,7 +30,7 @@ Commands:
 func main() {
 	if len(os.Args) < 2 {
 		fmt.Fprintln(os.Stdout, "Billiam is a headless recuring billing system")
-		fmt.Fprintln(os.Stdout, usage)
+		fmt.Fprint(os.Stdout, usage)
 		return
 	}
 	cmd := os.Args[1]
@@ -46,7 +46,7 @@ func main() {
 		cmdVersion()
 	default:
 		fmt.Fprintln(os.Stderr, "Error: Unknown command", cmd)
-		fmt.Fprintln(os.Stderr, usage)
+		fmt.Fprint(os.Stderr, usage)
 		os.Exit(2)
 	}
 }
@@ -83,7 +83,7 @@ func cmdServe() {
 
 	errCh := make(chan error)
 	go func() {
-		shutdownCh := make(chan os.Signal)
+		shutdownCh := make(chan os.Signal, 1)
 		signal.Notify(shutdownCh, os.Interrupt, syscall.SIGTERM)
 		<-shutdownCh
 
@@ -10,6 +10,11 @@ import (
 
 	"github.com/bojanz/envx"
 	"github.com/pelletier/go-toml"
+
+	"github.com/runbilliam/billiam/internal/gateway"
+	"github.com/runbilliam/billiam/internal/gateway/stripe"
+	"github.com/runbilliam/billiam/internal/gateway/testgateway"
+	"github.com/runbilliam/billiam/internal/user"
 )
 
 const exampleConfig = `
@@ -29,6 +34,24 @@ url = "postgres://${DB_USER}:${DB_PASS}@${DB_HOST}:${DB_PORT:5432}/${DB_NAME}"
 [log]
 format = "${LOG_FORMAT:json}" # One of: text, json.
 level = "${LOG_LEVEL:info}" # One of: debug, info, warn, error, fatal.
+
+[password]
+min_length = 10 # Minimum number of characters.
+min_classes = 2 # Minimum number of character classes (lowercase, uppercase, digits, symbols).
+memory = 65536 # argon2id memory, in KiB.
+iterations = 3 # argon2id iterations.
+threads = 2 # argon2id parallelism.
+
+[gateways]
+default = "" # The gateway used for new payment methods. Defaults to the first enabled gateway.
+
+[gateways.test]
+enabled = false # In-process gateway with magic card numbers, for testing only.
+
+[gateways.stripe]
+enabled = false
+secret_key = "${STRIPE_SECRET_KEY}"
+api_url = "${STRIPE_API_URL:https://api.stripe.com}" # Point to stripe-mock for testing.
 `
 
 // Config represents the app configuration.
@@ -46,6 +69,24 @@ type Config struct {
 		Format string
 		Level  string
 	}
+	Password struct {
+		MinLength  int `toml:"min_length"`
+		MinClasses int `toml:"min_classes"`
+		Memory     uint32
+		Iterations uint32
+		Threads    uint8
+	}
+	Gateways struct {
+		Default string
+		Test    struct {
+			Enabled bool
+		}
+		Stripe struct {
+			Enabled   bool
+			SecretKey string `toml:"secret_key"`
+			APIURL    string `toml:"api_url"`
+		}
+	}
 }
 
 // CreateConfig creates a config file with the given filename.
@@ -84,6 +125,63 @@ func ReadConfig(filename string) (*Config, error) {
 	config.Database.URL = envx.Expand(config.Database.URL)
 	config.Log.Format = envx.Expand(config.Log.Format)
 	config.Log.Level = envx.Expand(config.Log.Level)
+	config.Gateways.Default = envx.Expand(config.Gateways.Default)
+	config.Gateways.Stripe.SecretKey = envx.Expand(config.Gateways.Stripe.SecretKey)
+	config.Gateways.Stripe.APIURL = envx.Expand(config.Gateways.Stripe.APIURL)
 
 	return config, nil
 }
+
+// passwordParams returns the password hashing parameters.
+//
+// Unset values fall back to user.DefaultPasswordParams.
+func (c *Config) passwordParams() user.PasswordParams {
+	params := user.DefaultPasswordParams
+	if c.Password.Memory != 0 {
+		params.Memory = c.Password.Memory
+	}
+	if c.Password.Iterations != 0 {
+		params.Iterations = c.Password.Iterations
+	}
+	if c.Password.Threads != 0 {
+		params.Threads = c.Password.Threads
+	}
+
+	return params
+}
+
+// passwordPolicy returns the password policy.
+//
+// Unset values fall back to user.DefaultPasswordPolicy.
+func (c *Config) passwordPolicy() user.PasswordPolicy {
+	policy := user.DefaultPasswordPolicy
+	if c.Password.MinLength != 0 {
+		policy.MinLength = c.Password.MinLength
+	}
+	if c.Password.MinClasses != 0 {
+		policy.MinClasses = c.Password.MinClasses
+	}
+
+	return policy
+}
+
+// gateways returns the registry of configured gateways.
+func (c *Config) gateways() (*gateway.Registry, error) {
+	registry := gateway.NewRegistry()
+	if c.Gateways.Test.Enabled {
+		registry.Register("test", testgateway.New())
+	}
+	if c.Gateways.Stripe.Enabled {
+		if c.Gateways.Stripe.SecretKey == "" {
+			return nil, fmt.Errorf("gateways: the stripe secret key is required")
+		}
+		registry.Register("stripe", stripe.New(c.Gateways.Stripe.SecretKey, c.Gateways.Stripe.APIURL))
+	}
+	if c.Gateways.Default != "" {
+		if err := registry.SetDefault(c.Gateways.Default); err != nil {
+			return nil, fmt.Errorf("gateways: default gateway %q is not enabled", c.Gateways.Default)
+		}
+	}
+
+	return registry, nil
+}
@@ -37,4 +37,28 @@ func main() {
 		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
+
+	err = vfsgen.Generate(http.Dir("auth/assets"), vfsgen.Options{
+		Filename:        "auth/assets.go",
+		PackageName:     "auth",
+		BuildTags:       "!dev",
+		VariableName:    "Assets",
+		VariableComment: "Assets are auth assets, embedded by vfsgen.",
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+
+	err = vfsgen.Generate(http.Dir("internal/invoice/pdf/assets"), vfsgen.Options{
+		Filename:        "internal/invoice/pdf/assets.go",
+		PackageName:     "pdf",
+		BuildTags:       "!dev",
+		VariableName:    "Assets",
+		VariableComment: "Assets are invoice PDF assets, embedded by vfsgen.",
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
 }
@@ -9,13 +9,16 @@ require (
 	github.com/bojanz/httpx v0.0.0-20201007111036-dc00a5928a4a
 	github.com/go-chi/chi v4.1.2+incompatible
 	github.com/go-chi/httplog v0.1.6
+	github.com/jackc/pgconn v1.7.0
 	github.com/jackc/pgx/v4 v4.9.0
 	github.com/jackc/tern v1.12.1
+	github.com/jung-kurt/gofpdf v1.16.2
 	github.com/oklog/ulid/v2 v2.0.2
 	github.com/pelletier/go-toml v1.8.1
 	github.com/rs/zerolog v1.20.0
 	github.com/shurcooL/httpfs v0.0.0-20190707220628-8d4bc4ba7749
 	github.com/shurcooL/vfsgen v0.0.0-20200824052919-0d455de96546
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
 	golang.org/x/sync v0.0.0-20201020160332-67f06af15bc9
 	gopkg.in/yaml.v2 v2.3.0 // indirect
 )
@@ -0,0 +1,155 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package balance provides the customer credit balance.
+//
+// The balance is kept as an append-only ledger of transactions,
+// per customer and currency. The balance is the sum of the amounts,
+// also stored per customer and currency, and updated along with each
+// transaction while its row is locked. This keeps concurrent processes
+// from spending the same credit twice.
+package balance
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bojanz/currency"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+// MaxDescriptionLength is the maximum length of a transaction description, in characters.
+const MaxDescriptionLength = 500
+
+// ErrInsufficientBalance is returned when a debit exceeds the balance.
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+// TransactionType represents the type of a balance transaction.
+type TransactionType string
+
+// Transaction types.
+const (
+	// TransactionCreditNote is used for credits from credit notes.
+	TransactionCreditNote TransactionType = "credit_note"
+	// TransactionProration is used for credits from proration
+	// invoices with a negative total.
+	TransactionProration TransactionType = "proration"
+	// TransactionAdjustment is used for manual credits and debits.
+	TransactionAdjustment TransactionType = "adjustment"
+	// TransactionInvoicePayment is used for debits of credit
+	// applied to invoices on finalization.
+	TransactionInvoicePayment TransactionType = "invoice_payment"
+	// TransactionOverpayment is used for credits from payments
+	// exceeding the amount due.
+	TransactionOverpayment TransactionType = "overpayment"
+)
+
+// GetTransactionTypes returns all known transaction types.
+func GetTransactionTypes() []TransactionType {
+	return []TransactionType{
+		TransactionCreditNote,
+		TransactionProration,
+		TransactionAdjustment,
+		TransactionInvoicePayment,
+		TransactionOverpayment,
+	}
+}
+
+// IsValid returns whether t is a known transaction type.
+func (t TransactionType) IsValid() bool {
+	for _, transactionType := range GetTransactionTypes() {
+		if t == transactionType {
+			return true
+		}
+	}
+	return false
+}
+
+// Balance represents a customer's balance in a single currency.
+type Balance struct {
+	CustomerID ulid.ULID       `json:"customer_id"`
+	Amount     currency.Amount `json:"amount"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+// Transaction represents a change of a customer's balance.
+type Transaction struct {
+	ID         ulid.ULID       `json:"id"`
+	CustomerID ulid.ULID       `json:"customer_id"`
+	Type       TransactionType `json:"type"`
+	// Amount is positive for credits, and negative for debits.
+	Amount       currency.Amount `json:"amount"`
+	CreditNoteID ulid.ULID       `json:"credit_note_id"`
+	InvoiceID    ulid.ULID       `json:"invoice_id"`
+	Description  string          `json:"description"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// NewTransaction creates a new balance transaction.
+func NewTransaction(customerID ulid.ULID, transactionType TransactionType, amount currency.Amount) Transaction {
+	now := time.Now().UTC()
+	t := Transaction{
+		ID:         ulid.MustNew(ulid.Timestamp(now), rand.Reader),
+		CustomerID: customerID,
+		Type:       transactionType,
+		Amount:     amount,
+		CreatedAt:  now,
+	}
+
+	return t
+}
+
+// Validate validates the transaction.
+func (t Transaction) Validate() validation.Errors {
+	errs := validation.Errors{}
+	if t.ID == (ulid.ULID{}) {
+		errs.Add("id", validation.Required("ID is required."))
+	}
+	if t.CustomerID == (ulid.ULID{}) {
+		errs.Add("customer_id", validation.Required("Customer is required."))
+	}
+	if t.Type == "" {
+		errs.Add("type", validation.Required("Type is required."))
+	} else if !t.Type.IsValid() {
+		errs.Add("type", validation.InvalidChoice("Invalid type."))
+	}
+	if t.Amount.CurrencyCode() == "" {
+		errs.Add("amount", validation.Required("Amount is required."))
+	} else if t.Amount.IsZero() {
+		errs.Add("amount", validation.InvalidValue("Amount can't be zero."))
+	}
+	if utf8.RuneCountInString(t.Description) > MaxDescriptionLength {
+		errs.Add("description", validation.InvalidValue(fmt.Sprintf("Description can't be longer than %d characters.", MaxDescriptionLength)))
+	}
+	if t.CreatedAt.IsZero() {
+		errs.Add("created_at", validation.Required("CreatedAt is required."))
+	}
+
+	return errs
+}
+
+// Available returns the part of the given balance which can be
+// applied to the given amount due: the smaller of the two, or zero
+// if either is not positive.
+func Available(balance, due currency.Amount) (currency.Amount, error) {
+	zero, err := currency.NewAmount("0", due.CurrencyCode())
+	if err != nil {
+		return currency.Amount{}, err
+	}
+	if !balance.IsPositive() || !due.IsPositive() {
+		return zero, nil
+	}
+	cmp, err := balance.Cmp(due)
+	if err != nil {
+		return currency.Amount{}, err
+	}
+	if cmp < 0 {
+		return balance, nil
+	}
+	return due, nil
+}
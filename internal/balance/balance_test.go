@@ -0,0 +1,86 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package balance_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bojanz/currency"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/balance"
+	"github.com/runbilliam/billiam/internal/testutil"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+func TestAvailable(t *testing.T) {
+	tests := []struct {
+		balance string
+		due     string
+		want    string
+	}{
+		{"5.00", "12.00", "5.00"},
+		{"12.00", "12.00", "12.00"},
+		{"20.00", "12.00", "12.00"},
+		{"0", "12.00", "0"},
+		{"20.00", "0", "0"},
+		{"20.00", "-3.00", "0"},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got, err := balance.Available(mustAmount(t, tt.balance), mustAmount(t, tt.due))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Number() != tt.want || got.CurrencyCode() != "EUR" {
+				t.Errorf("got %v, want %v EUR", got, tt.want)
+			}
+		})
+	}
+
+	usd, _ := currency.NewAmount("5.00", "USD")
+	if _, err := balance.Available(usd, mustAmount(t, "12.00")); err == nil {
+		t.Error("expected a currency mismatch error")
+	}
+}
+
+func TestTransaction_Validate(t *testing.T) {
+	tests := []struct {
+		modify   func(tr *balance.Transaction)
+		wantPath string
+		wantCode string
+	}{
+		{func(tr *balance.Transaction) { tr.CustomerID = ulid.ULID{} }, "customer_id", validation.CodeRequired},
+		{func(tr *balance.Transaction) { tr.Type = "" }, "type", validation.CodeRequired},
+		{func(tr *balance.Transaction) { tr.Type = "refund" }, "type", validation.CodeInvalidChoice},
+		{func(tr *balance.Transaction) { tr.Amount = currency.Amount{} }, "amount", validation.CodeRequired},
+		{func(tr *balance.Transaction) { tr.Amount, _ = currency.NewAmount("0.00", "EUR") }, "amount", validation.CodeInvalidValue},
+		{func(tr *balance.Transaction) { tr.Description = strings.Repeat("a", balance.MaxDescriptionLength+1) }, "description", validation.CodeInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			amount, _ := currency.NewAmount("-10.00", "EUR")
+			tr := balance.NewTransaction(testutil.NewID(), balance.TransactionAdjustment, amount)
+			if errs := tr.Validate(); !errs.IsEmpty() {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			tt.modify(&tr)
+			errs := tr.Validate()
+			err, ok := errs.Get(tt.wantPath).(validation.Error)
+			if !ok || err.Code != tt.wantCode {
+				t.Errorf("%v: got %#v, want a %v error", tt.wantPath, errs.Get(tt.wantPath), tt.wantCode)
+			}
+		})
+	}
+}
+
+func mustAmount(t *testing.T, n string) currency.Amount {
+	t.Helper()
+	a, err := currency.NewAmount(n, "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
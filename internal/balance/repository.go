@@ -0,0 +1,201 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package balance
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bojanz/currency"
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+const transactionColumns = `id, customer_id, type, amount::TEXT, currency, credit_note_id, invoice_id,
+	description, created_at`
+
+// Repository loads and saves balance transactions.
+type Repository struct {
+	db database.Querier
+}
+
+// NewRepository creates a new balance repository.
+func NewRepository(db database.Querier) *Repository {
+	return &Repository{db: db}
+}
+
+// Get gets the balance of the given customer in the given currency.
+func (r *Repository) Get(ctx context.Context, customerID ulid.ULID, currencyCode string) (currency.Amount, error) {
+	var amount string
+	err := r.db.QueryRow(ctx, `
+		SELECT amount::TEXT FROM customer_balances
+		WHERE customer_id = $1 AND currency = $2`,
+		customerID.String(), currencyCode,
+	).Scan(&amount)
+	if errors.Is(err, pgx.ErrNoRows) {
+		amount = "0"
+	} else if err != nil {
+		return currency.Amount{}, err
+	}
+
+	return currency.NewAmount(amount, currencyCode)
+}
+
+// GetForUpdate gets and locks the balance of the given customer in the
+// given currency, until the end of the current transaction.
+//
+// Used before debiting the balance, so that concurrent debits can't
+// spend the same credit. Missing balances are created first, so that
+// there is always a row to lock.
+func (r *Repository) GetForUpdate(ctx context.Context, customerID ulid.ULID, currencyCode string) (currency.Amount, error) {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO customer_balances (customer_id, currency, amount, updated_at)
+		VALUES ($1, $2, 0, $3)
+		ON CONFLICT (customer_id, currency) DO NOTHING`,
+		customerID.String(), currencyCode, time.Now().UTC(),
+	)
+	if err != nil {
+		return currency.Amount{}, err
+	}
+	var amount string
+	err = r.db.QueryRow(ctx, `
+		SELECT amount::TEXT FROM customer_balances
+		WHERE customer_id = $1 AND currency = $2 FOR UPDATE`,
+		customerID.String(), currencyCode,
+	).Scan(&amount)
+	if err != nil {
+		return currency.Amount{}, err
+	}
+
+	return currency.NewAmount(amount, currencyCode)
+}
+
+// List lists the balances of the given customer, ordered by currency.
+//
+// Currencies without any transactions are not included.
+func (r *Repository) List(ctx context.Context, customerID ulid.ULID) ([]Balance, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT customer_id, amount::TEXT, currency, updated_at FROM customer_balances
+		WHERE customer_id = $1 ORDER BY currency`,
+		customerID.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []Balance
+	for rows.Next() {
+		var b Balance
+		var customerID, amount, currencyCode string
+		if err := rows.Scan(&customerID, &amount, &currencyCode, &b.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if b.CustomerID, err = ulid.Parse(customerID); err != nil {
+			return nil, err
+		}
+		if b.Amount, err = currency.NewAmount(amount, currencyCode); err != nil {
+			return nil, err
+		}
+		balances = append(balances, b)
+	}
+
+	return balances, rows.Err()
+}
+
+// ListTransactions lists the balance transactions of the given customer, oldest first.
+func (r *Repository) ListTransactions(ctx context.Context, customerID ulid.ULID) ([]Transaction, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+transactionColumns+` FROM customer_balance_transactions
+		WHERE customer_id = $1 ORDER BY id`,
+		customerID.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []Transaction
+	for rows.Next() {
+		var t Transaction
+		var id, customerID, transactionType, amount, currencyCode string
+		var creditNoteID, invoiceID *string
+		err := rows.Scan(&id, &customerID, &transactionType, &amount, &currencyCode, &creditNoteID,
+			&invoiceID, &t.Description, &t.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if t.ID, err = ulid.Parse(id); err != nil {
+			return nil, err
+		}
+		if t.CustomerID, err = ulid.Parse(customerID); err != nil {
+			return nil, err
+		}
+		if t.CreditNoteID, err = parseID(creditNoteID); err != nil {
+			return nil, err
+		}
+		if t.InvoiceID, err = parseID(invoiceID); err != nil {
+			return nil, err
+		}
+		if t.Amount, err = currency.NewAmount(amount, currencyCode); err != nil {
+			return nil, err
+		}
+		t.Type = TransactionType(transactionType)
+		transactions = append(transactions, t)
+	}
+
+	return transactions, rows.Err()
+}
+
+// Create creates the given balance transaction, and updates the balance.
+//
+// The balance row stays locked until the end of the transaction.
+// Debits exceeding the balance fail with ErrInsufficientBalance.
+func (r *Repository) Create(ctx context.Context, t Transaction) error {
+	err := database.WithTx(ctx, r.db, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO customer_balances (customer_id, currency, amount, updated_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (customer_id, currency) DO UPDATE
+			SET amount = customer_balances.amount + EXCLUDED.amount, updated_at = EXCLUDED.updated_at`,
+			t.CustomerID.String(), t.Amount.CurrencyCode(), t.Amount.Number(), t.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO customer_balance_transactions (id, customer_id, type, amount, currency, credit_note_id,
+				invoice_id, description, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			t.ID.String(), t.CustomerID.String(), string(t.Type), t.Amount.Number(), t.Amount.CurrencyCode(),
+			nullID(t.CreditNoteID), nullID(t.InvoiceID), t.Description, t.CreatedAt,
+		)
+		return err
+	})
+	if database.IsCheckViolation(err, "customer_balances_amount_check") {
+		return ErrInsufficientBalance
+	}
+
+	return err
+}
+
+// nullID converts a zero ID to nil, for use with nullable columns.
+func nullID(id ulid.ULID) *string {
+	if id == (ulid.ULID{}) {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
+// parseID parses a nullable ID, returning the zero ID for nil.
+func parseID(id *string) (ulid.ULID, error) {
+	if id == nil {
+		return ulid.ULID{}, nil
+	}
+	return ulid.Parse(*id)
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package catalog provides products and their prices.
+//
+// Products and prices are archived instead of deleted, so that
+// existing subscriptions keep referencing their prices.
+// Once created, a price's amount and interval can't be changed.
+package catalog
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bojanz/currency"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+// ErrProductNotFound is returned when a product could not be found.
+var ErrProductNotFound = errors.New("product not found")
+
+// ErrPriceNotFound is returned when a price could not be found.
+var ErrPriceNotFound = errors.New("price not found")
+
+// MaxTrialDays is the maximum length of a free trial, in days.
+const MaxTrialDays = 730
+
+// Product represents something that is sold, e.g. "Pro plan".
+type Product struct {
+	ID          ulid.ULID `json:"id"`
+	Version     int       `json:"version"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	ArchivedAt  time.Time `json:"archived_at"`
+}
+
+// NewProduct creates a new product.
+func NewProduct() Product {
+	now := time.Now().UTC()
+	p := Product{
+		ID:        ulid.MustNew(ulid.Timestamp(now), rand.Reader),
+		Version:   1,
+		CreatedAt: now,
+	}
+
+	return p
+}
+
+// IsArchived returns whether the product has been archived.
+func (p Product) IsArchived() bool {
+	return !p.ArchivedAt.IsZero()
+}
+
+// Validate validates the product.
+func (p Product) Validate() validation.Errors {
+	errs := validation.Errors{}
+	if p.ID == (ulid.ULID{}) {
+		errs.Add("id", validation.Required("ID is required."))
+	}
+	if p.Version == 0 {
+		errs.Add("version", validation.Required("Version is required."))
+	}
+	if p.Name == "" {
+		errs.Add("name", validation.Required("Name is required."))
+	}
+	if p.CreatedAt.IsZero() {
+		errs.Add("created_at", validation.Required("CreatedAt is required."))
+	}
+
+	return errs
+}
+
+// Price represents the recurring price of a product, e.g. "10 EUR per month".
+type Price struct {
+	ID        ulid.ULID `json:"id"`
+	ProductID ulid.ULID `json:"product_id"`
+	Nickname  string    `json:"nickname"`
+	// Model is the pricing model, see Calculate.
+	Model Model `json:"model"`
+	// Amount is the price per unit, or per package for package prices.
+	// Tiered prices use their tiers instead, with a zero amount
+	// determining the currency.
+	Amount currency.Amount `json:"amount"`
+	// PackageSize is the number of units per package, for package prices.
+	PackageSize int64 `json:"package_size"`
+	// Tiers are used by tiered prices, ordered by quantity.
+	Tiers []Tier `json:"tiers"`
+	// MinQuantity and MaxQuantity limit the subscription quantity,
+	// e.g. the number of seats. Zero if there is no limit.
+	MinQuantity int64    `json:"min_quantity"`
+	MaxQuantity int64    `json:"max_quantity"`
+	Interval    Interval `json:"interval"`
+	// MeterID is set for metered prices, which bill the usage reported
+	// for the meter at the end of each period, as the price quantity.
+	MeterID ulid.ULID `json:"meter_id"`
+	// TrialDays is the length of the free trial given to new subscriptions.
+	// Zero if the price has no trial.
+	TrialDays int       `json:"trial_days"`
+	CreatedAt time.Time `json:"created_at"`
+	// ArchivedAt is set when the price is no longer available for new subscriptions.
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+// NewPrice creates a new price for the given product.
+func NewPrice(productID ulid.ULID) Price {
+	now := time.Now().UTC()
+	p := Price{
+		ID:        ulid.MustNew(ulid.Timestamp(now), rand.Reader),
+		ProductID: productID,
+		Model:     ModelFlat,
+		CreatedAt: now,
+	}
+
+	return p
+}
+
+// IsArchived returns whether the price has been archived.
+func (p Price) IsArchived() bool {
+	return !p.ArchivedAt.IsZero()
+}
+
+// IsMetered returns whether the price bills reported usage.
+func (p Price) IsMetered() bool {
+	return p.MeterID != (ulid.ULID{})
+}
+
+// CheckQuantity checks whether the given subscription quantity is
+// allowed by the price.
+//
+// Errors are reported on the "quantity" path.
+func (p Price) CheckQuantity(quantity int64) validation.Errors {
+	errs := validation.Errors{}
+	minimum := p.MinQuantity
+	if minimum < 1 {
+		minimum = 1
+	}
+	if quantity < minimum {
+		errs.Add("quantity", validation.InvalidValue(fmt.Sprintf("Quantity must be at least %d.", minimum)))
+	} else if p.MaxQuantity != 0 && quantity > p.MaxQuantity {
+		errs.Add("quantity", validation.InvalidValue(fmt.Sprintf("Quantity can't be more than %d.", p.MaxQuantity)))
+	}
+
+	return errs
+}
+
+// Validate validates the price.
+func (p Price) Validate() validation.Errors {
+	errs := validation.Errors{}
+	if p.ID == (ulid.ULID{}) {
+		errs.Add("id", validation.Required("ID is required."))
+	}
+	if p.ProductID == (ulid.ULID{}) {
+		errs.Add("product_id", validation.Required("Product is required."))
+	}
+	if p.Amount.CurrencyCode() == "" {
+		errs.Add("amount", validation.Required("Amount is required."))
+	}
+	if p.CreatedAt.IsZero() {
+		errs.Add("created_at", validation.Required("CreatedAt is required."))
+	}
+
+	if p.Amount.CurrencyCode() != "" && p.Amount.IsNegative() {
+		errs.Add("amount", validation.InvalidValue("Amount can't be negative."))
+	}
+	if p.MinQuantity < 0 {
+		errs.Add("min_quantity", validation.InvalidValue("Minimum quantity can't be negative."))
+	}
+	if p.MaxQuantity < 0 {
+		errs.Add("max_quantity", validation.InvalidValue("Maximum quantity can't be negative."))
+	} else if p.MaxQuantity != 0 && p.MaxQuantity < p.MinQuantity {
+		errs.Add("max_quantity", validation.InvalidValue("Maximum quantity can't be less than the minimum quantity."))
+	}
+	if p.IsMetered() && p.TrialDays != 0 {
+		errs.Add("trial_days", validation.InvalidValue("Metered prices can't have a trial."))
+	}
+	if p.TrialDays < 0 || p.TrialDays > MaxTrialDays {
+		errs.Add("trial_days", validation.InvalidValue(fmt.Sprintf("Trial days must be between 0 and %d.", MaxTrialDays)))
+	}
+	errs.Merge("", p.validatePricing())
+	errs.Merge("interval", p.Interval.Validate())
+
+	return errs
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package catalog_test
+
+import (
+	"testing"
+
+	"github.com/runbilliam/billiam/internal/catalog"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+func TestPrice_CheckQuantity(t *testing.T) {
+	tests := []struct {
+		min      int64
+		max      int64
+		quantity int64
+		wantErr  bool
+	}{
+		{0, 0, 1, false},
+		{0, 0, 1000, false},
+		{0, 0, 0, true},
+		{5, 0, 5, false},
+		{5, 0, 4, true},
+		{5, 10, 10, false},
+		{5, 10, 11, true},
+		{0, 10, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			p := newPrice(catalog.ModelFlat, "10")
+			p.MinQuantity = tt.min
+			p.MaxQuantity = tt.max
+			errs := p.CheckQuantity(tt.quantity)
+			if tt.wantErr {
+				err, ok := errs.Get("quantity").(validation.Error)
+				if !ok || err.Code != validation.CodeInvalidValue {
+					t.Errorf("%v-%v, %v: got %#v, want an invalid value error", tt.min, tt.max, tt.quantity, errs.Get("quantity"))
+				}
+			} else if !errs.IsEmpty() {
+				t.Errorf("%v-%v, %v: unexpected errors: %v", tt.min, tt.max, tt.quantity, errs)
+			}
+		})
+	}
+
+	p := newPrice(catalog.ModelFlat, "10")
+	p.MinQuantity = 10
+	p.MaxQuantity = 5
+	err, ok := p.Validate().Get("max_quantity").(validation.Error)
+	if !ok || err.Code != validation.CodeInvalidValue {
+		t.Errorf("got %#v, want an invalid value error", p.Validate().Get("max_quantity"))
+	}
+}
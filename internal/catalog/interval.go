@@ -0,0 +1,64 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package catalog
+
+import (
+	"fmt"
+
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+// IntervalUnit represents the unit of a billing interval.
+type IntervalUnit string
+
+// Interval units.
+const (
+	IntervalDay   IntervalUnit = "day"
+	IntervalWeek  IntervalUnit = "week"
+	IntervalMonth IntervalUnit = "month"
+	IntervalYear  IntervalUnit = "year"
+)
+
+// maxIntervalCounts limits each interval to 3 years.
+var maxIntervalCounts = map[IntervalUnit]int{
+	IntervalDay:   1095,
+	IntervalWeek:  156,
+	IntervalMonth: 36,
+	IntervalYear:  3,
+}
+
+// Interval represents a billing interval, e.g. "every 3 months".
+type Interval struct {
+	Unit  IntervalUnit `json:"unit"`
+	Count int          `json:"count"`
+}
+
+// String returns the string representation of i.
+func (i Interval) String() string {
+	if i.Count == 1 {
+		return string(i.Unit)
+	}
+	return fmt.Sprintf("%d %ss", i.Count, i.Unit)
+}
+
+// Validate validates the interval.
+func (i Interval) Validate() validation.Errors {
+	errs := validation.Errors{}
+	if i.Unit == "" {
+		errs.Add("unit", validation.Required("Unit is required."))
+	}
+	if i.Count == 0 {
+		errs.Add("count", validation.Required("Count is required."))
+	}
+
+	maxCount, ok := maxIntervalCounts[i.Unit]
+	if i.Unit != "" && !ok {
+		errs.Add("unit", validation.InvalidChoice("Invalid interval unit."))
+	}
+	if i.Count < 0 || (ok && i.Count > maxCount) {
+		errs.Add("count", validation.InvalidValue(fmt.Sprintf("Count must be between 1 and %d.", maxCount)))
+	}
+
+	return errs
+}
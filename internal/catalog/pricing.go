@@ -0,0 +1,212 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package catalog
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bojanz/currency"
+
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+// Model represents a pricing model.
+type Model string
+
+// Pricing models.
+const (
+	// ModelFlat charges the price amount per unit.
+	ModelFlat Model = "flat"
+	// ModelPackage charges the price amount per package of units,
+	// e.g. per 1000 API calls. Partial packages are charged in full.
+	ModelPackage Model = "package"
+	// ModelGraduated charges the units within each tier at that tier's
+	// unit amount, e.g. the first 100 units at 1 EUR, the rest at 0.80 EUR.
+	// The tier's flat amount is charged once any unit falls into the tier.
+	ModelGraduated Model = "graduated"
+	// ModelVolume charges all units at the unit amount of the tier
+	// reached by the total quantity, plus that tier's flat amount.
+	ModelVolume Model = "volume"
+	// ModelStairstep charges the flat amount of the tier reached
+	// by the total quantity, regardless of the exact quantity.
+	ModelStairstep Model = "stairstep"
+)
+
+// IsValid returns whether m is a known pricing model.
+func (m Model) IsValid() bool {
+	switch m {
+	case ModelFlat, ModelPackage, ModelGraduated, ModelVolume, ModelStairstep:
+		return true
+	}
+	return false
+}
+
+// IsTiered returns whether the pricing model uses tiers.
+func (m Model) IsTiered() bool {
+	return m == ModelGraduated || m == ModelVolume || m == ModelStairstep
+}
+
+// Tier represents a price tier.
+type Tier struct {
+	// UpTo is the highest quantity in the tier, inclusive.
+	// Zero for the last tier, which has no upper bound.
+	UpTo       int64           `json:"up_to"`
+	UnitAmount currency.Amount `json:"unit_amount"`
+	FlatAmount currency.Amount `json:"flat_amount"`
+}
+
+// Charge represents a part of the amount calculated for a quantity.
+//
+// Flat and package prices have a single charge. Tiered prices
+// have a charge per tier that contributed to the amount.
+type Charge struct {
+	// Tier is the 1-based position of the tier in Price.Tiers.
+	// Zero for prices without tiers.
+	Tier int `json:"tier"`
+	// Quantity is the number of units charged at UnitAmount,
+	// or the number of packages for package prices.
+	Quantity   int64           `json:"quantity"`
+	UnitAmount currency.Amount `json:"unit_amount"`
+	FlatAmount currency.Amount `json:"flat_amount"`
+	// Amount is the charged amount, not rounded.
+	Amount currency.Amount `json:"amount"`
+}
+
+// Calculate calculates the amount for the given quantity.
+//
+// The amount is not rounded, so that it can be rounded once,
+// on the invoice line. Returns the amount and its breakdown.
+// Nothing is charged for a zero quantity.
+func (p Price) Calculate(quantity int64) (currency.Amount, []Charge, error) {
+	zero, err := currency.NewAmount("0", p.Amount.CurrencyCode())
+	if err != nil {
+		return currency.Amount{}, nil, err
+	}
+	if quantity < 0 {
+		return currency.Amount{}, nil, fmt.Errorf("price %v: negative quantity %d", p.ID, quantity)
+	}
+	if quantity == 0 {
+		return zero, nil, nil
+	}
+
+	var charges []Charge
+	switch p.Model {
+	case ModelFlat, "":
+		charges = append(charges, Charge{Quantity: quantity, UnitAmount: p.Amount, FlatAmount: zero})
+	case ModelPackage:
+		if p.PackageSize <= 0 {
+			return currency.Amount{}, nil, fmt.Errorf("price %v: invalid package size %d", p.ID, p.PackageSize)
+		}
+		packages := (quantity + p.PackageSize - 1) / p.PackageSize
+		charges = append(charges, Charge{Quantity: packages, UnitAmount: p.Amount, FlatAmount: zero})
+	case ModelGraduated:
+		var previous int64
+		for i, t := range p.Tiers {
+			upTo := t.UpTo
+			if upTo == 0 || upTo > quantity {
+				upTo = quantity
+			}
+			charges = append(charges, Charge{Tier: i + 1, Quantity: upTo - previous, UnitAmount: t.UnitAmount, FlatAmount: t.FlatAmount})
+			previous = upTo
+			if previous == quantity {
+				break
+			}
+		}
+		if previous != quantity {
+			return currency.Amount{}, nil, fmt.Errorf("price %v: no tier for quantity %d", p.ID, quantity)
+		}
+	case ModelVolume, ModelStairstep:
+		i := p.findTier(quantity)
+		if i == -1 {
+			return currency.Amount{}, nil, fmt.Errorf("price %v: no tier for quantity %d", p.ID, quantity)
+		}
+		t := p.Tiers[i]
+		if p.Model == ModelVolume {
+			charges = append(charges, Charge{Tier: i + 1, Quantity: quantity, UnitAmount: t.UnitAmount, FlatAmount: t.FlatAmount})
+		} else {
+			charges = append(charges, Charge{Tier: i + 1, Quantity: quantity, UnitAmount: zero, FlatAmount: t.FlatAmount})
+		}
+	default:
+		return currency.Amount{}, nil, fmt.Errorf("price %v: unknown pricing model %q", p.ID, p.Model)
+	}
+
+	total := zero
+	for i, c := range charges {
+		amount, err := c.UnitAmount.Mul(strconv.FormatInt(c.Quantity, 10))
+		if err != nil {
+			return currency.Amount{}, nil, err
+		}
+		if amount, err = amount.Add(c.FlatAmount); err != nil {
+			return currency.Amount{}, nil, err
+		}
+		if total, err = total.Add(amount); err != nil {
+			return currency.Amount{}, nil, err
+		}
+		charges[i].Amount = amount
+	}
+
+	return total, charges, nil
+}
+
+// findTier returns the index of the tier containing the given quantity,
+// or -1 if there is none.
+func (p Price) findTier(quantity int64) int {
+	for i, t := range p.Tiers {
+		if t.UpTo == 0 || quantity <= t.UpTo {
+			return i
+		}
+	}
+	return -1
+}
+
+// validatePricing validates the price's pricing model and tiers.
+func (p Price) validatePricing() validation.Errors {
+	errs := validation.Errors{}
+	if p.Model == "" {
+		errs.Add("model", validation.Required("Model is required."))
+	} else if !p.Model.IsValid() {
+		errs.Add("model", validation.InvalidChoice("Invalid model."))
+	}
+	if p.Model == ModelPackage && p.PackageSize <= 0 {
+		errs.Add("package_size", validation.InvalidValue("Package size must be positive."))
+	}
+	if p.Model != ModelPackage && p.PackageSize != 0 {
+		errs.Add("package_size", validation.InvalidValue("Package size is only allowed for package prices."))
+	}
+	if !p.Model.IsTiered() {
+		if len(p.Tiers) > 0 {
+			errs.Add("tiers", validation.InvalidValue("Tiers are only allowed for tiered prices."))
+		}
+		return errs
+	}
+	if len(p.Tiers) == 0 {
+		errs.Add("tiers", validation.Required("Tiers are required."))
+		return errs
+	}
+
+	currencyCode := p.Amount.CurrencyCode()
+	var previous int64
+	for i, t := range p.Tiers {
+		path := "tiers." + strconv.Itoa(i)
+		last := i == len(p.Tiers)-1
+		if last && t.UpTo != 0 {
+			errs.Add(path+".up_to", validation.InvalidValue("The last tier can't have an upper bound."))
+		} else if !last && t.UpTo <= previous {
+			errs.Add(path+".up_to", validation.InvalidValue("Tier bounds must be in ascending order."))
+		}
+		previous = t.UpTo
+		for field, amount := range map[string]currency.Amount{"unit_amount": t.UnitAmount, "flat_amount": t.FlatAmount} {
+			if amount.CurrencyCode() == "" {
+				errs.Add(path+"."+field, validation.Required("Amount is required."))
+			} else if amount.CurrencyCode() != currencyCode {
+				errs.Add(path+"."+field, validation.InvalidValue("Amount must be in the price currency."))
+			} else if amount.IsNegative() {
+				errs.Add(path+"."+field, validation.InvalidValue("Amount can't be negative."))
+			}
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package catalog_test
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/bojanz/currency"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/catalog"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+func TestPrice_Calculate(t *testing.T) {
+	flat := newPrice(catalog.ModelFlat, "0.0015")
+	pkg := newPrice(catalog.ModelPackage, "2.50")
+	pkg.PackageSize = 1000
+	graduated := newPrice(catalog.ModelGraduated, "0")
+	graduated.Tiers = []catalog.Tier{
+		{UpTo: 100, UnitAmount: amount("1.00"), FlatAmount: amount("0")},
+		{UpTo: 1000, UnitAmount: amount("0.80"), FlatAmount: amount("5")},
+		{UnitAmount: amount("0.50"), FlatAmount: amount("0")},
+	}
+	volume := graduated
+	volume.Model = catalog.ModelVolume
+	stairstep := newPrice(catalog.ModelStairstep, "0")
+	stairstep.Tiers = []catalog.Tier{
+		{UpTo: 5, UnitAmount: amount("0"), FlatAmount: amount("10")},
+		{UpTo: 20, UnitAmount: amount("0"), FlatAmount: amount("30")},
+		{UnitAmount: amount("0"), FlatAmount: amount("50")},
+	}
+
+	tests := []struct {
+		price       catalog.Price
+		quantity    int64
+		wantAmount  string
+		wantCharges int
+	}{
+		{flat, 0, "0", 0},
+		{flat, 3, "0.0045", 1},
+		{pkg, 1, "2.50", 1},
+		{pkg, 1000, "2.50", 1},
+		{pkg, 1001, "5.00", 1},
+		{graduated, 0, "0", 0},
+		{graduated, 50, "50.00", 1},
+		{graduated, 100, "100.00", 1},
+		{graduated, 101, "105.80", 2},
+		{graduated, 1500, "1075.00", 3},
+		{volume, 50, "50.00", 1},
+		{volume, 101, "85.80", 1},
+		{volume, 1500, "750.00", 1},
+		{stairstep, 1, "10", 1},
+		{stairstep, 5, "10", 1},
+		{stairstep, 6, "30", 1},
+		{stairstep, 100, "50", 1},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got, charges, err := tt.price.Calculate(tt.quantity)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(amount(tt.wantAmount)) {
+				t.Errorf("%v x %v: got %v, want %v", tt.price.Model, tt.quantity, got.Number(), tt.wantAmount)
+			}
+			if len(charges) != tt.wantCharges {
+				t.Errorf("%v x %v: got %v charges, want %v", tt.price.Model, tt.quantity, len(charges), tt.wantCharges)
+			}
+			// The breakdown adds up to the amount.
+			sum := amount("0")
+			for _, c := range charges {
+				sum, _ = sum.Add(c.Amount)
+			}
+			if !sum.Equal(got) {
+				t.Errorf("%v x %v: got a breakdown of %v, want %v", tt.price.Model, tt.quantity, sum.Number(), got.Number())
+			}
+		})
+	}
+
+	// Graduated breakdown.
+	_, charges, _ := graduated.Calculate(1500)
+	wantQuantities := []int64{100, 900, 500}
+	for i, c := range charges {
+		if c.Tier != i+1 {
+			t.Errorf("got %v, want %v", c.Tier, i+1)
+		}
+		if c.Quantity != wantQuantities[i] {
+			t.Errorf("got %v, want %v", c.Quantity, wantQuantities[i])
+		}
+	}
+	// Package breakdown.
+	_, charges, _ = pkg.Calculate(2500)
+	if charges[0].Quantity != 3 {
+		t.Errorf("got %v packages, want 3", charges[0].Quantity)
+	}
+
+	if _, _, err := flat.Calculate(-1); err == nil {
+		t.Error("expected an error for a negative quantity")
+	}
+}
+
+func TestPrice_ValidatePricing(t *testing.T) {
+	tests := []struct {
+		modify   func(p *catalog.Price)
+		wantPath string
+		wantCode string
+	}{
+		{func(p *catalog.Price) { p.Model = "" }, "model", validation.CodeRequired},
+		{func(p *catalog.Price) { p.Model = "dynamic" }, "model", validation.CodeInvalidChoice},
+		{func(p *catalog.Price) { p.Tiers = nil }, "tiers", validation.CodeRequired},
+		{func(p *catalog.Price) { p.Model = catalog.ModelFlat }, "tiers", validation.CodeInvalidValue},
+		{func(p *catalog.Price) { p.Model = catalog.ModelPackage }, "package_size", validation.CodeInvalidValue},
+		{func(p *catalog.Price) { p.PackageSize = 10 }, "package_size", validation.CodeInvalidValue},
+		{func(p *catalog.Price) { p.Tiers[1].UpTo = 100 }, "tiers.1.up_to", validation.CodeInvalidValue},
+		{func(p *catalog.Price) { p.Tiers[2].UpTo = 5000 }, "tiers.2.up_to", validation.CodeInvalidValue},
+		{func(p *catalog.Price) { p.Tiers[0].UnitAmount = currency.Amount{} }, "tiers.0.unit_amount", validation.CodeRequired},
+		{func(p *catalog.Price) { p.Tiers[0].FlatAmount, _ = currency.NewAmount("1", "USD") }, "tiers.0.flat_amount", validation.CodeInvalidValue},
+		{func(p *catalog.Price) { p.Tiers[1].UnitAmount = amount("-1") }, "tiers.1.unit_amount", validation.CodeInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			p := newPrice(catalog.ModelGraduated, "0")
+			p.Tiers = []catalog.Tier{
+				{UpTo: 100, UnitAmount: amount("1.00"), FlatAmount: amount("0")},
+				{UpTo: 1000, UnitAmount: amount("0.80"), FlatAmount: amount("0")},
+				{UnitAmount: amount("0.50"), FlatAmount: amount("0")},
+			}
+			if errs := p.Validate(); !errs.IsEmpty() {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			tt.modify(&p)
+			errs := p.Validate()
+			err, ok := errs.Get(tt.wantPath).(validation.Error)
+			if !ok || err.Code != tt.wantCode {
+				t.Errorf("%v: got %#v, want a %v error", tt.wantPath, errs.Get(tt.wantPath), tt.wantCode)
+			}
+		})
+	}
+}
+
+func newPrice(model catalog.Model, n string) catalog.Price {
+	p := catalog.NewPrice(ulid.MustNew(ulid.Now(), rand.Reader))
+	p.Model = model
+	p.Amount = amount(n)
+	p.Interval = catalog.Interval{Unit: catalog.IntervalMonth, Count: 1}
+	return p
+}
+
+func amount(n string) currency.Amount {
+	a, _ := currency.NewAmount(n, "EUR")
+	return a
+}
@@ -0,0 +1,319 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bojanz/currency"
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+const productColumns = `id, version, name, description, created_at, updated_at, archived_at`
+
+const priceColumns = `id, product_id, nickname, model, amount::TEXT, currency, package_size, tiers,
+	min_quantity, max_quantity, interval_unit, interval_count, meter_id, trial_days, created_at, archived_at`
+
+// ConflictError is returned when a product could not be updated
+// because it was modified in the meantime.
+type ConflictError struct {
+	ID      ulid.ULID
+	Version int
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("product %v was modified, version %v is out of date", e.ID, e.Version)
+}
+
+// Repository loads and saves products and prices.
+type Repository struct {
+	db database.Querier
+}
+
+// NewRepository creates a new catalog repository.
+func NewRepository(db database.Querier) *Repository {
+	return &Repository{db: db}
+}
+
+// GetProduct gets the product with the given ID.
+func (r *Repository) GetProduct(ctx context.Context, id ulid.ULID) (Product, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+productColumns+` FROM products WHERE id = $1`, id.String())
+
+	return scanProduct(row)
+}
+
+// ListProducts lists products, ordered by name.
+//
+// Archived products are only included if requested.
+func (r *Repository) ListProducts(ctx context.Context, includeArchived bool) ([]Product, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+productColumns+` FROM products
+		WHERE $1 OR archived_at IS NULL
+		ORDER BY name, id`,
+		includeArchived,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []Product
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+
+	return products, rows.Err()
+}
+
+// CreateProduct creates the given product.
+func (r *Repository) CreateProduct(ctx context.Context, p Product) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO products (`+productColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		p.ID.String(), p.Version, p.Name, p.Description, p.CreatedAt,
+		database.NullTime(p.UpdatedAt), database.NullTime(p.ArchivedAt),
+	)
+
+	return err
+}
+
+// UpdateProduct updates the given product.
+//
+// The update only succeeds if the stored version matches p.Version,
+// otherwise a *ConflictError is returned. On success, p.Version is
+// incremented and p.UpdatedAt is set to the current time.
+func (r *Repository) UpdateProduct(ctx context.Context, p *Product) error {
+	updatedAt := time.Now().UTC()
+	tag, err := r.db.Exec(ctx, `
+		UPDATE products
+		SET version = version + 1, name = $3, description = $4, updated_at = $5, archived_at = $6
+		WHERE id = $1 AND version = $2`,
+		p.ID.String(), p.Version, p.Name, p.Description, updatedAt, database.NullTime(p.ArchivedAt),
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		var exists bool
+		err := r.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)`, p.ID.String()).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrProductNotFound
+		}
+		return &ConflictError{ID: p.ID, Version: p.Version}
+	}
+	p.Version++
+	p.UpdatedAt = updatedAt
+
+	return nil
+}
+
+// ArchiveProduct archives the product with the given ID, along with its prices.
+//
+// Archived products and prices remain available to existing subscriptions.
+func (r *Repository) ArchiveProduct(ctx context.Context, id ulid.ULID) error {
+	now := time.Now().UTC()
+	return database.WithTx(ctx, r.db, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE products
+			SET version = version + 1, updated_at = $2, archived_at = COALESCE(archived_at, $2)
+			WHERE id = $1`,
+			id.String(), now,
+		)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrProductNotFound
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE prices SET archived_at = $2
+			WHERE product_id = $1 AND archived_at IS NULL`,
+			id.String(), now,
+		)
+		return err
+	})
+}
+
+// GetPrice gets the price with the given ID.
+func (r *Repository) GetPrice(ctx context.Context, id ulid.ULID) (Price, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+priceColumns+` FROM prices WHERE id = $1`, id.String())
+
+	return scanPrice(row)
+}
+
+// ListPrices lists the prices of the given product, ordered by creation time.
+//
+// Archived prices are only included if requested.
+func (r *Repository) ListPrices(ctx context.Context, productID ulid.ULID, includeArchived bool) ([]Price, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+priceColumns+` FROM prices
+		WHERE product_id = $1 AND ($2 OR archived_at IS NULL)
+		ORDER BY id`,
+		productID.String(), includeArchived,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prices []Price
+	for rows.Next() {
+		p, err := scanPrice(rows)
+		if err != nil {
+			return nil, err
+		}
+		prices = append(prices, p)
+	}
+
+	return prices, rows.Err()
+}
+
+// CreatePrice creates the given price.
+func (r *Repository) CreatePrice(ctx context.Context, p Price) error {
+	tiers := p.Tiers
+	if tiers == nil {
+		tiers = []Tier{}
+	}
+	tiersJSON, err := json.Marshal(tiers)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO prices (id, product_id, nickname, model, amount, currency, package_size, tiers,
+			min_quantity, max_quantity, interval_unit, interval_count, meter_id, trial_days, created_at, archived_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`,
+		p.ID.String(), p.ProductID.String(), p.Nickname, string(p.Model), p.Amount.Number(), p.Amount.CurrencyCode(),
+		p.PackageSize, tiersJSON, p.MinQuantity, p.MaxQuantity, string(p.Interval.Unit), p.Interval.Count, nullID(p.MeterID), p.TrialDays,
+		p.CreatedAt, database.NullTime(p.ArchivedAt),
+	)
+
+	return err
+}
+
+// UpdatePriceNickname updates the nickname of the price with the given ID.
+//
+// The nickname is the only part of a price that can be changed.
+func (r *Repository) UpdatePriceNickname(ctx context.Context, id ulid.ULID, nickname string) error {
+	tag, err := r.db.Exec(ctx, `UPDATE prices SET nickname = $2 WHERE id = $1`, id.String(), nickname)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPriceNotFound
+	}
+
+	return nil
+}
+
+// ArchivePrice archives the price with the given ID.
+func (r *Repository) ArchivePrice(ctx context.Context, id ulid.ULID) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE prices SET archived_at = COALESCE(archived_at, $2) WHERE id = $1`,
+		id.String(), time.Now().UTC(),
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPriceNotFound
+	}
+
+	return nil
+}
+
+// UnarchivePrice makes the price with the given ID available again.
+func (r *Repository) UnarchivePrice(ctx context.Context, id ulid.ULID) error {
+	tag, err := r.db.Exec(ctx, `UPDATE prices SET archived_at = NULL WHERE id = $1`, id.String())
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPriceNotFound
+	}
+
+	return nil
+}
+
+// scanProduct scans a product from the given row.
+func scanProduct(row pgx.Row) (Product, error) {
+	var p Product
+	var id string
+	var updatedAt, archivedAt *time.Time
+	err := row.Scan(&id, &p.Version, &p.Name, &p.Description, &p.CreatedAt, &updatedAt, &archivedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Product{}, ErrProductNotFound
+		}
+		return Product{}, err
+	}
+	if p.ID, err = ulid.Parse(id); err != nil {
+		return Product{}, err
+	}
+	p.UpdatedAt = database.TimeValue(updatedAt)
+	p.ArchivedAt = database.TimeValue(archivedAt)
+
+	return p, nil
+}
+
+// scanPrice scans a price from the given row.
+func scanPrice(row pgx.Row) (Price, error) {
+	var p Price
+	var id, productID, model, amount, currencyCode, unit string
+	var tiers []byte
+	var meterID *string
+	var archivedAt *time.Time
+	err := row.Scan(&id, &productID, &p.Nickname, &model, &amount, &currencyCode, &p.PackageSize, &tiers,
+		&p.MinQuantity, &p.MaxQuantity, &unit, &p.Interval.Count, &meterID, &p.TrialDays, &p.CreatedAt, &archivedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Price{}, ErrPriceNotFound
+		}
+		return Price{}, err
+	}
+	if p.ID, err = ulid.Parse(id); err != nil {
+		return Price{}, err
+	}
+	if p.ProductID, err = ulid.Parse(productID); err != nil {
+		return Price{}, err
+	}
+	if p.Amount, err = currency.NewAmount(amount, currencyCode); err != nil {
+		return Price{}, err
+	}
+	if err := json.Unmarshal(tiers, &p.Tiers); err != nil {
+		return Price{}, err
+	}
+	if meterID != nil {
+		if p.MeterID, err = ulid.Parse(*meterID); err != nil {
+			return Price{}, err
+		}
+	}
+	p.Model = Model(model)
+	p.Interval.Unit = IntervalUnit(unit)
+	p.ArchivedAt = database.TimeValue(archivedAt)
+
+	return p, nil
+}
+
+// nullID converts a zero ID to nil, for use with nullable columns.
+func nullID(id ulid.ULID) *string {
+	if id == (ulid.ULID{}) {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
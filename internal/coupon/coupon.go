@@ -0,0 +1,320 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package coupon provides coupons and their redemptions.
+//
+// A coupon is redeemed on a subscription by entering its code.
+// The discount is then applied to the subscription's invoices
+// for the duration of the coupon, see invoice.Invoice.ApplyCoupon.
+package coupon
+
+import (
+	"crypto/rand"
+	"errors"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/bojanz/currency"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+// ErrNotFound is returned when a coupon could not be found.
+var ErrNotFound = errors.New("coupon not found")
+
+// ErrCodeInUse is returned when a coupon code is already in use.
+var ErrCodeInUse = errors.New("coupon code already in use")
+
+// ErrLimitReached is returned when a coupon has reached its maximum number of redemptions.
+var ErrLimitReached = errors.New("coupon redemption limit reached")
+
+// ErrRedemptionNotFound is returned when a redemption could not be found.
+var ErrRedemptionNotFound = errors.New("coupon redemption not found")
+
+// ErrAlreadyRedeemed is returned when a subscription already has a coupon.
+var ErrAlreadyRedeemed = errors.New("subscription already has a coupon")
+
+var rxCode = regexp.MustCompile("^[A-Za-z0-9_-]{1,50}$")
+
+// rxPercent matches percentages with up to 2 decimals, as stored.
+var rxPercent = regexp.MustCompile(`^[0-9]{1,3}(\.[0-9]{1,2})?$`)
+
+// Type represents the type of a coupon.
+type Type string
+
+// Coupon types.
+const (
+	// TypePercent is used for coupons which take a percentage off.
+	TypePercent Type = "percent"
+	// TypeFixed is used for coupons which take a fixed amount off.
+	TypeFixed Type = "fixed"
+)
+
+// IsValid returns whether t is a known coupon type.
+func (t Type) IsValid() bool {
+	return t == TypePercent || t == TypeFixed
+}
+
+// Duration represents how long a redeemed coupon applies.
+type Duration string
+
+// Coupon durations.
+const (
+	// DurationOnce is used for coupons which apply to a single invoice.
+	DurationOnce Duration = "once"
+	// DurationRepeating is used for coupons which apply to a number of periods.
+	DurationRepeating Duration = "repeating"
+	// DurationForever is used for coupons which apply to all invoices.
+	DurationForever Duration = "forever"
+)
+
+// IsValid returns whether d is a known coupon duration.
+func (d Duration) IsValid() bool {
+	return d == DurationOnce || d == DurationRepeating || d == DurationForever
+}
+
+// Coupon represents a discount that customers can redeem, e.g. "SUMMER20".
+type Coupon struct {
+	ID      ulid.ULID `json:"id"`
+	Version int       `json:"version"`
+	// Code is entered by customers to redeem the coupon. Case insensitive.
+	Code string `json:"code"`
+	Name string `json:"name"`
+	Type Type   `json:"type"`
+	// PercentOff is a percentage, e.g. "20" for 20%. Set for percent coupons.
+	PercentOff string `json:"percent_off"`
+	// AmountsOff holds one amount per supported currency. Set for fixed coupons.
+	AmountsOff []currency.Amount `json:"amounts_off"`
+	Duration   Duration          `json:"duration"`
+	// DurationPeriods is the number of periods a repeating coupon applies to.
+	DurationPeriods int `json:"duration_periods"`
+	// MaxRedemptions is the maximum number of redemptions. Zero if unlimited.
+	MaxRedemptions int `json:"max_redemptions"`
+	TimesRedeemed  int `json:"times_redeemed"`
+	// ProductIDs restricts the coupon to the given products. Empty if unrestricted.
+	ProductIDs []ulid.ULID `json:"product_ids"`
+	// ExpiresAt is the time after which the coupon can no longer be redeemed.
+	// Existing redemptions are not affected. Zero if the coupon doesn't expire.
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// New creates a new coupon.
+func New() Coupon {
+	now := time.Now().UTC()
+	c := Coupon{
+		ID:        ulid.MustNew(ulid.Timestamp(now), rand.Reader),
+		Version:   1,
+		CreatedAt: now,
+	}
+
+	return c
+}
+
+// AmountOff returns the amount taken off by a fixed coupon in the given currency.
+func (c Coupon) AmountOff(currencyCode string) (currency.Amount, bool) {
+	for _, amount := range c.AmountsOff {
+		if amount.CurrencyCode() == currencyCode {
+			return amount, true
+		}
+	}
+	return currency.Amount{}, false
+}
+
+// AppliesToProduct returns whether the coupon can be used with the given product.
+func (c Coupon) AppliesToProduct(productID ulid.ULID) bool {
+	if len(c.ProductIDs) == 0 {
+		return true
+	}
+	for _, id := range c.ProductIDs {
+		if id == productID {
+			return true
+		}
+	}
+	return false
+}
+
+// CoversPeriod returns whether the coupon applies to the next
+// invoiced period of the given redemption.
+func (c Coupon) CoversPeriod(r Redemption) bool {
+	switch c.Duration {
+	case DurationOnce:
+		return r.PeriodsApplied < 1
+	case DurationRepeating:
+		return r.PeriodsApplied < c.DurationPeriods
+	case DurationForever:
+		return true
+	}
+	return false
+}
+
+// Discount returns the discount for the given amount.
+//
+// Percent discounts are rounded to the currency's minor units.
+// The discount never exceeds the given amount.
+func (c Coupon) Discount(amount currency.Amount) (currency.Amount, error) {
+	zero, err := currency.NewAmount("0", amount.CurrencyCode())
+	if err != nil {
+		return currency.Amount{}, err
+	}
+	if !amount.IsPositive() {
+		return zero, nil
+	}
+	var discount currency.Amount
+	switch c.Type {
+	case TypePercent:
+		if discount, err = amount.Mul(c.PercentOff); err != nil {
+			return currency.Amount{}, err
+		}
+		if discount, err = discount.Div("100"); err != nil {
+			return currency.Amount{}, err
+		}
+		discount = discount.Round()
+	case TypeFixed:
+		var ok bool
+		if discount, ok = c.AmountOff(amount.CurrencyCode()); !ok {
+			return zero, nil
+		}
+	default:
+		return zero, nil
+	}
+	if cmp, _ := discount.Cmp(amount); cmp > 0 {
+		discount = amount
+	}
+
+	return discount, nil
+}
+
+// CheckRedeemable checks whether the coupon can be redeemed
+// for the given product and currency at the given time.
+//
+// Errors are reported on the "code" path.
+func (c Coupon) CheckRedeemable(productID ulid.ULID, currencyCode string, now time.Time) validation.Errors {
+	errs := validation.Errors{}
+	if !c.ExpiresAt.IsZero() && !now.Before(c.ExpiresAt) {
+		errs.Add("code", validation.Expired("Coupon has expired."))
+	}
+	if c.MaxRedemptions > 0 && c.TimesRedeemed >= c.MaxRedemptions {
+		errs.Add("code", validation.LimitReached("Coupon has been fully redeemed."))
+	}
+	if !c.AppliesToProduct(productID) {
+		errs.Add("code", validation.InvalidChoice("Coupon is not valid for this plan."))
+	}
+	if c.Type == TypeFixed {
+		if _, ok := c.AmountOff(currencyCode); !ok {
+			errs.Add("code", validation.InvalidValue("Coupon is not valid for "+currencyCode+"."))
+		}
+	}
+
+	return errs
+}
+
+// Validate validates the coupon.
+func (c Coupon) Validate() validation.Errors {
+	errs := validation.Errors{}
+	if c.ID == (ulid.ULID{}) {
+		errs.Add("id", validation.Required("ID is required."))
+	}
+	if c.Version == 0 {
+		errs.Add("version", validation.Required("Version is required."))
+	}
+	if c.Code == "" {
+		errs.Add("code", validation.Required("Code is required."))
+	}
+	if c.Name == "" {
+		errs.Add("name", validation.Required("Name is required."))
+	}
+	if c.Type == "" {
+		errs.Add("type", validation.Required("Type is required."))
+	}
+	if c.Duration == "" {
+		errs.Add("duration", validation.Required("Duration is required."))
+	}
+	if c.CreatedAt.IsZero() {
+		errs.Add("created_at", validation.Required("CreatedAt is required."))
+	}
+
+	if c.Code != "" && !rxCode.MatchString(c.Code) {
+		errs.Add("code", validation.InvalidValue("Code must consist of up to 50 letters, numbers, dashes and underscores."))
+	}
+	if c.Type != "" && !c.Type.IsValid() {
+		errs.Add("type", validation.InvalidChoice("Invalid type."))
+	}
+	switch c.Type {
+	case TypePercent:
+		if c.PercentOff == "" {
+			errs.Add("percent_off", validation.Required("Percent off is required."))
+		} else if !rxPercent.MatchString(c.PercentOff) {
+			errs.Add("percent_off", validation.InvalidValue("Percent off must be a number with up to 2 decimals."))
+		} else if percent, err := strconv.ParseFloat(c.PercentOff, 64); err != nil || percent <= 0 || percent > 100 {
+			errs.Add("percent_off", validation.InvalidValue("Percent off must be a percentage between 0 and 100."))
+		}
+		if len(c.AmountsOff) > 0 {
+			errs.Add("amounts_off", validation.InvalidValue("Amounts off can't be used with percent coupons."))
+		}
+	case TypeFixed:
+		if len(c.AmountsOff) == 0 {
+			errs.Add("amounts_off", validation.Required("Amounts off are required."))
+		}
+		if c.PercentOff != "" {
+			errs.Add("percent_off", validation.InvalidValue("Percent off can't be used with fixed coupons."))
+		}
+	}
+	seen := make(map[string]bool, len(c.AmountsOff))
+	for i, amount := range c.AmountsOff {
+		path := "amounts_off." + strconv.Itoa(i)
+		switch {
+		case amount.CurrencyCode() == "":
+			errs.Add(path, validation.Required("Amount is required."))
+		case !amount.IsPositive():
+			errs.Add(path, validation.InvalidValue("Amount must be positive."))
+		case seen[amount.CurrencyCode()]:
+			errs.Add(path, validation.NotUnique("Only one amount per currency is allowed."))
+		}
+		seen[amount.CurrencyCode()] = true
+	}
+	if c.Duration != "" && !c.Duration.IsValid() {
+		errs.Add("duration", validation.InvalidChoice("Invalid duration."))
+	}
+	if c.Duration == DurationRepeating && c.DurationPeriods < 1 {
+		errs.Add("duration_periods", validation.InvalidValue("Repeating coupons must apply to at least one period."))
+	}
+	if c.Duration != DurationRepeating && c.DurationPeriods != 0 {
+		errs.Add("duration_periods", validation.InvalidValue("Duration periods can only be used with repeating coupons."))
+	}
+	if c.MaxRedemptions < 0 {
+		errs.Add("max_redemptions", validation.InvalidValue("Max redemptions can't be negative."))
+	}
+
+	return errs
+}
+
+// Redemption represents a coupon redeemed on a subscription.
+//
+// A subscription can have a single redemption.
+type Redemption struct {
+	ID             ulid.ULID `json:"id"`
+	CouponID       ulid.ULID `json:"coupon_id"`
+	CustomerID     ulid.ULID `json:"customer_id"`
+	SubscriptionID ulid.ULID `json:"subscription_id"`
+	// PeriodsApplied is the number of invoices the coupon was applied to.
+	PeriodsApplied int       `json:"periods_applied"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// NewRedemption creates a new redemption of the given coupon.
+func NewRedemption(couponID, customerID, subscriptionID ulid.ULID) Redemption {
+	now := time.Now().UTC()
+	r := Redemption{
+		ID:             ulid.MustNew(ulid.Timestamp(now), rand.Reader),
+		CouponID:       couponID,
+		CustomerID:     customerID,
+		SubscriptionID: subscriptionID,
+		CreatedAt:      now,
+	}
+
+	return r
+}
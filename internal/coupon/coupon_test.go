@@ -0,0 +1,184 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package coupon_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bojanz/currency"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/coupon"
+	"github.com/runbilliam/billiam/internal/testutil"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+func TestCoupon_Validate(t *testing.T) {
+	c := newPercentCoupon()
+	if errs := c.Validate(); !errs.IsEmpty() {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	c = newFixedCoupon()
+	if errs := c.Validate(); !errs.IsEmpty() {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+
+	tests := []struct {
+		modify   func(c *coupon.Coupon)
+		wantPath string
+		wantCode string
+	}{
+		{func(c *coupon.Coupon) { c.Code = "" }, "code", validation.CodeRequired},
+		{func(c *coupon.Coupon) { c.Code = "SUMMER 20" }, "code", validation.CodeInvalidValue},
+		{func(c *coupon.Coupon) { c.Name = "" }, "name", validation.CodeRequired},
+		{func(c *coupon.Coupon) { c.Type = "free" }, "type", validation.CodeInvalidChoice},
+		{func(c *coupon.Coupon) { c.PercentOff = "" }, "percent_off", validation.CodeRequired},
+		{func(c *coupon.Coupon) { c.PercentOff = "120" }, "percent_off", validation.CodeInvalidValue},
+		{func(c *coupon.Coupon) { c.PercentOff = "0" }, "percent_off", validation.CodeInvalidValue},
+		{func(c *coupon.Coupon) { c.PercentOff = "12.345" }, "percent_off", validation.CodeInvalidValue},
+		{func(c *coupon.Coupon) { c.PercentOff = "1e1" }, "percent_off", validation.CodeInvalidValue},
+		{func(c *coupon.Coupon) { c.AmountsOff = []currency.Amount{amount("5", "EUR")} }, "amounts_off", validation.CodeInvalidValue},
+		{func(c *coupon.Coupon) { c.Duration = "" }, "duration", validation.CodeRequired},
+		{func(c *coupon.Coupon) { c.Duration = "weekly" }, "duration", validation.CodeInvalidChoice},
+		{func(c *coupon.Coupon) { c.Duration = coupon.DurationRepeating }, "duration_periods", validation.CodeInvalidValue},
+		{func(c *coupon.Coupon) { c.DurationPeriods = 3 }, "duration_periods", validation.CodeInvalidValue},
+		{func(c *coupon.Coupon) { c.MaxRedemptions = -1 }, "max_redemptions", validation.CodeInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			c := newPercentCoupon()
+			tt.modify(&c)
+			testutil.AssertError(t, c.Validate(), tt.wantPath, tt.wantCode)
+		})
+	}
+
+	c = newFixedCoupon()
+	c.AmountsOff = nil
+	testutil.AssertError(t, c.Validate(), "amounts_off", validation.CodeRequired)
+	c.AmountsOff = []currency.Amount{amount("5", "EUR"), amount("-5", "USD"), amount("6", "EUR")}
+	errs := c.Validate()
+	testutil.AssertError(t, errs, "amounts_off.1", validation.CodeInvalidValue)
+	testutil.AssertError(t, errs, "amounts_off.2", validation.CodeNotUnique)
+}
+
+func TestCoupon_CheckRedeemable(t *testing.T) {
+	now := time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC)
+	productID := testutil.NewID()
+
+	c := newFixedCoupon()
+	c.ExpiresAt = now.Add(time.Hour)
+	c.MaxRedemptions = 10
+	c.TimesRedeemed = 9
+	c.ProductIDs = []ulid.ULID{productID}
+	if errs := c.CheckRedeemable(productID, "EUR", now); !errs.IsEmpty() {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+
+	tests := []struct {
+		modify       func(c *coupon.Coupon)
+		productID    ulid.ULID
+		currencyCode string
+		wantCode     string
+	}{
+		{func(c *coupon.Coupon) { c.ExpiresAt = now }, productID, "EUR", validation.CodeExpired},
+		{func(c *coupon.Coupon) { c.TimesRedeemed = 10 }, productID, "EUR", validation.CodeLimitReached},
+		{func(c *coupon.Coupon) {}, testutil.NewID(), "EUR", validation.CodeInvalidChoice},
+		{func(c *coupon.Coupon) {}, productID, "JPY", validation.CodeInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			c := c
+			tt.modify(&c)
+			testutil.AssertError(t, c.CheckRedeemable(tt.productID, tt.currencyCode, now), "code", tt.wantCode)
+		})
+	}
+}
+
+func TestCoupon_Discount(t *testing.T) {
+	percent := newPercentCoupon()
+	percent.PercentOff = "12.5"
+	fixed := newFixedCoupon()
+
+	tests := []struct {
+		coupon coupon.Coupon
+		amount currency.Amount
+		want   string
+	}{
+		{percent, amount("19.99", "EUR"), "2.50"},
+		{percent, amount("1999", "JPY"), "250"},
+		{percent, amount("0", "EUR"), "0"},
+		{fixed, amount("19.99", "EUR"), "5.00"},
+		{fixed, amount("19.99", "USD"), "6.00"},
+		// Discounts never exceed the discounted amount.
+		{fixed, amount("3.50", "EUR"), "3.50"},
+		// No amount in the given currency.
+		{fixed, amount("1999", "JPY"), "0"},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got, err := tt.coupon.Discount(tt.amount)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Number() != tt.want || got.CurrencyCode() != tt.amount.CurrencyCode() {
+				t.Errorf("got %v, want %v %v", got, tt.want, tt.amount.CurrencyCode())
+			}
+		})
+	}
+}
+
+func TestCoupon_CoversPeriod(t *testing.T) {
+	tests := []struct {
+		duration       coupon.Duration
+		periods        int
+		periodsApplied int
+		want           bool
+	}{
+		{coupon.DurationOnce, 0, 0, true},
+		{coupon.DurationOnce, 0, 1, false},
+		{coupon.DurationRepeating, 3, 2, true},
+		{coupon.DurationRepeating, 3, 3, false},
+		{coupon.DurationForever, 0, 100, true},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			c := newPercentCoupon()
+			c.Duration = tt.duration
+			c.DurationPeriods = tt.periods
+			r := coupon.NewRedemption(c.ID, testutil.NewID(), testutil.NewID())
+			r.PeriodsApplied = tt.periodsApplied
+			if got := c.CoversPeriod(r); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newPercentCoupon() coupon.Coupon {
+	c := coupon.New()
+	c.Code = "SUMMER20"
+	c.Name = "Summer sale"
+	c.Type = coupon.TypePercent
+	c.PercentOff = "20"
+	c.Duration = coupon.DurationOnce
+
+	return c
+}
+
+func newFixedCoupon() coupon.Coupon {
+	c := coupon.New()
+	c.Code = "WELCOME"
+	c.Name = "Welcome discount"
+	c.Type = coupon.TypeFixed
+	c.AmountsOff = []currency.Amount{amount("5.00", "EUR"), amount("6.00", "USD")}
+	c.Duration = coupon.DurationForever
+
+	return c
+}
+
+func amount(n, currencyCode string) currency.Amount {
+	a, _ := currency.NewAmount(n, currencyCode)
+	return a
+}
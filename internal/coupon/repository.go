@@ -0,0 +1,252 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package coupon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/bojanz/currency"
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+const couponColumns = `id, version, code, name, type, percent_off::TEXT, amounts_off, duration, duration_periods,
+	max_redemptions, times_redeemed, product_ids, expires_at, created_at, updated_at`
+
+const redemptionColumns = `id, coupon_id, customer_id, subscription_id, periods_applied, created_at`
+
+// Repository loads and saves coupons and their redemptions.
+type Repository struct {
+	db database.Querier
+}
+
+// NewRepository creates a new coupon repository.
+func NewRepository(db database.Querier) *Repository {
+	return &Repository{db: db}
+}
+
+// Get gets the coupon with the given ID.
+func (r *Repository) Get(ctx context.Context, id ulid.ULID) (Coupon, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+couponColumns+` FROM coupons WHERE id = $1`, id.String())
+
+	return scanCoupon(row)
+}
+
+// GetByCode gets the coupon with the given code, ignoring case.
+func (r *Repository) GetByCode(ctx context.Context, code string) (Coupon, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+couponColumns+` FROM coupons WHERE LOWER(code) = LOWER($1)`, code)
+
+	return scanCoupon(row)
+}
+
+// List lists all coupons, newest first.
+func (r *Repository) List(ctx context.Context) ([]Coupon, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+couponColumns+` FROM coupons ORDER BY id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var coupons []Coupon
+	for rows.Next() {
+		c, err := scanCoupon(rows)
+		if err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, c)
+	}
+
+	return coupons, rows.Err()
+}
+
+// Create creates the given coupon.
+//
+// Returns ErrCodeInUse if another coupon has the same code.
+func (r *Repository) Create(ctx context.Context, c Coupon) error {
+	amountsOff, productIDs, err := marshalLists(c)
+	if err != nil {
+		return err
+	}
+	var percentOff *string
+	if c.PercentOff != "" {
+		percentOff = &c.PercentOff
+	}
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO coupons (id, version, code, name, type, percent_off, amounts_off, duration, duration_periods,
+			max_redemptions, times_redeemed, product_ids, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+		c.ID.String(), c.Version, c.Code, c.Name, string(c.Type), percentOff, amountsOff, string(c.Duration),
+		c.DurationPeriods, c.MaxRedemptions, c.TimesRedeemed, productIDs, database.NullTime(c.ExpiresAt),
+		c.CreatedAt, database.NullTime(c.UpdatedAt),
+	)
+	if database.IsUniqueViolation(err) {
+		return ErrCodeInUse
+	}
+
+	return err
+}
+
+// GetRedemption gets the redemption of the given subscription.
+func (r *Repository) GetRedemption(ctx context.Context, subscriptionID ulid.ULID) (Redemption, error) {
+	var rd Redemption
+	var id, couponID, customerID, subID string
+	err := r.db.QueryRow(ctx, `
+		SELECT `+redemptionColumns+` FROM coupon_redemptions WHERE subscription_id = $1`,
+		subscriptionID.String(),
+	).Scan(&id, &couponID, &customerID, &subID, &rd.PeriodsApplied, &rd.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Redemption{}, ErrRedemptionNotFound
+		}
+		return Redemption{}, err
+	}
+	if rd.ID, err = ulid.Parse(id); err != nil {
+		return Redemption{}, err
+	}
+	if rd.CouponID, err = ulid.Parse(couponID); err != nil {
+		return Redemption{}, err
+	}
+	if rd.CustomerID, err = ulid.Parse(customerID); err != nil {
+		return Redemption{}, err
+	}
+	if rd.SubscriptionID, err = ulid.Parse(subID); err != nil {
+		return Redemption{}, err
+	}
+
+	return rd, nil
+}
+
+// Redeem creates the given redemption and increments the coupon's
+// redemption count.
+//
+// The count is checked and incremented atomically, so that concurrent
+// redemptions can't exceed the coupon's maximum. Returns ErrLimitReached
+// if the maximum was reached, and ErrAlreadyRedeemed if the subscription
+// already has a coupon. On success, c.TimesRedeemed is updated.
+func (r *Repository) Redeem(ctx context.Context, c *Coupon, rd Redemption) error {
+	var timesRedeemed int
+	err := database.WithTx(ctx, r.db, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, `
+			UPDATE coupons SET times_redeemed = times_redeemed + 1
+			WHERE id = $1 AND (max_redemptions = 0 OR times_redeemed < max_redemptions)
+			RETURNING times_redeemed`,
+			c.ID.String(),
+		).Scan(&timesRedeemed)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrLimitReached
+			}
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO coupon_redemptions (`+redemptionColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			rd.ID.String(), rd.CouponID.String(), rd.CustomerID.String(), rd.SubscriptionID.String(),
+			rd.PeriodsApplied, rd.CreatedAt,
+		)
+		if database.IsUniqueViolation(err) {
+			return ErrAlreadyRedeemed
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	c.TimesRedeemed = timesRedeemed
+
+	return nil
+}
+
+// UpdateRedemption updates the number of periods the given redemption was applied to.
+func (r *Repository) UpdateRedemption(ctx context.Context, rd Redemption) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE coupon_redemptions SET periods_applied = $2 WHERE id = $1`,
+		rd.ID.String(), rd.PeriodsApplied,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRedemptionNotFound
+	}
+
+	return nil
+}
+
+// DeleteRedemption deletes the redemption of the given subscription.
+//
+// The coupon's redemption count is left as is, since the
+// coupon was redeemed. Returns ErrRedemptionNotFound if the
+// subscription has no coupon.
+func (r *Repository) DeleteRedemption(ctx context.Context, subscriptionID ulid.ULID) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM coupon_redemptions WHERE subscription_id = $1`, subscriptionID.String())
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRedemptionNotFound
+	}
+
+	return nil
+}
+
+// marshalLists marshals the coupon's amounts and product IDs to JSON.
+func marshalLists(c Coupon) (amountsOff, productIDs []byte, err error) {
+	amounts := c.AmountsOff
+	if amounts == nil {
+		amounts = []currency.Amount{}
+	}
+	ids := c.ProductIDs
+	if ids == nil {
+		ids = []ulid.ULID{}
+	}
+	if amountsOff, err = json.Marshal(amounts); err != nil {
+		return nil, nil, err
+	}
+	if productIDs, err = json.Marshal(ids); err != nil {
+		return nil, nil, err
+	}
+
+	return amountsOff, productIDs, nil
+}
+
+// scanCoupon scans a coupon from the given row.
+func scanCoupon(row pgx.Row) (Coupon, error) {
+	var c Coupon
+	var id, couponType, duration string
+	var percentOff *string
+	var amountsOff, productIDs []byte
+	var expiresAt, updatedAt *time.Time
+	err := row.Scan(&id, &c.Version, &c.Code, &c.Name, &couponType, &percentOff, &amountsOff, &duration,
+		&c.DurationPeriods, &c.MaxRedemptions, &c.TimesRedeemed, &productIDs, &expiresAt, &c.CreatedAt, &updatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Coupon{}, ErrNotFound
+		}
+		return Coupon{}, err
+	}
+	if c.ID, err = ulid.Parse(id); err != nil {
+		return Coupon{}, err
+	}
+	if err := json.Unmarshal(amountsOff, &c.AmountsOff); err != nil {
+		return Coupon{}, err
+	}
+	if err := json.Unmarshal(productIDs, &c.ProductIDs); err != nil {
+		return Coupon{}, err
+	}
+	if percentOff != nil {
+		c.PercentOff = *percentOff
+	}
+	c.Type = Type(couponType)
+	c.Duration = Duration(duration)
+	c.ExpiresAt = database.TimeValue(expiresAt)
+	c.UpdatedAt = database.TimeValue(updatedAt)
+
+	return c, nil
+}
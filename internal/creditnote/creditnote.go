@@ -0,0 +1,348 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package creditnote provides credit notes, used to correct finalized invoices.
+//
+// A credit note credits all or part of the invoice lines, with taxes
+// recalculated for the credited amounts. The credited total is either
+// refunded through the gateway or added to the customer's balance.
+// Refunds are limited to the amount paid through the gateway, with the
+// rest added to the balance, as are declined refunds. Credit notes are numbered from their own
+// sequence, and are immutable, apart from the outcome of their refund.
+package creditnote
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bojanz/currency"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/gateway"
+	"github.com/runbilliam/billiam/internal/invoice"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+// ErrNotFound is returned when a credit note could not be found.
+var ErrNotFound = errors.New("credit note not found")
+
+// ErrNotRefundable is returned when refunding an invoice without a successful gateway payment.
+var ErrNotRefundable = errors.New("invoice has no refundable payment")
+
+// Method represents the way a credit note is settled.
+type Method string
+
+// Credit note methods.
+const (
+	// MethodRefund refunds the invoice payment through the gateway.
+	MethodRefund Method = "refund"
+	// MethodCustomerBalance credits the customer's balance.
+	MethodCustomerBalance Method = "customer_balance"
+)
+
+// IsValid returns whether the method is known.
+func (m Method) IsValid() bool {
+	return m == MethodRefund || m == MethodCustomerBalance
+}
+
+// RefundStatus represents the status of a credit note refund.
+type RefundStatus string
+
+// Refund statuses.
+const (
+	// RefundPending is used for refunds which weren't made yet,
+	// or whose outcome is unknown, see Refund.
+	RefundPending   RefundStatus = "pending"
+	RefundSucceeded RefundStatus = "succeeded"
+	RefundFailed    RefundStatus = "failed"
+)
+
+// CreditNote represents a credit note.
+type CreditNote struct {
+	ID         ulid.ULID `json:"id"`
+	InvoiceID  ulid.ULID `json:"invoice_id"`
+	CustomerID ulid.ULID `json:"customer_id"`
+	// Number is assigned when the credit note is issued.
+	Number   string          `json:"number"`
+	Method   Method          `json:"method"`
+	Memo     string          `json:"memo"`
+	Currency string          `json:"currency"`
+	Lines    []Line          `json:"lines"`
+	Taxes    []invoice.Tax   `json:"taxes"`
+	Subtotal currency.Amount `json:"subtotal"`
+	TaxTotal currency.Amount `json:"tax_total"`
+	Total    currency.Amount `json:"total"`
+	// RefundAmount is the part of the total refunded through the gateway.
+	// The rest is credited to the customer's balance.
+	RefundAmount currency.Amount `json:"refund_amount"`
+	// RefundStatus is empty for credit notes which aren't refunded.
+	RefundStatus RefundStatus `json:"refund_status"`
+	// RefundID is the gateway's ID for the refund, if refunded.
+	RefundID string `json:"refund_id"`
+	// RefundMessage describes why the refund failed. Failed refunds
+	// are credited to the customer's balance instead.
+	RefundMessage string    `json:"refund_message"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// RecordRefund records the outcome of the refund made through the gateway.
+//
+// Declined refunds are recorded as failed. Other errors are returned,
+// and the refund stays pending, since its outcome is unknown.
+func (cn *CreditNote) RecordRefund(r gateway.Refund, err error) error {
+	var declineErr *gateway.DeclineError
+	switch {
+	case errors.As(err, &declineErr):
+		cn.RefundStatus = RefundFailed
+		cn.RefundMessage = declineErr.Message
+	case errors.Is(err, gateway.ErrInvalidRequest):
+		cn.RefundStatus = RefundFailed
+		cn.RefundMessage = err.Error()
+	case err != nil:
+		return err
+	default:
+		cn.RefundStatus = RefundSucceeded
+		cn.RefundID = r.ID
+	}
+
+	return nil
+}
+
+// Line represents a credited invoice line.
+type Line struct {
+	ID            ulid.ULID `json:"id"`
+	InvoiceLineID ulid.ULID `json:"invoice_line_id"`
+	Description   string    `json:"description"`
+	// Amount is the credited amount, before taxes. Negative for discount lines.
+	Amount  currency.Amount `json:"amount"`
+	TaxRate string          `json:"tax_rate"`
+}
+
+// Item selects an invoice line to credit.
+type Item struct {
+	InvoiceLineID ulid.ULID `json:"invoice_line_id"`
+	// Amount is the amount to credit, before taxes.
+	// If empty, the remaining line amount is credited.
+	Amount currency.Amount `json:"amount"`
+}
+
+// Build builds a credit note for the given invoice.
+//
+// The previous credit notes of the invoice are used to determine the
+// remaining creditable amount of each line, and of the invoice as a whole.
+// Amounts exceeding them are reported as errors on the "lines" path.
+func Build(inv invoice.Invoice, previous []CreditNote, method Method, items []Item) (CreditNote, validation.Errors) {
+	errs := validation.Errors{}
+	if !inv.IsFinalized() || inv.Status == invoice.StatusVoid {
+		errs.Add("invoice_id", validation.InvalidValue("Only finalized invoices which are not void can be credited."))
+		return CreditNote{}, errs
+	}
+	if !method.IsValid() {
+		errs.Add("method", validation.InvalidChoice("Invalid method."))
+	} else if method == MethodRefund && inv.Status != invoice.StatusPaid {
+		errs.Add("method", validation.InvalidValue("Only paid invoices can be refunded."))
+	}
+	if len(items) == 0 {
+		errs.Add("lines", validation.Required("At least one line is required."))
+	}
+
+	credited, err := creditedLines(previous)
+	if err != nil {
+		errs.Add("lines", validation.InvalidValue(err.Error()))
+		return CreditNote{}, errs
+	}
+	now := time.Now().UTC()
+	cn := CreditNote{
+		ID:         ulid.MustNew(ulid.Timestamp(now), rand.Reader),
+		InvoiceID:  inv.ID,
+		CustomerID: inv.CustomerID,
+		Method:     method,
+		Currency:   inv.Currency,
+		CreatedAt:  now,
+	}
+	seen := make(map[ulid.ULID]bool)
+	for i, item := range items {
+		path := "lines." + strconv.Itoa(i)
+		line, ok := findLine(inv.Lines, item.InvoiceLineID)
+		if !ok {
+			errs.Add(path+".invoice_line_id", validation.InvalidChoice("Invalid invoice line."))
+			continue
+		}
+		if seen[line.ID] {
+			errs.Add(path+".invoice_line_id", validation.NotUnique("Each invoice line can only be credited once per credit note."))
+			continue
+		}
+		seen[line.ID] = true
+
+		remaining := line.Amount
+		if c, ok := credited[line.ID]; ok {
+			if remaining, err = remaining.Sub(c); err != nil {
+				errs.Add(path+".amount", validation.InvalidValue(err.Error()))
+				continue
+			}
+		}
+		amount := item.Amount
+		if amount.CurrencyCode() == "" {
+			amount = remaining
+		} else if amount.CurrencyCode() != inv.Currency {
+			errs.Add(path+".amount", validation.InvalidValue("Amount must be in the invoice currency."))
+			continue
+		}
+		amount = amount.Round()
+		// Discount lines have negative amounts, and are credited as such.
+		negative := line.Amount.IsNegative()
+		if amount.IsZero() || amount.IsNegative() != negative {
+			if negative {
+				errs.Add(path+".amount", validation.InvalidValue("Amount must be negative for discount lines."))
+			} else {
+				errs.Add(path+".amount", validation.InvalidValue("Amount must be positive."))
+			}
+			continue
+		}
+		if cmp, _ := amount.Cmp(remaining); (!negative && cmp > 0) || (negative && cmp < 0) {
+			errs.Add(path+".amount", validation.InvalidValue(fmt.Sprintf("Amount exceeds the remaining line amount of %v.", remaining)))
+			continue
+		}
+		cn.Lines = append(cn.Lines, Line{
+			ID:            ulid.MustNew(ulid.Timestamp(now), rand.Reader),
+			InvoiceLineID: line.ID,
+			Description:   line.Description,
+			Amount:        amount,
+			TaxRate:       line.TaxRate,
+		})
+	}
+	if !errs.IsEmpty() {
+		return CreditNote{}, errs
+	}
+
+	if err := cn.Recalculate(); err != nil {
+		errs.Add("lines", validation.InvalidValue(err.Error()))
+		return CreditNote{}, errs
+	}
+	creditable, err := CreditableTotal(inv, previous)
+	if err != nil {
+		errs.Add("lines", validation.InvalidValue(err.Error()))
+		return CreditNote{}, errs
+	}
+	if !cn.Total.IsPositive() {
+		errs.Add("lines", validation.InvalidValue("Total must be positive."))
+	} else if cmp, _ := cn.Total.Cmp(creditable); cmp > 0 {
+		errs.Add("lines", validation.InvalidValue(fmt.Sprintf("Total %v exceeds the remaining creditable total of %v.", cn.Total, creditable)))
+	}
+	if !errs.IsEmpty() {
+		return CreditNote{}, errs
+	}
+
+	return cn, errs
+}
+
+// CreditableTotal returns the remaining creditable total of the given
+// invoice: its total, minus the totals of its previous credit notes.
+func CreditableTotal(inv invoice.Invoice, previous []CreditNote) (currency.Amount, error) {
+	creditable := inv.Total
+	for _, cn := range previous {
+		var err error
+		if creditable, err = creditable.Sub(cn.Total); err != nil {
+			return currency.Amount{}, err
+		}
+	}
+
+	return creditable, nil
+}
+
+// RefundableAmount returns the remaining refundable amount of the given
+// gateway payment: its amount, minus the refund amounts of the previous
+// credit notes which weren't declined. Never negative.
+//
+// The payment only covers the part of the invoice total which wasn't
+// paid from the customer's balance, so refunds are limited to it.
+func RefundableAmount(paid currency.Amount, previous []CreditNote) (currency.Amount, error) {
+	refundable := paid
+	for _, cn := range previous {
+		if cn.Method != MethodRefund || cn.RefundStatus == RefundFailed {
+			continue
+		}
+		var err error
+		if refundable, err = refundable.Sub(cn.RefundAmount); err != nil {
+			return currency.Amount{}, err
+		}
+	}
+	if refundable.IsNegative() {
+		return currency.NewAmount("0", paid.CurrencyCode())
+	}
+
+	return refundable, nil
+}
+
+// Recalculate recalculates the totals of the credit note.
+//
+// Taxes are calculated the same way as for invoices, see invoice.CalculateTaxes.
+func (cn *CreditNote) Recalculate() error {
+	subtotal, err := currency.NewAmount("0", cn.Currency)
+	if err != nil {
+		return err
+	}
+	taxable := make(map[string]currency.Amount)
+	for _, line := range cn.Lines {
+		if subtotal, err = subtotal.Add(line.Amount); err != nil {
+			return err
+		}
+		if line.TaxRate != "" {
+			base, ok := taxable[line.TaxRate]
+			if !ok {
+				base, _ = currency.NewAmount("0", cn.Currency)
+			}
+			if taxable[line.TaxRate], err = base.Add(line.Amount); err != nil {
+				return err
+			}
+		}
+	}
+	taxes, taxTotal, err := invoice.CalculateTaxes(cn.Currency, taxable)
+	if err != nil {
+		return err
+	}
+	total, err := subtotal.Add(taxTotal)
+	if err != nil {
+		return err
+	}
+	cn.Taxes = taxes
+	cn.Subtotal = subtotal
+	cn.TaxTotal = taxTotal
+	cn.Total = total
+
+	return nil
+}
+
+// creditedLines sums the credited amounts of the given credit notes,
+// keyed by invoice line ID.
+func creditedLines(creditNotes []CreditNote) (map[ulid.ULID]currency.Amount, error) {
+	credited := make(map[ulid.ULID]currency.Amount)
+	for _, cn := range creditNotes {
+		for _, line := range cn.Lines {
+			c, ok := credited[line.InvoiceLineID]
+			if !ok {
+				credited[line.InvoiceLineID] = line.Amount
+				continue
+			}
+			var err error
+			if credited[line.InvoiceLineID], err = c.Add(line.Amount); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return credited, nil
+}
+
+// findLine finds the invoice line with the given ID.
+func findLine(lines []invoice.Line, id ulid.ULID) (invoice.Line, bool) {
+	for _, line := range lines {
+		if line.ID == id {
+			return line, true
+		}
+	}
+	return invoice.Line{}, false
+}
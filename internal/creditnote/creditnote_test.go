@@ -0,0 +1,314 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package creditnote_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bojanz/currency"
+
+	"github.com/runbilliam/billiam/internal/creditnote"
+	"github.com/runbilliam/billiam/internal/gateway"
+	"github.com/runbilliam/billiam/internal/invoice"
+	"github.com/runbilliam/billiam/internal/testutil"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+func TestBuild(t *testing.T) {
+	inv := newInvoice(t, invoice.StatusPaid)
+	plan, fee := inv.Lines[0], inv.Lines[1]
+
+	// Full credit of all lines.
+	cn, errs := creditnote.Build(inv, nil, creditnote.MethodRefund, []creditnote.Item{
+		{InvoiceLineID: plan.ID},
+		{InvoiceLineID: fee.ID},
+	})
+	if !errs.IsEmpty() {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if cn.Total.String() != inv.Total.String() {
+		t.Errorf("got %v, want %v", cn.Total, inv.Total)
+	}
+	if cn.InvoiceID != inv.ID || cn.CustomerID != inv.CustomerID || len(cn.Lines) != 2 {
+		t.Errorf("unexpected credit note: %+v", cn)
+	}
+
+	// Partial credit, with the tax recalculated.
+	cn, errs = creditnote.Build(inv, nil, creditnote.MethodCustomerBalance, []creditnote.Item{
+		{InvoiceLineID: plan.ID, Amount: amount("10.01")},
+	})
+	if !errs.IsEmpty() {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if cn.Subtotal.Number() != "10.01" || cn.TaxTotal.Number() != "2.00" || cn.Total.Number() != "12.01" {
+		t.Errorf("got %v + %v = %v, want 10.01 + 2.00 = 12.01", cn.Subtotal, cn.TaxTotal, cn.Total)
+	}
+	if len(cn.Taxes) != 1 || cn.Taxes[0].Rate != "20" || cn.Taxes[0].TaxableAmount.Number() != "10.01" {
+		t.Errorf("unexpected taxes: %+v", cn.Taxes)
+	}
+}
+
+func TestBuild_Remaining(t *testing.T) {
+	inv := newInvoice(t, invoice.StatusPaid)
+	plan, fee := inv.Lines[0], inv.Lines[1]
+	first, errs := creditnote.Build(inv, nil, creditnote.MethodRefund, []creditnote.Item{
+		{InvoiceLineID: plan.ID, Amount: amount("20.00")},
+	})
+	if !errs.IsEmpty() {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	previous := []creditnote.CreditNote{first}
+
+	creditable, err := creditnote.CreditableTotal(inv, previous)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creditable.Number() != "23.99" {
+		t.Errorf("got %v, want 23.99", creditable.Number())
+	}
+
+	// The remaining line amount is credited by default.
+	cn, errs := creditnote.Build(inv, previous, creditnote.MethodRefund, []creditnote.Item{
+		{InvoiceLineID: plan.ID},
+		{InvoiceLineID: fee.ID},
+	})
+	if !errs.IsEmpty() {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if cn.Lines[0].Amount.Number() != "9.99" {
+		t.Errorf("got %v, want 9.99", cn.Lines[0].Amount.Number())
+	}
+	if cn.Total.String() != creditable.String() {
+		t.Errorf("got %v, want %v", cn.Total, creditable)
+	}
+
+	// Exceeding the remaining line amount.
+	_, errs = creditnote.Build(inv, previous, creditnote.MethodRefund, []creditnote.Item{
+		{InvoiceLineID: plan.ID, Amount: amount("10.00")},
+	})
+	testutil.AssertError(t, errs, "lines.0.amount", validation.CodeInvalidValue)
+
+	// Fully credited invoices have nothing left to credit.
+	previous = append(previous, cn)
+	_, errs = creditnote.Build(inv, previous, creditnote.MethodRefund, []creditnote.Item{
+		{InvoiceLineID: plan.ID, Amount: amount("0.01")},
+	})
+	testutil.AssertError(t, errs, "lines.0.amount", validation.CodeInvalidValue)
+}
+
+func TestBuild_Discount(t *testing.T) {
+	inv := invoice.New(testutil.NewID(), "EUR")
+	plan, _ := invoice.NewLine(invoice.LineSubscription, "Pro", amount("100.00"), 1)
+	discount, _ := invoice.NewLine(invoice.LineDiscount, "25% off", amount("-25.00"), 1)
+	inv.AddLine(plan)
+	inv.AddLine(discount)
+	inv.TransitionTo(invoice.StatusOpen, time.Now())
+
+	// Crediting only the plan line would exceed the discounted total.
+	_, errs := creditnote.Build(inv, nil, creditnote.MethodCustomerBalance, []creditnote.Item{
+		{InvoiceLineID: plan.ID},
+	})
+	testutil.AssertError(t, errs, "lines", validation.CodeInvalidValue)
+
+	cn, errs := creditnote.Build(inv, nil, creditnote.MethodCustomerBalance, []creditnote.Item{
+		{InvoiceLineID: plan.ID},
+		{InvoiceLineID: discount.ID},
+	})
+	if !errs.IsEmpty() {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if cn.Total.Number() != "75.00" {
+		t.Errorf("got %v, want 75.00", cn.Total.Number())
+	}
+
+	// Discount lines are credited with negative amounts.
+	_, errs = creditnote.Build(inv, nil, creditnote.MethodCustomerBalance, []creditnote.Item{
+		{InvoiceLineID: plan.ID},
+		{InvoiceLineID: discount.ID, Amount: amount("25.00")},
+	})
+	testutil.AssertError(t, errs, "lines.1.amount", validation.CodeInvalidValue)
+}
+
+func TestBuild_Invalid(t *testing.T) {
+	draft := newInvoice(t, invoice.StatusDraft)
+	_, errs := creditnote.Build(draft, nil, creditnote.MethodCustomerBalance, []creditnote.Item{
+		{InvoiceLineID: draft.Lines[0].ID},
+	})
+	testutil.AssertError(t, errs, "invoice_id", validation.CodeInvalidValue)
+
+	open := newInvoice(t, invoice.StatusOpen)
+	tests := []struct {
+		method   creditnote.Method
+		items    []creditnote.Item
+		wantPath string
+		wantCode string
+	}{
+		{creditnote.Method("cash"), []creditnote.Item{{InvoiceLineID: open.Lines[0].ID}}, "method", validation.CodeInvalidChoice},
+		// Unpaid invoices can't be refunded.
+		{creditnote.MethodRefund, []creditnote.Item{{InvoiceLineID: open.Lines[0].ID}}, "method", validation.CodeInvalidValue},
+		{creditnote.MethodCustomerBalance, nil, "lines", validation.CodeRequired},
+		{creditnote.MethodCustomerBalance, []creditnote.Item{{InvoiceLineID: testutil.NewID()}}, "lines.0.invoice_line_id", validation.CodeInvalidChoice},
+		{creditnote.MethodCustomerBalance, []creditnote.Item{{InvoiceLineID: open.Lines[0].ID}, {InvoiceLineID: open.Lines[0].ID}}, "lines.1.invoice_line_id", validation.CodeNotUnique},
+		{creditnote.MethodCustomerBalance, []creditnote.Item{{InvoiceLineID: open.Lines[0].ID, Amount: amount("0")}}, "lines.0.amount", validation.CodeInvalidValue},
+		{creditnote.MethodCustomerBalance, []creditnote.Item{{InvoiceLineID: open.Lines[0].ID, Amount: mustAmount("5", "USD")}}, "lines.0.amount", validation.CodeInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			_, errs := creditnote.Build(open, nil, tt.method, tt.items)
+			testutil.AssertError(t, errs, tt.wantPath, tt.wantCode)
+		})
+	}
+}
+
+func TestUnusedItems(t *testing.T) {
+	start := time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 30)
+	inv := invoice.New(testutil.NewID(), "EUR")
+	inv.PeriodStart, inv.PeriodEnd = start, end
+	plan, _ := invoice.NewLine(invoice.LineSubscription, "Pro", amount("100.00"), 1)
+	plan.PeriodStart, plan.PeriodEnd = start, end
+	usage, _ := invoice.NewLine(invoice.LineUsage, "API calls", amount("50.00"), 1)
+	usage.PeriodStart, usage.PeriodEnd = start.AddDate(0, -1, 0), start
+	// 10% off the whole invoice.
+	discount, _ := invoice.NewLine(invoice.LineDiscount, "10% off", amount("-15.00"), 1)
+	for _, line := range []invoice.Line{plan, usage, discount} {
+		if err := inv.AddLine(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	inv.TransitionTo(invoice.StatusOpen, time.Now())
+	inv.TransitionTo(invoice.StatusPaid, time.Now())
+
+	// Canceled with 20 of 30 days remaining.
+	items, err := creditnote.UnusedItems(inv, start.AddDate(0, 0, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("got %v items, want 2", len(items))
+	}
+	if items[0].InvoiceLineID != plan.ID || items[0].Amount.Number() != "66.67" {
+		t.Errorf("got %v for %v, want 66.67 for %v", items[0].Amount, items[0].InvoiceLineID, plan.ID)
+	}
+	// The discount's share of the subscription line.
+	if items[1].InvoiceLineID != discount.ID || items[1].Amount.Number() != "-6.67" {
+		t.Errorf("got %v for %v, want -6.67 for %v", items[1].Amount, items[1].InvoiceLineID, discount.ID)
+	}
+	cn, errs := creditnote.Build(inv, nil, creditnote.MethodRefund, items)
+	if !errs.IsEmpty() {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if cn.Total.Number() != "60.00" {
+		t.Errorf("got %v, want 60.00", cn.Total.Number())
+	}
+
+	// Nothing is left after the end of the period.
+	if items, _ := creditnote.UnusedItems(inv, end); len(items) != 0 {
+		t.Errorf("got %v items, want 0", len(items))
+	}
+}
+
+func TestRefundableAmount(t *testing.T) {
+	// 47.99 invoice, of which 20.00 was paid from the customer's balance.
+	paid := amount("27.99")
+	refunded := creditnote.CreditNote{Method: creditnote.MethodRefund, RefundAmount: amount("10.00"), RefundStatus: creditnote.RefundSucceeded}
+	pending := creditnote.CreditNote{Method: creditnote.MethodRefund, RefundAmount: amount("5.00"), RefundStatus: creditnote.RefundPending}
+	declined := creditnote.CreditNote{Method: creditnote.MethodRefund, RefundAmount: amount("7.00"), RefundStatus: creditnote.RefundFailed}
+	credited := creditnote.CreditNote{Method: creditnote.MethodCustomerBalance, RefundAmount: amount("0")}
+	full := creditnote.CreditNote{Method: creditnote.MethodRefund, RefundAmount: amount("27.99"), RefundStatus: creditnote.RefundSucceeded}
+
+	tests := []struct {
+		name     string
+		previous []creditnote.CreditNote
+		want     string
+	}{
+		{"none", nil, "27.99"},
+		{"refunded", []creditnote.CreditNote{refunded, pending}, "12.99"},
+		{"declined", []creditnote.CreditNote{declined}, "27.99"},
+		{"credited", []creditnote.CreditNote{credited}, "27.99"},
+		{"exhausted", []creditnote.CreditNote{full, refunded}, "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := creditnote.RefundableAmount(paid, tt.previous)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Number() != tt.want {
+				t.Errorf("got %v, want %v", got.Number(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCreditNote_RecordRefund(t *testing.T) {
+	unreachable := errors.New("connection refused")
+	tests := []struct {
+		name        string
+		refund      gateway.Refund
+		err         error
+		wantErr     error
+		wantStatus  creditnote.RefundStatus
+		wantID      string
+		wantMessage string
+	}{
+		{"succeeded", gateway.Refund{ID: "re_123"}, nil, nil, creditnote.RefundSucceeded, "re_123", ""},
+		{"declined", gateway.Refund{}, &gateway.DeclineError{Code: gateway.DeclineGeneric, Message: "Refund declined."}, nil, creditnote.RefundFailed, "", "Refund declined."},
+		{"invalid", gateway.Refund{}, gateway.ErrInvalidRequest, nil, creditnote.RefundFailed, "", gateway.ErrInvalidRequest.Error()},
+		{"unknown", gateway.Refund{}, unreachable, unreachable, creditnote.RefundPending, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cn := creditnote.CreditNote{Method: creditnote.MethodRefund, RefundAmount: amount("10.00"), RefundStatus: creditnote.RefundPending}
+			if err := cn.RecordRefund(tt.refund, tt.err); err != tt.wantErr {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+			if cn.RefundStatus != tt.wantStatus {
+				t.Errorf("got %v, want %v", cn.RefundStatus, tt.wantStatus)
+			}
+			if cn.RefundID != tt.wantID || cn.RefundMessage != tt.wantMessage {
+				t.Errorf("got %q %q, want %q %q", cn.RefundID, cn.RefundMessage, tt.wantID, tt.wantMessage)
+			}
+		})
+	}
+}
+
+// newInvoice creates an invoice for 29.99 + 10.00, with a 20% tax.
+func newInvoice(t *testing.T, status invoice.Status) invoice.Invoice {
+	t.Helper()
+	inv := invoice.New(testutil.NewID(), "EUR")
+	plan, _ := invoice.NewLine(invoice.LineSubscription, "Pro", amount("29.99"), 1)
+	plan.TaxRate = "20"
+	fee, _ := invoice.NewLine(invoice.LineAdjustment, "Setup fee", amount("10.00"), 1)
+	fee.TaxRate = "20"
+	for _, line := range []invoice.Line{plan, fee} {
+		if err := inv.AddLine(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if inv.Total.Number() != "47.99" {
+		t.Fatalf("got %v, want 47.99", inv.Total.Number())
+	}
+	now := time.Now()
+	if status != invoice.StatusDraft {
+		inv.TransitionTo(invoice.StatusOpen, now)
+	}
+	if status == invoice.StatusPaid {
+		inv.TransitionTo(invoice.StatusPaid, now)
+	}
+
+	return inv
+}
+
+func amount(n string) currency.Amount {
+	return mustAmount(n, "EUR")
+}
+
+func mustAmount(n, currencyCode string) currency.Amount {
+	a, _ := currency.NewAmount(n, currencyCode)
+	return a
+}
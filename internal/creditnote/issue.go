@@ -0,0 +1,173 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package creditnote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bojanz/currency"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/balance"
+	"github.com/runbilliam/billiam/internal/gateway"
+	"github.com/runbilliam/billiam/internal/payment"
+	"github.com/runbilliam/billiam/internal/sequence"
+	"github.com/runbilliam/billiam/internal/settings"
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+// numberSequence is the name of the credit note number sequence.
+const numberSequence = "credit_note"
+
+// Issue issues the given credit note, built by Build.
+//
+// The number is allocated using the credit note number pattern and
+// reset policy from the settings. The credit note total is then either
+// added to the customer's balance, or marked as a pending refund,
+// to be refunded through the gateway once the credit note is stored.
+// Refunds are limited to the refundable amount of the gateway payment
+// (see RefundableAmount), with the rest added to the balance, since
+// that part of the invoice was paid from the balance.
+//
+// Must be called inside a transaction, with the invoice locked
+// (see invoice.Repository.GetForUpdate), so that concurrent credit
+// notes can't exceed the creditable total. The refund itself is made
+// by Refund, after the transaction is committed.
+//
+// Returns ErrNotRefundable if the invoice wasn't paid through a gateway.
+func Issue(ctx context.Context, tx database.Querier, cn *CreditNote, now time.Time) error {
+	var err error
+	if cn.RefundAmount, err = currency.NewAmount("0", cn.Currency); err != nil {
+		return err
+	}
+	if cn.Method == MethodRefund {
+		paid, err := refundablePayment(ctx, tx, cn.InvoiceID)
+		if err != nil {
+			return err
+		}
+		if paid == nil {
+			return ErrNotRefundable
+		}
+		previous, err := NewRepository(tx).ListByInvoice(ctx, cn.InvoiceID)
+		if err != nil {
+			return err
+		}
+		refundable, err := RefundableAmount(paid.Amount, previous)
+		if err != nil {
+			return err
+		}
+		cn.RefundAmount = cn.Total
+		if cmp, _ := cn.Total.Cmp(refundable); cmp > 0 {
+			cn.RefundAmount = refundable
+		}
+		if cn.RefundAmount.IsPositive() {
+			cn.RefundStatus = RefundPending
+		}
+	}
+	st, err := settings.NewStore(tx).Get(ctx)
+	if errors.Is(err, settings.ErrNotFound) {
+		st = settings.New()
+	} else if err != nil {
+		return err
+	}
+	loc, err := time.LoadLocation(st.Timezone)
+	if err != nil {
+		return err
+	}
+	year := now.In(loc).Year()
+	n, err := sequence.NewStore(tx).Next(ctx, numberSequence, st.CreditNoteNumberReset, year)
+	if err != nil {
+		return err
+	}
+	cn.Number = sequence.Format(st.CreditNoteNumberPattern, year, n)
+	cn.CreatedAt = now.UTC()
+	if err := NewRepository(tx).Create(ctx, cn); err != nil {
+		return err
+	}
+	amount, err := cn.Total.Sub(cn.RefundAmount)
+	if err != nil {
+		return err
+	}
+
+	return credit(ctx, tx, *cn, amount, cn.CreatedAt)
+}
+
+// Refund refunds the refund amount of the given credit note through the
+// gateway which processed the invoice payment, if still pending.
+//
+// The gateway is called with an idempotency key derived from the
+// credit note ID, so a refund whose outcome is unknown can be retried
+// without refunding twice. Declined refunds are recorded as failed, and
+// credited to the customer's balance instead. Other errors leave the
+// refund pending, to be retried by the Refunder.
+//
+// Must be called inside a transaction, with the credit note locked
+// (see Repository.GetForUpdate), so that the outcome is recorded once.
+func Refund(ctx context.Context, tx database.Querier, gateways *gateway.Registry, cn *CreditNote) error {
+	if cn.RefundStatus != RefundPending {
+		return nil
+	}
+	paid, err := refundablePayment(ctx, tx, cn.InvoiceID)
+	if err != nil {
+		return err
+	}
+	if paid == nil {
+		return ErrNotRefundable
+	}
+	g, err := gateways.Get(paid.Gateway)
+	if err != nil {
+		// The gateway was disabled after the invoice was paid.
+		return fmt.Errorf("refund credit note %v: %w", cn.ID, err)
+	}
+	r, err := g.Refund(ctx, gateway.RefundRequest{
+		PaymentID:      paid.RemoteID,
+		Amount:         cn.RefundAmount,
+		IdempotencyKey: "credit-note-" + cn.ID.String(),
+	})
+	if err := cn.RecordRefund(r, err); err != nil {
+		return fmt.Errorf("refund credit note %v: %w", cn.ID, err)
+	}
+	if err := NewRepository(tx).UpdateRefund(ctx, *cn); err != nil {
+		return err
+	}
+	if cn.RefundStatus == RefundFailed {
+		return credit(ctx, tx, *cn, cn.RefundAmount, time.Now())
+	}
+
+	return nil
+}
+
+// credit adds the given amount of the credit note to the customer's balance.
+func credit(ctx context.Context, tx database.Querier, cn CreditNote, amount currency.Amount, now time.Time) error {
+	if !amount.IsPositive() {
+		return nil
+	}
+	t := balance.NewTransaction(cn.CustomerID, balance.TransactionCreditNote, amount)
+	t.CreditNoteID = cn.ID
+	t.Description = "Credit note " + cn.Number
+	t.CreatedAt = now.UTC()
+
+	return balance.NewRepository(tx).Create(ctx, t)
+}
+
+// refundablePayment returns the last successful gateway payment
+// of the given invoice, or nil if there is none.
+func refundablePayment(ctx context.Context, tx database.Querier, invoiceID ulid.ULID) (*payment.Attempt, error) {
+	attempts, err := payment.NewRepository(tx).ListAttempts(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	var paid *payment.Attempt
+	for i := range attempts {
+		// Payments made outside of the gateways have no remote ID.
+		if attempts[i].Status == payment.AttemptSucceeded && attempts[i].RemoteID != "" {
+			paid = &attempts[i]
+		}
+	}
+
+	return paid, nil
+}
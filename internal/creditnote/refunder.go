@@ -0,0 +1,64 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package creditnote
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+	"github.com/rs/zerolog"
+
+	"github.com/runbilliam/billiam/internal/gateway"
+	"github.com/runbilliam/billiam/internal/worker"
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+// Refunder retries pending credit note refunds.
+type Refunder struct {
+	db       database.Querier
+	gateways *gateway.Registry
+	logger   *zerolog.Logger
+}
+
+// NewRefunder creates a new refunder.
+func NewRefunder(db database.Querier, gateways *gateway.Registry, logger *zerolog.Logger) *Refunder {
+	return &Refunder{db: db, gateways: gateways, logger: logger}
+}
+
+// Run refunds each credit note whose refund is still pending,
+// e.g. because the gateway couldn't be reached when it was issued.
+//
+// A refund which fails with an error is logged, and retried on the
+// next run. Returns the number of refunds whose outcome was recorded.
+func (r *Refunder) Run(ctx context.Context) (int, error) {
+	next := func(tx pgx.Tx, failed []ulid.ULID) (ulid.ULID, error) {
+		creditNotes, err := NewRepository(tx).ListPendingRefunds(ctx, failed, 1)
+		if err != nil || len(creditNotes) == 0 {
+			return ulid.ULID{}, err
+		}
+		cn := creditNotes[0]
+		if err := Refund(ctx, tx, r.gateways, &cn); err != nil {
+			return cn.ID, err
+		}
+		r.logger.Info().
+			Str("credit_note_id", cn.ID.String()).
+			Str("credit_note_number", cn.Number).
+			Str("invoice_id", cn.InvoiceID.String()).
+			Str("refund_id", cn.RefundID).
+			Str("refund_status", string(cn.RefundStatus)).
+			Str("refund_message", cn.RefundMessage).
+			Msg("Refund")
+		return cn.ID, nil
+	}
+	fail := func(id ulid.ULID, err error) error {
+		r.logger.Error().
+			Err(err).
+			Str("credit_note_id", id.String()).
+			Msg("Refund failed")
+		return nil
+	}
+
+	return worker.EachTx(ctx, r.db, next, fail)
+}
@@ -0,0 +1,277 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package creditnote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/bojanz/currency"
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/invoice"
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+const creditNoteColumns = `id, invoice_id, customer_id, number, method, memo, currency, taxes,
+	subtotal::TEXT, tax_total::TEXT, total::TEXT, refund_amount::TEXT, refund_status, refund_id,
+	refund_message, created_at`
+
+// Repository loads and saves credit notes.
+type Repository struct {
+	db database.Querier
+}
+
+// NewRepository creates a new credit note repository.
+func NewRepository(db database.Querier) *Repository {
+	return &Repository{db: db}
+}
+
+// Get gets the credit note with the given ID, including its lines.
+func (r *Repository) Get(ctx context.Context, id ulid.ULID) (CreditNote, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+creditNoteColumns+` FROM credit_notes WHERE id = $1`, id.String())
+	cn, err := scanCreditNote(row)
+	if err != nil {
+		return CreditNote{}, err
+	}
+	if cn.Lines, err = r.listLines(ctx, cn); err != nil {
+		return CreditNote{}, err
+	}
+
+	return cn, nil
+}
+
+// GetForUpdate gets the credit note with the given ID, including its lines,
+// and locks it until the end of the transaction.
+func (r *Repository) GetForUpdate(ctx context.Context, id ulid.ULID) (CreditNote, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+creditNoteColumns+` FROM credit_notes WHERE id = $1 FOR UPDATE`, id.String())
+	cn, err := scanCreditNote(row)
+	if err != nil {
+		return CreditNote{}, err
+	}
+	if cn.Lines, err = r.listLines(ctx, cn); err != nil {
+		return CreditNote{}, err
+	}
+
+	return cn, nil
+}
+
+// ListPendingRefunds lists credit notes with a pending refund, oldest first,
+// leaving out the given credit notes.
+//
+// The credit notes are locked until the end of the transaction.
+// Credit notes locked by another transaction are skipped.
+func (r *Repository) ListPendingRefunds(ctx context.Context, skip []ulid.ULID, limit int) ([]CreditNote, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+creditNoteColumns+` FROM credit_notes
+		WHERE refund_status = 'pending' AND id <> ALL($1)
+		ORDER BY id LIMIT $2
+		FOR UPDATE SKIP LOCKED`,
+		stringIDs(skip), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var creditNotes []CreditNote
+	for rows.Next() {
+		cn, err := scanCreditNote(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		creditNotes = append(creditNotes, cn)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range creditNotes {
+		if creditNotes[i].Lines, err = r.listLines(ctx, creditNotes[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return creditNotes, nil
+}
+
+// ListByInvoice lists the credit notes of the given invoice, oldest first,
+// including their lines.
+func (r *Repository) ListByInvoice(ctx context.Context, invoiceID ulid.ULID) ([]CreditNote, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+creditNoteColumns+` FROM credit_notes
+		WHERE invoice_id = $1 ORDER BY id`,
+		invoiceID.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var creditNotes []CreditNote
+	for rows.Next() {
+		cn, err := scanCreditNote(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		creditNotes = append(creditNotes, cn)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range creditNotes {
+		if creditNotes[i].Lines, err = r.listLines(ctx, creditNotes[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return creditNotes, nil
+}
+
+// Create creates the given credit note, including its lines.
+func (r *Repository) Create(ctx context.Context, cn *CreditNote) error {
+	taxes := cn.Taxes
+	if taxes == nil {
+		taxes = []invoice.Tax{}
+	}
+	taxesJSON, err := json.Marshal(taxes)
+	if err != nil {
+		return err
+	}
+
+	return database.WithTx(ctx, r.db, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO credit_notes (id, invoice_id, customer_id, number, method, memo, currency, taxes,
+				subtotal, tax_total, total, refund_amount, refund_status, refund_id, refund_message, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`,
+			cn.ID.String(), cn.InvoiceID.String(), cn.CustomerID.String(), cn.Number, string(cn.Method), cn.Memo,
+			cn.Currency, taxesJSON, cn.Subtotal.Number(), cn.TaxTotal.Number(), cn.Total.Number(),
+			cn.RefundAmount.Number(), string(cn.RefundStatus), cn.RefundID, cn.RefundMessage, cn.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		for i, line := range cn.Lines {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO credit_note_lines (id, credit_note_id, invoice_line_id, position, description, amount, tax_rate)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+				line.ID.String(), cn.ID.String(), line.InvoiceLineID.String(), i, line.Description,
+				line.Amount.Number(), line.TaxRate,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateRefund records the outcome of the given credit note's pending refund.
+//
+// Returns ErrNotFound if the credit note has no pending refund.
+func (r *Repository) UpdateRefund(ctx context.Context, cn CreditNote) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE credit_notes SET refund_status = $2, refund_id = $3, refund_message = $4
+		WHERE id = $1 AND refund_status = 'pending'`,
+		cn.ID.String(), string(cn.RefundStatus), cn.RefundID, cn.RefundMessage,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// listLines lists the lines of the given credit note.
+func (r *Repository) listLines(ctx context.Context, cn CreditNote) ([]Line, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, invoice_line_id, description, amount::TEXT, tax_rate FROM credit_note_lines
+		WHERE credit_note_id = $1 ORDER BY position`,
+		cn.ID.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []Line
+	for rows.Next() {
+		var line Line
+		var id, invoiceLineID, amount string
+		if err := rows.Scan(&id, &invoiceLineID, &line.Description, &amount, &line.TaxRate); err != nil {
+			return nil, err
+		}
+		if line.ID, err = ulid.Parse(id); err != nil {
+			return nil, err
+		}
+		if line.InvoiceLineID, err = ulid.Parse(invoiceLineID); err != nil {
+			return nil, err
+		}
+		if line.Amount, err = currency.NewAmount(amount, cn.Currency); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
+
+// scanCreditNote scans a credit note from the given row.
+func scanCreditNote(row pgx.Row) (CreditNote, error) {
+	var cn CreditNote
+	var id, invoiceID, customerID, method, subtotal, taxTotal, total, refundAmount, refundStatus string
+	var taxes []byte
+	err := row.Scan(&id, &invoiceID, &customerID, &cn.Number, &method, &cn.Memo, &cn.Currency, &taxes,
+		&subtotal, &taxTotal, &total, &refundAmount, &refundStatus, &cn.RefundID, &cn.RefundMessage, &cn.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return CreditNote{}, ErrNotFound
+		}
+		return CreditNote{}, err
+	}
+	if cn.ID, err = ulid.Parse(id); err != nil {
+		return CreditNote{}, err
+	}
+	if cn.InvoiceID, err = ulid.Parse(invoiceID); err != nil {
+		return CreditNote{}, err
+	}
+	if cn.CustomerID, err = ulid.Parse(customerID); err != nil {
+		return CreditNote{}, err
+	}
+	if err := json.Unmarshal(taxes, &cn.Taxes); err != nil {
+		return CreditNote{}, err
+	}
+	amounts := []struct {
+		dst    *currency.Amount
+		number string
+	}{
+		{&cn.Subtotal, subtotal},
+		{&cn.TaxTotal, taxTotal},
+		{&cn.Total, total},
+		{&cn.RefundAmount, refundAmount},
+	}
+	for _, a := range amounts {
+		if *a.dst, err = currency.NewAmount(a.number, cn.Currency); err != nil {
+			return CreditNote{}, err
+		}
+	}
+	cn.Method = Method(method)
+	cn.RefundStatus = RefundStatus(refundStatus)
+
+	return cn, nil
+}
+
+// stringIDs converts the given IDs to strings, for use with
+// array parameters. Never nil, since NULL arrays match nothing.
+func stringIDs(ids []ulid.ULID) []string {
+	s := make([]string, 0, len(ids))
+	for _, id := range ids {
+		s = append(s, id.String())
+	}
+	return s
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package creditnote
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/bojanz/currency"
+
+	"github.com/runbilliam/billiam/internal/invoice"
+)
+
+// UnusedItems returns the items crediting the unused time of the
+// given subscription invoice, from the given time until the end of
+// the invoiced period.
+//
+// Subscription lines are credited for the unused fraction of their
+// period, computed to the second. Discount lines are credited in the
+// same proportion, for the share of the discount which applied to the
+// subscription lines. Usage and proration lines are not credited.
+// Returns no items if nothing is left to credit.
+func UnusedItems(inv invoice.Invoice, at time.Time) ([]Item, error) {
+	// The subscription and non-discount amounts per tax rate,
+	// used to split the discount.
+	subscriptionAmounts := make(map[string]currency.Amount)
+	baseAmounts := make(map[string]currency.Amount)
+	for _, line := range inv.Lines {
+		if line.Type == invoice.LineDiscount {
+			continue
+		}
+		if err := addTo(baseAmounts, line.TaxRate, line.Amount); err != nil {
+			return nil, err
+		}
+		if line.Type == invoice.LineSubscription {
+			if err := addTo(subscriptionAmounts, line.TaxRate, line.Amount); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var items []Item
+	for _, line := range inv.Lines {
+		start, end := line.PeriodStart, line.PeriodEnd
+		if line.Type == invoice.LineDiscount {
+			start, end = inv.PeriodStart, inv.PeriodEnd
+		} else if line.Type != invoice.LineSubscription {
+			continue
+		}
+		total := int64(end.Sub(start) / time.Second)
+		remaining := total
+		if at.After(start) {
+			remaining = int64(end.Sub(at) / time.Second)
+		}
+		if total <= 0 || remaining <= 0 {
+			continue
+		}
+		amount, err := line.Amount.Mul(strconv.FormatInt(remaining, 10))
+		if err != nil {
+			return nil, err
+		}
+		if amount, err = amount.Div(strconv.FormatInt(total, 10)); err != nil {
+			return nil, err
+		}
+		if line.Type == invoice.LineDiscount {
+			share, ok := subscriptionAmounts[line.TaxRate]
+			base := baseAmounts[line.TaxRate]
+			if !ok || base.IsZero() {
+				continue
+			}
+			if amount, err = amount.Mul(share.Number()); err != nil {
+				return nil, err
+			}
+			if amount, err = amount.Div(base.Number()); err != nil {
+				return nil, err
+			}
+		}
+		amount = amount.Round()
+		if amount.IsZero() {
+			continue
+		}
+		items = append(items, Item{InvoiceLineID: line.ID, Amount: amount})
+	}
+
+	return items, nil
+}
+
+// addTo adds the amount to the given tax rate's total.
+func addTo(amounts map[string]currency.Amount, taxRate string, amount currency.Amount) error {
+	total, ok := amounts[taxRate]
+	if !ok {
+		amounts[taxRate] = amount
+		return nil
+	}
+	total, err := total.Add(amount)
+	if err != nil {
+		return err
+	}
+	amounts[taxRate] = total
+
+	return nil
+}
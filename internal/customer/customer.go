@@ -0,0 +1,199 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package customer provides customers, the people and companies being billed.
+package customer
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/bojanz/currency"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/pkg/timezone"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+// ErrNotFound is returned when a customer could not be found.
+var ErrNotFound = errors.New("customer not found")
+
+// Metadata limits.
+const (
+	MaxMetadataKeys        = 50
+	MaxMetadataKeyLength   = 40
+	MaxMetadataValueLength = 500
+)
+
+// Tax ID types.
+var taxIDTypes = []string{
+	"au_abn",  // Australian Business Number.
+	"br_cnpj", // Brazilian CNPJ.
+	"ca_bn",   // Canadian Business Number.
+	"ch_vat",  // Swiss VAT number.
+	"eu_vat",  // European VAT number.
+	"gb_vat",  // UK VAT number.
+	"in_gst",  // Indian GST number.
+	"jp_cn",   // Japanese Corporate Number.
+	"no_vat",  // Norwegian VAT number.
+	"nz_gst",  // New Zealand GST number.
+	"rs_pib",  // Serbian PIB number.
+	"us_ein",  // US Employer Identification Number.
+	"za_vat",  // South African VAT number.
+}
+
+var rxCountryCode = regexp.MustCompile("^[A-Z]{2}$")
+
+// Customer represents a customer.
+type Customer struct {
+	ID       ulid.ULID `json:"id"`
+	Version  int       `json:"version"`
+	Email    string    `json:"email"`
+	Name     string    `json:"name"`
+	Company  string    `json:"company"`
+	Address  Address   `json:"address"`
+	TaxIDs   []TaxID   `json:"tax_ids"`
+	Currency string    `json:"currency"`
+	// Timezone is an IANA timezone name. If empty, the site timezone is used.
+	Timezone string `json:"timezone"`
+	// Locale is a Unicode locale ID, used when formatting invoices and amounts.
+	Locale    string            `json:"locale"`
+	Metadata  map[string]string `json:"metadata"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Address represents a billing address.
+type Address struct {
+	Line1              string `json:"line1"`
+	Line2              string `json:"line2"`
+	Locality           string `json:"locality"`
+	AdministrativeArea string `json:"administrative_area"`
+	PostalCode         string `json:"postal_code"`
+	CountryCode        string `json:"country_code"`
+}
+
+// IsEmpty returns whether the address is empty.
+func (a Address) IsEmpty() bool {
+	return a == Address{}
+}
+
+// TaxID represents a tax identification number.
+type TaxID struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// New creates a new customer.
+func New() Customer {
+	now := time.Now().UTC()
+	c := Customer{
+		ID:        ulid.MustNew(ulid.Timestamp(now), rand.Reader),
+		Version:   1,
+		Metadata:  make(map[string]string),
+		CreatedAt: now,
+	}
+
+	return c
+}
+
+// GetTaxIDTypes returns the list of supported tax ID types.
+func GetTaxIDTypes() []string {
+	return taxIDTypes
+}
+
+// Validate validates the customer.
+func (c Customer) Validate() validation.Errors {
+	errs := validation.Errors{}
+	if c.ID == (ulid.ULID{}) {
+		errs.Add("id", validation.Required("ID is required."))
+	}
+	if c.Version == 0 {
+		errs.Add("version", validation.Required("Version is required."))
+	}
+	if c.Email == "" {
+		errs.Add("email", validation.Required("Email is required."))
+	}
+	if c.CreatedAt.IsZero() {
+		errs.Add("created_at", validation.Required("CreatedAt is required."))
+	}
+
+	if !validation.CheckEmail(c.Email) {
+		errs.Add("email", validation.InvalidValue("Email is invalid."))
+	}
+	if !currency.IsValid(c.Currency) {
+		errs.Add("currency", validation.InvalidChoice("Invalid currency."))
+	}
+	if !timezone.IsValid(c.Timezone) {
+		errs.Add("timezone", validation.InvalidChoice("Invalid timezone."))
+	}
+	if !validation.CheckLocale(c.Locale) {
+		errs.Add("locale", validation.InvalidValue("Invalid locale."))
+	}
+	errs.Merge("address", c.Address.Validate())
+	for i, taxID := range c.TaxIDs {
+		errs.Merge("tax_ids."+strconv.Itoa(i), taxID.Validate())
+	}
+	if len(c.Metadata) > MaxMetadataKeys {
+		errs.Add("metadata", validation.InvalidValue(fmt.Sprintf("Metadata can have at most %d keys.", MaxMetadataKeys)))
+	}
+	for key, value := range c.Metadata {
+		if key == "" || len(key) > MaxMetadataKeyLength {
+			errs.Add("metadata", validation.InvalidValue(fmt.Sprintf("Metadata keys must be 1-%d characters long.", MaxMetadataKeyLength)))
+		}
+		if len(value) > MaxMetadataValueLength {
+			errs.Add("metadata."+key, validation.InvalidValue(fmt.Sprintf("Metadata values can be at most %d characters long.", MaxMetadataValueLength)))
+		}
+	}
+
+	return errs
+}
+
+// Validate validates the address.
+//
+// An empty address is considered valid.
+func (a Address) Validate() validation.Errors {
+	errs := validation.Errors{}
+	if a.IsEmpty() {
+		return errs
+	}
+	if a.CountryCode == "" {
+		errs.Add("country_code", validation.Required("Country is required."))
+	}
+
+	if a.CountryCode != "" && !rxCountryCode.MatchString(a.CountryCode) {
+		errs.Add("country_code", validation.InvalidValue("Invalid country code."))
+	}
+
+	return errs
+}
+
+// Validate validates the tax ID.
+func (t TaxID) Validate() validation.Errors {
+	errs := validation.Errors{}
+	if t.Type == "" {
+		errs.Add("type", validation.Required("Type is required."))
+	}
+	if t.Value == "" {
+		errs.Add("value", validation.Required("Value is required."))
+	}
+
+	if t.Type != "" && !contains(taxIDTypes, t.Type) {
+		errs.Add("type", validation.InvalidChoice("Invalid tax ID type."))
+	}
+
+	return errs
+}
+
+func contains(a []string, x string) bool {
+	for _, v := range a {
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package customer_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/customer"
+	"github.com/runbilliam/billiam/internal/testutil"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+func TestCustomer_Validate(t *testing.T) {
+	c := newCustomer()
+	if errs := c.Validate(); !errs.IsEmpty() {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	// An empty address is valid.
+	c.Address = customer.Address{}
+	if errs := c.Validate(); !errs.IsEmpty() {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+
+	tests := []struct {
+		modify   func(c *customer.Customer)
+		wantPath string
+		wantCode string
+	}{
+		{func(c *customer.Customer) { c.ID = ulid.ULID{} }, "id", validation.CodeRequired},
+		{func(c *customer.Customer) { c.Version = 0 }, "version", validation.CodeRequired},
+		{func(c *customer.Customer) { c.Email = "" }, "email", validation.CodeRequired},
+		{func(c *customer.Customer) { c.Email = "jane" }, "email", validation.CodeInvalidValue},
+		{func(c *customer.Customer) { c.Currency = "XYZ" }, "currency", validation.CodeInvalidChoice},
+		{func(c *customer.Customer) { c.Timezone = "Europe/Nowhere" }, "timezone", validation.CodeInvalidChoice},
+		{func(c *customer.Customer) { c.Locale = "en_US" }, "locale", validation.CodeInvalidValue},
+		{func(c *customer.Customer) { c.Address.CountryCode = "" }, "address.country_code", validation.CodeRequired},
+		{func(c *customer.Customer) { c.Address.CountryCode = "rs" }, "address.country_code", validation.CodeInvalidValue},
+		{func(c *customer.Customer) { c.Address.CountryCode = "SRB" }, "address.country_code", validation.CodeInvalidValue},
+		{func(c *customer.Customer) { c.TaxIDs[0].Type = "" }, "tax_ids.0.type", validation.CodeRequired},
+		{func(c *customer.Customer) { c.TaxIDs[0].Type = "rs_jmbg" }, "tax_ids.0.type", validation.CodeInvalidChoice},
+		{func(c *customer.Customer) { c.TaxIDs[0].Value = "" }, "tax_ids.0.value", validation.CodeRequired},
+		{func(c *customer.Customer) { c.Metadata[""] = "empty" }, "metadata", validation.CodeInvalidValue},
+		{func(c *customer.Customer) { c.Metadata[strings.Repeat("k", 41)] = "long" }, "metadata", validation.CodeInvalidValue},
+		{func(c *customer.Customer) { c.Metadata["notes"] = strings.Repeat("v", 501) }, "metadata.notes", validation.CodeInvalidValue},
+		{func(c *customer.Customer) {
+			for i := 0; i <= customer.MaxMetadataKeys; i++ {
+				c.Metadata["key"+strconv.Itoa(i)] = "value"
+			}
+		}, "metadata", validation.CodeInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			c := newCustomer()
+			tt.modify(&c)
+			testutil.AssertError(t, c.Validate(), tt.wantPath, tt.wantCode)
+		})
+	}
+}
+
+func newCustomer() customer.Customer {
+	c := customer.New()
+	c.Email = "jane@example.com"
+	c.Name = "Jane Doe"
+	c.Address = customer.Address{
+		Line1:       "Bulevar Kralja Aleksandra 73",
+		Locality:    "Belgrade",
+		PostalCode:  "11000",
+		CountryCode: "RS",
+	}
+	c.TaxIDs = []customer.TaxID{{Type: "rs_pib", Value: "101134702"}}
+	c.Currency = "RSD"
+	c.Timezone = "Europe/Belgrade"
+	c.Locale = "sr-Latn"
+	c.Metadata["crm_id"] = "42"
+
+	return c
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package customer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+const customerColumns = `id, version, email, name, company, address, tax_ids, currency, timezone, locale, metadata, created_at, updated_at`
+
+// ConflictError is returned when a customer could not be updated
+// because it was modified in the meantime.
+type ConflictError struct {
+	ID      ulid.ULID
+	Version int
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("customer %v was modified, version %v is out of date", e.ID, e.Version)
+}
+
+// Repository loads and saves customers.
+type Repository struct {
+	db database.Querier
+}
+
+// NewRepository creates a new customer repository.
+func NewRepository(db database.Querier) *Repository {
+	return &Repository{db: db}
+}
+
+// Get gets the customer with the given ID.
+func (r *Repository) Get(ctx context.Context, id ulid.ULID) (Customer, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+customerColumns+` FROM customers WHERE id = $1`, id.String())
+
+	return scanCustomer(row)
+}
+
+// GetForUpdate gets the customer with the given ID, locking its row
+// until the end of the current transaction.
+func (r *Repository) GetForUpdate(ctx context.Context, id ulid.ULID) (Customer, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+customerColumns+` FROM customers WHERE id = $1 FOR UPDATE`, id.String())
+
+	return scanCustomer(row)
+}
+
+// ListByEmail lists customers with the given email.
+//
+// Emails are compared case-insensitively. Multiple customers
+// can share the same email (e.g. a person billed for two companies).
+func (r *Repository) ListByEmail(ctx context.Context, email string) ([]Customer, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+customerColumns+` FROM customers WHERE lower(email) = lower($1) ORDER BY id`, email)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanCustomers(rows)
+}
+
+// List lists up to limit customers, ordered by ID (creation time).
+//
+// Pass the last seen ID as after to get the next page.
+func (r *Repository) List(ctx context.Context, after ulid.ULID, limit int) ([]Customer, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+customerColumns+` FROM customers
+		WHERE id > $1 ORDER BY id LIMIT $2`,
+		after.String(), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanCustomers(rows)
+}
+
+// Create creates the given customer.
+func (r *Repository) Create(ctx context.Context, c Customer) error {
+	address, taxIDs, metadata, err := marshalJSONFields(c)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO customers (`+customerColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		c.ID.String(), c.Version, c.Email, c.Name, c.Company, address, taxIDs,
+		c.Currency, c.Timezone, c.Locale, metadata, c.CreatedAt, database.NullTime(c.UpdatedAt),
+	)
+
+	return err
+}
+
+// Update updates the given customer.
+//
+// The update only succeeds if the stored version matches c.Version,
+// otherwise a *ConflictError is returned. On success, c.Version is
+// incremented and c.UpdatedAt is set to the current time.
+func (r *Repository) Update(ctx context.Context, c *Customer) error {
+	address, taxIDs, metadata, err := marshalJSONFields(*c)
+	if err != nil {
+		return err
+	}
+	updatedAt := time.Now().UTC()
+	tag, err := r.db.Exec(ctx, `
+		UPDATE customers
+		SET version = version + 1, email = $3, name = $4, company = $5, address = $6,
+			tax_ids = $7, currency = $8, timezone = $9, locale = $10, metadata = $11, updated_at = $12
+		WHERE id = $1 AND version = $2`,
+		c.ID.String(), c.Version, c.Email, c.Name, c.Company, address,
+		taxIDs, c.Currency, c.Timezone, c.Locale, metadata, updatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		var exists bool
+		err := r.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM customers WHERE id = $1)`, c.ID.String()).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrNotFound
+		}
+		return &ConflictError{ID: c.ID, Version: c.Version}
+	}
+	c.Version++
+	c.UpdatedAt = updatedAt
+
+	return nil
+}
+
+// Delete deletes the customer with the given ID.
+func (r *Repository) Delete(ctx context.Context, id ulid.ULID) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM customers WHERE id = $1`, id.String())
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// marshalJSONFields marshals the fields stored as JSONB.
+func marshalJSONFields(c Customer) (address, taxIDs, metadata []byte, err error) {
+	if address, err = json.Marshal(c.Address); err != nil {
+		return nil, nil, nil, err
+	}
+	if c.TaxIDs == nil {
+		c.TaxIDs = []TaxID{}
+	}
+	if taxIDs, err = json.Marshal(c.TaxIDs); err != nil {
+		return nil, nil, nil, err
+	}
+	if c.Metadata == nil {
+		c.Metadata = map[string]string{}
+	}
+	if metadata, err = json.Marshal(c.Metadata); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return address, taxIDs, metadata, nil
+}
+
+// scanCustomers scans customers from the given rows.
+func scanCustomers(rows pgx.Rows) ([]Customer, error) {
+	defer rows.Close()
+
+	var customers []Customer
+	for rows.Next() {
+		c, err := scanCustomer(rows)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, c)
+	}
+
+	return customers, rows.Err()
+}
+
+// scanCustomer scans a customer from the given row.
+func scanCustomer(row pgx.Row) (Customer, error) {
+	var c Customer
+	var id string
+	var address, taxIDs, metadata []byte
+	var updatedAt *time.Time
+	err := row.Scan(&id, &c.Version, &c.Email, &c.Name, &c.Company, &address, &taxIDs,
+		&c.Currency, &c.Timezone, &c.Locale, &metadata, &c.CreatedAt, &updatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Customer{}, ErrNotFound
+		}
+		return Customer{}, err
+	}
+	if c.ID, err = ulid.Parse(id); err != nil {
+		return Customer{}, err
+	}
+	if err := json.Unmarshal(address, &c.Address); err != nil {
+		return Customer{}, err
+	}
+	if err := json.Unmarshal(taxIDs, &c.TaxIDs); err != nil {
+		return Customer{}, err
+	}
+	if err := json.Unmarshal(metadata, &c.Metadata); err != nil {
+		return Customer{}, err
+	}
+	c.UpdatedAt = database.TimeValue(updatedAt)
+
+	return c, nil
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dunning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+	"github.com/rs/zerolog"
+
+	"github.com/runbilliam/billiam/internal/gateway"
+	"github.com/runbilliam/billiam/internal/invoice"
+	"github.com/runbilliam/billiam/internal/payment"
+	"github.com/runbilliam/billiam/internal/settings"
+	"github.com/runbilliam/billiam/internal/subscription"
+	"github.com/runbilliam/billiam/internal/worker"
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+// PaymentRetryDelay is the delay before retrying a payment
+// attempt which failed with an error.
+const PaymentRetryDelay = time.Hour
+
+// Collector collects payments for open invoices.
+type Collector struct {
+	db       database.Querier
+	gateways *gateway.Registry
+	logger   *zerolog.Logger
+}
+
+// NewCollector creates a new collector.
+func NewCollector(db database.Querier, gateways *gateway.Registry, logger *zerolog.Logger) *Collector {
+	return &Collector{db: db, gateways: gateways, logger: logger}
+}
+
+// Run makes a payment attempt for each open invoice whose next
+// payment attempt is due. Pending attempts are checked for their
+// outcome instead, until the gateway is done processing them.
+//
+// The invoice stays locked while the gateway is charged. An attempt
+// which fails with an error is rolled back and logged, and the invoice
+// is retried after PaymentRetryDelay. Returns the number of attempts made.
+func (c *Collector) Run(ctx context.Context, now time.Time) (int, error) {
+	next := func(tx pgx.Tx, failed []ulid.ULID) (ulid.ULID, error) {
+		invoices, err := invoice.NewRepository(tx).ListDueForPayment(ctx, now, failed, 1)
+		if err != nil || len(invoices) == 0 {
+			return ulid.ULID{}, err
+		}
+		if err := c.collect(ctx, tx, invoices[0], now); err != nil {
+			return invoices[0].ID, fmt.Errorf("collect invoice %v: %w", invoices[0].ID, err)
+		}
+		return invoices[0].ID, nil
+	}
+	fail := func(id ulid.ULID, err error) error {
+		retryAt := now.Add(PaymentRetryDelay)
+		c.logger.Error().
+			Err(err).
+			Str("invoice_id", id.String()).
+			Time("next_attempt_at", retryAt).
+			Msg("Payment attempt failed")
+		return invoice.NewRepository(c.db).PostponePayment(ctx, id, retryAt)
+	}
+
+	return worker.EachTx(ctx, c.db, next, fail)
+}
+
+// collect makes a payment attempt for the given invoice, and updates
+// the invoice and its subscription based on the outcome.
+func (c *Collector) collect(ctx context.Context, tx pgx.Tx, inv invoice.Invoice, now time.Time) error {
+	st, err := settings.NewStore(tx).Get(ctx)
+	if errors.Is(err, settings.ErrNotFound) {
+		st = settings.New()
+	} else if err != nil {
+		return err
+	}
+	loc, err := time.LoadLocation(st.Timezone)
+	if err != nil {
+		return err
+	}
+	paymentRepo := payment.NewRepository(tx)
+	first := now
+	var attempts []payment.Attempt
+	if inv.AttemptCount > 0 {
+		if attempts, err = paymentRepo.ListAttempts(ctx, inv.ID); err != nil {
+			return err
+		}
+		if len(attempts) > 0 {
+			first = attempts[0].CreatedAt
+		}
+	}
+
+	var attempt payment.Attempt
+	if n := len(attempts); n > 0 && attempts[n-1].Status == payment.AttemptPending {
+		// The previous payment is still being processed, check its outcome
+		// instead of charging the customer again.
+		attempt = attempts[n-1]
+		g, err := c.gateways.Get(attempt.Gateway)
+		if err != nil {
+			return err
+		}
+		if err := Resolve(ctx, g, &attempt); err != nil {
+			return err
+		}
+		if attempt.Status != payment.AttemptPending {
+			if err := paymentRepo.UpdateAttempt(ctx, attempt); err != nil {
+				return err
+			}
+		}
+	} else {
+		inv.AttemptCount++
+		attempt = payment.NewAttempt(inv.ID, inv.AttemptCount, inv.AmountDue, now)
+		method, err := paymentRepo.GetDefaultMethod(ctx, inv.CustomerID)
+		if errors.Is(err, payment.ErrNotFound) {
+			attempt.Message = "The customer has no payment method."
+		} else if err != nil {
+			return err
+		} else if g, err := c.gateways.Get(method.Gateway); err != nil {
+			// The gateway was disabled after the payment method was saved.
+			attempt.PaymentMethodID = method.ID
+			attempt.Gateway = method.Gateway
+			attempt.Message = fmt.Sprintf("The %q gateway is not enabled.", method.Gateway)
+		} else {
+			// Errors roll back the attempt, see Charge.
+			if err := Charge(ctx, g, method, inv, &attempt); err != nil {
+				return err
+			}
+		}
+		if err := paymentRepo.CreateAttempt(ctx, attempt); err != nil {
+			return err
+		}
+	}
+
+	var sub subscription.Subscription
+	subRepo := subscription.NewRepository(tx)
+	if inv.SubscriptionID != (ulid.ULID{}) {
+		if sub, err = subRepo.Get(ctx, inv.SubscriptionID); err != nil {
+			return err
+		}
+	}
+	event := c.logger.Info()
+	switch attempt.Status {
+	case payment.AttemptSucceeded:
+		if errs := inv.TransitionTo(invoice.StatusPaid, now); !errs.IsEmpty() {
+			return fmt.Errorf("invalid invoice: %v", errs)
+		}
+	case payment.AttemptPending:
+		// Checked again once the gateway had time to process the payment.
+		inv.NextPaymentAttemptAt = now.Add(PaymentRetryDelay)
+	default:
+		event = c.logger.Warn()
+		if sub.Status == subscription.StatusActive || sub.Status == subscription.StatusTrialing {
+			sub.TransitionTo(subscription.StatusPastDue, now)
+		}
+		next, retry := NextAttempt(st.DunningSchedule, first, inv.AttemptCount, attempt.DeclineCode, loc)
+		if retry {
+			inv.NextPaymentAttemptAt = next
+		} else {
+			if errs := inv.TransitionTo(invoice.StatusUncollectible, now); !errs.IsEmpty() {
+				return fmt.Errorf("invalid invoice: %v", errs)
+			}
+			if sub.Status.CanTransitionTo(st.DunningFinalStatus) {
+				sub.TransitionTo(st.DunningFinalStatus, now)
+			}
+		}
+	}
+	if err := invoice.NewRepository(tx).Update(ctx, &inv); err != nil {
+		return err
+	}
+	if inv.Status == invoice.StatusPaid {
+		if err := reactivate(ctx, tx, &sub, now); err != nil {
+			return err
+		}
+	}
+	if sub.ID != (ulid.ULID{}) && len(sub.PendingChanges()) > 0 {
+		if err := subRepo.Update(ctx, &sub); err != nil {
+			return err
+		}
+	}
+
+	event.
+		Str("invoice_id", inv.ID.String()).
+		Str("invoice_number", inv.Number).
+		Str("customer_id", inv.CustomerID.String()).
+		Str("subscription_id", nullID(sub.ID)).
+		Int("attempt", attempt.Number).
+		Str("gateway", attempt.Gateway).
+		Str("payment_id", attempt.RemoteID).
+		Str("amount", attempt.Amount.Number()).
+		Str("currency", attempt.Amount.CurrencyCode()).
+		Str("status", string(attempt.Status)).
+		Str("decline_code", string(attempt.DeclineCode)).
+		Bool("hard_decline", attempt.DeclineCode.IsHard()).
+		Str("invoice_status", string(inv.Status)).
+		Str("subscription_status", string(sub.Status)).
+		Time("next_attempt_at", inv.NextPaymentAttemptAt).
+		Msg("Payment attempt")
+
+	return nil
+}
+
+// reactivate reactivates the given subscription once its
+// invoices are paid, see Reactivate.
+//
+// Must be called after the paid invoice is saved.
+func reactivate(ctx context.Context, tx pgx.Tx, sub *subscription.Subscription, now time.Time) error {
+	if sub.Status != subscription.StatusPastDue && sub.Status != subscription.StatusUnpaid {
+		return nil
+	}
+	invoices, err := invoice.NewRepository(tx).ListBySubscription(ctx, sub.ID)
+	if err != nil {
+		return err
+	}
+	Reactivate(sub, invoices, now)
+
+	return nil
+}
+
+// nullID returns the string representation of the given ID, or an empty
+// string for the zero ID.
+func nullID(id ulid.ULID) string {
+	if id == (ulid.ULID{}) {
+		return ""
+	}
+	return id.String()
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dunning collects payments for open invoices, retrying failed
+// payments on the schedule configured in the settings.
+//
+// The first attempt is made once the invoice is finalized. Failed
+// attempts are retried on each day of the dunning schedule, counted
+// from the first attempt. Hard declines (e.g. a stolen card) are not
+// retried. Once the retries are exhausted, the invoice is marked as
+// uncollectible, and its subscription moves to the final status from
+// the settings (canceled or unpaid).
+package dunning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/runbilliam/billiam/internal/gateway"
+	"github.com/runbilliam/billiam/internal/invoice"
+	"github.com/runbilliam/billiam/internal/payment"
+	"github.com/runbilliam/billiam/internal/subscription"
+)
+
+// NextAttempt returns the time of the next payment retry, given the
+// dunning schedule, the time of the first attempt, the number of
+// attempts made so far and the decline code of the latest attempt.
+//
+// Days are added on the wall clock of the given location, so that
+// retries keep the same local time across DST changes.
+// Returns false if the payment should not be retried.
+func NextAttempt(schedule []int, first time.Time, attempts int, code gateway.DeclineCode, loc *time.Location) (time.Time, bool) {
+	if code.IsHard() || attempts < 1 || attempts > len(schedule) {
+		return time.Time{}, false
+	}
+	return first.In(loc).AddDate(0, 0, schedule[attempts-1]).UTC(), true
+}
+
+// Reactivate reactivates the given subscription once its invoices are
+// paid, including unpaid subscriptions whose payment retries were exhausted.
+//
+// Past due and unpaid subscriptions stay that way while any of the given
+// invoices of the subscription are still open or uncollectible.
+func Reactivate(sub *subscription.Subscription, invoices []invoice.Invoice, now time.Time) {
+	if sub.Status != subscription.StatusPastDue && sub.Status != subscription.StatusUnpaid {
+		return
+	}
+	for _, inv := range invoices {
+		if inv.Status == invoice.StatusOpen || inv.Status == invoice.StatusUncollectible {
+			return
+		}
+	}
+	sub.TransitionTo(subscription.StatusActive, now)
+}
+
+// Charge charges the given payment method for the invoice's amount
+// due, and records the outcome on the given attempt.
+//
+// The charge is made off-session, with an idempotency key derived
+// from the invoice ID and attempt number, making it safe to repeat
+// the same attempt. Declines are recorded on the attempt. Network and
+// gateway errors are returned instead, since the gateway might have
+// captured the payment: the attempt must not be recorded, so that it
+// is retried with the same number, and therefore the same key.
+// Payments requiring customer action count as declined, since the
+// customer isn't present to complete the action. Payments which are
+// still being processed leave the attempt pending, see Resolve.
+func Charge(ctx context.Context, g gateway.Gateway, m payment.Method, inv invoice.Invoice, a *payment.Attempt) error {
+	a.PaymentMethodID = m.ID
+	a.Gateway = m.Gateway
+	p, err := g.Charge(ctx, gateway.PaymentRequest{
+		Amount:          inv.AmountDue,
+		CustomerID:      m.RemoteCustomerID,
+		PaymentMethodID: m.RemoteID,
+		Description:     fmt.Sprintf("Invoice %v", inv.Number),
+		OffSession:      true,
+		IdempotencyKey:  fmt.Sprintf("%v-%d", inv.ID, a.Number),
+		Metadata: map[string]string{
+			"invoice_id":     inv.ID.String(),
+			"invoice_number": inv.Number,
+		},
+	})
+	var declineErr *gateway.DeclineError
+	switch {
+	case errors.As(err, &declineErr):
+		a.RemoteID = declineErr.Payment.ID
+		a.DeclineCode = declineErr.Code
+		a.Message = declineErr.Message
+	case err != nil:
+		return fmt.Errorf("charge invoice %v: %w", inv.ID, err)
+	case p.Status == gateway.PaymentSucceeded:
+		a.RemoteID = p.ID
+		a.Status = payment.AttemptSucceeded
+	case p.Status == gateway.PaymentPending:
+		a.RemoteID = p.ID
+		a.Status = payment.AttemptPending
+		a.Message = "Payment is being processed."
+	default:
+		a.RemoteID = p.ID
+		a.DeclineCode = gateway.DeclineAuthenticationRequired
+		a.Message = fmt.Sprintf("Payment is %v.", p.Status)
+	}
+
+	return nil
+}
+
+// Resolve fetches the payment of the given pending attempt from the
+// gateway, and records its outcome on the attempt.
+//
+// The attempt stays pending while the gateway is still processing
+// the payment. Network and gateway errors are returned.
+func Resolve(ctx context.Context, g gateway.Gateway, a *payment.Attempt) error {
+	p, err := g.GetPayment(ctx, a.RemoteID)
+	if err != nil {
+		return fmt.Errorf("get payment %v: %w", a.RemoteID, err)
+	}
+	switch p.Status {
+	case gateway.PaymentPending:
+	case gateway.PaymentSucceeded:
+		a.Status = payment.AttemptSucceeded
+		a.Message = ""
+	default:
+		a.Status = payment.AttemptFailed
+		a.DeclineCode = p.DeclineCode
+		a.Message = fmt.Sprintf("Payment is %v.", p.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,253 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dunning_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bojanz/currency"
+
+	"github.com/runbilliam/billiam/internal/dunning"
+	"github.com/runbilliam/billiam/internal/gateway"
+	"github.com/runbilliam/billiam/internal/gateway/testgateway"
+	"github.com/runbilliam/billiam/internal/invoice"
+	"github.com/runbilliam/billiam/internal/payment"
+	"github.com/runbilliam/billiam/internal/subscription"
+	"github.com/runbilliam/billiam/internal/testutil"
+)
+
+func TestNextAttempt(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	schedule := []int{1, 3, 5, 7}
+	// The first attempt happens at 09:00 Berlin time (CEST).
+	first := time.Date(2020, 10, 22, 7, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		attempts int
+		code     gateway.DeclineCode
+		want     time.Time
+		wantOK   bool
+	}{
+		{0, gateway.DeclineGeneric, time.Time{}, false},
+		{1, gateway.DeclineGeneric, time.Date(2020, 10, 23, 7, 0, 0, 0, time.UTC), true},
+		{2, gateway.DeclineInsufficientFunds, time.Date(2020, 10, 25, 8, 0, 0, 0, time.UTC), true},
+		{3, gateway.DeclineProcessingError, time.Date(2020, 10, 27, 8, 0, 0, 0, time.UTC), true},
+		{4, "", time.Date(2020, 10, 29, 8, 0, 0, 0, time.UTC), true},
+		{5, gateway.DeclineGeneric, time.Time{}, false},
+		// Hard declines are never retried.
+		{1, gateway.DeclineExpiredCard, time.Time{}, false},
+		{2, gateway.DeclineFraudulent, time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got, ok := dunning.NextAttempt(schedule, first, tt.attempts, tt.code, berlin)
+			if !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if ok != tt.wantOK {
+				t.Errorf("got %v, want %v", ok, tt.wantOK)
+			}
+		})
+	}
+
+	// An empty schedule means no retries.
+	if _, ok := dunning.NextAttempt(nil, first, 1, gateway.DeclineGeneric, berlin); ok {
+		t.Error("expected no retries for an empty schedule")
+	}
+}
+
+func TestCharge(t *testing.T) {
+	tests := []struct {
+		card        string
+		wantStatus  payment.AttemptStatus
+		wantDecline gateway.DeclineCode
+	}{
+		{testgateway.CardSuccess, payment.AttemptSucceeded, ""},
+		{testgateway.CardInsufficientFunds, payment.AttemptFailed, gateway.DeclineInsufficientFunds},
+		{testgateway.CardExpired, payment.AttemptFailed, gateway.DeclineExpiredCard},
+		// Off-session payments can't complete the required action.
+		{testgateway.CardRequiresAction, payment.AttemptFailed, gateway.DeclineAuthenticationRequired},
+		{testgateway.CardProcessing, payment.AttemptPending, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			ctx := context.Background()
+			g := testgateway.New()
+			pm, err := g.SavePaymentMethod(ctx, gateway.PaymentMethodRequest{Email: "jane@example.com", Token: tt.card})
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := payment.NewMethod(testutil.NewID(), "test", pm)
+			inv := newInvoice(t)
+			a := payment.NewAttempt(inv.ID, 1, inv.Total, time.Now())
+			if err := dunning.Charge(ctx, g, m, inv, &a); err != nil {
+				t.Fatal(err)
+			}
+
+			if a.Status != tt.wantStatus {
+				t.Errorf("got %v, want %v", a.Status, tt.wantStatus)
+			}
+			if a.DeclineCode != tt.wantDecline {
+				t.Errorf("got %v, want %v", a.DeclineCode, tt.wantDecline)
+			}
+			if a.PaymentMethodID != m.ID || a.Gateway != "test" || a.RemoteID == "" {
+				t.Errorf("unexpected attempt: %+v", a)
+			}
+
+			// Repeating the same attempt doesn't charge twice.
+			repeated := payment.NewAttempt(inv.ID, 1, inv.Total, time.Now())
+			if err := dunning.Charge(ctx, g, m, inv, &repeated); err != nil {
+				t.Fatal(err)
+			}
+			if repeated.RemoteID != a.RemoteID {
+				t.Errorf("got %v, want %v", repeated.RemoteID, a.RemoteID)
+			}
+		})
+	}
+}
+
+func TestCharge_Error(t *testing.T) {
+	g := testgateway.New()
+	m := payment.Method{ID: testutil.NewID(), Gateway: "test", RemoteID: "pm_unknown"}
+	inv := newInvoice(t)
+	a := payment.NewAttempt(inv.ID, 1, inv.Total, time.Now())
+	// Errors are returned instead of being recorded, so that the
+	// attempt is retried with the same idempotency key.
+	if err := dunning.Charge(context.Background(), g, m, inv, &a); err == nil {
+		t.Error("expected an error")
+	}
+	if a.Status != payment.AttemptFailed || a.DeclineCode != "" || a.Message != "" {
+		t.Errorf("unexpected attempt: %+v", a)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	ctx := context.Background()
+	g := testgateway.New()
+	pm, err := g.SavePaymentMethod(ctx, gateway.PaymentMethodRequest{Email: "jane@example.com", Token: testgateway.CardProcessing})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := payment.NewMethod(testutil.NewID(), "test", pm)
+	inv := newInvoice(t)
+	a := payment.NewAttempt(inv.ID, 1, inv.Total, time.Now())
+	if err := dunning.Charge(ctx, g, m, inv, &a); err != nil {
+		t.Fatal(err)
+	}
+
+	// The attempt stays pending until the payment is processed.
+	if err := dunning.Resolve(ctx, g, &a); err != nil {
+		t.Fatal(err)
+	}
+	if a.Status != payment.AttemptPending {
+		t.Errorf("got %v, want %v", a.Status, payment.AttemptPending)
+	}
+	if _, err := g.Settle(a.RemoteID); err != nil {
+		t.Fatal(err)
+	}
+	if err := dunning.Resolve(ctx, g, &a); err != nil {
+		t.Fatal(err)
+	}
+	if a.Status != payment.AttemptSucceeded || a.Message != "" {
+		t.Errorf("unexpected attempt: %+v", a)
+	}
+
+	unknown := payment.Attempt{Status: payment.AttemptPending, RemoteID: "pay_unknown"}
+	if err := dunning.Resolve(ctx, g, &unknown); !errors.Is(err, gateway.ErrPaymentNotFound) {
+		t.Errorf("got %v, want %v", err, gateway.ErrPaymentNotFound)
+	}
+}
+
+func TestCheckPayment(t *testing.T) {
+	tests := []struct {
+		amount          string
+		currency        string
+		wantOverpayment string
+		wantErr         bool
+	}{
+		{"19.99", "EUR", "0.00", false},
+		{"25.00", "EUR", "5.01", false},
+		{"19.98", "EUR", "", true},
+		{"19.99", "USD", "", true},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			amount, _ := currency.NewAmount(tt.amount, tt.currency)
+			overpayment, errs := dunning.CheckPayment(newInvoice(t), amount)
+			if tt.wantErr {
+				if errs.Get("amount") == nil {
+					t.Errorf("expected an amount error, got %v", errs)
+				}
+				return
+			}
+			if !errs.IsEmpty() {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if overpayment.Number() != tt.wantOverpayment {
+				t.Errorf("got %v, want %v", overpayment.Number(), tt.wantOverpayment)
+			}
+		})
+	}
+
+	// Nothing is due once credit covers the total.
+	inv := invoice.New(testutil.NewID(), "EUR")
+	price, _ := currency.NewAmount("19.99", "EUR")
+	line, _ := invoice.NewLine(invoice.LineAdjustment, "Setup fee", price, 1)
+	inv.AddLine(line)
+	inv.ApplyCredit(price)
+	amount, _ := currency.NewAmount("10.00", "EUR")
+	if overpayment, errs := dunning.CheckPayment(inv, amount); !errs.IsEmpty() || overpayment.Number() != "10.00" {
+		t.Errorf("got %v (%v), want 10.00", overpayment.Number(), errs)
+	}
+}
+
+func TestReactivate(t *testing.T) {
+	now := time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC)
+	sub := subscription.New(testutil.NewID(), testutil.NewID(), subscription.StatusActive)
+	sub.TransitionTo(subscription.StatusPastDue, now)
+	// Two overdue invoices, from consecutive periods.
+	first := newInvoice(t)
+	second := newInvoice(t)
+
+	first.TransitionTo(invoice.StatusPaid, now)
+	dunning.Reactivate(&sub, []invoice.Invoice{second, first}, now)
+	if sub.Status != subscription.StatusPastDue {
+		t.Errorf("got %v, want %v", sub.Status, subscription.StatusPastDue)
+	}
+
+	second.TransitionTo(invoice.StatusUncollectible, now)
+	sub.TransitionTo(subscription.StatusUnpaid, now)
+	dunning.Reactivate(&sub, []invoice.Invoice{second, first}, now)
+	if sub.Status != subscription.StatusUnpaid {
+		t.Errorf("got %v, want %v", sub.Status, subscription.StatusUnpaid)
+	}
+
+	second.TransitionTo(invoice.StatusPaid, now)
+	dunning.Reactivate(&sub, []invoice.Invoice{second, first}, now)
+	if sub.Status != subscription.StatusActive {
+		t.Errorf("got %v, want %v", sub.Status, subscription.StatusActive)
+	}
+}
+
+func newInvoice(t *testing.T) invoice.Invoice {
+	t.Helper()
+	inv := invoice.New(testutil.NewID(), "EUR")
+	price, _ := currency.NewAmount("19.99", "EUR")
+	line, err := invoice.NewLine(invoice.LineAdjustment, "Setup fee", price, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := inv.AddLine(line); err != nil {
+		t.Fatal(err)
+	}
+	inv.TransitionTo(invoice.StatusOpen, time.Now())
+	inv.Number = "INV-2020-00001"
+
+	return inv
+}
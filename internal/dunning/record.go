@@ -0,0 +1,104 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dunning
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bojanz/currency"
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/balance"
+	"github.com/runbilliam/billiam/internal/invoice"
+	"github.com/runbilliam/billiam/internal/payment"
+	"github.com/runbilliam/billiam/internal/subscription"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+// CheckPayment checks the amount of a payment for the given invoice,
+// and returns the overpayment, the amount above the amount due.
+//
+// Errors are reported on the "amount" path.
+func CheckPayment(inv invoice.Invoice, amount currency.Amount) (currency.Amount, validation.Errors) {
+	errs := validation.Errors{}
+	if amount.CurrencyCode() == "" {
+		errs.Add("amount", validation.Required("Amount is required."))
+		return currency.Amount{}, errs
+	}
+	if amount.CurrencyCode() != inv.Currency {
+		errs.Add("amount", validation.InvalidValue("Amount currency must match the invoice currency."))
+		return currency.Amount{}, errs
+	}
+	overpayment, err := amount.Sub(inv.AmountDue)
+	if err != nil {
+		errs.Add("amount", validation.InvalidValue("Invalid amount."))
+		return currency.Amount{}, errs
+	}
+	if overpayment.IsNegative() {
+		errs.Add("amount", validation.InvalidValue("Amount can't be less than the amount due."))
+		return currency.Amount{}, errs
+	}
+
+	return overpayment, errs
+}
+
+// RecordPayment records a payment for the given invoice received
+// outside of the gateways, such as a bank transfer, and marks the
+// invoice as paid.
+//
+// The amount must cover the amount due, see CheckPayment. Any amount
+// above it is credited to the customer's balance as an overpayment.
+// Past due and unpaid subscriptions become active again once all of
+// their invoices are paid, as with payments made by the Collector.
+//
+// Must be called inside a transaction, with the invoice locked
+// (see invoice.Repository.GetForUpdate).
+func RecordPayment(ctx context.Context, tx pgx.Tx, inv *invoice.Invoice, amount currency.Amount, now time.Time) error {
+	overpayment, errs := CheckPayment(*inv, amount)
+	if !errs.IsEmpty() {
+		return fmt.Errorf("record payment for invoice %v: %v", inv.ID, errs)
+	}
+	if errs := inv.TransitionTo(invoice.StatusPaid, now); !errs.IsEmpty() {
+		return fmt.Errorf("record payment for invoice %v: %v", inv.ID, errs)
+	}
+	inv.AttemptCount++
+	attempt := payment.NewAttempt(inv.ID, inv.AttemptCount, amount, now)
+	attempt.Status = payment.AttemptSucceeded
+	attempt.Message = "Paid outside of the gateways."
+	if err := payment.NewRepository(tx).CreateAttempt(ctx, attempt); err != nil {
+		return err
+	}
+	if err := invoice.NewRepository(tx).Update(ctx, inv); err != nil {
+		return err
+	}
+
+	if inv.SubscriptionID != (ulid.ULID{}) {
+		subRepo := subscription.NewRepository(tx)
+		sub, err := subRepo.Get(ctx, inv.SubscriptionID)
+		if err != nil {
+			return err
+		}
+		if err := reactivate(ctx, tx, &sub, now); err != nil {
+			return err
+		}
+		if len(sub.PendingChanges()) > 0 {
+			if err := subRepo.Update(ctx, &sub); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !overpayment.IsPositive() {
+		return nil
+	}
+	t := balance.NewTransaction(inv.CustomerID, balance.TransactionOverpayment, overpayment)
+	t.InvoiceID = inv.ID
+	t.Description = "Overpayment of invoice " + inv.Number
+	t.CreatedAt = now.UTC()
+
+	return balance.NewRepository(tx).Create(ctx, t)
+}
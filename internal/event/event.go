@@ -0,0 +1,48 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package event records events that customers are notified of,
+// e.g. a trial ending soon.
+package event
+
+import (
+	"crypto/rand"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// Type represents the type of an event.
+type Type string
+
+// Event types.
+const (
+	// TypeTrialWillEnd is used when a subscription's trial is about to end.
+	TypeTrialWillEnd Type = "subscription.trial_will_end"
+)
+
+// Event represents something that happened to a customer's account.
+type Event struct {
+	ID         ulid.ULID `json:"id"`
+	Type       Type      `json:"type"`
+	CustomerID ulid.ULID `json:"customer_id"`
+	// SubscriptionID is set for subscription events.
+	SubscriptionID ulid.ULID `json:"subscription_id"`
+	// Data holds type specific details, e.g. the trial end.
+	Data      map[string]interface{} `json:"data"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// New creates a new event of the given type.
+func New(eventType Type, customerID ulid.ULID) Event {
+	now := time.Now().UTC()
+	e := Event{
+		ID:         ulid.MustNew(ulid.Timestamp(now), rand.Reader),
+		Type:       eventType,
+		CustomerID: customerID,
+		Data:       map[string]interface{}{},
+		CreatedAt:  now,
+	}
+
+	return e
+}
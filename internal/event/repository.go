@@ -0,0 +1,87 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package event
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+const eventColumns = `id, type, customer_id, subscription_id, data, created_at`
+
+// Repository loads and saves events.
+type Repository struct {
+	db database.Querier
+}
+
+// NewRepository creates a new event repository.
+func NewRepository(db database.Querier) *Repository {
+	return &Repository{db: db}
+}
+
+// ListByCustomer lists the events of the given customer, newest first.
+func (r *Repository) ListByCustomer(ctx context.Context, customerID ulid.ULID, limit int) ([]Event, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+eventColumns+` FROM events
+		WHERE customer_id = $1 ORDER BY id DESC LIMIT $2`,
+		customerID.String(), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		var id, eventType, customerID string
+		var subscriptionID *string
+		var data []byte
+		if err := rows.Scan(&id, &eventType, &customerID, &subscriptionID, &data, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if e.ID, err = ulid.Parse(id); err != nil {
+			return nil, err
+		}
+		if e.CustomerID, err = ulid.Parse(customerID); err != nil {
+			return nil, err
+		}
+		if subscriptionID != nil {
+			if e.SubscriptionID, err = ulid.Parse(*subscriptionID); err != nil {
+				return nil, err
+			}
+		}
+		if err := json.Unmarshal(data, &e.Data); err != nil {
+			return nil, err
+		}
+		e.Type = Type(eventType)
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// Create creates the given event.
+func (r *Repository) Create(ctx context.Context, e Event) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	var subscriptionID *string
+	if e.SubscriptionID != (ulid.ULID{}) {
+		id := e.SubscriptionID.String()
+		subscriptionID = &id
+	}
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO events (`+eventColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		e.ID.String(), string(e.Type), e.CustomerID.String(), subscriptionID, data, e.CreatedAt,
+	)
+
+	return err
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package gateway defines the interface to payment gateways.
+//
+// Gateways are registered in a Registry, under the name used in the
+// [gateways] section of the config. Gateway specific identifiers (for
+// payments, payment methods and customers) are opaque strings.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bojanz/currency"
+)
+
+// ErrPaymentNotFound is returned when a payment could not be found.
+var ErrPaymentNotFound = errors.New("payment not found")
+
+// ErrInvalidRequest is returned for requests the gateway can't process,
+// e.g. capturing more than was authorized.
+var ErrInvalidRequest = errors.New("invalid gateway request")
+
+// Gateway represents a payment gateway.
+type Gateway interface {
+	// Authorize authorizes a payment, to be captured later.
+	Authorize(ctx context.Context, req PaymentRequest) (Payment, error)
+	// Capture captures an authorized payment.
+	//
+	// The amount can be lower than the authorized amount.
+	Capture(ctx context.Context, paymentID string, amount currency.Amount) (Payment, error)
+	// Charge authorizes and captures a payment.
+	Charge(ctx context.Context, req PaymentRequest) (Payment, error)
+	// Refund refunds a captured payment, fully or partially.
+	Refund(ctx context.Context, req RefundRequest) (Refund, error)
+	// SavePaymentMethod saves a payment method for future payments.
+	SavePaymentMethod(ctx context.Context, req PaymentMethodRequest) (PaymentMethod, error)
+	// GetPayment fetches the current state of a payment.
+	GetPayment(ctx context.Context, paymentID string) (Payment, error)
+}
+
+// PaymentStatus represents a payment status.
+type PaymentStatus string
+
+// Payment statuses.
+const (
+	// PaymentRequiresAction is used for payments waiting for the customer
+	// to complete an action, such as 3D Secure authentication.
+	PaymentRequiresAction PaymentStatus = "requires_action"
+	// PaymentPending is used for payments which are still being
+	// processed, e.g. bank debits. See Gateway.GetPayment.
+	PaymentPending PaymentStatus = "pending"
+	// PaymentAuthorized is used for authorized payments, not yet captured.
+	PaymentAuthorized PaymentStatus = "authorized"
+	// PaymentSucceeded is used for captured payments.
+	PaymentSucceeded PaymentStatus = "succeeded"
+	// PaymentFailed is used for declined payments.
+	PaymentFailed PaymentStatus = "failed"
+	// PaymentCanceled is used for canceled payments.
+	PaymentCanceled PaymentStatus = "canceled"
+)
+
+// PaymentRequest represents a request to authorize or charge a payment.
+type PaymentRequest struct {
+	Amount currency.Amount
+	// CustomerID is the gateway customer ID, see PaymentMethod.
+	CustomerID      string
+	PaymentMethodID string
+	Description     string
+	// OffSession indicates that the customer is not present,
+	// e.g. for subscription renewals.
+	OffSession bool
+	// IdempotencyKey ensures that retried requests are only processed once.
+	IdempotencyKey string
+	Metadata       map[string]string
+}
+
+// RefundRequest represents a request to refund a payment.
+type RefundRequest struct {
+	PaymentID      string
+	Amount         currency.Amount
+	Reason         string
+	IdempotencyKey string
+}
+
+// PaymentMethodRequest represents a request to save a payment method.
+type PaymentMethodRequest struct {
+	// CustomerID is the gateway customer ID. If empty, a gateway
+	// customer is created using the email and name.
+	CustomerID string
+	Email      string
+	Name       string
+	// Token is the gateway specific payment method token, usually
+	// created by the gateway's JavaScript library.
+	Token string
+	// IdempotencyKey ensures that a retried request creates the
+	// gateway customer only once.
+	IdempotencyKey string
+}
+
+// Payment represents a payment.
+type Payment struct {
+	ID             string          `json:"id"`
+	Status         PaymentStatus   `json:"status"`
+	Amount         currency.Amount `json:"amount"`
+	AmountCaptured currency.Amount `json:"amount_captured"`
+	AmountRefunded currency.Amount `json:"amount_refunded"`
+	// NextActionURL is the URL the customer must visit when the
+	// status is PaymentRequiresAction.
+	NextActionURL string `json:"next_action_url"`
+	// DeclineCode is set for failed payments.
+	DeclineCode DeclineCode `json:"decline_code"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// Refund represents a refund.
+type Refund struct {
+	ID        string          `json:"id"`
+	PaymentID string          `json:"payment_id"`
+	Amount    currency.Amount `json:"amount"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// PaymentMethod represents a saved payment method.
+type PaymentMethod struct {
+	ID         string `json:"id"`
+	CustomerID string `json:"customer_id"`
+	Type       string `json:"type"`
+	Brand      string `json:"brand"`
+	Last4      string `json:"last4"`
+	ExpMonth   int    `json:"exp_month"`
+	ExpYear    int    `json:"exp_year"`
+}
+
+// DeclineCode represents the reason for a declined payment.
+type DeclineCode string
+
+// Decline codes.
+const (
+	DeclineGeneric                DeclineCode = "card_declined"
+	DeclineInsufficientFunds      DeclineCode = "insufficient_funds"
+	DeclineAuthenticationRequired DeclineCode = "authentication_required"
+	DeclineProcessingError        DeclineCode = "processing_error"
+	DeclineExpiredCard            DeclineCode = "expired_card"
+	DeclineIncorrectNumber        DeclineCode = "incorrect_number"
+	DeclineLostCard               DeclineCode = "lost_card"
+	DeclineStolenCard             DeclineCode = "stolen_card"
+	DeclineFraudulent             DeclineCode = "fraudulent"
+)
+
+// hardDeclines are decline codes for which retrying is pointless.
+var hardDeclines = []DeclineCode{
+	DeclineExpiredCard,
+	DeclineIncorrectNumber,
+	DeclineLostCard,
+	DeclineStolenCard,
+	DeclineFraudulent,
+}
+
+// IsHard returns whether the decline is permanent.
+//
+// Payments failing with a hard decline should not be retried
+// using the same payment method.
+func (c DeclineCode) IsHard() bool {
+	for _, code := range hardDeclines {
+		if code == c {
+			return true
+		}
+	}
+	return false
+}
+
+// DeclineError is returned when a payment is declined.
+type DeclineError struct {
+	Code    DeclineCode
+	Message string
+	// Payment is the failed payment, if the gateway created one.
+	Payment Payment
+}
+
+func (e *DeclineError) Error() string {
+	return fmt.Sprintf("payment declined: %v (%v)", e.Message, e.Code)
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package gateway_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/runbilliam/billiam/internal/gateway"
+	"github.com/runbilliam/billiam/internal/gateway/testgateway"
+)
+
+func TestDeclineCode_IsHard(t *testing.T) {
+	tests := []struct {
+		code gateway.DeclineCode
+		want bool
+	}{
+		{gateway.DeclineGeneric, false},
+		{gateway.DeclineInsufficientFunds, false},
+		{gateway.DeclineAuthenticationRequired, false},
+		{gateway.DeclineExpiredCard, true},
+		{gateway.DeclineStolenCard, true},
+		{gateway.DeclineFraudulent, true},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got := tt.code.IsHard()
+			if got != tt.want {
+				t.Errorf("%v: got %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := gateway.NewRegistry()
+	if _, _, err := r.Default(); err != gateway.ErrNotFound {
+		t.Errorf("got %v, want %v", err, gateway.ErrNotFound)
+	}
+
+	first := testgateway.New()
+	second := testgateway.New()
+	r.Register("first", first)
+	r.Register("second", second)
+	g, name, err := r.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g != first || name != "first" {
+		t.Errorf("got %v, want first", name)
+	}
+
+	if err := r.SetDefault("third"); err != gateway.ErrNotFound {
+		t.Errorf("got %v, want %v", err, gateway.ErrNotFound)
+	}
+	if err := r.SetDefault("second"); err != nil {
+		t.Fatal(err)
+	}
+	if g, _, _ := r.Default(); g != second {
+		t.Error("expected the second gateway to be the default")
+	}
+	if _, err := r.Get("third"); err != gateway.ErrNotFound {
+		t.Errorf("got %v, want %v", err, gateway.ErrNotFound)
+	}
+
+	want := []string{"first", "second"}
+	if got := r.Names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
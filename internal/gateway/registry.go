@@ -0,0 +1,72 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package gateway
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrNotFound is returned when a gateway is not registered.
+var ErrNotFound = errors.New("gateway not found")
+
+// Registry holds the configured gateways.
+type Registry struct {
+	gateways    map[string]Gateway
+	defaultName string
+}
+
+// NewRegistry creates a new, empty registry.
+func NewRegistry() *Registry {
+	return &Registry{gateways: make(map[string]Gateway)}
+}
+
+// Register registers a gateway under the given name.
+//
+// The first registered gateway becomes the default.
+func (r *Registry) Register(name string, g Gateway) {
+	r.gateways[name] = g
+	if r.defaultName == "" {
+		r.defaultName = name
+	}
+}
+
+// Get gets the gateway with the given name.
+func (r *Registry) Get(name string) (Gateway, error) {
+	g, ok := r.gateways[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return g, nil
+}
+
+// SetDefault sets the default gateway.
+func (r *Registry) SetDefault(name string) error {
+	if _, ok := r.gateways[name]; !ok {
+		return ErrNotFound
+	}
+	r.defaultName = name
+
+	return nil
+}
+
+// Default returns the default gateway and its name.
+func (r *Registry) Default() (Gateway, string, error) {
+	g, err := r.Get(r.defaultName)
+	if err != nil {
+		return nil, "", err
+	}
+	return g, r.defaultName, nil
+}
+
+// Names returns the names of all registered gateways, sorted.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.gateways))
+	for name := range r.gateways {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,417 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package stripe provides a Stripe gateway.
+//
+// Payments are Stripe PaymentIntents, confirmed on creation.
+// Payment methods are Stripe PaymentMethods, attached to a Stripe customer.
+//
+// The API base URL is configurable, allowing the gateway to be pointed
+// at stripe-mock or an httptest server.
+package stripe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bojanz/currency"
+
+	"github.com/runbilliam/billiam/internal/gateway"
+)
+
+// DefaultURL is the default API base URL.
+const DefaultURL = "https://api.stripe.com"
+
+// APIVersion is the Stripe API version used for all requests.
+const APIVersion = "2020-08-27"
+
+// Error represents a Stripe API error, other than a card decline.
+type Error struct {
+	StatusCode int
+	Type       string `json:"type"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("stripe: %v (%v %v, status %v)", e.Message, e.Type, e.Code, e.StatusCode)
+}
+
+// Gateway is a Stripe gateway.
+type Gateway struct {
+	secretKey string
+	baseURL   string
+	client    *http.Client
+}
+
+// New creates a new Stripe gateway.
+//
+// The base URL defaults to DefaultURL if empty.
+func New(secretKey, baseURL string) *Gateway {
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	return &Gateway{
+		secretKey: secretKey,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Authorize implements gateway.Gateway.
+//
+// The PaymentIntent is created with a manual capture method.
+func (g *Gateway) Authorize(ctx context.Context, req gateway.PaymentRequest) (gateway.Payment, error) {
+	return g.createPaymentIntent(ctx, req, "manual")
+}
+
+// Capture implements gateway.Gateway.
+func (g *Gateway) Capture(ctx context.Context, paymentID string, amount currency.Amount) (gateway.Payment, error) {
+	params := url.Values{}
+	params.Set("amount_to_capture", strconv.FormatInt(amount.ToMinorUnits(), 10))
+	var pi paymentIntent
+	err := g.do(ctx, http.MethodPost, "/v1/payment_intents/"+url.PathEscape(paymentID)+"/capture", params, "", &pi)
+	if err != nil {
+		return gateway.Payment{}, err
+	}
+
+	return pi.toPayment()
+}
+
+// Charge implements gateway.Gateway.
+func (g *Gateway) Charge(ctx context.Context, req gateway.PaymentRequest) (gateway.Payment, error) {
+	return g.createPaymentIntent(ctx, req, "automatic")
+}
+
+// Refund implements gateway.Gateway.
+func (g *Gateway) Refund(ctx context.Context, req gateway.RefundRequest) (gateway.Refund, error) {
+	params := url.Values{}
+	params.Set("payment_intent", req.PaymentID)
+	params.Set("amount", strconv.FormatInt(req.Amount.ToMinorUnits(), 10))
+	if req.Reason != "" {
+		params.Set("reason", req.Reason)
+	}
+	var r struct {
+		ID       string `json:"id"`
+		Amount   int64  `json:"amount"`
+		Currency string `json:"currency"`
+		Created  int64  `json:"created"`
+	}
+	if err := g.do(ctx, http.MethodPost, "/v1/refunds", params, req.IdempotencyKey, &r); err != nil {
+		return gateway.Refund{}, err
+	}
+	amount, err := fromMinorUnits(r.Amount, r.Currency)
+	if err != nil {
+		return gateway.Refund{}, err
+	}
+
+	return gateway.Refund{
+		ID:        r.ID,
+		PaymentID: req.PaymentID,
+		Amount:    amount,
+		CreatedAt: time.Unix(r.Created, 0).UTC(),
+	}, nil
+}
+
+// SavePaymentMethod implements gateway.Gateway.
+//
+// The token is a PaymentMethod ID created by Stripe.js. It is attached
+// to the given Stripe customer, which is created first if needed.
+func (g *Gateway) SavePaymentMethod(ctx context.Context, req gateway.PaymentMethodRequest) (gateway.PaymentMethod, error) {
+	customerID := req.CustomerID
+	if customerID == "" {
+		params := url.Values{}
+		params.Set("email", req.Email)
+		if req.Name != "" {
+			params.Set("name", req.Name)
+		}
+		var c struct {
+			ID string `json:"id"`
+		}
+		if err := g.do(ctx, http.MethodPost, "/v1/customers", params, req.IdempotencyKey, &c); err != nil {
+			return gateway.PaymentMethod{}, err
+		}
+		customerID = c.ID
+	}
+
+	params := url.Values{}
+	params.Set("customer", customerID)
+	var pm struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Card struct {
+			Brand    string `json:"brand"`
+			Last4    string `json:"last4"`
+			ExpMonth int    `json:"exp_month"`
+			ExpYear  int    `json:"exp_year"`
+		} `json:"card"`
+	}
+	err := g.do(ctx, http.MethodPost, "/v1/payment_methods/"+url.PathEscape(req.Token)+"/attach", params, "", &pm)
+	if err != nil {
+		return gateway.PaymentMethod{}, err
+	}
+
+	return gateway.PaymentMethod{
+		ID:         pm.ID,
+		CustomerID: customerID,
+		Type:       pm.Type,
+		Brand:      pm.Card.Brand,
+		Last4:      pm.Card.Last4,
+		ExpMonth:   pm.Card.ExpMonth,
+		ExpYear:    pm.Card.ExpYear,
+	}, nil
+}
+
+// GetPayment implements gateway.Gateway.
+func (g *Gateway) GetPayment(ctx context.Context, paymentID string) (gateway.Payment, error) {
+	var pi paymentIntent
+	if err := g.do(ctx, http.MethodGet, "/v1/payment_intents/"+url.PathEscape(paymentID), nil, "", &pi); err != nil {
+		return gateway.Payment{}, err
+	}
+
+	return pi.toPayment()
+}
+
+// createPaymentIntent creates and confirms a PaymentIntent.
+func (g *Gateway) createPaymentIntent(ctx context.Context, req gateway.PaymentRequest, captureMethod string) (gateway.Payment, error) {
+	params := url.Values{}
+	params.Set("amount", strconv.FormatInt(req.Amount.ToMinorUnits(), 10))
+	params.Set("currency", strings.ToLower(req.Amount.CurrencyCode()))
+	if req.CustomerID != "" {
+		params.Set("customer", req.CustomerID)
+	}
+	params.Set("payment_method", req.PaymentMethodID)
+	params.Set("capture_method", captureMethod)
+	params.Set("confirm", "true")
+	if req.OffSession {
+		params.Set("off_session", "true")
+	}
+	if req.Description != "" {
+		params.Set("description", req.Description)
+	}
+	keys := make([]string, 0, len(req.Metadata))
+	for k := range req.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		params.Set("metadata["+k+"]", req.Metadata[k])
+	}
+
+	var pi paymentIntent
+	if err := g.do(ctx, http.MethodPost, "/v1/payment_intents", params, req.IdempotencyKey, &pi); err != nil {
+		return gateway.Payment{}, err
+	}
+	p, err := pi.toPayment()
+	if err != nil {
+		return gateway.Payment{}, err
+	}
+	if p.Status == gateway.PaymentFailed {
+		message := "The payment failed."
+		if pi.LastPaymentError != nil {
+			message = pi.LastPaymentError.Message
+		}
+		return gateway.Payment{}, &gateway.DeclineError{Code: p.DeclineCode, Message: message, Payment: p}
+	}
+
+	return p, nil
+}
+
+// do sends an API request and decodes the response into v.
+//
+// Card errors are returned as *gateway.DeclineError, missing payments
+// as gateway.ErrPaymentNotFound, and other errors as *Error.
+func (g *Gateway) do(ctx context.Context, method, path string, params url.Values, idempotencyKey string, v interface{}) error {
+	var body *strings.Reader
+	target := g.baseURL + path
+	if method == http.MethodGet {
+		body = strings.NewReader("")
+		if len(params) > 0 {
+			target += "?" + params.Encode()
+		}
+	} else {
+		body = strings.NewReader(params.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+g.secretKey)
+	req.Header.Set("Stripe-Version", APIVersion)
+	if method != http.MethodGet {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("stripe: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return fmt.Errorf("stripe: %w", err)
+		}
+		return nil
+	}
+	var errResp struct {
+		Error struct {
+			Error
+			DeclineCode   string         `json:"decline_code"`
+			PaymentIntent *paymentIntent `json:"payment_intent"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+		return &Error{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	}
+	apiErr := errResp.Error
+	apiErr.StatusCode = resp.StatusCode
+	if apiErr.Type == "card_error" {
+		declineErr := &gateway.DeclineError{
+			Code:    declineCode(apiErr.Code, apiErr.DeclineCode),
+			Message: apiErr.Message,
+		}
+		if apiErr.PaymentIntent != nil {
+			if p, err := apiErr.PaymentIntent.toPayment(); err == nil {
+				p.Status = gateway.PaymentFailed
+				p.DeclineCode = declineErr.Code
+				declineErr.Payment = p
+			}
+		}
+		return declineErr
+	}
+	if apiErr.Code == "resource_missing" && strings.HasPrefix(path, "/v1/payment_intents/") {
+		return gateway.ErrPaymentNotFound
+	}
+	if resp.StatusCode == http.StatusBadRequest {
+		return fmt.Errorf("%w: %v", gateway.ErrInvalidRequest, &apiErr.Error)
+	}
+
+	return &apiErr.Error
+}
+
+// paymentIntent represents a Stripe PaymentIntent.
+type paymentIntent struct {
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	Amount         int64  `json:"amount"`
+	AmountReceived int64  `json:"amount_received"`
+	Currency       string `json:"currency"`
+	Created        int64  `json:"created"`
+	NextAction     *struct {
+		RedirectToURL *struct {
+			URL string `json:"url"`
+		} `json:"redirect_to_url"`
+	} `json:"next_action"`
+	LastPaymentError *struct {
+		Code        string `json:"code"`
+		DeclineCode string `json:"decline_code"`
+		Message     string `json:"message"`
+	} `json:"last_payment_error"`
+	Charges struct {
+		Data []struct {
+			AmountRefunded int64 `json:"amount_refunded"`
+		} `json:"data"`
+	} `json:"charges"`
+}
+
+// toPayment converts the PaymentIntent to a gateway payment.
+func (pi paymentIntent) toPayment() (gateway.Payment, error) {
+	amount, err := fromMinorUnits(pi.Amount, pi.Currency)
+	if err != nil {
+		return gateway.Payment{}, err
+	}
+	captured, err := fromMinorUnits(pi.AmountReceived, pi.Currency)
+	if err != nil {
+		return gateway.Payment{}, err
+	}
+	var refundedMinor int64
+	for _, charge := range pi.Charges.Data {
+		refundedMinor += charge.AmountRefunded
+	}
+	refunded, err := fromMinorUnits(refundedMinor, pi.Currency)
+	if err != nil {
+		return gateway.Payment{}, err
+	}
+	p := gateway.Payment{
+		ID:             pi.ID,
+		Amount:         amount,
+		AmountCaptured: captured,
+		AmountRefunded: refunded,
+		CreatedAt:      time.Unix(pi.Created, 0).UTC(),
+	}
+	switch pi.Status {
+	case "succeeded":
+		p.Status = gateway.PaymentSucceeded
+	case "requires_capture":
+		p.Status = gateway.PaymentAuthorized
+	case "requires_action", "requires_confirmation":
+		p.Status = gateway.PaymentRequiresAction
+		if pi.NextAction != nil && pi.NextAction.RedirectToURL != nil {
+			p.NextActionURL = pi.NextAction.RedirectToURL.URL
+		}
+	case "processing":
+		p.Status = gateway.PaymentPending
+	case "canceled":
+		p.Status = gateway.PaymentCanceled
+	default:
+		// A PaymentIntent that requires a new payment method has failed.
+		p.Status = gateway.PaymentFailed
+		if pi.LastPaymentError != nil {
+			p.DeclineCode = declineCode(pi.LastPaymentError.Code, pi.LastPaymentError.DeclineCode)
+		} else {
+			p.DeclineCode = gateway.DeclineGeneric
+		}
+	}
+
+	return p, nil
+}
+
+// declineCode converts a Stripe error code and decline code to a gateway decline code.
+//
+// Stripe reports most declines as "card_declined", with the specific
+// reason in the decline code. The gateway decline codes match Stripe's.
+func declineCode(code, declineCode string) gateway.DeclineCode {
+	if code == "card_declined" && declineCode != "" {
+		if declineCode == "generic_decline" {
+			return gateway.DeclineGeneric
+		}
+		return gateway.DeclineCode(declineCode)
+	}
+	if code == "" {
+		return gateway.DeclineGeneric
+	}
+	return gateway.DeclineCode(code)
+}
+
+// fromMinorUnits converts an amount in minor units to a currency.Amount.
+func fromMinorUnits(n int64, currencyCode string) (currency.Amount, error) {
+	currencyCode = strings.ToUpper(currencyCode)
+	digits, ok := currency.GetDigits(currencyCode)
+	if !ok {
+		return currency.Amount{}, currency.InvalidCurrencyCodeError{CurrencyCode: currencyCode}
+	}
+	a, err := currency.NewAmount(strconv.FormatInt(n, 10), currencyCode)
+	if err != nil {
+		return currency.Amount{}, err
+	}
+	a, err = a.Div(strconv.FormatInt(int64(math.Pow10(int(digits))), 10))
+	if err != nil {
+		return currency.Amount{}, err
+	}
+
+	return a.Round(), nil
+}
+
+// Ensure the gateway interface is implemented.
+var _ gateway.Gateway = (*Gateway)(nil)
@@ -0,0 +1,415 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package stripe_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bojanz/currency"
+
+	"github.com/runbilliam/billiam/internal/gateway"
+	"github.com/runbilliam/billiam/internal/gateway/stripe"
+)
+
+// stub is a minimal stand-in for the Stripe API.
+type stub struct {
+	t        *testing.T
+	mu       sync.Mutex
+	requests []*http.Request
+	forms    []map[string]string
+	// idempotency maps idempotency keys to PaymentIntent IDs.
+	idempotency map[string]string
+	intents     map[string]map[string]interface{}
+}
+
+func newStub(t *testing.T) (*stub, *httptest.Server) {
+	s := &stub{
+		t:           t,
+		idempotency: make(map[string]string),
+		intents:     make(map[string]map[string]interface{}),
+	}
+	return s, httptest.NewServer(s)
+}
+
+func (s *stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer sk_test_123" {
+		writeJSON(w, http.StatusUnauthorized, apiError("invalid_request_error", "", "Invalid API Key provided."))
+		return
+	}
+	if r.Header.Get("Stripe-Version") != stripe.APIVersion {
+		s.t.Errorf("got Stripe-Version %q, want %q", r.Header.Get("Stripe-Version"), stripe.APIVersion)
+	}
+	r.ParseForm()
+	form := make(map[string]string)
+	for k := range r.PostForm {
+		form[k] = r.PostForm.Get(k)
+	}
+	s.requests = append(s.requests, r)
+	s.forms = append(s.forms, form)
+
+	path := r.URL.Path
+	switch {
+	case r.Method == http.MethodPost && path == "/v1/customers":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"id": "cus_123", "email": form["email"]})
+	case r.Method == http.MethodPost && strings.HasPrefix(path, "/v1/payment_methods/") && strings.HasSuffix(path, "/attach"):
+		id := strings.TrimSuffix(strings.TrimPrefix(path, "/v1/payment_methods/"), "/attach")
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"id":       id,
+			"type":     "card",
+			"customer": form["customer"],
+			"card":     map[string]interface{}{"brand": "visa", "last4": "4242", "exp_month": 12, "exp_year": 2030},
+		})
+	case r.Method == http.MethodPost && path == "/v1/payment_intents":
+		key := r.Header.Get("Idempotency-Key")
+		if id, ok := s.idempotency[key]; ok && key != "" {
+			writeJSON(w, http.StatusOK, s.intents[id])
+			return
+		}
+		id := "pi_" + string(rune('a'+len(s.intents)))
+		pi := map[string]interface{}{
+			"id":              id,
+			"amount":          json.Number(form["amount"]),
+			"amount_received": 0,
+			"currency":        form["currency"],
+			"created":         1604224800,
+			"charges":         map[string]interface{}{"data": []interface{}{}},
+		}
+		switch form["payment_method"] {
+		case "pm_card_visa":
+			if form["capture_method"] == "manual" {
+				pi["status"] = "requires_capture"
+			} else {
+				pi["status"] = "succeeded"
+				pi["amount_received"] = json.Number(form["amount"])
+				pi["charges"] = map[string]interface{}{"data": []interface{}{map[string]interface{}{"amount_refunded": 0}}}
+			}
+		case "pm_card_chargeDeclinedInsufficientFunds":
+			pi["status"] = "requires_payment_method"
+			errResp := apiError("card_error", "card_declined", "Your card has insufficient funds.")
+			errResp["error"].(map[string]interface{})["decline_code"] = "insufficient_funds"
+			errResp["error"].(map[string]interface{})["payment_intent"] = pi
+			s.intents[id] = pi
+			writeJSON(w, http.StatusPaymentRequired, errResp)
+			return
+		case "pm_card_authenticationRequired":
+			if form["off_session"] == "true" {
+				pi["status"] = "requires_payment_method"
+				errResp := apiError("card_error", "authentication_required", "This payment requires authentication.")
+				errResp["error"].(map[string]interface{})["payment_intent"] = pi
+				s.intents[id] = pi
+				writeJSON(w, http.StatusPaymentRequired, errResp)
+				return
+			}
+			pi["status"] = "requires_action"
+			pi["next_action"] = map[string]interface{}{
+				"type":            "redirect_to_url",
+				"redirect_to_url": map[string]interface{}{"url": "https://hooks.stripe.com/3d_secure/" + id},
+			}
+		case "pm_bankDebit_processing":
+			pi["status"] = "processing"
+		}
+		s.intents[id] = pi
+		if key != "" {
+			s.idempotency[key] = id
+		}
+		writeJSON(w, http.StatusOK, pi)
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/capture"):
+		id := strings.TrimSuffix(strings.TrimPrefix(path, "/v1/payment_intents/"), "/capture")
+		pi, ok := s.intents[id]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, apiError("invalid_request_error", "resource_missing", "No such payment_intent."))
+			return
+		}
+		pi["status"] = "succeeded"
+		pi["amount_received"] = json.Number(form["amount_to_capture"])
+		writeJSON(w, http.StatusOK, pi)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/v1/payment_intents/"):
+		pi, ok := s.intents[strings.TrimPrefix(path, "/v1/payment_intents/")]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, apiError("invalid_request_error", "resource_missing", "No such payment_intent."))
+			return
+		}
+		writeJSON(w, http.StatusOK, pi)
+	case r.Method == http.MethodPost && path == "/v1/refunds":
+		pi, ok := s.intents[form["payment_intent"]]
+		if !ok || pi["status"] != "succeeded" {
+			writeJSON(w, http.StatusBadRequest, apiError("invalid_request_error", "charge_not_refundable", "This payment can't be refunded."))
+			return
+		}
+		pi["charges"] = map[string]interface{}{"data": []interface{}{map[string]interface{}{"amount_refunded": json.Number(form["amount"])}}}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"id":       "re_123",
+			"amount":   json.Number(form["amount"]),
+			"currency": pi["currency"],
+			"created":  1604224900,
+		})
+	default:
+		writeJSON(w, http.StatusNotFound, apiError("invalid_request_error", "", "Unrecognized request URL."))
+	}
+}
+
+func TestGateway_SavePaymentMethod(t *testing.T) {
+	s, server := newStub(t)
+	defer server.Close()
+	g := stripe.New("sk_test_123", server.URL)
+
+	pm, err := g.SavePaymentMethod(context.Background(), gateway.PaymentMethodRequest{
+		Email:          "jane@example.com",
+		Name:           "Jane Doe",
+		Token:          "pm_card_visa",
+		IdempotencyKey: "customer-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gateway.PaymentMethod{
+		ID:         "pm_card_visa",
+		CustomerID: "cus_123",
+		Type:       "card",
+		Brand:      "visa",
+		Last4:      "4242",
+		ExpMonth:   12,
+		ExpYear:    2030,
+	}
+	if pm != want {
+		t.Errorf("got %+v, want %+v", pm, want)
+	}
+	if len(s.forms) != 2 || s.forms[0]["email"] != "jane@example.com" || s.forms[1]["customer"] != "cus_123" {
+		t.Errorf("unexpected requests: %v", s.forms)
+	}
+	if key := s.requests[0].Header.Get("Idempotency-Key"); key != "customer-1" {
+		t.Errorf("got %q, want customer-1", key)
+	}
+
+	// Existing customers are reused.
+	_, err = g.SavePaymentMethod(context.Background(), gateway.PaymentMethodRequest{CustomerID: "cus_456", Token: "pm_card_visa"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.forms) != 3 || s.forms[2]["customer"] != "cus_456" {
+		t.Errorf("unexpected requests: %v", s.forms)
+	}
+}
+
+func TestGateway_Charge(t *testing.T) {
+	s, server := newStub(t)
+	defer server.Close()
+	g := stripe.New("sk_test_123", server.URL)
+	ctx := context.Background()
+
+	req := gateway.PaymentRequest{
+		Amount:          amount("19.99", "EUR"),
+		CustomerID:      "cus_123",
+		PaymentMethodID: "pm_card_visa",
+		OffSession:      true,
+		IdempotencyKey:  "invoice-1",
+		Metadata:        map[string]string{"invoice_id": "01EN"},
+	}
+	p, err := g.Charge(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != gateway.PaymentSucceeded || p.AmountCaptured.String() != "19.99 EUR" {
+		t.Errorf("unexpected payment: %+v", p)
+	}
+	form := s.forms[0]
+	wantForm := map[string]string{
+		"amount":               "1999",
+		"currency":             "eur",
+		"customer":             "cus_123",
+		"payment_method":       "pm_card_visa",
+		"capture_method":       "automatic",
+		"confirm":              "true",
+		"off_session":          "true",
+		"metadata[invoice_id]": "01EN",
+	}
+	for k, v := range wantForm {
+		if form[k] != v {
+			t.Errorf("%v: got %q, want %q", k, form[k], v)
+		}
+	}
+	if key := s.requests[0].Header.Get("Idempotency-Key"); key != "invoice-1" {
+		t.Errorf("got %q, want invoice-1", key)
+	}
+
+	// Retrying with the same idempotency key returns the same payment.
+	retried, err := g.Charge(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retried.ID != p.ID {
+		t.Errorf("got %v, want %v", retried.ID, p.ID)
+	}
+}
+
+func TestGateway_ChargeDeclined(t *testing.T) {
+	_, server := newStub(t)
+	defer server.Close()
+	g := stripe.New("sk_test_123", server.URL)
+	ctx := context.Background()
+
+	tests := []struct {
+		paymentMethodID string
+		offSession      bool
+		wantCode        gateway.DeclineCode
+	}{
+		{"pm_card_chargeDeclinedInsufficientFunds", true, gateway.DeclineInsufficientFunds},
+		{"pm_card_authenticationRequired", true, gateway.DeclineAuthenticationRequired},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			_, err := g.Charge(ctx, gateway.PaymentRequest{
+				Amount:          amount("10", "USD"),
+				PaymentMethodID: tt.paymentMethodID,
+				OffSession:      tt.offSession,
+			})
+			var declineErr *gateway.DeclineError
+			if !errors.As(err, &declineErr) {
+				t.Fatalf("got %v, want a decline error", err)
+			}
+			if declineErr.Code != tt.wantCode {
+				t.Errorf("got %v, want %v", declineErr.Code, tt.wantCode)
+			}
+			if declineErr.Payment.ID == "" || declineErr.Payment.Status != gateway.PaymentFailed {
+				t.Errorf("unexpected payment: %+v", declineErr.Payment)
+			}
+		})
+	}
+
+	// On-session payments requiring authentication return the action URL.
+	p, err := g.Charge(ctx, gateway.PaymentRequest{
+		Amount:          amount("10", "USD"),
+		PaymentMethodID: "pm_card_authenticationRequired",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != gateway.PaymentRequiresAction || !strings.HasPrefix(p.NextActionURL, "https://hooks.stripe.com/") {
+		t.Errorf("unexpected payment: %+v", p)
+	}
+}
+
+func TestGateway_ChargeProcessing(t *testing.T) {
+	_, server := newStub(t)
+	defer server.Close()
+	g := stripe.New("sk_test_123", server.URL)
+	ctx := context.Background()
+
+	p, err := g.Charge(ctx, gateway.PaymentRequest{
+		Amount:          amount("10", "USD"),
+		PaymentMethodID: "pm_bankDebit_processing",
+		OffSession:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != gateway.PaymentPending || p.DeclineCode != "" {
+		t.Errorf("unexpected payment: %+v", p)
+	}
+	p, err = g.GetPayment(ctx, p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != gateway.PaymentPending {
+		t.Errorf("got %v, want %v", p.Status, gateway.PaymentPending)
+	}
+}
+
+func TestGateway_AuthorizeCaptureRefund(t *testing.T) {
+	s, server := newStub(t)
+	defer server.Close()
+	g := stripe.New("sk_test_123", server.URL)
+	ctx := context.Background()
+
+	p, err := g.Authorize(ctx, gateway.PaymentRequest{Amount: amount("1000", "JPY"), PaymentMethodID: "pm_card_visa"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != gateway.PaymentAuthorized || p.Amount.String() != "1000 JPY" {
+		t.Errorf("unexpected payment: %+v", p)
+	}
+	if s.forms[0]["capture_method"] != "manual" || s.forms[0]["amount"] != "1000" {
+		t.Errorf("unexpected request: %v", s.forms[0])
+	}
+
+	p, err = g.Capture(ctx, p.ID, amount("800", "JPY"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != gateway.PaymentSucceeded || p.AmountCaptured.String() != "800 JPY" {
+		t.Errorf("unexpected payment: %+v", p)
+	}
+
+	refund, err := g.Refund(ctx, gateway.RefundRequest{PaymentID: p.ID, Amount: amount("300", "JPY"), IdempotencyKey: "refund-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refund.ID != "re_123" || refund.Amount.String() != "300 JPY" {
+		t.Errorf("unexpected refund: %+v", refund)
+	}
+	if key := s.requests[2].Header.Get("Idempotency-Key"); key != "refund-1" {
+		t.Errorf("got %q, want refund-1", key)
+	}
+
+	p, err = g.GetPayment(ctx, p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.AmountRefunded.String() != "300 JPY" {
+		t.Errorf("got %v, want 300 JPY", p.AmountRefunded)
+	}
+}
+
+func TestGateway_Errors(t *testing.T) {
+	_, server := newStub(t)
+	defer server.Close()
+	ctx := context.Background()
+
+	g := stripe.New("sk_test_123", server.URL)
+	if _, err := g.GetPayment(ctx, "pi_unknown"); err != gateway.ErrPaymentNotFound {
+		t.Errorf("got %v, want %v", err, gateway.ErrPaymentNotFound)
+	}
+	_, err := g.Refund(ctx, gateway.RefundRequest{PaymentID: "pi_unknown", Amount: amount("1", "USD")})
+	if !errors.Is(err, gateway.ErrInvalidRequest) {
+		t.Errorf("got %v, want %v", err, gateway.ErrInvalidRequest)
+	}
+
+	g = stripe.New("sk_test_invalid", server.URL)
+	_, err = g.GetPayment(ctx, "pi_a")
+	var apiErr *stripe.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("got %v, want an unauthorized error", err)
+	}
+}
+
+func apiError(errType, code, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"error": map[string]interface{}{
+			"type":    errType,
+			"code":    code,
+			"message": message,
+		},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func amount(n, currencyCode string) currency.Amount {
+	a, _ := currency.NewAmount(n, currencyCode)
+	return a
+}
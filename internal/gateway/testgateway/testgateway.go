@@ -0,0 +1,275 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package testgateway provides an in-process gateway for testing.
+//
+// No network requests are made, and all state is kept in memory.
+// The outcome of each payment is determined by the card number used
+// to save the payment method:
+//   4242424242424242: Succeeds.
+//   4000000000000002: Declined (card_declined).
+//   4000000000009995: Declined (insufficient_funds).
+//   4000000000000069: Declined (expired_card), a hard decline.
+//   4000002500003155: Requires action. Off-session payments are
+//                     declined (authentication_required) instead.
+//   4000000000007726: Pending until Settle is called.
+// Other numbers are rejected when saving the payment method.
+package testgateway
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bojanz/currency"
+
+	"github.com/runbilliam/billiam/internal/gateway"
+)
+
+// Magic card numbers.
+const (
+	CardSuccess           = "4242424242424242"
+	CardDecline           = "4000000000000002"
+	CardInsufficientFunds = "4000000000009995"
+	CardExpired           = "4000000000000069"
+	CardRequiresAction    = "4000002500003155"
+	CardProcessing        = "4000000000007726"
+)
+
+// declines maps card numbers to their decline codes.
+var declines = map[string]gateway.DeclineCode{
+	CardDecline:           gateway.DeclineGeneric,
+	CardInsufficientFunds: gateway.DeclineInsufficientFunds,
+	CardExpired:           gateway.DeclineExpiredCard,
+}
+
+// Gateway is an in-process test gateway.
+//
+// IDs are assigned sequentially, e.g. "pay_test_1", so that
+// results are deterministic.
+type Gateway struct {
+	mu             sync.Mutex
+	counter        int
+	cards          map[string]string
+	payments       map[string]*gateway.Payment
+	idempotencyKey map[string]string
+}
+
+// New creates a new test gateway.
+func New() *Gateway {
+	return &Gateway{
+		cards:          make(map[string]string),
+		payments:       make(map[string]*gateway.Payment),
+		idempotencyKey: make(map[string]string),
+	}
+}
+
+// Authorize implements gateway.Gateway.
+func (g *Gateway) Authorize(ctx context.Context, req gateway.PaymentRequest) (gateway.Payment, error) {
+	return g.pay(req, gateway.PaymentAuthorized)
+}
+
+// Capture implements gateway.Gateway.
+func (g *Gateway) Capture(ctx context.Context, paymentID string, amount currency.Amount) (gateway.Payment, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p, ok := g.payments[paymentID]
+	if !ok {
+		return gateway.Payment{}, gateway.ErrPaymentNotFound
+	}
+	if p.Status != gateway.PaymentAuthorized {
+		return gateway.Payment{}, fmt.Errorf("%w: payment %v is %v", gateway.ErrInvalidRequest, paymentID, p.Status)
+	}
+	if cmp, err := amount.Cmp(p.Amount); err != nil || cmp > 0 {
+		return gateway.Payment{}, fmt.Errorf("%w: capture amount exceeds the authorized amount", gateway.ErrInvalidRequest)
+	}
+	p.Status = gateway.PaymentSucceeded
+	p.AmountCaptured = amount
+
+	return *p, nil
+}
+
+// Charge implements gateway.Gateway.
+func (g *Gateway) Charge(ctx context.Context, req gateway.PaymentRequest) (gateway.Payment, error) {
+	return g.pay(req, gateway.PaymentSucceeded)
+}
+
+// Refund implements gateway.Gateway.
+func (g *Gateway) Refund(ctx context.Context, req gateway.RefundRequest) (gateway.Refund, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p, ok := g.payments[req.PaymentID]
+	if !ok {
+		return gateway.Refund{}, gateway.ErrPaymentNotFound
+	}
+	if p.Status != gateway.PaymentSucceeded {
+		return gateway.Refund{}, fmt.Errorf("%w: payment %v is %v", gateway.ErrInvalidRequest, req.PaymentID, p.Status)
+	}
+	refunded, err := p.AmountRefunded.Add(req.Amount)
+	if err != nil {
+		return gateway.Refund{}, fmt.Errorf("%w: %v", gateway.ErrInvalidRequest, err)
+	}
+	if cmp, _ := refunded.Cmp(p.AmountCaptured); cmp > 0 || !req.Amount.IsPositive() {
+		return gateway.Refund{}, fmt.Errorf("%w: invalid refund amount %v", gateway.ErrInvalidRequest, req.Amount)
+	}
+	p.AmountRefunded = refunded
+
+	return gateway.Refund{
+		ID:        g.nextID("re"),
+		PaymentID: p.ID,
+		Amount:    req.Amount,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// SavePaymentMethod implements gateway.Gateway.
+//
+// The token is the card number.
+func (g *Gateway) SavePaymentMethod(ctx context.Context, req gateway.PaymentMethodRequest) (gateway.PaymentMethod, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch req.Token {
+	case CardSuccess, CardDecline, CardInsufficientFunds, CardExpired, CardRequiresAction, CardProcessing:
+	default:
+		return gateway.PaymentMethod{}, &gateway.DeclineError{Code: gateway.DeclineIncorrectNumber, Message: "Invalid card number."}
+	}
+	customerID := req.CustomerID
+	if customerID == "" {
+		customerID = g.nextID("cus")
+	}
+	pm := gateway.PaymentMethod{
+		ID:         g.nextID("pm"),
+		CustomerID: customerID,
+		Type:       "card",
+		Brand:      "visa",
+		Last4:      req.Token[len(req.Token)-4:],
+		ExpMonth:   12,
+		ExpYear:    time.Now().Year() + 5,
+	}
+	g.cards[pm.ID] = req.Token
+
+	return pm, nil
+}
+
+// GetPayment implements gateway.Gateway.
+func (g *Gateway) GetPayment(ctx context.Context, paymentID string) (gateway.Payment, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p, ok := g.payments[paymentID]
+	if !ok {
+		return gateway.Payment{}, gateway.ErrPaymentNotFound
+	}
+	return *p, nil
+}
+
+// CompleteAction simulates the customer completing the required action,
+// e.g. 3D Secure authentication, for the given payment.
+func (g *Gateway) CompleteAction(paymentID string) (gateway.Payment, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p, ok := g.payments[paymentID]
+	if !ok {
+		return gateway.Payment{}, gateway.ErrPaymentNotFound
+	}
+	if p.Status != gateway.PaymentRequiresAction {
+		return gateway.Payment{}, fmt.Errorf("%w: payment %v is %v", gateway.ErrInvalidRequest, paymentID, p.Status)
+	}
+	p.Status = gateway.PaymentSucceeded
+	p.AmountCaptured = p.Amount
+	p.NextActionURL = ""
+
+	return *p, nil
+}
+
+// Settle simulates the gateway finishing the processing
+// of the given pending payment, which then succeeds.
+func (g *Gateway) Settle(paymentID string) (gateway.Payment, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p, ok := g.payments[paymentID]
+	if !ok {
+		return gateway.Payment{}, gateway.ErrPaymentNotFound
+	}
+	if p.Status != gateway.PaymentPending {
+		return gateway.Payment{}, fmt.Errorf("%w: payment %v is %v", gateway.ErrInvalidRequest, paymentID, p.Status)
+	}
+	p.Status = gateway.PaymentSucceeded
+	p.AmountCaptured = p.Amount
+
+	return *p, nil
+}
+
+// pay creates a payment with the given status on success.
+func (g *Gateway) pay(req gateway.PaymentRequest, status gateway.PaymentStatus) (gateway.Payment, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if req.IdempotencyKey != "" {
+		if id, ok := g.idempotencyKey[req.IdempotencyKey]; ok {
+			return g.result(g.payments[id])
+		}
+	}
+	card, ok := g.cards[req.PaymentMethodID]
+	if !ok {
+		return gateway.Payment{}, fmt.Errorf("%w: unknown payment method %q", gateway.ErrInvalidRequest, req.PaymentMethodID)
+	}
+	if !req.Amount.IsPositive() {
+		return gateway.Payment{}, fmt.Errorf("%w: invalid amount %v", gateway.ErrInvalidRequest, req.Amount)
+	}
+	zero, _ := currency.NewAmount("0", req.Amount.CurrencyCode())
+	p := &gateway.Payment{
+		ID:             g.nextID("pay"),
+		Status:         status,
+		Amount:         req.Amount,
+		AmountCaptured: zero,
+		AmountRefunded: zero,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if code, ok := declines[card]; ok {
+		p.Status = gateway.PaymentFailed
+		p.DeclineCode = code
+	} else if card == CardRequiresAction {
+		if req.OffSession {
+			p.Status = gateway.PaymentFailed
+			p.DeclineCode = gateway.DeclineAuthenticationRequired
+		} else {
+			p.Status = gateway.PaymentRequiresAction
+			p.NextActionURL = "https://billiam.test/authenticate/" + p.ID
+		}
+	} else if card == CardProcessing {
+		p.Status = gateway.PaymentPending
+	} else if status == gateway.PaymentSucceeded {
+		p.AmountCaptured = req.Amount
+	}
+	g.payments[p.ID] = p
+	if req.IdempotencyKey != "" {
+		g.idempotencyKey[req.IdempotencyKey] = p.ID
+	}
+
+	return g.result(p)
+}
+
+// result returns the given payment, or a decline error if it failed.
+func (g *Gateway) result(p *gateway.Payment) (gateway.Payment, error) {
+	if p.Status == gateway.PaymentFailed {
+		return gateway.Payment{}, &gateway.DeclineError{
+			Code:    p.DeclineCode,
+			Message: "The card was declined.",
+			Payment: *p,
+		}
+	}
+	return *p, nil
+}
+
+// nextID returns the next ID with the given prefix.
+func (g *Gateway) nextID(prefix string) string {
+	g.counter++
+	return fmt.Sprintf("%v_test_%d", prefix, g.counter)
+}
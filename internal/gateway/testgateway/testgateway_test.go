@@ -0,0 +1,220 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package testgateway_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bojanz/currency"
+
+	"github.com/runbilliam/billiam/internal/gateway"
+	"github.com/runbilliam/billiam/internal/gateway/testgateway"
+)
+
+func TestGateway_Charge(t *testing.T) {
+	tests := []struct {
+		card        string
+		offSession  bool
+		wantStatus  gateway.PaymentStatus
+		wantDecline gateway.DeclineCode
+	}{
+		{testgateway.CardSuccess, false, gateway.PaymentSucceeded, ""},
+		{testgateway.CardSuccess, true, gateway.PaymentSucceeded, ""},
+		{testgateway.CardDecline, false, "", gateway.DeclineGeneric},
+		{testgateway.CardInsufficientFunds, true, "", gateway.DeclineInsufficientFunds},
+		{testgateway.CardExpired, true, "", gateway.DeclineExpiredCard},
+		{testgateway.CardRequiresAction, false, gateway.PaymentRequiresAction, ""},
+		{testgateway.CardRequiresAction, true, "", gateway.DeclineAuthenticationRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			ctx := context.Background()
+			g := testgateway.New()
+			pm := savePaymentMethod(t, g, tt.card)
+			p, err := g.Charge(ctx, gateway.PaymentRequest{
+				Amount:          amount("10.00"),
+				PaymentMethodID: pm.ID,
+				OffSession:      tt.offSession,
+			})
+			if tt.wantDecline != "" {
+				var declineErr *gateway.DeclineError
+				if !errors.As(err, &declineErr) {
+					t.Fatalf("got %v, want a decline error", err)
+				}
+				if declineErr.Code != tt.wantDecline {
+					t.Errorf("got %v, want %v", declineErr.Code, tt.wantDecline)
+				}
+				if declineErr.Payment.Status != gateway.PaymentFailed {
+					t.Errorf("got %v, want %v", declineErr.Payment.Status, gateway.PaymentFailed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Status != tt.wantStatus {
+				t.Errorf("got %v, want %v", p.Status, tt.wantStatus)
+			}
+			fetched, err := g.GetPayment(ctx, p.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fetched.Status != p.Status {
+				t.Errorf("got %v, want %v", fetched.Status, p.Status)
+			}
+		})
+	}
+}
+
+func TestGateway_Idempotency(t *testing.T) {
+	ctx := context.Background()
+	g := testgateway.New()
+	pm := savePaymentMethod(t, g, testgateway.CardSuccess)
+	req := gateway.PaymentRequest{
+		Amount:          amount("10.00"),
+		PaymentMethodID: pm.ID,
+		IdempotencyKey:  "invoice-1-attempt-1",
+	}
+	first, err := g.Charge(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := g.Charge(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ID != second.ID {
+		t.Errorf("got %v, want %v", second.ID, first.ID)
+	}
+	req.IdempotencyKey = "invoice-1-attempt-2"
+	third, _ := g.Charge(ctx, req)
+	if third.ID == first.ID {
+		t.Error("expected a new payment for a new idempotency key")
+	}
+}
+
+func TestGateway_AuthorizeCaptureRefund(t *testing.T) {
+	ctx := context.Background()
+	g := testgateway.New()
+	pm := savePaymentMethod(t, g, testgateway.CardSuccess)
+	p, err := g.Authorize(ctx, gateway.PaymentRequest{Amount: amount("10.00"), PaymentMethodID: pm.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != gateway.PaymentAuthorized {
+		t.Errorf("got %v, want %v", p.Status, gateway.PaymentAuthorized)
+	}
+	// Refunds require a captured payment.
+	_, err = g.Refund(ctx, gateway.RefundRequest{PaymentID: p.ID, Amount: amount("1.00")})
+	if !errors.Is(err, gateway.ErrInvalidRequest) {
+		t.Errorf("got %v, want %v", err, gateway.ErrInvalidRequest)
+	}
+	if _, err := g.Capture(ctx, p.ID, amount("10.01")); !errors.Is(err, gateway.ErrInvalidRequest) {
+		t.Errorf("got %v, want %v", err, gateway.ErrInvalidRequest)
+	}
+	p, err = g.Capture(ctx, p.ID, amount("8.00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != gateway.PaymentSucceeded || p.AmountCaptured.Number() != "8.00" {
+		t.Errorf("got %v %v, want succeeded 8.00", p.Status, p.AmountCaptured)
+	}
+
+	if _, err := g.Refund(ctx, gateway.RefundRequest{PaymentID: p.ID, Amount: amount("5.00")}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = g.Refund(ctx, gateway.RefundRequest{PaymentID: p.ID, Amount: amount("3.01")})
+	if !errors.Is(err, gateway.ErrInvalidRequest) {
+		t.Errorf("got %v, want %v", err, gateway.ErrInvalidRequest)
+	}
+	p, _ = g.GetPayment(ctx, p.ID)
+	if p.AmountRefunded.Number() != "5.00" {
+		t.Errorf("got %v, want 5.00", p.AmountRefunded.Number())
+	}
+	if _, err := g.GetPayment(ctx, "pay_unknown"); err != gateway.ErrPaymentNotFound {
+		t.Errorf("got %v, want %v", err, gateway.ErrPaymentNotFound)
+	}
+}
+
+func TestGateway_CompleteAction(t *testing.T) {
+	ctx := context.Background()
+	g := testgateway.New()
+	pm := savePaymentMethod(t, g, testgateway.CardRequiresAction)
+	p, err := g.Charge(ctx, gateway.PaymentRequest{Amount: amount("10.00"), PaymentMethodID: pm.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.NextActionURL == "" {
+		t.Error("expected a next action URL")
+	}
+	p, err = g.CompleteAction(p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != gateway.PaymentSucceeded {
+		t.Errorf("got %v, want %v", p.Status, gateway.PaymentSucceeded)
+	}
+}
+
+func TestGateway_Settle(t *testing.T) {
+	ctx := context.Background()
+	g := testgateway.New()
+	pm := savePaymentMethod(t, g, testgateway.CardProcessing)
+	p, err := g.Charge(ctx, gateway.PaymentRequest{Amount: amount("10.00"), PaymentMethodID: pm.ID, OffSession: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != gateway.PaymentPending {
+		t.Errorf("got %v, want %v", p.Status, gateway.PaymentPending)
+	}
+	p, err = g.Settle(p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != gateway.PaymentSucceeded || p.AmountCaptured != p.Amount {
+		t.Errorf("unexpected payment: %+v", p)
+	}
+	if _, err := g.Settle(p.ID); !errors.Is(err, gateway.ErrInvalidRequest) {
+		t.Errorf("got %v, want %v", err, gateway.ErrInvalidRequest)
+	}
+}
+
+func TestGateway_SavePaymentMethod(t *testing.T) {
+	ctx := context.Background()
+	g := testgateway.New()
+	pm := savePaymentMethod(t, g, testgateway.CardSuccess)
+	if pm.CustomerID == "" || pm.Last4 != "4242" {
+		t.Errorf("unexpected payment method: %+v", pm)
+	}
+	other, _ := g.SavePaymentMethod(ctx, gateway.PaymentMethodRequest{CustomerID: pm.CustomerID, Token: testgateway.CardDecline})
+	if other.CustomerID != pm.CustomerID {
+		t.Errorf("got %v, want %v", other.CustomerID, pm.CustomerID)
+	}
+
+	_, err := g.SavePaymentMethod(ctx, gateway.PaymentMethodRequest{Token: "1234"})
+	var declineErr *gateway.DeclineError
+	if !errors.As(err, &declineErr) || declineErr.Code != gateway.DeclineIncorrectNumber {
+		t.Errorf("got %v, want an incorrect number decline", err)
+	}
+}
+
+func savePaymentMethod(t *testing.T, g *testgateway.Gateway, card string) gateway.PaymentMethod {
+	t.Helper()
+	pm, err := g.SavePaymentMethod(context.Background(), gateway.PaymentMethodRequest{
+		Email: "jane@example.com",
+		Token: card,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pm
+}
+
+func amount(n string) currency.Amount {
+	a, _ := currency.NewAmount(n, "USD")
+	return a
+}
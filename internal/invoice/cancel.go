@@ -0,0 +1,96 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package invoice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/runbilliam/billiam/internal/catalog"
+	"github.com/runbilliam/billiam/internal/period"
+	"github.com/runbilliam/billiam/internal/schedule"
+	"github.com/runbilliam/billiam/internal/settings"
+	"github.com/runbilliam/billiam/internal/subscription"
+)
+
+// Cancel cancels the given subscription immediately.
+//
+// A final invoice is generated for the usage of the current period,
+// up to the time of cancellation, and for any pending lines. Usage is
+// only billed for active and past due subscriptions, see Generator.
+// The subscription's active schedule, if any, is canceled as well.
+//
+// Returns the final invoice, if one was generated. A final invoice
+// with a negative total credits the customer's balance.
+func Cancel(ctx context.Context, tx pgx.Tx, sub *subscription.Subscription, c subscription.Cancellation, now time.Time) (Invoice, error) {
+	billUsage := sub.Status == subscription.StatusActive || sub.Status == subscription.StatusPastDue
+	if errs := sub.Cancel(c, now); !errs.IsEmpty() {
+		return Invoice{}, fmt.Errorf("cancel subscription %v: %v", sub.ID, errs)
+	}
+	if err := subscription.NewRepository(tx).Update(ctx, sub); err != nil {
+		return Invoice{}, err
+	}
+	scheduleRepo := schedule.NewRepository(tx)
+	s, err := scheduleRepo.GetActive(ctx, sub.ID)
+	if err == nil {
+		s.Cancel(now)
+		if err := scheduleRepo.Update(ctx, &s); err != nil {
+			return Invoice{}, err
+		}
+	} else if !errors.Is(err, schedule.ErrNotFound) {
+		return Invoice{}, err
+	}
+
+	price, err := catalog.NewRepository(tx).GetPrice(ctx, sub.PriceID)
+	if err != nil {
+		return Invoice{}, err
+	}
+	usage := period.Period{Start: sub.CurrentPeriodStart, End: sub.CurrentPeriodEnd}
+	if now.Before(usage.End) {
+		usage.End = now.UTC()
+	}
+	inv := New(sub.CustomerID, price.Amount.CurrencyCode())
+	inv.SubscriptionID = sub.ID
+	inv.PeriodStart = usage.Start
+	inv.PeriodEnd = usage.End
+	if billUsage && usage.End.After(usage.Start) {
+		st, err := settings.NewStore(tx).Get(ctx)
+		if err != nil && !errors.Is(err, settings.ErrNotFound) {
+			return Invoice{}, err
+		}
+		if err := addUsage(ctx, tx, &inv, *sub, usage, st.TaxRate); err != nil {
+			return Invoice{}, err
+		}
+	}
+	pending, err := NewRepository(tx).TakePendingLines(ctx, sub.ID, inv.Currency)
+	if err != nil {
+		return Invoice{}, err
+	}
+	for _, line := range pending {
+		if err := inv.AddLine(line); err != nil {
+			return Invoice{}, err
+		}
+	}
+	if len(inv.Lines) == 0 {
+		return Invoice{}, nil
+	}
+	if errs := inv.Validate(); !errs.IsEmpty() {
+		return Invoice{}, fmt.Errorf("invalid invoice: %v", errs)
+	}
+	if err := NewRepository(tx).Create(ctx, &inv); err != nil {
+		return Invoice{}, err
+	}
+	if err := Finalize(ctx, tx, &inv, now); err != nil {
+		return Invoice{}, err
+	}
+	if err := creditProration(ctx, tx, inv); err != nil {
+		return Invoice{}, err
+	}
+
+	return inv, nil
+}
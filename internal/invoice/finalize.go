@@ -0,0 +1,103 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package invoice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/runbilliam/billiam/internal/balance"
+	"github.com/runbilliam/billiam/internal/sequence"
+	"github.com/runbilliam/billiam/internal/settings"
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+// numberSequence is the name of the invoice number sequence.
+const numberSequence = "invoice"
+
+// Finalize finalizes the given draft invoice and assigns its number.
+//
+// The number is allocated using the invoice number pattern and reset
+// policy from the settings, with the year of finalization in the site
+// timezone. Drafts never consume numbers, keeping the sequence gapless.
+//
+// Any credit in the customer's balance is applied to the amount due,
+// with the balance locked until the end of the transaction. The first
+// payment attempt is then scheduled right away for the rest. Invoices
+// with nothing left to pay are marked as paid instead.
+//
+// Must be called inside a transaction, with the invoice locked
+// (see Repository.GetForUpdate), since the allocated number is only
+// released if the transaction is rolled back.
+func Finalize(ctx context.Context, tx database.Querier, inv *Invoice, now time.Time) error {
+	return finalize(ctx, tx, inv, true, now)
+}
+
+// finalize finalizes the given draft invoice, optionally
+// applying credit from the customer's balance.
+func finalize(ctx context.Context, tx database.Querier, inv *Invoice, useBalance bool, now time.Time) error {
+	if inv.IsFinalized() {
+		return ErrFinalized
+	}
+	st, err := settings.NewStore(tx).Get(ctx)
+	if errors.Is(err, settings.ErrNotFound) {
+		st = settings.New()
+	} else if err != nil {
+		return err
+	}
+	loc, err := time.LoadLocation(st.Timezone)
+	if err != nil {
+		return err
+	}
+	year := now.In(loc).Year()
+	n, err := sequence.NewStore(tx).Next(ctx, numberSequence, st.InvoiceNumberReset, year)
+	if err != nil {
+		return err
+	}
+	inv.Number = sequence.Format(st.InvoiceNumberPattern, year, n)
+	if useBalance {
+		if err := applyBalance(ctx, tx, inv, now); err != nil {
+			return err
+		}
+	}
+	if errs := inv.TransitionTo(StatusOpen, now); !errs.IsEmpty() {
+		return fmt.Errorf("finalize invoice %v: %v", inv.ID, errs)
+	}
+	if inv.AmountDue.IsPositive() {
+		inv.NextPaymentAttemptAt = now.UTC()
+	} else if errs := inv.TransitionTo(StatusPaid, now); !errs.IsEmpty() {
+		return fmt.Errorf("finalize invoice %v: %v", inv.ID, errs)
+	}
+
+	return NewRepository(tx).Update(ctx, inv)
+}
+
+// applyBalance applies credit from the customer's balance to the
+// given draft invoice, debiting the balance by the applied amount.
+func applyBalance(ctx context.Context, tx database.Querier, inv *Invoice, now time.Time) error {
+	if !inv.AmountDue.IsPositive() {
+		return nil
+	}
+	balanceRepo := balance.NewRepository(tx)
+	available, err := balanceRepo.GetForUpdate(ctx, inv.CustomerID, inv.Currency)
+	if err != nil {
+		return err
+	}
+	applied, err := inv.ApplyCredit(available)
+	if err != nil || applied.IsZero() {
+		return err
+	}
+	debit, err := applied.Mul("-1")
+	if err != nil {
+		return err
+	}
+	t := balance.NewTransaction(inv.CustomerID, balance.TransactionInvoicePayment, debit)
+	t.InvoiceID = inv.ID
+	t.Description = "Applied to invoice " + inv.Number
+	t.CreatedAt = now.UTC()
+
+	return balanceRepo.Create(ctx, t)
+}
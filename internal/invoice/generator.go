@@ -0,0 +1,393 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package invoice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+	"github.com/rs/zerolog"
+
+	"github.com/runbilliam/billiam/internal/catalog"
+	"github.com/runbilliam/billiam/internal/coupon"
+	"github.com/runbilliam/billiam/internal/customer"
+	"github.com/runbilliam/billiam/internal/meter"
+	"github.com/runbilliam/billiam/internal/payment"
+	"github.com/runbilliam/billiam/internal/period"
+	"github.com/runbilliam/billiam/internal/schedule"
+	"github.com/runbilliam/billiam/internal/settings"
+	"github.com/runbilliam/billiam/internal/subscription"
+	"github.com/runbilliam/billiam/internal/worker"
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+// RenewalRetryDelay is the delay before retrying a failed renewal.
+const RenewalRetryDelay = time.Hour
+
+// Generator generates invoices for due subscription periods.
+type Generator struct {
+	db     database.Querier
+	logger *zerolog.Logger
+}
+
+// NewGenerator creates a new invoice generator.
+func NewGenerator(db database.Querier, logger *zerolog.Logger) *Generator {
+	return &Generator{db: db, logger: logger}
+}
+
+// Run renews all subscriptions whose current period ended before now,
+// generating and finalizing an invoice for each new period.
+//
+// Trialing subscriptions whose trial ended are converted to active
+// and invoiced if the customer has a default payment method, and
+// canceled otherwise.
+//
+// The phases of subscription schedules are applied as their
+// periods start, before the periods are invoiced.
+//
+// Subscriptions scheduled for cancellation are canceled instead of
+// renewed, with a final invoice for their usage, see Cancel.
+//
+// Paused subscriptions keep being renewed, with their invoices settled
+// according to the pause behavior. They are resumed once their resume
+// time has passed, see Resume.
+//
+// Subscriptions which missed several periods are renewed once per
+// period. A failed renewal is rolled back and logged, and the
+// subscription is retried after RenewalRetryDelay.
+//
+// Returns the number of renewed subscriptions.
+func (g *Generator) Run(ctx context.Context, now time.Time) (int, error) {
+	next := func(tx pgx.Tx, failed []ulid.ULID) (ulid.ULID, error) {
+		subRepo := subscription.NewRepository(tx)
+		subs, err := subRepo.ListDue(ctx, now, failed, 1)
+		if err != nil || len(subs) == 0 {
+			return ulid.ULID{}, err
+		}
+		sub := subs[0]
+		inv, err := g.renew(ctx, tx, &sub, now)
+		if err != nil {
+			return sub.ID, fmt.Errorf("renew subscription %v: %w", sub.ID, err)
+		}
+		if err := subRepo.ClearRenewalFailure(ctx, sub.ID); err != nil {
+			return sub.ID, err
+		}
+		if sub.Status == subscription.StatusCanceled {
+			g.logger.Info().
+				Str("subscription_id", sub.ID.String()).
+				Str("reason", string(sub.CancelReason)).
+				Msg("Canceled subscription")
+		} else if inv.ID == (ulid.ULID{}) {
+			g.logger.Info().
+				Str("subscription_id", sub.ID.String()).
+				Msg("Resumed subscription")
+		}
+		if inv.ID == (ulid.ULID{}) {
+			return sub.ID, nil
+		}
+		g.logger.Info().
+			Str("subscription_id", sub.ID.String()).
+			Str("invoice_id", inv.ID.String()).
+			Str("invoice_number", inv.Number).
+			Time("period_start", inv.PeriodStart).
+			Time("period_end", inv.PeriodEnd).
+			Msg("Generated invoice")
+		return sub.ID, nil
+	}
+	fail := func(id ulid.ULID, err error) error {
+		retryAt := now.Add(RenewalRetryDelay)
+		g.logger.Error().
+			Err(err).
+			Str("subscription_id", id.String()).
+			Time("next_attempt_at", retryAt).
+			Msg("Renewal failed")
+		return subscription.NewRepository(g.db).MarkRenewalFailed(ctx, id, now, retryAt)
+	}
+
+	return worker.EachTx(ctx, g.db, next, fail)
+}
+
+// renew advances the given subscription to its next period, and
+// generates and finalizes an invoice for that period.
+//
+// Ended trials are converted or canceled, and paused subscriptions
+// are resumed once their resume time falls within the current period,
+// see Run. No invoice is returned for canceled trials, or for resumed
+// subscriptions which keep their billing cycle. Canceled subscriptions
+// only get a final invoice if they have usage or pending lines.
+func (g *Generator) renew(ctx context.Context, tx pgx.Tx, sub *subscription.Subscription, now time.Time) (Invoice, error) {
+	if sub.CancelAtPeriodEnd && !sub.CurrentPeriodEnd.After(now) {
+		c := subscription.Cancellation{Reason: sub.CancelReason, Feedback: sub.CancelFeedback}
+		return Cancel(ctx, tx, sub, c, now)
+	}
+	if sub.ResumeIsDue(now) && !sub.ResumesAt.After(sub.CurrentPeriodEnd) {
+		return Resume(ctx, tx, sub, "", now)
+	}
+	catalogRepo := catalog.NewRepository(tx)
+	price, err := catalogRepo.GetPrice(ctx, sub.PriceID)
+	if err != nil {
+		return Invoice{}, err
+	}
+	product, err := catalogRepo.GetProduct(ctx, price.ProductID)
+	if err != nil {
+		return Invoice{}, err
+	}
+	cust, err := customer.NewRepository(tx).Get(ctx, sub.CustomerID)
+	if err != nil {
+		return Invoice{}, err
+	}
+	st, err := settings.NewStore(tx).Get(ctx)
+	if err != nil && !errors.Is(err, settings.ErrNotFound) {
+		return Invoice{}, err
+	}
+	loc, err := period.Location(cust.Timezone, st.Timezone)
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	// Usage is billed in arrears, for the period that just ended.
+	// Usage during a trial is free.
+	var p, usage period.Period
+	if sub.Status == subscription.StatusTrialing {
+		_, err := payment.NewRepository(tx).GetDefaultMethod(ctx, sub.CustomerID)
+		if err != nil && !errors.Is(err, payment.ErrNotFound) {
+			return Invoice{}, err
+		}
+		converted, errs := sub.ConvertTrial(err == nil, price.Interval, loc, now)
+		if !errs.IsEmpty() {
+			return Invoice{}, fmt.Errorf("convert trial: %v", errs)
+		}
+		if sub.Status == subscription.StatusCanceled {
+			return Invoice{}, subscription.NewRepository(tx).Update(ctx, sub)
+		}
+		p = converted
+	} else {
+		usage = period.Period{Start: sub.CurrentPeriodStart, End: sub.CurrentPeriodEnd}
+		p = sub.Renew(sub.Schedule(price.Interval, loc))
+	}
+	// Scheduled phases take effect at the start of the new period.
+	changed, err := schedule.Apply(ctx, tx, sub, p.Start, now)
+	if err != nil {
+		return Invoice{}, err
+	}
+	if changed && sub.PriceID != price.ID {
+		if price, err = catalogRepo.GetPrice(ctx, sub.PriceID); err != nil {
+			return Invoice{}, err
+		}
+		if product, err = catalogRepo.GetProduct(ctx, price.ProductID); err != nil {
+			return Invoice{}, err
+		}
+	}
+	var inv Invoice
+	if sub.Status == subscription.StatusPaused {
+		inv, err = draft(ctx, tx, *sub, product, price, p, usage, st.TaxRate)
+		if err == nil {
+			err = settlePaused(ctx, tx, &inv, sub.PauseBehavior, now)
+		}
+	} else {
+		inv, err = issue(ctx, tx, *sub, product, price, p, usage, st.TaxRate, now)
+	}
+	if err != nil {
+		return Invoice{}, err
+	}
+	if err := subscription.NewRepository(tx).Update(ctx, sub); err != nil {
+		return Invoice{}, err
+	}
+
+	return inv, nil
+}
+
+// InvoiceCurrentPeriod generates and finalizes an invoice for the
+// current period of the given subscription.
+//
+// Used to invoice the first period of new subscriptions which don't
+// start with a trial. Later periods are invoiced by the Generator.
+func InvoiceCurrentPeriod(ctx context.Context, tx pgx.Tx, sub subscription.Subscription, now time.Time) (Invoice, error) {
+	catalogRepo := catalog.NewRepository(tx)
+	price, err := catalogRepo.GetPrice(ctx, sub.PriceID)
+	if err != nil {
+		return Invoice{}, err
+	}
+	product, err := catalogRepo.GetProduct(ctx, price.ProductID)
+	if err != nil {
+		return Invoice{}, err
+	}
+	st, err := settings.NewStore(tx).Get(ctx)
+	if err != nil && !errors.Is(err, settings.ErrNotFound) {
+		return Invoice{}, err
+	}
+	p := period.Period{Start: sub.CurrentPeriodStart, End: sub.CurrentPeriodEnd}
+
+	return issue(ctx, tx, sub, product, price, p, period.Period{}, st.TaxRate, now)
+}
+
+// issue generates and finalizes an invoice for the given subscription period.
+//
+// The usage of the subscription's metered prices is billed for the given
+// usage period, unless it is empty. Pending lines, such as prorations,
+// are added as well.
+func issue(ctx context.Context, tx pgx.Tx, sub subscription.Subscription, product catalog.Product, price catalog.Price, p, usage period.Period, taxRate string, now time.Time) (Invoice, error) {
+	inv, err := draft(ctx, tx, sub, product, price, p, usage, taxRate)
+	if err != nil {
+		return Invoice{}, err
+	}
+	if err := Finalize(ctx, tx, &inv, now); err != nil {
+		return Invoice{}, err
+	}
+
+	return inv, nil
+}
+
+// draft generates a draft invoice for the given subscription period, see issue.
+//
+// Pending lines and coupons are not applied while the subscription is
+// paused, leaving them for the first invoice after the pause.
+func draft(ctx context.Context, tx pgx.Tx, sub subscription.Subscription, product catalog.Product, price catalog.Price, p, usage period.Period, taxRate string) (Invoice, error) {
+	inv, err := Build(sub, product, price, p, taxRate)
+	if err != nil {
+		return Invoice{}, err
+	}
+	if !usage.Start.IsZero() {
+		if err := addUsage(ctx, tx, &inv, sub, usage, taxRate); err != nil {
+			return Invoice{}, err
+		}
+	}
+	if sub.Status != subscription.StatusPaused {
+		pending, err := NewRepository(tx).TakePendingLines(ctx, sub.ID, inv.Currency)
+		if err != nil {
+			return Invoice{}, err
+		}
+		for _, line := range pending {
+			if err := inv.AddLine(line); err != nil {
+				return Invoice{}, err
+			}
+		}
+		if err := applyCoupon(ctx, tx, &inv, sub.ID, product.ID); err != nil {
+			return Invoice{}, err
+		}
+	}
+	if errs := inv.Validate(); !errs.IsEmpty() {
+		return Invoice{}, fmt.Errorf("invalid invoice: %v", errs)
+	}
+	if err := NewRepository(tx).Create(ctx, &inv); err != nil {
+		return Invoice{}, err
+	}
+
+	return inv, nil
+}
+
+// addUsage adds a usage line for each of the subscription's metered
+// prices to the draft invoice, with the quantity aggregated over the
+// given usage period.
+func addUsage(ctx context.Context, tx pgx.Tx, inv *Invoice, sub subscription.Subscription, usage period.Period, taxRate string) error {
+	catalogRepo := catalog.NewRepository(tx)
+	meterRepo := meter.NewRepository(tx)
+	for _, priceID := range sub.MeteredPriceIDs {
+		price, err := catalogRepo.GetPrice(ctx, priceID)
+		if err != nil {
+			return err
+		}
+		product, err := catalogRepo.GetProduct(ctx, price.ProductID)
+		if err != nil {
+			return err
+		}
+		m, err := meterRepo.Get(ctx, price.MeterID)
+		if err != nil {
+			return fmt.Errorf("price %v: %w", price.ID, err)
+		}
+		quantity, err := meterRepo.Aggregate(ctx, m, sub.CustomerID, usage.Start, usage.End)
+		if err != nil {
+			return err
+		}
+		line, err := UsageLine(product, price, m, quantity, usage, taxRate)
+		if err != nil {
+			return err
+		}
+		if err := inv.AddLine(line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyCoupon applies the coupon redeemed on the given subscription
+// to the draft invoice, if the coupon still covers the invoiced period.
+func applyCoupon(ctx context.Context, tx pgx.Tx, inv *Invoice, subscriptionID, productID ulid.ULID) error {
+	repo := coupon.NewRepository(tx)
+	rd, err := repo.GetRedemption(ctx, subscriptionID)
+	if errors.Is(err, coupon.ErrRedemptionNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	c, err := repo.Get(ctx, rd.CouponID)
+	if err != nil {
+		return err
+	}
+	if !c.CoversPeriod(rd) || !c.AppliesToProduct(productID) {
+		return nil
+	}
+	applied, err := inv.ApplyCoupon(c)
+	if err != nil || !applied {
+		return err
+	}
+	rd.PeriodsApplied++
+
+	return repo.UpdateRedemption(ctx, rd)
+}
+
+// Build builds a draft invoice for the given subscription period.
+//
+// The subscription is charged in advance, for the full period,
+// using its quantity.
+func Build(sub subscription.Subscription, product catalog.Product, price catalog.Price, p period.Period, taxRate string) (Invoice, error) {
+	inv := New(sub.CustomerID, price.Amount.CurrencyCode())
+	inv.SubscriptionID = sub.ID
+	inv.PeriodStart = p.Start.UTC()
+	inv.PeriodEnd = p.End.UTC()
+
+	line, err := NewPriceLine(LineSubscription, describePrice(product, price), price, sub.Quantity)
+	if err != nil {
+		return Invoice{}, err
+	}
+	line.TaxRate = taxRate
+	line.PeriodStart = inv.PeriodStart
+	line.PeriodEnd = inv.PeriodEnd
+	if err := inv.AddLine(line); err != nil {
+		return Invoice{}, err
+	}
+
+	return inv, nil
+}
+
+// UsageLine builds an invoice line for the usage of a metered price.
+//
+// The aggregated quantity is charged using the price's pricing model.
+func UsageLine(product catalog.Product, price catalog.Price, m meter.Meter, quantity int64, usage period.Period, taxRate string) (Line, error) {
+	description := describePrice(product, price) + " (" + m.Name + ")"
+	line, err := NewPriceLine(LineUsage, description, price, quantity)
+	if err != nil {
+		return Line{}, err
+	}
+	line.TaxRate = taxRate
+	line.PeriodStart = usage.Start.UTC()
+	line.PeriodEnd = usage.End.UTC()
+
+	return line, nil
+}
+
+// describePrice returns the line description for the given price.
+func describePrice(product catalog.Product, price catalog.Price) string {
+	description := product.Name
+	if price.Nickname != "" {
+		description += " - " + price.Nickname
+	}
+
+	return description
+}
@@ -0,0 +1,555 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package invoice provides invoices and their generation.
+//
+// Invoices start out as drafts which can be freely modified.
+// Once finalized, an invoice is immutable: only its status can change.
+package invoice
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/bojanz/currency"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/balance"
+	"github.com/runbilliam/billiam/internal/catalog"
+	"github.com/runbilliam/billiam/internal/coupon"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+// unitAmountDigits is the number of decimals stored for line unit amounts.
+const unitAmountDigits = 6
+
+// ErrNotFound is returned when an invoice could not be found.
+var ErrNotFound = errors.New("invoice not found")
+
+// ErrFinalized is returned when attempting to modify a finalized invoice.
+var ErrFinalized = errors.New("invoice is finalized")
+
+// Status represents an invoice status.
+type Status string
+
+// Invoice statuses.
+const (
+	// StatusDraft is used for invoices that can still be modified.
+	StatusDraft Status = "draft"
+	// StatusOpen is used for finalized invoices awaiting payment.
+	StatusOpen Status = "open"
+	// StatusPaid is used for paid invoices. Final.
+	StatusPaid Status = "paid"
+	// StatusVoid is used for canceled invoices. Final.
+	StatusVoid Status = "void"
+	// StatusUncollectible is used for invoices unlikely to be paid.
+	StatusUncollectible Status = "uncollectible"
+)
+
+// transitions maps each status to the statuses it can transition to.
+var transitions = map[Status][]Status{
+	StatusDraft:         {StatusOpen},
+	StatusOpen:          {StatusPaid, StatusVoid, StatusUncollectible},
+	StatusPaid:          {},
+	StatusVoid:          {},
+	StatusUncollectible: {StatusPaid, StatusVoid},
+}
+
+// IsValid returns whether the status is known.
+func (s Status) IsValid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// CanTransitionTo returns whether s can transition to the given status.
+func (s Status) CanTransitionTo(to Status) bool {
+	for _, status := range transitions[s] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// LineType represents the type of an invoice line.
+type LineType string
+
+// Line types.
+const (
+	// LineSubscription is used for recurring subscription charges.
+	LineSubscription LineType = "subscription"
+	// LineUsage is used for metered usage, charged in arrears.
+	LineUsage LineType = "usage"
+	// LineDiscount is used for discounts. Has a negative amount.
+	LineDiscount LineType = "discount"
+	// LineProration is used for mid-period subscription changes.
+	// Has a negative amount for credits of unused time.
+	LineProration LineType = "proration"
+	// LineAdjustment is used for manually added charges and credits.
+	LineAdjustment LineType = "adjustment"
+)
+
+// Invoice represents an invoice.
+type Invoice struct {
+	ID             ulid.ULID `json:"id"`
+	Version        int       `json:"version"`
+	CustomerID     ulid.ULID `json:"customer_id"`
+	SubscriptionID ulid.ULID `json:"subscription_id"`
+	// Number is assigned on finalization.
+	Number        string          `json:"number"`
+	Status        Status          `json:"status"`
+	Currency      string          `json:"currency"`
+	Lines         []Line          `json:"lines"`
+	Taxes         []Tax           `json:"taxes"`
+	Subtotal      currency.Amount `json:"subtotal"`
+	DiscountTotal currency.Amount `json:"discount_total"`
+	TaxTotal      currency.Amount `json:"tax_total"`
+	Total         currency.Amount `json:"total"`
+	// CreditApplied is the part of the total paid from the
+	// customer's balance on finalization.
+	CreditApplied currency.Amount `json:"credit_applied"`
+	// AmountDue is the part of the total left to be paid.
+	AmountDue   currency.Amount `json:"amount_due"`
+	PeriodStart time.Time       `json:"period_start"`
+	PeriodEnd   time.Time       `json:"period_end"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	FinalizedAt time.Time       `json:"finalized_at"`
+	PaidAt      time.Time       `json:"paid_at"`
+	VoidedAt    time.Time       `json:"voided_at"`
+	// MarkedUncollectibleAt is the time the invoice was marked as uncollectible.
+	MarkedUncollectibleAt time.Time `json:"marked_uncollectible_at"`
+	// AttemptCount is the number of payment attempts made so far.
+	AttemptCount int `json:"attempt_count"`
+	// NextPaymentAttemptAt is the time of the next automatic payment
+	// attempt. Zero if no further attempts will be made.
+	NextPaymentAttemptAt time.Time `json:"next_payment_attempt_at"`
+}
+
+// Line represents an invoice line.
+type Line struct {
+	ID          ulid.ULID `json:"id"`
+	Type        LineType  `json:"type"`
+	Description string    `json:"description"`
+	// PriceID is set for lines generated from catalog prices.
+	PriceID    ulid.ULID       `json:"price_id"`
+	Quantity   int64           `json:"quantity"`
+	UnitAmount currency.Amount `json:"unit_amount"`
+	// Amount is the line total, before taxes, rounded to the currency's minor units.
+	Amount currency.Amount `json:"amount"`
+	// TaxRate is a percentage, e.g. "20" for 20%. Empty if not taxed.
+	TaxRate     string    `json:"tax_rate"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	// Breakdown shows how the amount was calculated, for package
+	// and tiered prices. Empty for other lines.
+	Breakdown []catalog.Charge `json:"breakdown"`
+}
+
+// Tax represents the tax applied to all lines with the same rate.
+type Tax struct {
+	Rate          string          `json:"rate"`
+	TaxableAmount currency.Amount `json:"taxable_amount"`
+	Amount        currency.Amount `json:"amount"`
+}
+
+// New creates a new draft invoice.
+func New(customerID ulid.ULID, currencyCode string) Invoice {
+	now := time.Now().UTC()
+	zero, _ := currency.NewAmount("0", currencyCode)
+	inv := Invoice{
+		ID:            ulid.MustNew(ulid.Timestamp(now), rand.Reader),
+		Version:       1,
+		CustomerID:    customerID,
+		Status:        StatusDraft,
+		Currency:      currencyCode,
+		Subtotal:      zero,
+		DiscountTotal: zero,
+		TaxTotal:      zero,
+		Total:         zero,
+		CreditApplied: zero,
+		AmountDue:     zero,
+		CreatedAt:     now,
+	}
+
+	return inv
+}
+
+// NewLine creates a new line with the given unit amount and quantity.
+//
+// The line amount is rounded to the currency's minor units.
+func NewLine(lineType LineType, description string, unitAmount currency.Amount, quantity int64) (Line, error) {
+	amount, err := unitAmount.Mul(strconv.FormatInt(quantity, 10))
+	if err != nil {
+		return Line{}, err
+	}
+	line := Line{
+		ID:          ulid.MustNew(ulid.Now(), rand.Reader),
+		Type:        lineType,
+		Description: description,
+		Quantity:    quantity,
+		UnitAmount:  unitAmount,
+		Amount:      amount.Round(),
+	}
+
+	return line, nil
+}
+
+// NewPriceLine creates a new line for the given quantity of a catalog price.
+//
+// The line amount is calculated using the price's pricing model, and
+// rounded to the currency's minor units. The unit amount is the average
+// per unit for package and tiered prices, whose breakdown is included.
+func NewPriceLine(lineType LineType, description string, price catalog.Price, quantity int64) (Line, error) {
+	amount, charges, err := price.Calculate(quantity)
+	if err != nil {
+		return Line{}, err
+	}
+	unitAmount := price.Amount
+	if price.Model != catalog.ModelFlat && price.Model != "" {
+		unitAmount = amount
+		if quantity > 1 {
+			if unitAmount, err = amount.Div(strconv.FormatInt(quantity, 10)); err != nil {
+				return Line{}, err
+			}
+		}
+		unitAmount = unitAmount.RoundTo(unitAmountDigits, currency.RoundHalfUp)
+	} else {
+		charges = nil
+	}
+	line := Line{
+		ID:          ulid.MustNew(ulid.Now(), rand.Reader),
+		Type:        lineType,
+		Description: description,
+		PriceID:     price.ID,
+		Quantity:    quantity,
+		UnitAmount:  unitAmount,
+		Amount:      amount.Round(),
+		Breakdown:   charges,
+	}
+
+	return line, nil
+}
+
+// IsFinalized returns whether the invoice has been finalized.
+func (inv Invoice) IsFinalized() bool {
+	return inv.Status != StatusDraft
+}
+
+// AddLine adds a line to a draft invoice and recalculates the totals.
+func (inv *Invoice) AddLine(line Line) error {
+	if inv.IsFinalized() {
+		return ErrFinalized
+	}
+	if line.Amount.CurrencyCode() != inv.Currency {
+		return currency.MismatchError{Op: "Invoice.AddLine", A: inv.Total, B: line.Amount}
+	}
+	inv.Lines = append(inv.Lines, line)
+
+	return inv.Recalculate()
+}
+
+// ApplyCoupon adds discount lines for the given coupon to a draft invoice.
+//
+// One discount line is added per tax rate, so that the discount reduces
+// the taxable amount of the discounted lines. Percent coupons discount
+// each rate separately, while fixed coupons are used up rate by rate.
+// Returns whether any discount lines were added.
+func (inv *Invoice) ApplyCoupon(c coupon.Coupon) (bool, error) {
+	if inv.IsFinalized() {
+		return false, ErrFinalized
+	}
+	var rates []string
+	amounts := make(map[string]currency.Amount)
+	for _, line := range inv.Lines {
+		if line.Type == LineDiscount {
+			continue
+		}
+		amount, ok := amounts[line.TaxRate]
+		if !ok {
+			rates = append(rates, line.TaxRate)
+			amounts[line.TaxRate] = line.Amount
+			continue
+		}
+		var err error
+		if amounts[line.TaxRate], err = amount.Add(line.Amount); err != nil {
+			return false, err
+		}
+	}
+	sort.Strings(rates)
+
+	var remaining currency.Amount
+	if c.Type == coupon.TypeFixed {
+		var ok bool
+		if remaining, ok = c.AmountOff(inv.Currency); !ok {
+			return false, nil
+		}
+	}
+	applied := false
+	for _, rate := range rates {
+		var discount currency.Amount
+		var err error
+		if c.Type == coupon.TypeFixed {
+			discount = remaining
+			if cmp, _ := discount.Cmp(amounts[rate]); cmp > 0 {
+				discount = amounts[rate]
+			}
+			if !discount.IsPositive() {
+				continue
+			}
+			if remaining, err = remaining.Sub(discount); err != nil {
+				return false, err
+			}
+		} else {
+			if discount, err = c.Discount(amounts[rate]); err != nil {
+				return false, err
+			}
+			if !discount.IsPositive() {
+				continue
+			}
+		}
+		if discount, err = discount.Mul("-1"); err != nil {
+			return false, err
+		}
+		line, err := NewLine(LineDiscount, c.Name, discount, 1)
+		if err != nil {
+			return false, err
+		}
+		line.TaxRate = rate
+		line.PeriodStart = inv.PeriodStart
+		line.PeriodEnd = inv.PeriodEnd
+		if err := inv.AddLine(line); err != nil {
+			return false, err
+		}
+		applied = true
+	}
+
+	return applied, nil
+}
+
+// Recalculate recalculates the totals of a draft invoice.
+//
+// Taxes are calculated per tax rate, on the sum of the line amounts
+// (including discounts) with that rate, and rounded once per rate.
+// Nothing is due for negative totals, those are credited instead.
+func (inv *Invoice) Recalculate() error {
+	if inv.IsFinalized() {
+		return ErrFinalized
+	}
+	zero, err := currency.NewAmount("0", inv.Currency)
+	if err != nil {
+		return err
+	}
+	subtotal, discountTotal, taxTotal := zero, zero, zero
+	taxable := make(map[string]currency.Amount)
+	for _, line := range inv.Lines {
+		if line.Type == LineDiscount {
+			discountTotal, err = discountTotal.Sub(line.Amount)
+		} else {
+			subtotal, err = subtotal.Add(line.Amount)
+		}
+		if err != nil {
+			return err
+		}
+		if line.TaxRate != "" {
+			base, ok := taxable[line.TaxRate]
+			if !ok {
+				base = zero
+			}
+			if taxable[line.TaxRate], err = base.Add(line.Amount); err != nil {
+				return err
+			}
+		}
+	}
+
+	if inv.Taxes, taxTotal, err = CalculateTaxes(inv.Currency, taxable); err != nil {
+		return err
+	}
+
+	total, err := subtotal.Sub(discountTotal)
+	if err != nil {
+		return err
+	}
+	if total, err = total.Add(taxTotal); err != nil {
+		return err
+	}
+	inv.Subtotal = subtotal
+	inv.DiscountTotal = discountTotal
+	inv.TaxTotal = taxTotal
+	inv.Total = total
+	inv.CreditApplied = zero
+	inv.AmountDue = total
+	if total.IsNegative() {
+		inv.AmountDue = zero
+	}
+
+	return nil
+}
+
+// ApplyCredit applies credit from the given customer balance to the
+// amount due, and returns the applied amount.
+//
+// The applied amount is limited by both the balance and the amount due.
+func (inv *Invoice) ApplyCredit(available currency.Amount) (currency.Amount, error) {
+	if inv.Status != StatusDraft {
+		return currency.Amount{}, ErrFinalized
+	}
+	applied, err := balance.Available(available, inv.AmountDue)
+	if err != nil {
+		return currency.Amount{}, err
+	}
+	if inv.CreditApplied, err = inv.CreditApplied.Add(applied); err != nil {
+		return currency.Amount{}, err
+	}
+	if inv.AmountDue, err = inv.AmountDue.Sub(applied); err != nil {
+		return currency.Amount{}, err
+	}
+
+	return applied, nil
+}
+
+// CalculateTaxes calculates the taxes for the given taxable amounts,
+// keyed by tax rate, and returns them sorted by rate, along with the
+// tax total.
+//
+// Each tax is rounded once. Negative taxable amounts are not taxed.
+func CalculateTaxes(currencyCode string, taxable map[string]currency.Amount) ([]Tax, currency.Amount, error) {
+	taxTotal, err := currency.NewAmount("0", currencyCode)
+	if err != nil {
+		return nil, currency.Amount{}, err
+	}
+	rates := make([]string, 0, len(taxable))
+	for rate := range taxable {
+		rates = append(rates, rate)
+	}
+	sort.Strings(rates)
+	taxes := make([]Tax, 0, len(rates))
+	for _, rate := range rates {
+		base := taxable[rate]
+		if base.IsNegative() {
+			base, _ = currency.NewAmount("0", currencyCode)
+		}
+		amount, err := base.Mul(rate)
+		if err != nil {
+			return nil, currency.Amount{}, err
+		}
+		if amount, err = amount.Div("100"); err != nil {
+			return nil, currency.Amount{}, err
+		}
+		amount = amount.Round()
+		taxes = append(taxes, Tax{Rate: rate, TaxableAmount: base, Amount: amount})
+		if taxTotal, err = taxTotal.Add(amount); err != nil {
+			return nil, currency.Amount{}, err
+		}
+	}
+
+	return taxes, taxTotal, nil
+}
+
+// TransitionTo transitions the invoice to the given status.
+//
+// Only defined transitions are allowed, others are reported
+// as errors on the "status" path.
+func (inv *Invoice) TransitionTo(to Status, now time.Time) validation.Errors {
+	errs := validation.Errors{}
+	if !to.IsValid() {
+		errs.Add("status", validation.InvalidChoice("Invalid status."))
+		return errs
+	}
+	if !inv.Status.CanTransitionTo(to) {
+		errs.Add("status", validation.InvalidValue(fmt.Sprintf("Can't transition from %s to %s.", inv.Status, to)))
+		return errs
+	}
+	now = now.UTC()
+	switch to {
+	case StatusOpen:
+		inv.FinalizedAt = now
+	case StatusPaid:
+		inv.PaidAt = now
+	case StatusVoid:
+		inv.VoidedAt = now
+	case StatusUncollectible:
+		inv.MarkedUncollectibleAt = now
+	}
+	if to != StatusOpen {
+		// Only open invoices are collected automatically.
+		inv.NextPaymentAttemptAt = time.Time{}
+	}
+	inv.Status = to
+
+	return errs
+}
+
+// Validate validates the invoice.
+func (inv Invoice) Validate() validation.Errors {
+	errs := validation.Errors{}
+	if inv.ID == (ulid.ULID{}) {
+		errs.Add("id", validation.Required("ID is required."))
+	}
+	if inv.Version == 0 {
+		errs.Add("version", validation.Required("Version is required."))
+	}
+	if inv.CustomerID == (ulid.ULID{}) {
+		errs.Add("customer_id", validation.Required("Customer is required."))
+	}
+	if inv.Status == "" {
+		errs.Add("status", validation.Required("Status is required."))
+	}
+	if inv.Currency == "" {
+		errs.Add("currency", validation.Required("Currency is required."))
+	}
+	if inv.CreatedAt.IsZero() {
+		errs.Add("created_at", validation.Required("CreatedAt is required."))
+	}
+
+	if inv.Status != "" && !inv.Status.IsValid() {
+		errs.Add("status", validation.InvalidChoice("Invalid status."))
+	}
+	if !currency.IsValid(inv.Currency) {
+		errs.Add("currency", validation.InvalidChoice("Invalid currency."))
+	}
+	for i, line := range inv.Lines {
+		errs.Merge("lines."+strconv.Itoa(i), line.Validate(inv.Currency))
+	}
+
+	return errs
+}
+
+// Validate validates the line against the invoice currency.
+func (l Line) Validate(currencyCode string) validation.Errors {
+	errs := validation.Errors{}
+	if l.ID == (ulid.ULID{}) {
+		errs.Add("id", validation.Required("ID is required."))
+	}
+	if l.Type == "" {
+		errs.Add("type", validation.Required("Type is required."))
+	}
+	if l.Description == "" {
+		errs.Add("description", validation.Required("Description is required."))
+	}
+	if l.Amount.CurrencyCode() == "" {
+		errs.Add("amount", validation.Required("Amount is required."))
+	}
+
+	if l.Amount.CurrencyCode() != "" && l.Amount.CurrencyCode() != currencyCode {
+		errs.Add("amount", validation.InvalidValue("Amount currency must match the invoice currency."))
+	}
+	if l.Type == LineDiscount && l.Amount.CurrencyCode() != "" && l.Amount.IsPositive() {
+		errs.Add("amount", validation.InvalidValue("Discount amount can't be positive."))
+	}
+	if l.Quantity < 0 {
+		errs.Add("quantity", validation.InvalidValue("Quantity can't be negative."))
+	}
+	if l.TaxRate != "" {
+		rate, err := strconv.ParseFloat(l.TaxRate, 64)
+		if err != nil || rate < 0 || rate > 100 {
+			errs.Add("tax_rate", validation.InvalidValue("Tax rate must be a percentage between 0 and 100."))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,414 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package invoice_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bojanz/currency"
+
+	"github.com/runbilliam/billiam/internal/catalog"
+	"github.com/runbilliam/billiam/internal/coupon"
+	"github.com/runbilliam/billiam/internal/invoice"
+	"github.com/runbilliam/billiam/internal/meter"
+	"github.com/runbilliam/billiam/internal/period"
+	"github.com/runbilliam/billiam/internal/subscription"
+	"github.com/runbilliam/billiam/internal/testutil"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+func TestNewLine(t *testing.T) {
+	tests := []struct {
+		unitAmount string
+		currency   string
+		quantity   int64
+		want       string
+	}{
+		{"9.99", "USD", 3, "29.97"},
+		{"0.3333", "USD", 3, "1.00"},
+		{"0.125", "EUR", 1, "0.13"},
+		{"1.5", "JPY", 3, "5"},
+		{"1.0005", "KWD", 1, "1.001"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			unitAmount, _ := currency.NewAmount(tt.unitAmount, tt.currency)
+			line, err := invoice.NewLine(invoice.LineAdjustment, "Test", unitAmount, tt.quantity)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line.Amount.Number() != tt.want {
+				t.Errorf("got %v, want %v", line.Amount.Number(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPriceLine(t *testing.T) {
+	price := catalog.NewPrice(testutil.NewID())
+	price.Model = catalog.ModelGraduated
+	price.Amount = mustAmount(t, "0", "EUR")
+	price.Tiers = []catalog.Tier{
+		{UpTo: 100, UnitAmount: mustAmount(t, "1.00", "EUR"), FlatAmount: mustAmount(t, "0", "EUR")},
+		{UpTo: 1000, UnitAmount: mustAmount(t, "0.80", "EUR"), FlatAmount: mustAmount(t, "5", "EUR")},
+		{UnitAmount: mustAmount(t, "0.4999", "EUR"), FlatAmount: mustAmount(t, "0", "EUR")},
+	}
+
+	line, err := invoice.NewPriceLine(invoice.LineUsage, "Test", price, 1500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line.PriceID != price.ID {
+		t.Errorf("got %v, want %v", line.PriceID, price.ID)
+	}
+	if line.Quantity != 1500 {
+		t.Errorf("got %v, want 1500", line.Quantity)
+	}
+	// 100 + 720 + 5 + 249.95, with the unit amount being the average.
+	assertAmount(t, line.Amount, "1074.95")
+	assertAmount(t, line.UnitAmount, "0.716633")
+	if len(line.Breakdown) != 3 {
+		t.Errorf("got %v tiers, want 3", len(line.Breakdown))
+	}
+
+	// Flat prices have no breakdown.
+	price = catalog.NewPrice(testutil.NewID())
+	price.Amount = mustAmount(t, "9.99", "USD")
+	line, err = invoice.NewPriceLine(invoice.LineSubscription, "Test", price, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAmount(t, line.Amount, "29.97")
+	assertAmount(t, line.UnitAmount, "9.99")
+	if len(line.Breakdown) != 0 {
+		t.Errorf("got %v tiers, want 0", len(line.Breakdown))
+	}
+}
+
+func TestInvoice_Recalculate(t *testing.T) {
+	inv := invoice.New(testutil.NewID(), "EUR")
+	addLine(t, &inv, invoice.LineSubscription, "10.00", "20")
+	addLine(t, &inv, invoice.LineSubscription, "0.05", "20")
+	addLine(t, &inv, invoice.LineAdjustment, "3.33", "7")
+	addLine(t, &inv, invoice.LineAdjustment, "1.00", "")
+	addLine(t, &inv, invoice.LineDiscount, "-2.00", "20")
+
+	assertAmount(t, inv.Subtotal, "14.38")
+	assertAmount(t, inv.DiscountTotal, "2.00")
+	// 20% of 8.05 is 1.61, 7% of 3.33 is 0.2331, rounded to 0.23.
+	assertAmount(t, inv.TaxTotal, "1.84")
+	assertAmount(t, inv.Total, "14.22")
+	assertAmount(t, inv.AmountDue, "14.22")
+	if len(inv.Taxes) != 2 {
+		t.Fatalf("got %v taxes, want 2", len(inv.Taxes))
+	}
+	if inv.Taxes[0].Rate != "20" || inv.Taxes[1].Rate != "7" {
+		t.Errorf("got rates %v, %v, want 20, 7", inv.Taxes[0].Rate, inv.Taxes[1].Rate)
+	}
+	assertAmount(t, inv.Taxes[0].TaxableAmount, "8.05")
+	assertAmount(t, inv.Taxes[0].Amount, "1.61")
+	assertAmount(t, inv.Taxes[1].Amount, "0.23")
+
+	// Taxes are rounded once per rate, not per line.
+	inv = invoice.New(testutil.NewID(), "EUR")
+	addLine(t, &inv, invoice.LineSubscription, "0.02", "20")
+	addLine(t, &inv, invoice.LineSubscription, "0.02", "20")
+	addLine(t, &inv, invoice.LineSubscription, "0.02", "20")
+	assertAmount(t, inv.TaxTotal, "0.01")
+	assertAmount(t, inv.Total, "0.07")
+
+	// A discount larger than the taxable amount doesn't produce negative taxes.
+	inv = invoice.New(testutil.NewID(), "EUR")
+	addLine(t, &inv, invoice.LineSubscription, "5.00", "20")
+	addLine(t, &inv, invoice.LineDiscount, "-6.00", "20")
+	assertAmount(t, inv.TaxTotal, "0.00")
+	assertAmount(t, inv.Total, "-1.00")
+	// Nothing is due for negative totals.
+	assertAmount(t, inv.AmountDue, "0")
+
+	// Lines in a different currency are rejected.
+	usd, _ := currency.NewAmount("1", "USD")
+	line, _ := invoice.NewLine(invoice.LineAdjustment, "Test", usd, 1)
+	if err := inv.AddLine(line); err == nil {
+		t.Error("expected a currency mismatch error")
+	}
+}
+
+func TestInvoice_Finalized(t *testing.T) {
+	inv := invoice.New(testutil.NewID(), "EUR")
+	addLine(t, &inv, invoice.LineSubscription, "10.00", "")
+	now := time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC)
+	if errs := inv.TransitionTo(invoice.StatusOpen, now); !errs.IsEmpty() {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if !inv.IsFinalized() {
+		t.Error("expected the invoice to be finalized")
+	}
+	if !inv.FinalizedAt.Equal(now) {
+		t.Errorf("got %v, want %v", inv.FinalizedAt, now)
+	}
+
+	amount, _ := currency.NewAmount("1", "EUR")
+	line, _ := invoice.NewLine(invoice.LineAdjustment, "Test", amount, 1)
+	if err := inv.AddLine(line); err != invoice.ErrFinalized {
+		t.Errorf("got %v, want %v", err, invoice.ErrFinalized)
+	}
+	if err := inv.Recalculate(); err != invoice.ErrFinalized {
+		t.Errorf("got %v, want %v", err, invoice.ErrFinalized)
+	}
+}
+
+func TestInvoice_ApplyCredit(t *testing.T) {
+	tests := []struct {
+		available   string
+		wantApplied string
+		wantDue     string
+	}{
+		{"5.00", "5.00", "7.00"},
+		{"12.00", "12.00", "0.00"},
+		{"20.00", "12.00", "0.00"},
+		{"0", "0", "12.00"},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			inv := invoice.New(testutil.NewID(), "EUR")
+			addLine(t, &inv, invoice.LineSubscription, "12.00", "")
+			applied, err := inv.ApplyCredit(mustAmount(t, tt.available, "EUR"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertAmount(t, applied, tt.wantApplied)
+			assertAmount(t, inv.CreditApplied, tt.wantApplied)
+			assertAmount(t, inv.AmountDue, tt.wantDue)
+			assertAmount(t, inv.Total, "12.00")
+		})
+	}
+
+	// Credit can't be applied to finalized invoices.
+	inv := invoice.New(testutil.NewID(), "EUR")
+	addLine(t, &inv, invoice.LineSubscription, "12.00", "")
+	inv.TransitionTo(invoice.StatusOpen, time.Now())
+	if _, err := inv.ApplyCredit(mustAmount(t, "5.00", "EUR")); err != invoice.ErrFinalized {
+		t.Errorf("got %v, want %v", err, invoice.ErrFinalized)
+	}
+}
+
+func TestInvoice_TransitionTo(t *testing.T) {
+	tests := []struct {
+		from     invoice.Status
+		to       invoice.Status
+		wantCode string
+	}{
+		{invoice.StatusDraft, invoice.StatusOpen, ""},
+		{invoice.StatusDraft, invoice.StatusPaid, validation.CodeInvalidValue},
+		{invoice.StatusOpen, invoice.StatusPaid, ""},
+		{invoice.StatusOpen, invoice.StatusVoid, ""},
+		{invoice.StatusOpen, invoice.StatusUncollectible, ""},
+		{invoice.StatusOpen, invoice.StatusDraft, validation.CodeInvalidValue},
+		{invoice.StatusUncollectible, invoice.StatusPaid, ""},
+		{invoice.StatusPaid, invoice.StatusVoid, validation.CodeInvalidValue},
+		{invoice.StatusVoid, invoice.StatusOpen, validation.CodeInvalidValue},
+		{invoice.StatusOpen, invoice.Status("unknown"), validation.CodeInvalidChoice},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			inv := invoice.New(testutil.NewID(), "EUR")
+			inv.Status = tt.from
+			errs := inv.TransitionTo(tt.to, time.Now())
+			if tt.wantCode == "" {
+				if !errs.IsEmpty() {
+					t.Errorf("%v -> %v: unexpected errors: %v", tt.from, tt.to, errs)
+				}
+				if inv.Status != tt.to {
+					t.Errorf("got %v, want %v", inv.Status, tt.to)
+				}
+				return
+			}
+			err, ok := errs.Get("status").(validation.Error)
+			if !ok || err.Code != tt.wantCode {
+				t.Errorf("%v -> %v: got %#v, want code %v", tt.from, tt.to, errs.Get("status"), tt.wantCode)
+			}
+			if inv.Status != tt.from {
+				t.Errorf("got %v, want %v", inv.Status, tt.from)
+			}
+		})
+	}
+}
+
+func TestInvoice_ApplyCoupon(t *testing.T) {
+	percent := coupon.New()
+	percent.Name = "25% off"
+	percent.Type = coupon.TypePercent
+	percent.PercentOff = "25"
+	fixed := coupon.New()
+	fixed.Name = "12 EUR off"
+	fixed.Type = coupon.TypeFixed
+	fixed.AmountsOff = []currency.Amount{mustAmount(t, "12.00", "EUR")}
+
+	tests := []struct {
+		coupon        coupon.Coupon
+		wantDiscounts []string
+		wantTotal     string
+	}{
+		// 25% of 3.33 is 0.8325, rounded to 0.83.
+		{percent, []string{"-2.50", "-0.83"}, "3.33"},
+		// Fixed amounts are used up rate by rate.
+		{fixed, []string{"-10.00", "-2.00"}, "12.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			inv := invoice.New(testutil.NewID(), "EUR")
+			addLine(t, &inv, invoice.LineSubscription, "10.00", "20")
+			addLine(t, &inv, invoice.LineAdjustment, "3.33", "7")
+			applied, err := inv.ApplyCoupon(tt.coupon)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !applied {
+				t.Fatal("coupon was not applied")
+			}
+			discounts := inv.Lines[2:]
+			if len(discounts) != len(tt.wantDiscounts) {
+				t.Fatalf("got %v discount lines, want %v", len(discounts), len(tt.wantDiscounts))
+			}
+			for i, line := range discounts {
+				if line.Type != invoice.LineDiscount || line.Description != tt.coupon.Name {
+					t.Errorf("unexpected line: %+v", line)
+				}
+				assertAmount(t, line.Amount, tt.wantDiscounts[i])
+			}
+			if discounts[0].TaxRate != "20" || discounts[1].TaxRate != "7" {
+				t.Errorf("got rates %v, %v, want 20, 7", discounts[0].TaxRate, discounts[1].TaxRate)
+			}
+			assertAmount(t, inv.DiscountTotal, tt.wantTotal)
+		})
+	}
+
+	// Fixed coupons without an amount in the invoice currency are skipped.
+	inv := invoice.New(testutil.NewID(), "USD")
+	addLine(t, &inv, invoice.LineSubscription, "10.00", "")
+	applied, err := inv.ApplyCoupon(fixed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied || len(inv.Lines) != 1 {
+		t.Errorf("unexpected discount lines: %+v", inv.Lines)
+	}
+
+	inv.TransitionTo(invoice.StatusOpen, time.Now())
+	if _, err := inv.ApplyCoupon(percent); err != invoice.ErrFinalized {
+		t.Errorf("got %v, want %v", err, invoice.ErrFinalized)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	product := catalog.NewProduct()
+	product.Name = "Pro"
+	price := catalog.NewPrice(product.ID)
+	price.Nickname = "Monthly"
+	price.Amount, _ = currency.NewAmount("19.99", "EUR")
+	price.Interval = catalog.Interval{Unit: catalog.IntervalMonth, Count: 1}
+	sub := subscription.New(testutil.NewID(), price.ID, subscription.StatusActive)
+	p := period.Period{
+		Start: time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2020, 12, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	inv, err := invoice.Build(sub, product, price, p, "19")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := inv.Validate(); !errs.IsEmpty() {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	if inv.Status != invoice.StatusDraft {
+		t.Errorf("got %v, want %v", inv.Status, invoice.StatusDraft)
+	}
+	if inv.SubscriptionID != sub.ID {
+		t.Errorf("got %v, want %v", inv.SubscriptionID, sub.ID)
+	}
+	if !inv.PeriodStart.Equal(p.Start) || !inv.PeriodEnd.Equal(p.End) {
+		t.Errorf("got %v - %v, want %v - %v", inv.PeriodStart, inv.PeriodEnd, p.Start, p.End)
+	}
+	if len(inv.Lines) != 1 {
+		t.Fatalf("got %v lines, want 1", len(inv.Lines))
+	}
+	if inv.Lines[0].Description != "Pro - Monthly" {
+		t.Errorf("got %v, want Pro - Monthly", inv.Lines[0].Description)
+	}
+	if inv.Lines[0].PriceID != price.ID {
+		t.Errorf("got %v, want %v", inv.Lines[0].PriceID, price.ID)
+	}
+	assertAmount(t, inv.TaxTotal, "3.80")
+	assertAmount(t, inv.Total, "23.79")
+}
+
+func TestUsageLine(t *testing.T) {
+	product := catalog.NewProduct()
+	product.Name = "API"
+	m := meter.New()
+	m.Key = "api_calls"
+	m.Name = "API calls"
+	price := catalog.NewPrice(product.ID)
+	price.MeterID = m.ID
+	price.Amount, _ = currency.NewAmount("0.0015", "EUR")
+	usage := period.Period{
+		Start: time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	line, err := invoice.UsageLine(product, price, m, 12345, usage, "19")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line.Type != invoice.LineUsage {
+		t.Errorf("got %v, want %v", line.Type, invoice.LineUsage)
+	}
+	if line.Description != "API (API calls)" {
+		t.Errorf("got %v, want API (API calls)", line.Description)
+	}
+	if line.Quantity != 12345 {
+		t.Errorf("got %v, want 12345", line.Quantity)
+	}
+	if !line.PeriodStart.Equal(usage.Start) || !line.PeriodEnd.Equal(usage.End) {
+		t.Errorf("got %v - %v, want %v - %v", line.PeriodStart, line.PeriodEnd, usage.Start, usage.End)
+	}
+	assertAmount(t, line.Amount, "18.52")
+}
+
+func addLine(t *testing.T, inv *invoice.Invoice, lineType invoice.LineType, amount, taxRate string) {
+	t.Helper()
+	a, err := currency.NewAmount(amount, inv.Currency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, err := invoice.NewLine(lineType, "Test", a, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line.TaxRate = taxRate
+	if err := inv.AddLine(line); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertAmount(t *testing.T, got currency.Amount, want string) {
+	t.Helper()
+	if got.Number() != want {
+		t.Errorf("got %v, want %v", got.Number(), want)
+	}
+}
+
+func mustAmount(t *testing.T, n, currencyCode string) currency.Amount {
+	t.Helper()
+	a, err := currency.NewAmount(n, currencyCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
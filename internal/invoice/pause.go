@@ -0,0 +1,171 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package invoice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/catalog"
+	"github.com/runbilliam/billiam/internal/customer"
+	"github.com/runbilliam/billiam/internal/period"
+	"github.com/runbilliam/billiam/internal/settings"
+	"github.com/runbilliam/billiam/internal/subscription"
+)
+
+// Resume resumes the given paused subscription.
+//
+// The subscription resumes at its scheduled resume time if that has
+// passed, and at the current time otherwise. With ResumeReanchor, the
+// billing cycle is anchored at the time of resumption, and the new
+// period is invoiced right away. If the current period was invoiced
+// before the pause, its unused time is credited on the new invoice,
+// so that the overlap isn't billed twice. With ResumeKeepCycle, the current
+// period is left as is, and the next one is invoiced by the Generator.
+// An empty behavior uses the one chosen when pausing.
+//
+// Returns the invoice, if one was generated.
+func Resume(ctx context.Context, tx pgx.Tx, sub *subscription.Subscription, behavior subscription.ResumeBehavior, now time.Time) (Invoice, error) {
+	if behavior == "" {
+		behavior = sub.ResumeBehavior
+	}
+	at := now
+	if sub.ResumeIsDue(now) {
+		at = sub.ResumesAt
+	}
+	if errs := sub.Resume(now); !errs.IsEmpty() {
+		return Invoice{}, fmt.Errorf("resume subscription %v: %v", sub.ID, errs)
+	}
+	if behavior != subscription.ResumeReanchor {
+		return Invoice{}, subscription.NewRepository(tx).Update(ctx, sub)
+	}
+
+	catalogRepo := catalog.NewRepository(tx)
+	price, err := catalogRepo.GetPrice(ctx, sub.PriceID)
+	if err != nil {
+		return Invoice{}, err
+	}
+	product, err := catalogRepo.GetProduct(ctx, price.ProductID)
+	if err != nil {
+		return Invoice{}, err
+	}
+	cust, err := customer.NewRepository(tx).Get(ctx, sub.CustomerID)
+	if err != nil {
+		return Invoice{}, err
+	}
+	st, err := settings.NewStore(tx).Get(ctx)
+	if err != nil && !errors.Is(err, settings.ErrNotFound) {
+		return Invoice{}, err
+	}
+	loc, err := period.Location(cust.Timezone, st.Timezone)
+	if err != nil {
+		return Invoice{}, err
+	}
+	credit, err := unusedTime(ctx, tx, *sub, product, price, at, st.TaxRate)
+	if err != nil {
+		return Invoice{}, err
+	}
+	sub.SetSchedule(period.NewSchedule(at, price.Interval, loc), at)
+	if err := subscription.NewRepository(tx).Update(ctx, sub); err != nil {
+		return Invoice{}, err
+	}
+
+	p := period.Period{Start: sub.CurrentPeriodStart, End: sub.CurrentPeriodEnd}
+	inv, err := draft(ctx, tx, *sub, product, price, p, period.Period{}, st.TaxRate)
+	if err != nil {
+		return Invoice{}, err
+	}
+	// The credit is added after the coupon was applied, since it
+	// is already discounted.
+	for _, line := range credit {
+		if err := inv.AddLine(line); err != nil {
+			return Invoice{}, err
+		}
+	}
+	if err := Finalize(ctx, tx, &inv, now); err != nil {
+		return Invoice{}, err
+	}
+	if err := creditProration(ctx, tx, inv); err != nil {
+		return Invoice{}, err
+	}
+
+	return inv, nil
+}
+
+// unusedTime returns the line crediting the unused time of the
+// subscription's current period from the given time, if that period
+// was invoiced and the invoice is paid or still being collected.
+//
+// The credit is based on what the invoice charged for the
+// subscription, including its discounts, see InvoicedAmount.
+func unusedTime(ctx context.Context, tx pgx.Tx, sub subscription.Subscription, product catalog.Product, price catalog.Price, at time.Time, taxRate string) ([]Line, error) {
+	p := period.Period{Start: sub.CurrentPeriodStart, End: sub.CurrentPeriodEnd}
+	if at.Before(p.Start) {
+		at = p.Start
+	}
+	total := int64(p.End.Sub(p.Start) / time.Second)
+	remaining := int64(p.End.Sub(at) / time.Second)
+	if total <= 0 || remaining <= 0 {
+		return nil, nil
+	}
+	inv, err := currentInvoice(ctx, tx, sub)
+	if err != nil || inv.ID == (ulid.ULID{}) {
+		return nil, err
+	}
+	if inv.Status != StatusOpen && inv.Status != StatusPaid {
+		return nil, nil
+	}
+	amount, ok, err := InvoicedAmount(inv, sub.PriceID, sub.Quantity)
+	if err != nil || !ok {
+		return nil, err
+	}
+	item := ProrationItem{
+		Description: describePrice(product, price),
+		Price:       price,
+		Quantity:    sub.Quantity,
+		Amount:      amount,
+	}
+	line, err := prorationLine("Unused time on "+item.Description, item, remaining, total, true)
+	if err != nil || line.Amount.IsZero() {
+		return nil, err
+	}
+	line.TaxRate = taxRate
+	line.PeriodStart = at.UTC()
+	line.PeriodEnd = p.End.UTC()
+
+	return []Line{line}, nil
+}
+
+// settlePaused settles an invoice generated for a paused
+// subscription, according to the given pause behavior.
+//
+// Finalized invoices consume a number even though they are
+// voided, keeping the invoice sequence gapless. The customer's
+// balance is left untouched, since nothing will be collected.
+func settlePaused(ctx context.Context, tx pgx.Tx, inv *Invoice, behavior subscription.PauseBehavior, now time.Time) error {
+	if behavior == subscription.PauseKeepAsDraft {
+		return nil
+	}
+	if err := finalize(ctx, tx, inv, false, now); err != nil {
+		return err
+	}
+	// Invoices with nothing to pay are already paid.
+	if inv.Status != StatusOpen {
+		return nil
+	}
+	to := StatusVoid
+	if behavior == subscription.PauseMarkUncollectible {
+		to = StatusUncollectible
+	}
+	if errs := inv.TransitionTo(to, now); !errs.IsEmpty() {
+		return fmt.Errorf("settle invoice %v: %v", inv.ID, errs)
+	}
+
+	return NewRepository(tx).Update(ctx, inv)
+}
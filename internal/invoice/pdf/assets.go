@@ -0,0 +1,193 @@
+// Code generated by vfsgen; DO NOT EDIT.
+
+// +build !dev
+
+package pdf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	pathpkg "path"
+	"time"
+)
+
+// Assets are invoice PDF assets, embedded by vfsgen.
+var Assets = func() http.FileSystem {
+	fs := vfsgen۰FS{
+		"/": &vfsgen۰DirInfo{
+			name:    "/",
+			modTime: time.Date(2026, 10, 17, 5, 27, 36, 724712232, time.UTC),
+		},
+		"/templates": &vfsgen۰DirInfo{
+			name:    "templates",
+			modTime: time.Date(2026, 10, 17, 5, 27, 36, 724712232, time.UTC),
+		},
+		"/templates/invoice.pdf.hbs": &vfsgen۰CompressedFileInfo{
+			name:             "invoice.pdf.hbs",
+			modTime:          time.Date(2026, 10, 17, 6, 17, 5, 217959623, time.UTC),
+			uncompressedSize: 1271,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x7c\x52\xc1\x8e\xd3\x30\x10\xbd\xf7\x2b\x46\xaa\x90\x40\x42\xa5\x45\x2c\x54\x51\x55\x69\x61\xf7\x80\xb4\x07\x28\xcb\xb9\x72\x93\x69\x3b\x92\x63\x07\x7b\x42\xb7\x4c\xfc\xef\xc8\x4e\xd2\xb4\x74\x97\x5b\xe2\x79\xf3\xde\x9b\x37\x33\x86\xc7\x3d\x02\x99\xdf\x96\x72\x04\xad\x8e\xb6\xe6\x09\xdc\xab\x7c\x0f\x9a\x0c\x02\x79\x50\xdd\x33\xe4\xb6\x2c\x95\x29\xb2\xd1\x18\x00\xb6\xd6\x30\x2c\x1c\xee\x6a\xad\x5c\xb3\xb1\xba\x68\x88\x95\xa6\x7c\x09\x0b\x4f\x7f\x70\x99\x50\x8c\x4f\x0c\x8b\x87\xe6\x4b\xb3\x5a\xc2\x22\xb7\x86\xd1\x70\x5b\x72\xf6\x00\x8b\x03\x15\xbc\x5f\x66\x57\x08\x68\xfe\x5b\x9b\x4c\x26\x89\x23\x5a\x4c\x1f\xbe\x52\x39\xc2\xa2\x2c\x23\xf7\xca\x1e\x20\x35\x7b\x50\x0e\xa1\x42\x97\xa3\x61\xb5\x43\x0f\x76\x0b\xbc\x47\xa8\xd4\x0e\x5b\xc8\x5b\x38\x10\xef\xe3\x74\xa5\x72\x3b\x32\x3e\x12\xdf\x97\x15\x1f\x13\xb9\x07\x65\x8a\xee\xcb\xb3\x72\x4c\x66\x97\x3a\x60\x9c\xb8\x69\x67\xac\xc3\x62\x32\x4a\x71\xc4\x14\x60\x36\x1f\xa5\xa9\x1f\x40\xc4\x13\xe3\xda\xa8\x12\x43\x18\xb5\x16\x67\xd3\x73\xe8\x87\x1e\xfa\xb5\x5b\x80\x88\xa9\xcb\x0d\xba\x10\x5a\x58\x97\x6f\x6c\xeb\x90\x77\x8a\x31\x03\x91\x42\x71\x64\x15\x19\xd3\x36\x8e\x48\xb6\x08\xa1\x07\x7d\x4b\xff\x11\x76\xaa\x88\xbc\xa3\xed\xc9\xc6\xc7\x73\x17\x27\xee\xcf\xa4\x35\xb0\xbd\x92\x16\x19\x63\xbc\x88\x0d\x69\xbd\x66\x3b\xe8\x88\xf0\x9e\x7c\xcb\x1e\x11\x2f\x8c\x39\x1d\xc5\x65\xdf\xdc\x64\x0f\x70\x87\x3e\x77\x54\x31\x59\x03\x0d\xcc\xa6\xd9\x0a\xbe\xf3\x31\x7e\x7e\xca\x56\xf0\xd3\x10\x43\xe5\x62\x12\x0d\xcc\xe6\xd9\x0a\x6e\x4b\x5b\x1b\x1e\xa5\x45\xbf\x64\x2b\xad\x27\x84\x41\x44\xa4\x18\x64\x42\xe8\x85\x44\x7e\xd5\xca\x30\xf1\x31\x84\x5e\x51\xa4\x36\xc4\xeb\xa4\x19\x42\xaf\x2a\xa2\x92\xee\x75\xc0\xc9\x43\x7b\xe8\x30\x4f\x8a\xb3\xe9\x34\x49\x5e\x42\x2e\x6c\xb6\xc9\x0f\x19\xa5\x69\x62\xef\xfc\x7d\xb6\x82\x1f\xf5\x86\x2d\x2b\x3d\x88\xfb\xee\xe5\x24\x5f\x90\xcf\xa3\x9f\x75\xff\x7c\x6a\xbe\xeb\x2a\x7e\xe8\xbe\x02\x0f\x06\xda\xbc\x58\x3d\xa1\x3f\x27\x79\x54\x4f\xf0\x5a\xc4\xa5\x93\x7a\xf5\xe6\xd9\x14\x7a\xef\x57\x6b\x6d\x19\x2e\x07\xb8\x74\x9f\x3b\x2c\x88\xd7\xaa\xaa\x34\xe1\x73\x09\x9d\x68\x6e\x5b\x08\x6c\x94\x56\x66\x38\x02\x91\xe7\x29\xae\x6c\xb4\xd7\x02\x45\x8d\xff\xce\xb0\x2e\x6a\x3c\x8b\xe2\xef\x00\x4c\xac\xc1\x3f\xf7\x04\x00\x00"),
+		},
+	}
+	fs["/"].(*vfsgen۰DirInfo).entries = []os.FileInfo{
+		fs["/templates"].(os.FileInfo),
+	}
+	fs["/templates"].(*vfsgen۰DirInfo).entries = []os.FileInfo{
+		fs["/templates/invoice.pdf.hbs"].(os.FileInfo),
+	}
+
+	return fs
+}()
+
+type vfsgen۰FS map[string]interface{}
+
+func (fs vfsgen۰FS) Open(path string) (http.File, error) {
+	path = pathpkg.Clean("/" + path)
+	f, ok := fs[path]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+	}
+
+	switch f := f.(type) {
+	case *vfsgen۰CompressedFileInfo:
+		gr, err := gzip.NewReader(bytes.NewReader(f.compressedContent))
+		if err != nil {
+			// This should never happen because we generate the gzip bytes such that they are always valid.
+			panic("unexpected error reading own gzip compressed bytes: " + err.Error())
+		}
+		return &vfsgen۰CompressedFile{
+			vfsgen۰CompressedFileInfo: f,
+			gr:                        gr,
+		}, nil
+	case *vfsgen۰DirInfo:
+		return &vfsgen۰Dir{
+			vfsgen۰DirInfo: f,
+		}, nil
+	default:
+		// This should never happen because we generate only the above types.
+		panic(fmt.Sprintf("unexpected type %T", f))
+	}
+}
+
+// vfsgen۰CompressedFileInfo is a static definition of a gzip compressed file.
+type vfsgen۰CompressedFileInfo struct {
+	name              string
+	modTime           time.Time
+	compressedContent []byte
+	uncompressedSize  int64
+}
+
+func (f *vfsgen۰CompressedFileInfo) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, fmt.Errorf("cannot Readdir from file %s", f.name)
+}
+func (f *vfsgen۰CompressedFileInfo) Stat() (os.FileInfo, error) { return f, nil }
+
+func (f *vfsgen۰CompressedFileInfo) GzipBytes() []byte {
+	return f.compressedContent
+}
+
+func (f *vfsgen۰CompressedFileInfo) Name() string       { return f.name }
+func (f *vfsgen۰CompressedFileInfo) Size() int64        { return f.uncompressedSize }
+func (f *vfsgen۰CompressedFileInfo) Mode() os.FileMode  { return 0444 }
+func (f *vfsgen۰CompressedFileInfo) ModTime() time.Time { return f.modTime }
+func (f *vfsgen۰CompressedFileInfo) IsDir() bool        { return false }
+func (f *vfsgen۰CompressedFileInfo) Sys() interface{}   { return nil }
+
+// vfsgen۰CompressedFile is an opened compressedFile instance.
+type vfsgen۰CompressedFile struct {
+	*vfsgen۰CompressedFileInfo
+	gr      *gzip.Reader
+	grPos   int64 // Actual gr uncompressed position.
+	seekPos int64 // Seek uncompressed position.
+}
+
+func (f *vfsgen۰CompressedFile) Read(p []byte) (n int, err error) {
+	if f.grPos > f.seekPos {
+		// Rewind to beginning.
+		err = f.gr.Reset(bytes.NewReader(f.compressedContent))
+		if err != nil {
+			return 0, err
+		}
+		f.grPos = 0
+	}
+	if f.grPos < f.seekPos {
+		// Fast-forward.
+		_, err = io.CopyN(ioutil.Discard, f.gr, f.seekPos-f.grPos)
+		if err != nil {
+			return 0, err
+		}
+		f.grPos = f.seekPos
+	}
+	n, err = f.gr.Read(p)
+	f.grPos += int64(n)
+	f.seekPos = f.grPos
+	return n, err
+}
+func (f *vfsgen۰CompressedFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		f.seekPos = 0 + offset
+	case io.SeekCurrent:
+		f.seekPos += offset
+	case io.SeekEnd:
+		f.seekPos = f.uncompressedSize + offset
+	default:
+		panic(fmt.Errorf("invalid whence value: %v", whence))
+	}
+	return f.seekPos, nil
+}
+func (f *vfsgen۰CompressedFile) Close() error {
+	return f.gr.Close()
+}
+
+// vfsgen۰DirInfo is a static definition of a directory.
+type vfsgen۰DirInfo struct {
+	name    string
+	modTime time.Time
+	entries []os.FileInfo
+}
+
+func (d *vfsgen۰DirInfo) Read([]byte) (int, error) {
+	return 0, fmt.Errorf("cannot Read from directory %s", d.name)
+}
+func (d *vfsgen۰DirInfo) Close() error               { return nil }
+func (d *vfsgen۰DirInfo) Stat() (os.FileInfo, error) { return d, nil }
+
+func (d *vfsgen۰DirInfo) Name() string       { return d.name }
+func (d *vfsgen۰DirInfo) Size() int64        { return 0 }
+func (d *vfsgen۰DirInfo) Mode() os.FileMode  { return 0755 | os.ModeDir }
+func (d *vfsgen۰DirInfo) ModTime() time.Time { return d.modTime }
+func (d *vfsgen۰DirInfo) IsDir() bool        { return true }
+func (d *vfsgen۰DirInfo) Sys() interface{}   { return nil }
+
+// vfsgen۰Dir is an opened dir instance.
+type vfsgen۰Dir struct {
+	*vfsgen۰DirInfo
+	pos int // Position within entries for Seek and Readdir.
+}
+
+func (d *vfsgen۰Dir) Seek(offset int64, whence int) (int64, error) {
+	if offset == 0 && whence == io.SeekStart {
+		d.pos = 0
+		return 0, nil
+	}
+	return 0, fmt.Errorf("unsupported Seek in directory %s", d.name)
+}
+
+func (d *vfsgen۰Dir) Readdir(count int) ([]os.FileInfo, error) {
+	if d.pos >= len(d.entries) && count > 0 {
+		return nil, io.EOF
+	}
+	if count <= 0 || count > len(d.entries)-d.pos {
+		count = len(d.entries) - d.pos
+	}
+	e := d.entries[d.pos : d.pos+count]
+	d.pos += count
+	return e, nil
+}
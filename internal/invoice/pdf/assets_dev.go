@@ -0,0 +1,11 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// +build dev
+
+package pdf
+
+import "net/http"
+
+// Assets are invoice PDF assets, read from disk.
+var Assets http.FileSystem = http.Dir("internal/invoice/pdf/assets")
@@ -0,0 +1,390 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package pdf renders invoices as PDF documents.
+//
+// The layout is defined by the "invoice.pdf.hbs" handlebars template,
+// which renders a list of layout commands, one per line:
+//   font <regular|bold|italic> <size>
+//   text <L|C|R> <content>
+//   row <width>:<L|C|R> <content> | <width>:<L|C|R> <content> | ...
+//   line
+//   space <mm>
+// Row widths are percentages of the page width, without margins.
+// Empty lines and lines starting with # are ignored.
+//
+// The embedded template can be overridden by placing a template with
+// the same name in the "templates" directory of the site.
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aymerick/raymond"
+	"github.com/bojanz/currency"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/shurcooL/httpfs/vfsutil"
+
+	"github.com/runbilliam/billiam/internal/customer"
+	"github.com/runbilliam/billiam/internal/invoice"
+	"github.com/runbilliam/billiam/internal/period"
+	"github.com/runbilliam/billiam/internal/settings"
+)
+
+// TemplateName is the name of the invoice layout template.
+const TemplateName = "invoice.pdf.hbs"
+
+// ErrNotFinalized is returned when attempting to render a draft invoice.
+var ErrNotFinalized = errors.New("pdf: invoice is not finalized")
+
+// letterCountries are the countries using the Letter paper size.
+var letterCountries = []string{"BZ", "CA", "CL", "CO", "CR", "GT", "MX", "PA", "PH", "PR", "SV", "US", "VE"}
+
+const (
+	margin     = 20.0
+	fontFamily = "Helvetica"
+)
+
+// Renderer renders invoices as PDF documents.
+type Renderer struct {
+	siteDir string
+}
+
+// NewRenderer creates a new renderer.
+//
+// Templates found in the "templates" directory of the given site
+// directory override the embedded ones.
+func NewRenderer(siteDir string) *Renderer {
+	return &Renderer{siteDir: siteDir}
+}
+
+// Render renders the given finalized invoice to w.
+//
+// The paper size is Letter for customers in North America (and other
+// countries using it), A4 otherwise. Amounts are formatted using the
+// customer's locale. Text is encoded as Windows-1252, characters
+// outside of it are replaced.
+func (r *Renderer) Render(w io.Writer, inv invoice.Invoice, cust customer.Customer, st settings.Settings) error {
+	if !inv.IsFinalized() {
+		return ErrNotFinalized
+	}
+	tpl, err := r.template()
+	if err != nil {
+		return err
+	}
+	data, err := newData(inv, cust, st)
+	if err != nil {
+		return err
+	}
+	result, err := tpl.Exec(data)
+	if err != nil {
+		return fmt.Errorf("pdf: %v: %w", TemplateName, err)
+	}
+	commands, err := Parse(result)
+	if err != nil {
+		return fmt.Errorf("pdf: %v: %w", TemplateName, err)
+	}
+
+	doc := gofpdf.New("P", "mm", PaperSize(cust.Address.CountryCode), "")
+	doc.SetCreationDate(inv.FinalizedAt)
+	doc.SetTitle("Invoice "+inv.Number, true)
+	doc.SetAuthor(st.SiteName, true)
+	doc.SetMargins(margin, margin, margin)
+	doc.SetAutoPageBreak(true, margin)
+	doc.AddPage()
+	draw(doc, commands)
+	if err := doc.Error(); err != nil {
+		return fmt.Errorf("pdf: %w", err)
+	}
+
+	return doc.Output(w)
+}
+
+// template loads and parses the layout template.
+func (r *Renderer) template() (*raymond.Template, error) {
+	var b []byte
+	var err error
+	override := filepath.Join(r.siteDir, "templates", TemplateName)
+	if _, statErr := os.Stat(override); r.siteDir != "" && statErr == nil {
+		b, err = ioutil.ReadFile(override)
+	} else {
+		b, err = vfsutil.ReadFile(Assets, "templates/"+TemplateName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("pdf: %w", err)
+	}
+	tpl, err := raymond.Parse(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("pdf: %v: %w", TemplateName, err)
+	}
+
+	return tpl, nil
+}
+
+// PaperSize returns the paper size used for the given country.
+func PaperSize(countryCode string) string {
+	for _, c := range letterCountries {
+		if c == countryCode {
+			return "Letter"
+		}
+	}
+	return "A4"
+}
+
+// Command represents a layout command.
+type Command struct {
+	Name string
+	// Args holds the command arguments, e.g. the font style and size.
+	Args []string
+	// Text holds the text of "text" commands.
+	Text  string
+	Cells []Cell
+}
+
+// Cell represents a row cell.
+type Cell struct {
+	// Width is a percentage of the page width, without margins.
+	Width float64
+	Align string
+	Text  string
+}
+
+// Parse parses the given layout into commands.
+//
+// Template output is HTML escaped by handlebars, so the text is unescaped.
+func Parse(layout string) ([]Command, error) {
+	var commands []Command
+	for i, line := range strings.Split(layout, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, rest := split(line)
+		cmd := Command{Name: name}
+		var err error
+		switch name {
+		case "font":
+			cmd.Args = strings.Fields(rest)
+			if len(cmd.Args) != 2 || !isStyle(cmd.Args[0]) {
+				err = errors.New("expected a style and a size")
+			} else if _, parseErr := strconv.ParseFloat(cmd.Args[1], 64); parseErr != nil {
+				err = errors.New("invalid font size")
+			}
+		case "text":
+			align, text := split(rest)
+			if !isAlign(align) {
+				err = errors.New("invalid alignment")
+			}
+			cmd.Args = []string{align}
+			cmd.Text = html.UnescapeString(text)
+		case "row":
+			for _, c := range strings.Split(rest, "|") {
+				spec, text := split(strings.TrimSpace(c))
+				parts := strings.SplitN(spec, ":", 2)
+				if len(parts) != 2 || !isAlign(parts[1]) {
+					err = errors.New("invalid cell, expected <width>:<L|C|R> <content>")
+					break
+				}
+				width, parseErr := strconv.ParseFloat(parts[0], 64)
+				if parseErr != nil || width <= 0 {
+					err = errors.New("invalid cell width")
+					break
+				}
+				cmd.Cells = append(cmd.Cells, Cell{Width: width, Align: parts[1], Text: html.UnescapeString(text)})
+			}
+		case "line":
+		case "space":
+			cmd.Args = []string{rest}
+			if _, parseErr := strconv.ParseFloat(rest, 64); parseErr != nil {
+				err = errors.New("invalid space")
+			}
+		default:
+			err = fmt.Errorf("unknown command %q", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		commands = append(commands, cmd)
+	}
+
+	return commands, nil
+}
+
+// draw draws the given commands.
+func draw(doc *gofpdf.Fpdf, commands []Command) {
+	tr := doc.UnicodeTranslatorFromDescriptor("")
+	pageWidth, _ := doc.GetPageSize()
+	width := pageWidth - 2*margin
+	fontSize := 10.0
+	doc.SetFont(fontFamily, "", fontSize)
+	lineHeight := func() float64 {
+		// Points to millimeters, with 40% leading.
+		return fontSize * 0.3528 * 1.4
+	}
+
+	for _, cmd := range commands {
+		switch cmd.Name {
+		case "font":
+			fontSize, _ = strconv.ParseFloat(cmd.Args[1], 64)
+			doc.SetFont(fontFamily, styles[cmd.Args[0]], fontSize)
+		case "text":
+			doc.MultiCell(width, lineHeight(), tr(cmd.Text), "", cmd.Args[0], false)
+		case "row":
+			for _, cell := range cmd.Cells {
+				doc.CellFormat(width*cell.Width/100, lineHeight(), tr(cell.Text), "", 0, cell.Align, false, 0, "")
+			}
+			doc.Ln(lineHeight())
+		case "line":
+			y := doc.GetY() + 1
+			doc.Line(margin, y, margin+width, y)
+			doc.SetY(y + 1)
+		case "space":
+			space, _ := strconv.ParseFloat(cmd.Args[0], 64)
+			doc.Ln(space)
+		}
+	}
+}
+
+// styles maps font styles to gofpdf styles.
+var styles = map[string]string{
+	"regular": "",
+	"bold":    "B",
+	"italic":  "I",
+}
+
+func isStyle(s string) bool {
+	_, ok := styles[s]
+	return ok
+}
+
+func isAlign(s string) bool {
+	return s == "L" || s == "C" || s == "R"
+}
+
+// split splits s into its first word and the rest.
+func split(s string) (string, string) {
+	parts := strings.SplitN(s, " ", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], strings.TrimSpace(parts[1])
+}
+
+// newData prepares the template data.
+//
+// All values are single line strings without the "|" row separator,
+// so that they can't break the layout.
+func newData(inv invoice.Invoice, cust customer.Customer, st settings.Settings) (map[string]interface{}, error) {
+	// Dates are shown in the timezone used for billing the customer.
+	loc, err := period.Location(cust.Timezone, st.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	localeID := cust.Locale
+	if localeID == "" {
+		localeID = "en"
+	}
+	formatter := currency.NewFormatter(currency.NewLocale(localeID))
+
+	lines := make([]map[string]interface{}, 0, len(inv.Lines))
+	for _, line := range inv.Lines {
+		lines = append(lines, map[string]interface{}{
+			"description": clean(line.Description),
+			"quantity":    strconv.FormatInt(line.Quantity, 10),
+			"unit_price":  formatter.Format(line.UnitAmount),
+			"amount":      formatter.Format(line.Amount),
+			"period":      formatPeriod(line.PeriodStart, line.PeriodEnd, loc),
+		})
+	}
+	taxes := make([]map[string]interface{}, 0, len(inv.Taxes))
+	for _, tax := range inv.Taxes {
+		taxes = append(taxes, map[string]interface{}{
+			"rate":           clean(tax.Rate),
+			"taxable_amount": formatter.Format(tax.TaxableAmount),
+			"amount":         formatter.Format(tax.Amount),
+		})
+	}
+	discountTotal := ""
+	if !inv.DiscountTotal.IsZero() {
+		negative, err := inv.DiscountTotal.Mul("-1")
+		if err != nil {
+			return nil, err
+		}
+		discountTotal = formatter.Format(negative)
+	}
+
+	creditApplied := ""
+	if !inv.CreditApplied.IsZero() {
+		negative, err := inv.CreditApplied.Mul("-1")
+		if err != nil {
+			return nil, err
+		}
+		creditApplied = formatter.Format(negative)
+	}
+
+	data := map[string]interface{}{
+		"site_name":      clean(st.SiteName),
+		"number":         clean(inv.Number),
+		"status":         string(inv.Status),
+		"date":           inv.FinalizedAt.In(loc).Format("2006-01-02"),
+		"period":         formatPeriod(inv.PeriodStart, inv.PeriodEnd, loc),
+		"bill_to":        billTo(cust),
+		"lines":          lines,
+		"taxes":          taxes,
+		"subtotal":       formatter.Format(inv.Subtotal),
+		"discount_total": discountTotal,
+		"tax_total":      formatter.Format(inv.TaxTotal),
+		"total":          formatter.Format(inv.Total),
+		"credit_applied": creditApplied,
+		"amount_due":     formatter.Format(inv.AmountDue),
+	}
+
+	return data, nil
+}
+
+// billTo returns the non-empty customer name and address lines.
+func billTo(c customer.Customer) []string {
+	a := c.Address
+	locality := strings.TrimSpace(strings.Join([]string{a.PostalCode, a.Locality}, " "))
+	candidates := []string{c.Name, c.Company, a.Line1, a.Line2, locality, a.AdministrativeArea, a.CountryCode}
+	if c.Name == "" && c.Company == "" {
+		candidates = append([]string{c.Email}, candidates...)
+	}
+	lines := make([]string, 0, len(candidates))
+	for _, line := range candidates {
+		if line = clean(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	for _, taxID := range c.TaxIDs {
+		lines = append(lines, clean(taxID.Value))
+	}
+
+	return lines
+}
+
+// formatPeriod formats the given period as dates in the given location.
+//
+// The end is exclusive, so the last day of the period is shown instead.
+func formatPeriod(start, end time.Time, loc *time.Location) string {
+	if start.IsZero() || end.IsZero() {
+		return ""
+	}
+	last := end.In(loc).Add(-time.Nanosecond)
+	return start.In(loc).Format("2006-01-02") + " - " + last.Format("2006-01-02")
+}
+
+// clean converts s to a single line, without the "|" row separator.
+func clean(s string) string {
+	s = strings.NewReplacer("\r", " ", "\n", " ", "|", "/").Replace(s)
+	return strings.TrimSpace(s)
+}
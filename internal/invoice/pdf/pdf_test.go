@@ -0,0 +1,166 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package pdf_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bojanz/currency"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/customer"
+	"github.com/runbilliam/billiam/internal/invoice"
+	"github.com/runbilliam/billiam/internal/invoice/pdf"
+	"github.com/runbilliam/billiam/internal/settings"
+)
+
+func TestParse(t *testing.T) {
+	layout := `
+# Comment.
+font bold 18
+text R Tom &amp; Jerry
+
+row 60:L Description | 40:R 1.234,56 &#8364;
+line
+space 5.5
+`
+	got, err := pdf.Parse(layout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []pdf.Command{
+		{Name: "font", Args: []string{"bold", "18"}},
+		{Name: "text", Args: []string{"R"}, Text: "Tom & Jerry"},
+		{Name: "row", Cells: []pdf.Cell{
+			{Width: 60, Align: "L", Text: "Description"},
+			{Width: 40, Align: "R", Text: "1.234,56 €"},
+		}},
+		{Name: "line"},
+		{Name: "space", Args: []string{"5.5"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []string{
+		"font bold",
+		"font heavy 10",
+		"font bold big",
+		"text X Hello",
+		"row 60 Description",
+		"row 60:X Description",
+		"row -5:L Description",
+		"space a lot",
+		"image logo.png",
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			_, err := pdf.Parse(tt)
+			if err == nil {
+				t.Errorf("%q: expected an error", tt)
+			}
+		})
+	}
+}
+
+func TestPaperSize(t *testing.T) {
+	tests := []struct {
+		countryCode string
+		want        string
+	}{
+		{"US", "Letter"},
+		{"CA", "Letter"},
+		{"DE", "A4"},
+		{"", "A4"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got := pdf.PaperSize(tt.countryCode)
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderer_Render(t *testing.T) {
+	inv, cust, st := newInvoice(t)
+	r := pdf.NewRenderer("")
+
+	var buf bytes.Buffer
+	if err := r.Render(&buf, inv, cust, st); err != pdf.ErrNotFinalized {
+		t.Errorf("got %v, want %v", err, pdf.ErrNotFinalized)
+	}
+
+	inv.TransitionTo(invoice.StatusOpen, time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC))
+	inv.Number = "INV-2020-00001"
+	if err := r.Render(&buf, inv, cust, st); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Errorf("expected a PDF document, got %q", buf.Bytes()[:10])
+	}
+}
+
+func TestRenderer_Override(t *testing.T) {
+	inv, cust, st := newInvoice(t)
+	inv.TransitionTo(invoice.StatusOpen, time.Now())
+	dir, err := ioutil.TempDir("", "billiam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(dir, "templates"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	override := filepath.Join(dir, "templates", pdf.TemplateName)
+	if err := ioutil.WriteFile(override, []byte("unknown {{site_name}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The invalid override is used instead of the embedded template.
+	var buf bytes.Buffer
+	err = pdf.NewRenderer(dir).Render(&buf, inv, cust, st)
+	if err == nil {
+		t.Error("expected an error from the overridden template")
+	}
+}
+
+func newInvoice(t *testing.T) (invoice.Invoice, customer.Customer, settings.Settings) {
+	t.Helper()
+	cust := customer.New()
+	cust.Name = "Jane Doe | ACME"
+	cust.Locale = "de"
+	cust.Timezone = "UTC"
+	cust.Address = customer.Address{
+		Line1:       "Friedrichstraße 123",
+		Locality:    "Berlin",
+		PostalCode:  "10117",
+		CountryCode: "DE",
+	}
+	inv := invoice.New(cust.ID, "EUR")
+	amount, _ := currency.NewAmount("1234.5", "EUR")
+	line, err := invoice.NewLine(invoice.LineSubscription, "Pro\nMonthly", amount, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line.TaxRate = "19"
+	line.PeriodStart = time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC)
+	line.PeriodEnd = time.Date(2020, 12, 1, 0, 0, 0, 0, time.UTC)
+	inv.AddLine(line)
+	inv.SubscriptionID = ulid.MustNew(ulid.Now(), rand.Reader)
+
+	return inv, cust, settings.New()
+}
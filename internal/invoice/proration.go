@@ -0,0 +1,360 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package invoice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bojanz/currency"
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/balance"
+	"github.com/runbilliam/billiam/internal/catalog"
+	"github.com/runbilliam/billiam/internal/period"
+	"github.com/runbilliam/billiam/internal/settings"
+	"github.com/runbilliam/billiam/internal/subscription"
+)
+
+// ProrationBehavior represents how the proration of a subscription change is billed.
+type ProrationBehavior string
+
+// Proration behaviors.
+const (
+	// ProrateInvoiceNow invoices the proration immediately.
+	ProrateInvoiceNow ProrationBehavior = "invoice_now"
+	// ProrateNextInvoice adds the proration to the next invoice.
+	ProrateNextInvoice ProrationBehavior = "next_invoice"
+	// ProrateNone doesn't prorate the change.
+	ProrateNone ProrationBehavior = "none"
+)
+
+// IsValid returns whether b is a known proration behavior.
+func (b ProrationBehavior) IsValid() bool {
+	return b == ProrateInvoiceNow || b == ProrateNextInvoice || b == ProrateNone
+}
+
+// ProrationItem represents what a subscription is charged for
+// during a period, before or after a change.
+type ProrationItem struct {
+	Description string
+	Price       catalog.Price
+	Quantity    int64
+	// Amount is the amount invoiced for the whole period, after
+	// discounts. If empty, it is calculated from the price.
+	Amount currency.Amount
+}
+
+// Prorate returns the proration lines for changing from one item to
+// another at the given time, during the given period.
+//
+// The unused time on the old item is credited, and the remaining time
+// on the new item is charged, both computed to the second. The period
+// boundaries are expected to be in the customer's timezone, as returned
+// by the subscription's schedule. No lines are returned if the change
+// happens at or after the end of the period.
+func Prorate(p period.Period, at time.Time, from, to ProrationItem, taxRate string) ([]Line, error) {
+	if at.Before(p.Start) {
+		at = p.Start
+	}
+	total := int64(p.End.Sub(p.Start) / time.Second)
+	remaining := int64(p.End.Sub(at) / time.Second)
+	if total <= 0 || remaining <= 0 {
+		return nil, nil
+	}
+
+	credit, err := prorationLine("Unused time on "+from.Description, from, remaining, total, true)
+	if err != nil {
+		return nil, err
+	}
+	charge, err := prorationLine("Remaining time on "+to.Description, to, remaining, total, false)
+	if err != nil {
+		return nil, err
+	}
+	lines := []Line{credit, charge}
+	for i := range lines {
+		lines[i].TaxRate = taxRate
+		lines[i].PeriodStart = at.UTC()
+		lines[i].PeriodEnd = p.End.UTC()
+	}
+
+	return lines, nil
+}
+
+// prorationLine creates a proration line for the given fraction of the item's amount.
+func prorationLine(description string, item ProrationItem, remaining, total int64, credit bool) (Line, error) {
+	amount := item.Amount
+	var err error
+	if amount.CurrencyCode() == "" {
+		if amount, _, err = item.Price.Calculate(item.Quantity); err != nil {
+			return Line{}, err
+		}
+	}
+	if amount, err = amount.Mul(strconv.FormatInt(remaining, 10)); err != nil {
+		return Line{}, err
+	}
+	if amount, err = amount.Div(strconv.FormatInt(total, 10)); err != nil {
+		return Line{}, err
+	}
+	unitAmount := amount
+	if item.Quantity > 1 {
+		if unitAmount, err = amount.Div(strconv.FormatInt(item.Quantity, 10)); err != nil {
+			return Line{}, err
+		}
+	}
+	amount = amount.Round()
+	unitAmount = unitAmount.RoundTo(unitAmountDigits, currency.RoundHalfUp)
+	// Credits are negated after rounding, to avoid negative zeros.
+	if credit && !amount.IsZero() {
+		if amount, err = amount.Mul("-1"); err != nil {
+			return Line{}, err
+		}
+		if unitAmount, err = unitAmount.Mul("-1"); err != nil {
+			return Line{}, err
+		}
+	}
+	line, err := NewLine(LineProration, description, unitAmount, 1)
+	if err != nil {
+		return Line{}, err
+	}
+	line.PriceID = item.Price.ID
+	line.Quantity = item.Quantity
+	line.Amount = amount
+
+	return line, nil
+}
+
+// Change represents a change of a subscription's price or quantity.
+type Change struct {
+	Price    catalog.Price
+	Quantity int64
+	// ChangedBy is the ID of the user who made the change, if any.
+	ChangedBy ulid.ULID
+	// ProrationDate is the time used to prorate the change.
+	ProrationDate time.Time
+	Proration     ProrationBehavior
+}
+
+// PreviewChange returns the proration lines for the given subscription change.
+//
+// Only active and past due subscriptions are prorated, since other
+// subscriptions haven't been invoiced for their current period.
+// The unused time is credited based on what the invoice of the current
+// period charged for the subscription, including its discounts, see
+// InvoicedAmount. The list price is used if that invoice can't be found.
+func PreviewChange(ctx context.Context, tx pgx.Tx, sub subscription.Subscription, c Change) ([]Line, error) {
+	if c.Proration == ProrateNone {
+		return nil, nil
+	}
+	if sub.Status != subscription.StatusActive && sub.Status != subscription.StatusPastDue {
+		return nil, nil
+	}
+	catalogRepo := catalog.NewRepository(tx)
+	from, err := prorationItem(ctx, catalogRepo, sub.PriceID, sub.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	inv, err := currentInvoice(ctx, tx, sub)
+	if err != nil {
+		return nil, err
+	}
+	if inv.ID != (ulid.ULID{}) {
+		amount, ok, err := InvoicedAmount(inv, sub.PriceID, sub.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			from.Amount = amount
+		}
+	}
+	to, err := prorationItem(ctx, catalogRepo, c.Price.ID, c.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	st, err := settings.NewStore(tx).Get(ctx)
+	if err != nil && !errors.Is(err, settings.ErrNotFound) {
+		return nil, err
+	}
+	p := period.Period{Start: sub.CurrentPeriodStart, End: sub.CurrentPeriodEnd}
+
+	return Prorate(p, c.ProrationDate, from, to, st.TaxRate)
+}
+
+// InvoicedAmount returns the amount the given invoice charged for the
+// subscription lines with the given price and quantity, before taxes.
+//
+// Discount lines are included in proportion to the subscription lines'
+// share of the discounted amount, per tax rate. Returns false if the
+// invoice has no matching subscription lines.
+func InvoicedAmount(inv Invoice, priceID ulid.ULID, quantity int64) (currency.Amount, bool, error) {
+	matched := make(map[string]currency.Amount)
+	base := make(map[string]currency.Amount)
+	discounts := make(map[string]currency.Amount)
+	for _, line := range inv.Lines {
+		amounts := base
+		if line.Type == LineDiscount {
+			amounts = discounts
+		} else if line.Type == LineSubscription && line.PriceID == priceID && line.Quantity == quantity {
+			if err := addAmount(matched, line.TaxRate, line.Amount); err != nil {
+				return currency.Amount{}, false, err
+			}
+		}
+		if err := addAmount(amounts, line.TaxRate, line.Amount); err != nil {
+			return currency.Amount{}, false, err
+		}
+	}
+	if len(matched) == 0 {
+		return currency.Amount{}, false, nil
+	}
+
+	amount, err := currency.NewAmount("0", inv.Currency)
+	if err != nil {
+		return currency.Amount{}, false, err
+	}
+	for rate, m := range matched {
+		if amount, err = amount.Add(m); err != nil {
+			return currency.Amount{}, false, err
+		}
+		discount, ok := discounts[rate]
+		if !ok || base[rate].IsZero() {
+			continue
+		}
+		if discount, err = discount.Mul(m.Number()); err != nil {
+			return currency.Amount{}, false, err
+		}
+		if discount, err = discount.Div(base[rate].Number()); err != nil {
+			return currency.Amount{}, false, err
+		}
+		if amount, err = amount.Add(discount); err != nil {
+			return currency.Amount{}, false, err
+		}
+	}
+
+	return amount, true, nil
+}
+
+// addAmount adds the amount to the given tax rate's total.
+func addAmount(amounts map[string]currency.Amount, taxRate string, amount currency.Amount) error {
+	total, ok := amounts[taxRate]
+	if !ok {
+		amounts[taxRate] = amount
+		return nil
+	}
+	total, err := total.Add(amount)
+	if err != nil {
+		return err
+	}
+	amounts[taxRate] = total
+
+	return nil
+}
+
+// currentInvoice loads the invoice of the subscription's current
+// period, including its lines. Returns the zero invoice if not found.
+func currentInvoice(ctx context.Context, tx pgx.Tx, sub subscription.Subscription) (Invoice, error) {
+	repo := NewRepository(tx)
+	invoices, err := repo.ListBySubscription(ctx, sub.ID)
+	if err != nil {
+		return Invoice{}, err
+	}
+	for _, inv := range invoices {
+		if inv.IsFinalized() && inv.Status != StatusVoid &&
+			inv.PeriodStart.Equal(sub.CurrentPeriodStart) && inv.PeriodEnd.Equal(sub.CurrentPeriodEnd) {
+			return repo.Get(ctx, inv.ID)
+		}
+	}
+
+	return Invoice{}, nil
+}
+
+// ApplyChange applies the given change to the subscription, billing
+// the proration according to the change's proration behavior.
+//
+// Quantity changes are recorded along with the user who made them.
+// Returns the proration invoice, if one was generated. A proration
+// invoice with a negative total credits the customer's balance.
+func ApplyChange(ctx context.Context, tx pgx.Tx, sub *subscription.Subscription, c Change, now time.Time) (Invoice, error) {
+	lines, err := PreviewChange(ctx, tx, *sub, c)
+	if err != nil {
+		return Invoice{}, err
+	}
+	sub.PriceID = c.Price.ID
+	sub.ChangeQuantity(c.Quantity, c.ChangedBy, now)
+	if errs := sub.Validate(); !errs.IsEmpty() {
+		return Invoice{}, fmt.Errorf("invalid subscription: %v", errs)
+	}
+	if err := subscription.NewRepository(tx).Update(ctx, sub); err != nil {
+		return Invoice{}, err
+	}
+	if len(lines) == 0 {
+		return Invoice{}, nil
+	}
+	if c.Proration == ProrateNextInvoice {
+		return Invoice{}, NewRepository(tx).AddPendingLines(ctx, sub.ID, lines)
+	}
+
+	inv := New(sub.CustomerID, c.Price.Amount.CurrencyCode())
+	inv.SubscriptionID = sub.ID
+	inv.PeriodStart = lines[0].PeriodStart
+	inv.PeriodEnd = lines[0].PeriodEnd
+	for _, line := range lines {
+		if err := inv.AddLine(line); err != nil {
+			return Invoice{}, err
+		}
+	}
+	if errs := inv.Validate(); !errs.IsEmpty() {
+		return Invoice{}, fmt.Errorf("invalid invoice: %v", errs)
+	}
+	if err := NewRepository(tx).Create(ctx, &inv); err != nil {
+		return Invoice{}, err
+	}
+	if err := Finalize(ctx, tx, &inv, now); err != nil {
+		return Invoice{}, err
+	}
+	if err := creditProration(ctx, tx, inv); err != nil {
+		return Invoice{}, err
+	}
+
+	return inv, nil
+}
+
+// creditProration credits the customer's balance with the
+// total of the given finalized invoice, if it is negative.
+func creditProration(ctx context.Context, tx pgx.Tx, inv Invoice) error {
+	if !inv.Total.IsNegative() {
+		return nil
+	}
+	credit, err := inv.Total.Mul("-1")
+	if err != nil {
+		return err
+	}
+	t := balance.NewTransaction(inv.CustomerID, balance.TransactionProration, credit)
+	t.InvoiceID = inv.ID
+	t.Description = "Proration credit for invoice " + inv.Number
+
+	return balance.NewRepository(tx).Create(ctx, t)
+}
+
+// prorationItem loads the given price as a proration item.
+func prorationItem(ctx context.Context, catalogRepo *catalog.Repository, priceID ulid.ULID, quantity int64) (ProrationItem, error) {
+	price, err := catalogRepo.GetPrice(ctx, priceID)
+	if err != nil {
+		return ProrationItem{}, err
+	}
+	product, err := catalogRepo.GetProduct(ctx, price.ProductID)
+	if err != nil {
+		return ProrationItem{}, err
+	}
+	item := ProrationItem{
+		Description: describePrice(product, price),
+		Price:       price,
+		Quantity:    quantity,
+	}
+
+	return item, nil
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package invoice_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/runbilliam/billiam/internal/catalog"
+	"github.com/runbilliam/billiam/internal/invoice"
+	"github.com/runbilliam/billiam/internal/period"
+	"github.com/runbilliam/billiam/internal/testutil"
+)
+
+func TestProrate(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Berlin")
+	// The period spans the DST change on October 25th, making it an hour longer.
+	p := period.Period{
+		Start: time.Date(2020, 10, 1, 0, 0, 0, 0, loc),
+		End:   time.Date(2020, 11, 1, 0, 0, 0, 0, loc),
+	}
+	basic := catalog.NewPrice(testutil.NewID())
+	basic.Amount = mustAmount(t, "31.00", "EUR")
+	pro := catalog.NewPrice(testutil.NewID())
+	pro.Amount = mustAmount(t, "62.00", "EUR")
+	from := invoice.ProrationItem{Description: "Basic", Price: basic, Quantity: 1}
+	to := invoice.ProrationItem{Description: "Pro", Price: pro, Quantity: 1}
+
+	tests := []struct {
+		at         time.Time
+		to         invoice.ProrationItem
+		wantCredit string
+		wantCharge string
+	}{
+		// 1386000 of 2682000 seconds remaining.
+		{time.Date(2020, 10, 16, 0, 0, 0, 0, loc), to, "-16.02", "32.04"},
+		// One second remaining.
+		{p.End.Add(-time.Second), to, "0.00", "0.00"},
+		// Changes before the start of the period are prorated from the start.
+		{p.Start.Add(-time.Hour), to, "-31.00", "62.00"},
+		// 3 seats.
+		{time.Date(2020, 10, 16, 0, 0, 0, 0, loc), invoice.ProrationItem{Description: "Basic", Price: basic, Quantity: 3}, "-16.02", "48.06"},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			lines, err := invoice.Prorate(p, tt.at, from, tt.to, "19")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(lines) != 2 {
+				t.Fatalf("got %v lines, want 2", len(lines))
+			}
+			assertAmount(t, lines[0].Amount, tt.wantCredit)
+			assertAmount(t, lines[1].Amount, tt.wantCharge)
+			for _, line := range lines {
+				if line.Type != invoice.LineProration {
+					t.Errorf("got %v, want %v", line.Type, invoice.LineProration)
+				}
+				if line.TaxRate != "19" {
+					t.Errorf("got %v, want 19", line.TaxRate)
+				}
+				if !line.PeriodEnd.Equal(p.End) {
+					t.Errorf("got %v, want %v", line.PeriodEnd, p.End)
+				}
+			}
+		})
+	}
+
+	lines, err := invoice.Prorate(p, p.End, from, to, "19")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 0 {
+		t.Errorf("got %v lines, want 0", len(lines))
+	}
+
+	lines, _ = invoice.Prorate(p, time.Date(2020, 10, 16, 0, 0, 0, 0, loc), from, to, "19")
+	if lines[0].Description != "Unused time on Basic" {
+		t.Errorf("got %v, want Unused time on Basic", lines[0].Description)
+	}
+	if lines[1].Description != "Remaining time on Pro" {
+		t.Errorf("got %v, want Remaining time on Pro", lines[1].Description)
+	}
+}
+
+func TestProrate_InvoicedAmount(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Berlin")
+	p := period.Period{
+		Start: time.Date(2020, 10, 1, 0, 0, 0, 0, loc),
+		End:   time.Date(2020, 11, 1, 0, 0, 0, 0, loc),
+	}
+	basic := catalog.NewPrice(testutil.NewID())
+	basic.Amount = mustAmount(t, "31.00", "EUR")
+	pro := catalog.NewPrice(testutil.NewID())
+	pro.Amount = mustAmount(t, "62.00", "EUR")
+	// Invoiced at 50% off.
+	from := invoice.ProrationItem{Description: "Basic", Price: basic, Quantity: 1, Amount: mustAmount(t, "15.50", "EUR")}
+	to := invoice.ProrationItem{Description: "Pro", Price: pro, Quantity: 1}
+
+	lines, err := invoice.Prorate(p, time.Date(2020, 10, 16, 0, 0, 0, 0, loc), from, to, "19")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAmount(t, lines[0].Amount, "-8.01")
+	assertAmount(t, lines[1].Amount, "32.04")
+}
+
+func TestInvoicedAmount(t *testing.T) {
+	inv := invoice.New(testutil.NewID(), "EUR")
+	basic := catalog.NewPrice(testutil.NewID())
+	basic.Amount = mustAmount(t, "30.00", "EUR")
+	plan, _ := invoice.NewLine(invoice.LineSubscription, "Basic", basic.Amount, 2)
+	plan.PriceID = basic.ID
+	usage, _ := invoice.NewLine(invoice.LineUsage, "API calls", mustAmount(t, "40.00", "EUR"), 1)
+	// 10% off the whole invoice.
+	discount, _ := invoice.NewLine(invoice.LineDiscount, "10% off", mustAmount(t, "-10.00", "EUR"), 1)
+	for _, line := range []invoice.Line{plan, usage, discount} {
+		if err := inv.AddLine(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	amount, ok, err := invoice.InvoicedAmount(inv, basic.ID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("got false, want true")
+	}
+	// 60.00, minus its 60% share of the discount.
+	assertAmount(t, amount.Round(), "54.00")
+
+	// The quantity was changed since.
+	if _, ok, _ := invoice.InvoicedAmount(inv, basic.ID, 3); ok {
+		t.Error("got true, want false")
+	}
+}
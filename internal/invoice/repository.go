@@ -0,0 +1,537 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package invoice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bojanz/currency"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/catalog"
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+const invoiceColumns = `id, version, customer_id, subscription_id, number, status, currency, taxes,
+	subtotal::TEXT, discount_total::TEXT, tax_total::TEXT, total::TEXT, credit_applied::TEXT, amount_due::TEXT,
+	period_start, period_end, created_at, updated_at, finalized_at, paid_at, voided_at, marked_uncollectible_at,
+	attempt_count, next_payment_attempt_at`
+
+const lineColumns = `id, type, description, price_id, quantity, unit_amount::TEXT, amount::TEXT,
+	tax_rate, period_start, period_end, breakdown`
+
+// ConflictError is returned when an invoice could not be updated
+// because it was modified in the meantime.
+type ConflictError struct {
+	ID      ulid.ULID
+	Version int
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("invoice %v was modified, version %v is out of date", e.ID, e.Version)
+}
+
+// Repository loads and saves invoices.
+type Repository struct {
+	db database.Querier
+}
+
+// NewRepository creates a new invoice repository.
+func NewRepository(db database.Querier) *Repository {
+	return &Repository{db: db}
+}
+
+// Get gets the invoice with the given ID, including its lines.
+func (r *Repository) Get(ctx context.Context, id ulid.ULID) (Invoice, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+invoiceColumns+` FROM invoices WHERE id = $1`, id.String())
+	inv, err := scanInvoice(row)
+	if err != nil {
+		return Invoice{}, err
+	}
+	if inv.Lines, err = r.listLines(ctx, inv); err != nil {
+		return Invoice{}, err
+	}
+
+	return inv, nil
+}
+
+// GetForUpdate gets the invoice with the given ID and locks it
+// until the end of the current transaction.
+func (r *Repository) GetForUpdate(ctx context.Context, id ulid.ULID) (Invoice, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+invoiceColumns+` FROM invoices WHERE id = $1 FOR UPDATE`, id.String())
+	inv, err := scanInvoice(row)
+	if err != nil {
+		return Invoice{}, err
+	}
+	if inv.Lines, err = r.listLines(ctx, inv); err != nil {
+		return Invoice{}, err
+	}
+
+	return inv, nil
+}
+
+// ListByCustomer lists the invoices of the given customer, newest first.
+//
+// The invoice lines are not loaded.
+func (r *Repository) ListByCustomer(ctx context.Context, customerID ulid.ULID) ([]Invoice, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+invoiceColumns+` FROM invoices
+		WHERE customer_id = $1 ORDER BY id DESC`,
+		customerID.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanInvoices(rows)
+}
+
+// ListBySubscription lists the invoices of the given subscription, newest first.
+//
+// The invoice lines are not loaded.
+func (r *Repository) ListBySubscription(ctx context.Context, subscriptionID ulid.ULID) ([]Invoice, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+invoiceColumns+` FROM invoices
+		WHERE subscription_id = $1 ORDER BY id DESC`,
+		subscriptionID.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanInvoices(rows)
+}
+
+// ListDueForPayment lists the open invoices whose next payment attempt
+// is due at the given time, leaving out the given skipped invoices.
+//
+// The invoice lines are not loaded. The rows are locked until the end
+// of the transaction, skipping rows already locked by another process,
+// so that each invoice is charged only once.
+func (r *Repository) ListDueForPayment(ctx context.Context, now time.Time, skip []ulid.ULID, limit int) ([]Invoice, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+invoiceColumns+` FROM invoices
+		WHERE status = $1 AND next_payment_attempt_at <= $2 AND id <> ALL($3)
+		ORDER BY next_payment_attempt_at LIMIT $4
+		FOR UPDATE SKIP LOCKED`,
+		string(StatusOpen), now, stringIDs(skip), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanInvoices(rows)
+}
+
+// PostponePayment postpones the next payment attempt of the given
+// open invoice until the given time.
+//
+// Used when an attempt failed before it could be recorded, so the
+// attempt count is left unchanged.
+func (r *Repository) PostponePayment(ctx context.Context, id ulid.ULID, retryAt time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE invoices SET version = version + 1, next_payment_attempt_at = $3
+		WHERE id = $1 AND status = $2`,
+		id.String(), string(StatusOpen), retryAt.UTC(),
+	)
+
+	return err
+}
+
+// Create creates the given invoice, including its lines.
+func (r *Repository) Create(ctx context.Context, inv *Invoice) error {
+	taxes, err := marshalTaxes(inv.Taxes)
+	if err != nil {
+		return err
+	}
+
+	return database.WithTx(ctx, r.db, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO invoices (id, version, customer_id, subscription_id, number, status, currency, taxes,
+				subtotal, discount_total, tax_total, total, period_start, period_end,
+				created_at, updated_at, finalized_at, paid_at, voided_at, marked_uncollectible_at,
+				attempt_count, next_payment_attempt_at, credit_applied, amount_due)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22,
+				$23, $24)`,
+			inv.ID.String(), inv.Version, inv.CustomerID.String(), nullID(inv.SubscriptionID), nullString(inv.Number),
+			string(inv.Status), inv.Currency, taxes, inv.Subtotal.Number(), inv.DiscountTotal.Number(),
+			inv.TaxTotal.Number(), inv.Total.Number(), database.NullTime(inv.PeriodStart), database.NullTime(inv.PeriodEnd),
+			inv.CreatedAt, database.NullTime(inv.UpdatedAt), database.NullTime(inv.FinalizedAt),
+			database.NullTime(inv.PaidAt), database.NullTime(inv.VoidedAt), database.NullTime(inv.MarkedUncollectibleAt),
+			inv.AttemptCount, database.NullTime(inv.NextPaymentAttemptAt), inv.CreditApplied.Number(),
+			inv.AmountDue.Number(),
+		)
+		if err != nil {
+			return err
+		}
+		return insertLines(ctx, tx, inv)
+	})
+}
+
+// Update updates the given invoice.
+//
+// The lines and totals of draft invoices are replaced. For finalized
+// invoices only the status, its timestamps and the payment attempt
+// fields are updated, since the stored invoice is immutable.
+//
+// The update only succeeds if the stored version matches inv.Version,
+// otherwise a *ConflictError is returned. On success, inv.Version is
+// incremented and inv.UpdatedAt is set to the current time.
+func (r *Repository) Update(ctx context.Context, inv *Invoice) error {
+	taxes, err := marshalTaxes(inv.Taxes)
+	if err != nil {
+		return err
+	}
+	updatedAt := time.Now().UTC()
+	err = database.WithTx(ctx, r.db, func(tx pgx.Tx) error {
+		var previous string
+		err := tx.QueryRow(ctx, `SELECT status FROM invoices WHERE id = $1 FOR UPDATE`, inv.ID.String()).Scan(&previous)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		var tag pgconn.CommandTag
+		if Status(previous) == StatusDraft {
+			// Replace the lines while the stored invoice is still a draft,
+			// before the update below possibly finalizes it.
+			if _, err := tx.Exec(ctx, `DELETE FROM invoice_lines WHERE invoice_id = $1`, inv.ID.String()); err != nil {
+				return err
+			}
+			if err := insertLines(ctx, tx, inv); err != nil {
+				return err
+			}
+			tag, err = tx.Exec(ctx, `
+				UPDATE invoices
+				SET version = version + 1, number = $3, status = $4, taxes = $5, subtotal = $6,
+					discount_total = $7, tax_total = $8, total = $9, period_start = $10, period_end = $11,
+					updated_at = $12, finalized_at = $13, paid_at = $14, voided_at = $15,
+					marked_uncollectible_at = $16, attempt_count = $17, next_payment_attempt_at = $18,
+					credit_applied = $19, amount_due = $20
+				WHERE id = $1 AND version = $2`,
+				inv.ID.String(), inv.Version, nullString(inv.Number), string(inv.Status), taxes,
+				inv.Subtotal.Number(), inv.DiscountTotal.Number(), inv.TaxTotal.Number(), inv.Total.Number(),
+				database.NullTime(inv.PeriodStart), database.NullTime(inv.PeriodEnd), updatedAt,
+				database.NullTime(inv.FinalizedAt), database.NullTime(inv.PaidAt),
+				database.NullTime(inv.VoidedAt), database.NullTime(inv.MarkedUncollectibleAt),
+				inv.AttemptCount, database.NullTime(inv.NextPaymentAttemptAt), inv.CreditApplied.Number(),
+				inv.AmountDue.Number(),
+			)
+		} else {
+			tag, err = tx.Exec(ctx, `
+				UPDATE invoices
+				SET version = version + 1, status = $3, updated_at = $4, paid_at = $5, voided_at = $6,
+					marked_uncollectible_at = $7, attempt_count = $8, next_payment_attempt_at = $9
+				WHERE id = $1 AND version = $2`,
+				inv.ID.String(), inv.Version, string(inv.Status), updatedAt, database.NullTime(inv.PaidAt),
+				database.NullTime(inv.VoidedAt), database.NullTime(inv.MarkedUncollectibleAt),
+				inv.AttemptCount, database.NullTime(inv.NextPaymentAttemptAt),
+			)
+		}
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return &ConflictError{ID: inv.ID, Version: inv.Version}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	inv.Version++
+	inv.UpdatedAt = updatedAt
+
+	return nil
+}
+
+// Delete deletes the given draft invoice.
+//
+// Finalized invoices can't be deleted, ErrFinalized is returned instead.
+func (r *Repository) Delete(ctx context.Context, id ulid.ULID) error {
+	var status string
+	err := r.db.QueryRow(ctx, `SELECT status FROM invoices WHERE id = $1`, id.String()).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if Status(status) != StatusDraft {
+		return ErrFinalized
+	}
+	_, err = r.db.Exec(ctx, `DELETE FROM invoices WHERE id = $1 AND status = $2`, id.String(), string(StatusDraft))
+
+	return err
+}
+
+// AddPendingLines adds lines to the next invoice of the given subscription.
+func (r *Repository) AddPendingLines(ctx context.Context, subscriptionID ulid.ULID, lines []Line) error {
+	now := time.Now().UTC()
+	return database.WithTx(ctx, r.db, func(tx pgx.Tx) error {
+		for i, line := range lines {
+			breakdown, err := marshalBreakdown(line.Breakdown)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(ctx, `
+				INSERT INTO invoice_pending_lines (id, subscription_id, position, type, description, price_id,
+					quantity, unit_amount, amount, currency, tax_rate, period_start, period_end, breakdown, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+				line.ID.String(), subscriptionID.String(), i, string(line.Type), line.Description, nullID(line.PriceID),
+				line.Quantity, line.UnitAmount.Number(), line.Amount.Number(), line.Amount.CurrencyCode(), line.TaxRate,
+				database.NullTime(line.PeriodStart), database.NullTime(line.PeriodEnd), breakdown, now,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// TakePendingLines removes and returns the lines pending for the next
+// invoice of the given subscription, oldest first.
+//
+// Only lines in the given currency are taken.
+func (r *Repository) TakePendingLines(ctx context.Context, subscriptionID ulid.ULID, currencyCode string) ([]Line, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH taken AS (
+			DELETE FROM invoice_pending_lines
+			WHERE subscription_id = $1 AND currency = $2
+			RETURNING *
+		)
+		SELECT `+lineColumns+` FROM taken
+		ORDER BY created_at, position`,
+		subscriptionID.String(), currencyCode,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []Line
+	for rows.Next() {
+		line, err := scanLine(rows, currencyCode)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
+
+// listLines lists the lines of the given invoice, in order.
+func (r *Repository) listLines(ctx context.Context, inv Invoice) ([]Line, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+lineColumns+` FROM invoice_lines
+		WHERE invoice_id = $1 ORDER BY position`,
+		inv.ID.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []Line
+	for rows.Next() {
+		line, err := scanLine(rows, inv.Currency)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
+
+// insertLines inserts the lines of the given invoice.
+func insertLines(ctx context.Context, tx pgx.Tx, inv *Invoice) error {
+	for i, line := range inv.Lines {
+		breakdown, err := marshalBreakdown(line.Breakdown)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO invoice_lines (id, invoice_id, position, type, description, price_id, quantity,
+				unit_amount, amount, tax_rate, period_start, period_end, breakdown)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+			line.ID.String(), inv.ID.String(), i, string(line.Type), line.Description, nullID(line.PriceID),
+			line.Quantity, line.UnitAmount.Number(), line.Amount.Number(), line.TaxRate,
+			database.NullTime(line.PeriodStart), database.NullTime(line.PeriodEnd), breakdown,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// marshalBreakdown marshals the line breakdown for storage.
+func marshalBreakdown(breakdown []catalog.Charge) ([]byte, error) {
+	if breakdown == nil {
+		breakdown = []catalog.Charge{}
+	}
+	return json.Marshal(breakdown)
+}
+
+// marshalTaxes marshals the invoice taxes for storage.
+func marshalTaxes(taxes []Tax) ([]byte, error) {
+	if taxes == nil {
+		taxes = []Tax{}
+	}
+	return json.Marshal(taxes)
+}
+
+// nullID returns nil for empty IDs, the ID string otherwise.
+func nullID(id ulid.ULID) *string {
+	if id == (ulid.ULID{}) {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
+// nullString returns nil for empty strings.
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// stringIDs converts the given IDs to strings, for use with
+// array parameters. Never nil, since NULL arrays match nothing.
+func stringIDs(ids []ulid.ULID) []string {
+	s := make([]string, 0, len(ids))
+	for _, id := range ids {
+		s = append(s, id.String())
+	}
+	return s
+}
+
+// scanLine scans an invoice line from the given row.
+func scanLine(row pgx.Row, currencyCode string) (Line, error) {
+	var line Line
+	var id, lineType, unitAmount, amount string
+	var priceID *string
+	var periodStart, periodEnd *time.Time
+	var breakdown []byte
+	err := row.Scan(&id, &lineType, &line.Description, &priceID, &line.Quantity,
+		&unitAmount, &amount, &line.TaxRate, &periodStart, &periodEnd, &breakdown)
+	if err != nil {
+		return Line{}, err
+	}
+	if line.ID, err = ulid.Parse(id); err != nil {
+		return Line{}, err
+	}
+	if priceID != nil {
+		if line.PriceID, err = ulid.Parse(*priceID); err != nil {
+			return Line{}, err
+		}
+	}
+	if line.UnitAmount, err = currency.NewAmount(unitAmount, currencyCode); err != nil {
+		return Line{}, err
+	}
+	if line.Amount, err = currency.NewAmount(amount, currencyCode); err != nil {
+		return Line{}, err
+	}
+	if err := json.Unmarshal(breakdown, &line.Breakdown); err != nil {
+		return Line{}, err
+	}
+	line.Type = LineType(lineType)
+	line.PeriodStart = database.TimeValue(periodStart)
+	line.PeriodEnd = database.TimeValue(periodEnd)
+
+	return line, nil
+}
+
+// scanInvoices scans invoices from the given rows.
+func scanInvoices(rows pgx.Rows) ([]Invoice, error) {
+	defer rows.Close()
+
+	var invoices []Invoice
+	for rows.Next() {
+		inv, err := scanInvoice(rows)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, inv)
+	}
+
+	return invoices, rows.Err()
+}
+
+// scanInvoice scans an invoice from the given row.
+func scanInvoice(row pgx.Row) (Invoice, error) {
+	var inv Invoice
+	var id, customerID, status, subtotal, discountTotal, taxTotal, total, creditApplied, amountDue string
+	var subscriptionID, number *string
+	var taxes []byte
+	var periodStart, periodEnd, updatedAt, finalizedAt, paidAt, voidedAt, uncollectibleAt, nextAttemptAt *time.Time
+	err := row.Scan(&id, &inv.Version, &customerID, &subscriptionID, &number, &status, &inv.Currency, &taxes,
+		&subtotal, &discountTotal, &taxTotal, &total, &creditApplied, &amountDue, &periodStart, &periodEnd,
+		&inv.CreatedAt, &updatedAt, &finalizedAt, &paidAt, &voidedAt, &uncollectibleAt,
+		&inv.AttemptCount, &nextAttemptAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Invoice{}, ErrNotFound
+		}
+		return Invoice{}, err
+	}
+	if inv.ID, err = ulid.Parse(id); err != nil {
+		return Invoice{}, err
+	}
+	if inv.CustomerID, err = ulid.Parse(customerID); err != nil {
+		return Invoice{}, err
+	}
+	if subscriptionID != nil {
+		if inv.SubscriptionID, err = ulid.Parse(*subscriptionID); err != nil {
+			return Invoice{}, err
+		}
+	}
+	if number != nil {
+		inv.Number = *number
+	}
+	if err := json.Unmarshal(taxes, &inv.Taxes); err != nil {
+		return Invoice{}, err
+	}
+	amounts := []struct {
+		dst    *currency.Amount
+		number string
+	}{
+		{&inv.Subtotal, subtotal},
+		{&inv.DiscountTotal, discountTotal},
+		{&inv.TaxTotal, taxTotal},
+		{&inv.Total, total},
+		{&inv.CreditApplied, creditApplied},
+		{&inv.AmountDue, amountDue},
+	}
+	for _, a := range amounts {
+		if *a.dst, err = currency.NewAmount(a.number, inv.Currency); err != nil {
+			return Invoice{}, err
+		}
+	}
+	inv.Status = Status(status)
+	inv.PeriodStart = database.TimeValue(periodStart)
+	inv.PeriodEnd = database.TimeValue(periodEnd)
+	inv.UpdatedAt = database.TimeValue(updatedAt)
+	inv.FinalizedAt = database.TimeValue(finalizedAt)
+	inv.PaidAt = database.TimeValue(paidAt)
+	inv.VoidedAt = database.TimeValue(voidedAt)
+	inv.MarkedUncollectibleAt = database.TimeValue(uncollectibleAt)
+	inv.NextPaymentAttemptAt = database.TimeValue(nextAttemptAt)
+
+	return inv, nil
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package meter provides usage metering.
+//
+// Usage events are reported per customer and meter, e.g. "api_calls",
+// and aggregated per billing period. Metered prices (see catalog.Price)
+// bill the aggregated quantity at the end of each period.
+//
+// Events are stored in a table partitioned by month, see EnsurePartitions.
+// Events older than MaxEventAge are rejected, so that they always fall
+// into an existing partition. So are events reported after their period
+// was invoiced, since they would never be billed.
+package meter
+
+import (
+	"crypto/rand"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+// ErrNotFound is returned when a meter could not be found.
+var ErrNotFound = errors.New("meter not found")
+
+// ErrKeyInUse is returned when a meter key is already in use.
+var ErrKeyInUse = errors.New("meter key already in use")
+
+// ErrEventNotFound is returned when a usage event could not be found.
+var ErrEventNotFound = errors.New("usage event not found")
+
+// ErrPeriodInvoiced is returned when a usage event falls into a period
+// for which the customer's usage was already invoiced.
+var ErrPeriodInvoiced = errors.New("usage event falls into an already invoiced period")
+
+// Event timestamp limits.
+const (
+	// MaxEventAge is how far in the past usage events can be reported.
+	MaxEventAge = 7 * 24 * time.Hour
+	// MaxClockSkew is how far in the future usage events can be reported.
+	MaxClockSkew = 5 * time.Minute
+)
+
+var rxKey = regexp.MustCompile("^[a-z0-9_]{1,40}$")
+
+// Aggregation represents how usage events are aggregated per period.
+type Aggregation string
+
+// Aggregations.
+const (
+	// AggregationSum sums the quantities, e.g. for API calls.
+	AggregationSum Aggregation = "sum"
+	// AggregationMax uses the highest quantity, e.g. for peak storage.
+	AggregationMax Aggregation = "max"
+	// AggregationLast uses the most recent quantity, e.g. for current storage.
+	AggregationLast Aggregation = "last"
+)
+
+// IsValid returns whether a is a known aggregation.
+func (a Aggregation) IsValid() bool {
+	return a == AggregationSum || a == AggregationMax || a == AggregationLast
+}
+
+// Meter represents something whose usage is billed, e.g. API calls.
+type Meter struct {
+	ID ulid.ULID `json:"id"`
+	// Key identifies the meter in usage events, e.g. "api_calls".
+	Key         string      `json:"key"`
+	Name        string      `json:"name"`
+	Aggregation Aggregation `json:"aggregation"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// New creates a new meter.
+func New() Meter {
+	now := time.Now().UTC()
+	m := Meter{
+		ID:        ulid.MustNew(ulid.Timestamp(now), rand.Reader),
+		CreatedAt: now,
+	}
+
+	return m
+}
+
+// Validate validates the meter.
+func (m Meter) Validate() validation.Errors {
+	errs := validation.Errors{}
+	if m.ID == (ulid.ULID{}) {
+		errs.Add("id", validation.Required("ID is required."))
+	}
+	if m.Key == "" {
+		errs.Add("key", validation.Required("Key is required."))
+	}
+	if m.Name == "" {
+		errs.Add("name", validation.Required("Name is required."))
+	}
+	if m.Aggregation == "" {
+		errs.Add("aggregation", validation.Required("Aggregation is required."))
+	}
+	if m.CreatedAt.IsZero() {
+		errs.Add("created_at", validation.Required("CreatedAt is required."))
+	}
+
+	if m.Key != "" && !rxKey.MatchString(m.Key) {
+		errs.Add("key", validation.InvalidValue("Key must consist of up to 40 lowercase letters, numbers and underscores."))
+	}
+	if m.Aggregation != "" && !m.Aggregation.IsValid() {
+		errs.Add("aggregation", validation.InvalidChoice("Invalid aggregation."))
+	}
+
+	return errs
+}
+
+// Event represents a usage event.
+type Event struct {
+	ID         ulid.ULID `json:"id"`
+	MeterID    ulid.ULID `json:"meter_id"`
+	CustomerID ulid.ULID `json:"customer_id"`
+	Quantity   int64     `json:"quantity"`
+	// Timestamp is the time the usage occurred.
+	Timestamp time.Time `json:"timestamp"`
+	// IdempotencyKey deduplicates events reported more than once.
+	// Unique per customer.
+	IdempotencyKey string    `json:"idempotency_key"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// NewEvent creates a new usage event.
+//
+// The idempotency key defaults to the event ID.
+func NewEvent(meterID, customerID ulid.ULID, quantity int64, timestamp time.Time, idempotencyKey string) Event {
+	now := time.Now().UTC()
+	e := Event{
+		ID:             ulid.MustNew(ulid.Timestamp(now), rand.Reader),
+		MeterID:        meterID,
+		CustomerID:     customerID,
+		Quantity:       quantity,
+		Timestamp:      timestamp.UTC(),
+		IdempotencyKey: idempotencyKey,
+		CreatedAt:      now,
+	}
+	if e.IdempotencyKey == "" {
+		e.IdempotencyKey = e.ID.String()
+	}
+
+	return e
+}
+
+// Validate validates the event, as received at the given time.
+//
+// Events falling into an already invoiced period are rejected
+// when ingested, see Repository.Ingest.
+func (e Event) Validate(now time.Time) validation.Errors {
+	errs := validation.Errors{}
+	if e.ID == (ulid.ULID{}) {
+		errs.Add("id", validation.Required("ID is required."))
+	}
+	if e.MeterID == (ulid.ULID{}) {
+		errs.Add("meter", validation.Required("Meter is required."))
+	}
+	if e.CustomerID == (ulid.ULID{}) {
+		errs.Add("customer_id", validation.Required("Customer is required."))
+	}
+	if e.Timestamp.IsZero() {
+		errs.Add("timestamp", validation.Required("Timestamp is required."))
+	}
+	if e.IdempotencyKey == "" {
+		errs.Add("idempotency_key", validation.Required("Idempotency key is required."))
+	}
+
+	if e.Quantity < 0 {
+		errs.Add("quantity", validation.InvalidValue("Quantity can't be negative."))
+	}
+	if !e.Timestamp.IsZero() && e.Timestamp.Before(now.Add(-MaxEventAge)) {
+		errs.Add("timestamp", validation.Expired("Timestamp is too far in the past."))
+	}
+	if e.Timestamp.After(now.Add(MaxClockSkew)) {
+		errs.Add("timestamp", validation.InvalidValue("Timestamp can't be in the future."))
+	}
+	if len(e.IdempotencyKey) > 255 {
+		errs.Add("idempotency_key", validation.InvalidValue("Idempotency key can't be longer than 255 characters."))
+	}
+
+	return errs
+}
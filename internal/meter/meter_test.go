@@ -0,0 +1,117 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package meter_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runbilliam/billiam/internal/meter"
+	"github.com/runbilliam/billiam/internal/testutil"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+func TestMeter_Validate(t *testing.T) {
+	tests := []struct {
+		key         string
+		aggregation meter.Aggregation
+		wantPath    string
+		wantCode    string
+	}{
+		{"api_calls", meter.AggregationSum, "", ""},
+		{"storage_gb", meter.AggregationLast, "", ""},
+		{"", meter.AggregationSum, "key", validation.CodeRequired},
+		{"API calls", meter.AggregationSum, "key", validation.CodeInvalidValue},
+		{strings.Repeat("a", 41), meter.AggregationSum, "key", validation.CodeInvalidValue},
+		{"api_calls", "", "aggregation", validation.CodeRequired},
+		{"api_calls", "avg", "aggregation", validation.CodeInvalidChoice},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			m := meter.New()
+			m.Key = tt.key
+			m.Name = "Test"
+			m.Aggregation = tt.aggregation
+			errs := m.Validate()
+			if tt.wantPath == "" {
+				if !errs.IsEmpty() {
+					t.Errorf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			testutil.AssertError(t, errs, tt.wantPath, tt.wantCode)
+		})
+	}
+}
+
+func TestEvent_Validate(t *testing.T) {
+	now := time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		quantity  int64
+		timestamp time.Time
+		key       string
+		wantPath  string
+		wantCode  string
+	}{
+		{10, now, "", "", ""},
+		{0, now.Add(-time.Hour), "request-1", "", ""},
+		{1, now.Add(meter.MaxClockSkew), "", "", ""},
+		{-1, now, "", "quantity", validation.CodeInvalidValue},
+		{1, now.Add(time.Hour), "", "timestamp", validation.CodeInvalidValue},
+		{1, now, strings.Repeat("k", 256), "idempotency_key", validation.CodeInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			e := meter.NewEvent(testutil.NewID(), testutil.NewID(), tt.quantity, tt.timestamp, tt.key)
+			errs := e.Validate(now)
+			if tt.wantPath == "" {
+				if !errs.IsEmpty() {
+					t.Errorf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			testutil.AssertError(t, errs, tt.wantPath, tt.wantCode)
+		})
+	}
+
+	// Events are limited by their age.
+	e := meter.NewEvent(testutil.NewID(), testutil.NewID(), 1, now.Add(-meter.MaxEventAge), "")
+	if errs := e.Validate(now); !errs.IsEmpty() {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	e = meter.NewEvent(testutil.NewID(), testutil.NewID(), 1, now.Add(-meter.MaxEventAge-time.Second), "")
+	testutil.AssertError(t, e.Validate(now), "timestamp", validation.CodeExpired)
+
+	// The idempotency key defaults to the event ID.
+	e = meter.NewEvent(testutil.NewID(), testutil.NewID(), 1, now, "")
+	if e.IdempotencyKey != e.ID.String() {
+		t.Errorf("got %v, want %v", e.IdempotencyKey, e.ID.String())
+	}
+}
+
+func TestPartitions(t *testing.T) {
+	loc, _ := time.LoadLocation("America/New_York")
+	// Still November in New York, but already December in UTC.
+	from := time.Date(2020, 11, 30, 20, 0, 0, 0, loc)
+	to := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	partitions := meter.Partitions(from, to)
+	wantNames := []string{"usage_events_2020_12", "usage_events_2021_01", "usage_events_2021_02"}
+	if len(partitions) != len(wantNames) {
+		t.Fatalf("got %v partitions, want %v", len(partitions), len(wantNames))
+	}
+	for i, p := range partitions {
+		if p.Name != wantNames[i] {
+			t.Errorf("got %v, want %v", p.Name, wantNames[i])
+		}
+		if i > 0 && !p.Start.Equal(partitions[i-1].End) {
+			t.Errorf("got %v, want %v", p.Start, partitions[i-1].End)
+		}
+	}
+	wantStart := time.Date(2020, 12, 1, 0, 0, 0, 0, time.UTC)
+	if !partitions[0].Start.Equal(wantStart) {
+		t.Errorf("got %v, want %v", partitions[0].Start, wantStart)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package meter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+// Partition represents a monthly partition of the usage events table.
+//
+// The start is inclusive, the end is exclusive.
+type Partition struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// Partitions returns the monthly partitions covering the given time range.
+//
+// Months are calculated in UTC.
+func Partitions(from, to time.Time) []Partition {
+	from, to = from.UTC(), to.UTC()
+	start := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	var partitions []Partition
+	for !start.After(to) {
+		end := start.AddDate(0, 1, 0)
+		partitions = append(partitions, Partition{
+			Name:  fmt.Sprintf("usage_events_%04d_%02d", start.Year(), start.Month()),
+			Start: start,
+			End:   end,
+		})
+		start = end
+	}
+
+	return partitions
+}
+
+// EnsurePartitions creates any missing partitions needed to store
+// usage events reported at the given time, and during the next month.
+func EnsurePartitions(ctx context.Context, db database.Querier, now time.Time) error {
+	partitions := Partitions(now.Add(-MaxEventAge), now.AddDate(0, 1, 0))
+	for _, p := range partitions {
+		_, err := db.Exec(ctx, fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s PARTITION OF usage_events FOR VALUES FROM (%s) TO (%s)`,
+			pgx.Identifier{p.Name}.Sanitize(), quoteTime(p.Start), quoteTime(p.End),
+		))
+		if err != nil {
+			return fmt.Errorf("create partition %v: %w", p.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// quoteTime quotes the given time for use as a partition bound.
+func quoteTime(t time.Time) string {
+	return "'" + t.Format(time.RFC3339) + "'"
+}
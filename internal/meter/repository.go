@@ -0,0 +1,231 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package meter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+const meterColumns = `id, key, name, aggregation, created_at`
+
+const eventColumns = `id, meter_id, customer_id, quantity, timestamp, idempotency_key, created_at`
+
+// Repository loads and saves meters and their usage events.
+type Repository struct {
+	db database.Querier
+}
+
+// NewRepository creates a new meter repository.
+func NewRepository(db database.Querier) *Repository {
+	return &Repository{db: db}
+}
+
+// Get gets the meter with the given ID.
+func (r *Repository) Get(ctx context.Context, id ulid.ULID) (Meter, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+meterColumns+` FROM meters WHERE id = $1`, id.String())
+
+	return scanMeter(row)
+}
+
+// GetByKey gets the meter with the given key.
+func (r *Repository) GetByKey(ctx context.Context, key string) (Meter, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+meterColumns+` FROM meters WHERE key = $1`, key)
+
+	return scanMeter(row)
+}
+
+// List lists all meters, ordered by key.
+func (r *Repository) List(ctx context.Context) ([]Meter, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+meterColumns+` FROM meters ORDER BY key`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var meters []Meter
+	for rows.Next() {
+		m, err := scanMeter(rows)
+		if err != nil {
+			return nil, err
+		}
+		meters = append(meters, m)
+	}
+
+	return meters, rows.Err()
+}
+
+// Create creates the given meter.
+//
+// Returns ErrKeyInUse if another meter has the same key.
+func (r *Repository) Create(ctx context.Context, m Meter) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO meters (`+meterColumns+`)
+		VALUES ($1, $2, $3, $4, $5)`,
+		m.ID.String(), m.Key, m.Name, string(m.Aggregation), m.CreatedAt,
+	)
+	if database.IsUniqueViolation(err) {
+		return ErrKeyInUse
+	}
+
+	return err
+}
+
+// Ingest stores the given usage event, unless the customer already
+// reported an event with the same idempotency key.
+//
+// Returns whether the event was stored. Otherwise e is replaced by
+// the previously stored event. Returns ErrPeriodInvoiced if the event
+// falls into a period for which the customer's usage of the meter was
+// already invoiced. The customer's subscriptions are locked while
+// checking, so that they can't be renewed until the event is stored.
+func (r *Repository) Ingest(ctx context.Context, e *Event) (bool, error) {
+	stored := false
+	err := database.WithTx(ctx, r.db, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			SELECT id FROM subscriptions WHERE customer_id = $1 FOR SHARE`,
+			e.CustomerID.String(),
+		)
+		if err != nil {
+			return err
+		}
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO usage_event_keys (customer_id, idempotency_key, event_id, timestamp)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT DO NOTHING`,
+			e.CustomerID.String(), e.IdempotencyKey, e.ID.String(), e.Timestamp,
+		)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			previous, err := r.getEventByKey(ctx, tx, e.CustomerID, e.IdempotencyKey)
+			if err != nil {
+				return err
+			}
+			*e = previous
+			return nil
+		}
+		invoicedUntil, err := invoicedUntil(ctx, tx, e.MeterID, e.CustomerID)
+		if err != nil {
+			return err
+		}
+		if e.Timestamp.Before(invoicedUntil) {
+			return ErrPeriodInvoiced
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO usage_events (`+eventColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			e.ID.String(), e.MeterID.String(), e.CustomerID.String(), e.Quantity, e.Timestamp,
+			e.IdempotencyKey, e.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		stored = true
+		return nil
+	})
+
+	return stored, err
+}
+
+// Aggregate aggregates the quantities of the given meter's usage events
+// reported by the given customer in the given time range.
+//
+// The start is inclusive, the end is exclusive.
+// Returns 0 if there were no usage events.
+func (r *Repository) Aggregate(ctx context.Context, m Meter, customerID ulid.ULID, start, end time.Time) (int64, error) {
+	var query string
+	switch m.Aggregation {
+	case AggregationSum:
+		query = `SELECT COALESCE(SUM(quantity), 0) FROM usage_events
+			WHERE meter_id = $1 AND customer_id = $2 AND timestamp >= $3 AND timestamp < $4`
+	case AggregationMax:
+		query = `SELECT COALESCE(MAX(quantity), 0) FROM usage_events
+			WHERE meter_id = $1 AND customer_id = $2 AND timestamp >= $3 AND timestamp < $4`
+	case AggregationLast:
+		query = `SELECT COALESCE((SELECT quantity FROM usage_events
+			WHERE meter_id = $1 AND customer_id = $2 AND timestamp >= $3 AND timestamp < $4
+			ORDER BY timestamp DESC, id DESC LIMIT 1), 0)`
+	default:
+		return 0, fmt.Errorf("meter %v: unknown aggregation %q", m.Key, m.Aggregation)
+	}
+	var quantity int64
+	err := r.db.QueryRow(ctx, query, m.ID.String(), customerID.String(), start, end).Scan(&quantity)
+
+	return quantity, err
+}
+
+// invoicedUntil returns the end of the last period for which the given
+// customer's usage of the given meter was invoiced, or the zero time
+// if it was never invoiced.
+func invoicedUntil(ctx context.Context, tx pgx.Tx, meterID, customerID ulid.ULID) (time.Time, error) {
+	var end *time.Time
+	err := tx.QueryRow(ctx, `
+		SELECT MAX(l.period_end) FROM invoice_lines l
+			JOIN invoices i ON i.id = l.invoice_id
+			JOIN prices p ON p.id = l.price_id
+		WHERE i.customer_id = $1 AND l.type = 'usage' AND p.meter_id = $2`,
+		customerID.String(), meterID.String(),
+	).Scan(&end)
+
+	return database.TimeValue(end), err
+}
+
+// getEventByKey gets the usage event with the given idempotency key.
+func (r *Repository) getEventByKey(ctx context.Context, tx pgx.Tx, customerID ulid.ULID, idempotencyKey string) (Event, error) {
+	var e Event
+	var id, meterID, custID string
+	err := tx.QueryRow(ctx, `
+		SELECT `+eventColumns+` FROM usage_events
+		WHERE (id, timestamp) = (
+			SELECT event_id, timestamp FROM usage_event_keys
+			WHERE customer_id = $1 AND idempotency_key = $2
+		)`,
+		customerID.String(), idempotencyKey,
+	).Scan(&id, &meterID, &custID, &e.Quantity, &e.Timestamp, &e.IdempotencyKey, &e.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Event{}, ErrEventNotFound
+		}
+		return Event{}, err
+	}
+	if e.ID, err = ulid.Parse(id); err != nil {
+		return Event{}, err
+	}
+	if e.MeterID, err = ulid.Parse(meterID); err != nil {
+		return Event{}, err
+	}
+	if e.CustomerID, err = ulid.Parse(custID); err != nil {
+		return Event{}, err
+	}
+
+	return e, nil
+}
+
+// scanMeter scans a meter from the given row.
+func scanMeter(row pgx.Row) (Meter, error) {
+	var m Meter
+	var id, aggregation string
+	err := row.Scan(&id, &m.Key, &m.Name, &aggregation, &m.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Meter{}, ErrNotFound
+		}
+		return Meter{}, err
+	}
+	if m.ID, err = ulid.Parse(id); err != nil {
+		return Meter{}, err
+	}
+	m.Aggregation = Aggregation(aggregation)
+
+	return m, nil
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package payment provides stored payment methods and payment attempts.
+package payment
+
+import (
+	"crypto/rand"
+	"errors"
+	"time"
+
+	"github.com/bojanz/currency"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/gateway"
+)
+
+// ErrNotFound is returned when a payment method could not be found.
+var ErrNotFound = errors.New("payment method not found")
+
+// Method represents a customer's payment method, stored by a gateway.
+type Method struct {
+	ID         ulid.ULID `json:"id"`
+	CustomerID ulid.ULID `json:"customer_id"`
+	// Gateway is the name of the gateway in the gateway.Registry.
+	Gateway string `json:"gateway"`
+	// RemoteID is the gateway's ID for the payment method.
+	RemoteID string `json:"remote_id"`
+	// RemoteCustomerID is the gateway's ID for the customer, if any.
+	RemoteCustomerID string `json:"remote_customer_id"`
+	Type             string `json:"type"`
+	Brand            string `json:"brand"`
+	Last4            string `json:"last4"`
+	ExpMonth         int    `json:"exp_month"`
+	ExpYear          int    `json:"exp_year"`
+	// IsDefault indicates whether the payment method is used for
+	// automatic payments. Each customer has at most one.
+	IsDefault bool      `json:"is_default"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewMethod creates a new payment method from the one saved by the given gateway.
+func NewMethod(customerID ulid.ULID, gatewayName string, pm gateway.PaymentMethod) Method {
+	now := time.Now().UTC()
+	m := Method{
+		ID:               ulid.MustNew(ulid.Timestamp(now), rand.Reader),
+		CustomerID:       customerID,
+		Gateway:          gatewayName,
+		RemoteID:         pm.ID,
+		RemoteCustomerID: pm.CustomerID,
+		Type:             pm.Type,
+		Brand:            pm.Brand,
+		Last4:            pm.Last4,
+		ExpMonth:         pm.ExpMonth,
+		ExpYear:          pm.ExpYear,
+		CreatedAt:        now,
+	}
+
+	return m
+}
+
+// AttemptStatus represents the status of a payment attempt.
+type AttemptStatus string
+
+// Attempt statuses.
+const (
+	// AttemptPending is used while the gateway is processing the payment.
+	AttemptPending   AttemptStatus = "pending"
+	AttemptSucceeded AttemptStatus = "succeeded"
+	AttemptFailed    AttemptStatus = "failed"
+)
+
+// Attempt represents an attempt to pay an invoice.
+//
+// Attempts are append-only, recording each charge made through
+// a gateway, whether it succeeded or not. Only pending attempts
+// are updated, once the outcome of their payment is known.
+type Attempt struct {
+	ID        ulid.ULID `json:"id"`
+	InvoiceID ulid.ULID `json:"invoice_id"`
+	// PaymentMethodID is empty if the customer had no payment method.
+	PaymentMethodID ulid.ULID `json:"payment_method_id"`
+	// Number is the attempt's position for the invoice, starting from 1.
+	Number  int    `json:"number"`
+	Gateway string `json:"gateway"`
+	// RemoteID is the gateway's ID for the payment, if one was created.
+	RemoteID    string              `json:"remote_id"`
+	Status      AttemptStatus       `json:"status"`
+	Amount      currency.Amount     `json:"amount"`
+	DeclineCode gateway.DeclineCode `json:"decline_code"`
+	Message     string              `json:"message"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+// NewAttempt creates a new failed payment attempt for the given invoice.
+//
+// The attempt is marked as succeeded once the gateway confirms the payment.
+func NewAttempt(invoiceID ulid.ULID, number int, amount currency.Amount, now time.Time) Attempt {
+	now = now.UTC()
+	a := Attempt{
+		ID:        ulid.MustNew(ulid.Timestamp(now), rand.Reader),
+		InvoiceID: invoiceID,
+		Number:    number,
+		Status:    AttemptFailed,
+		Amount:    amount,
+		CreatedAt: now,
+	}
+
+	return a
+}
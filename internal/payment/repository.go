@@ -0,0 +1,242 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package payment
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bojanz/currency"
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/gateway"
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+const methodColumns = `id, customer_id, gateway, remote_id, remote_customer_id, type, brand, last4,
+	exp_month, exp_year, is_default, created_at`
+
+const attemptColumns = `id, invoice_id, payment_method_id, number, gateway, remote_id, status,
+	amount::TEXT, currency, decline_code, message, created_at`
+
+// Repository loads and saves payment methods and attempts.
+type Repository struct {
+	db database.Querier
+}
+
+// NewRepository creates a new payment repository.
+func NewRepository(db database.Querier) *Repository {
+	return &Repository{db: db}
+}
+
+// GetMethod gets the payment method with the given ID.
+func (r *Repository) GetMethod(ctx context.Context, id ulid.ULID) (Method, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+methodColumns+` FROM payment_methods WHERE id = $1`, id.String())
+
+	return scanMethod(row)
+}
+
+// GetDefaultMethod gets the default payment method of the given customer.
+//
+// Returns ErrNotFound if the customer has no default payment method.
+func (r *Repository) GetDefaultMethod(ctx context.Context, customerID ulid.ULID) (Method, error) {
+	row := r.db.QueryRow(ctx, `
+		SELECT `+methodColumns+` FROM payment_methods
+		WHERE customer_id = $1 AND is_default`,
+		customerID.String(),
+	)
+
+	return scanMethod(row)
+}
+
+// ListMethods lists the payment methods of the given customer, oldest first.
+func (r *Repository) ListMethods(ctx context.Context, customerID ulid.ULID) ([]Method, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+methodColumns+` FROM payment_methods
+		WHERE customer_id = $1 ORDER BY id`,
+		customerID.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var methods []Method
+	for rows.Next() {
+		m, err := scanMethod(rows)
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, m)
+	}
+
+	return methods, rows.Err()
+}
+
+// CreateMethod creates the given payment method.
+//
+// If the payment method is the default, it replaces the customer's
+// previous default payment method.
+func (r *Repository) CreateMethod(ctx context.Context, m Method) error {
+	return database.WithTx(ctx, r.db, func(tx pgx.Tx) error {
+		if m.IsDefault {
+			_, err := tx.Exec(ctx, `
+				UPDATE payment_methods SET is_default = FALSE
+				WHERE customer_id = $1 AND is_default`,
+				m.CustomerID.String(),
+			)
+			if err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO payment_methods (`+methodColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+			m.ID.String(), m.CustomerID.String(), m.Gateway, m.RemoteID, m.RemoteCustomerID, m.Type,
+			m.Brand, m.Last4, m.ExpMonth, m.ExpYear, m.IsDefault, m.CreatedAt,
+		)
+		return err
+	})
+}
+
+// SetDefaultMethod makes the given payment method the customer's default,
+// replacing the previous default payment method.
+//
+// Returns ErrNotFound if the customer has no such payment method.
+func (r *Repository) SetDefaultMethod(ctx context.Context, customerID ulid.ULID, id ulid.ULID) error {
+	return database.WithTx(ctx, r.db, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			UPDATE payment_methods SET is_default = FALSE
+			WHERE customer_id = $1 AND is_default AND id <> $2`,
+			customerID.String(), id.String(),
+		)
+		if err != nil {
+			return err
+		}
+		tag, err := tx.Exec(ctx, `
+			UPDATE payment_methods SET is_default = TRUE
+			WHERE customer_id = $1 AND id = $2`,
+			customerID.String(), id.String(),
+		)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+// ListAttempts lists the payment attempts of the given invoice, oldest first.
+func (r *Repository) ListAttempts(ctx context.Context, invoiceID ulid.ULID) ([]Attempt, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+attemptColumns+` FROM payment_attempts
+		WHERE invoice_id = $1 ORDER BY number`,
+		invoiceID.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []Attempt
+	for rows.Next() {
+		a, err := scanAttempt(rows)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, a)
+	}
+
+	return attempts, rows.Err()
+}
+
+// CreateAttempt creates the given payment attempt.
+func (r *Repository) CreateAttempt(ctx context.Context, a Attempt) error {
+	var paymentMethodID *string
+	if a.PaymentMethodID != (ulid.ULID{}) {
+		id := a.PaymentMethodID.String()
+		paymentMethodID = &id
+	}
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO payment_attempts (id, invoice_id, payment_method_id, number, gateway, remote_id, status,
+			amount, currency, decline_code, message, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		a.ID.String(), a.InvoiceID.String(), paymentMethodID, a.Number, a.Gateway, a.RemoteID, string(a.Status),
+		a.Amount.Number(), a.Amount.CurrencyCode(), string(a.DeclineCode), a.Message, a.CreatedAt,
+	)
+
+	return err
+}
+
+// UpdateAttempt updates the outcome of the given pending payment attempt.
+func (r *Repository) UpdateAttempt(ctx context.Context, a Attempt) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE payment_attempts SET status = $1, decline_code = $2, message = $3
+		WHERE id = $4 AND status = 'pending'`,
+		string(a.Status), string(a.DeclineCode), a.Message, a.ID.String(),
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// scanMethod scans a payment method from the given row.
+func scanMethod(row pgx.Row) (Method, error) {
+	var m Method
+	var id, customerID string
+	err := row.Scan(&id, &customerID, &m.Gateway, &m.RemoteID, &m.RemoteCustomerID, &m.Type, &m.Brand,
+		&m.Last4, &m.ExpMonth, &m.ExpYear, &m.IsDefault, &m.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Method{}, ErrNotFound
+		}
+		return Method{}, err
+	}
+	if m.ID, err = ulid.Parse(id); err != nil {
+		return Method{}, err
+	}
+	if m.CustomerID, err = ulid.Parse(customerID); err != nil {
+		return Method{}, err
+	}
+
+	return m, nil
+}
+
+// scanAttempt scans a payment attempt from the given row.
+func scanAttempt(row pgx.Row) (Attempt, error) {
+	var a Attempt
+	var id, invoiceID, status, amount, currencyCode, declineCode string
+	var paymentMethodID *string
+	err := row.Scan(&id, &invoiceID, &paymentMethodID, &a.Number, &a.Gateway, &a.RemoteID, &status,
+		&amount, &currencyCode, &declineCode, &a.Message, &a.CreatedAt)
+	if err != nil {
+		return Attempt{}, err
+	}
+	if a.ID, err = ulid.Parse(id); err != nil {
+		return Attempt{}, err
+	}
+	if a.InvoiceID, err = ulid.Parse(invoiceID); err != nil {
+		return Attempt{}, err
+	}
+	if paymentMethodID != nil {
+		if a.PaymentMethodID, err = ulid.Parse(*paymentMethodID); err != nil {
+			return Attempt{}, err
+		}
+	}
+	if a.Amount, err = currency.NewAmount(amount, currencyCode); err != nil {
+		return Attempt{}, err
+	}
+	a.Status = AttemptStatus(status)
+	a.DeclineCode = gateway.DeclineCode(declineCode)
+
+	return a, nil
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package payment
+
+import (
+	"context"
+
+	"github.com/runbilliam/billiam/internal/customer"
+	"github.com/runbilliam/billiam/internal/gateway"
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+// SaveMethod saves the given token with the default gateway, and stores
+// the result as the customer's default payment method.
+//
+// The gateway customer of the customer's earlier payment methods is
+// reused, so that all of them belong to the same gateway customer.
+// Returns gateway.ErrNotFound if no gateway is enabled, and a
+// *gateway.DeclineError if the gateway rejected the token.
+func SaveMethod(ctx context.Context, db database.Querier, gateways *gateway.Registry, cust customer.Customer, token string) (Method, error) {
+	g, gatewayName, err := gateways.Default()
+	if err != nil {
+		return Method{}, err
+	}
+	repo := NewRepository(db)
+	methods, err := repo.ListMethods(ctx, cust.ID)
+	if err != nil {
+		return Method{}, err
+	}
+	req := gateway.PaymentMethodRequest{
+		Email: cust.Email,
+		Name:  cust.Name,
+		Token: token,
+		// Saving the first payment method creates the gateway customer.
+		IdempotencyKey: "customer-" + cust.ID.String(),
+	}
+	for _, m := range methods {
+		if m.Gateway == gatewayName && m.RemoteCustomerID != "" {
+			req.CustomerID = m.RemoteCustomerID
+		}
+	}
+	pm, err := g.SavePaymentMethod(ctx, req)
+	if err != nil {
+		return Method{}, err
+	}
+	m := NewMethod(cust.ID, gatewayName, pm)
+	m.IsDefault = true
+	if err := repo.CreateMethod(ctx, m); err != nil {
+		return Method{}, err
+	}
+
+	return m, nil
+}
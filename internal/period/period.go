@@ -0,0 +1,236 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package period calculates billing periods.
+//
+// Periods are calculated in the customer's timezone, on local wall
+// clock time. A monthly subscription anchored at midnight on the 15th
+// renews at midnight on the 15th of each month in the customer's
+// timezone, regardless of DST transitions in between.
+package period
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/runbilliam/billiam/internal/catalog"
+)
+
+// Period represents a billing period.
+//
+// The start is inclusive, the end is exclusive.
+type Period struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Contains returns whether the period contains the given time.
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+// Duration returns the duration of the period.
+func (p Period) Duration() time.Duration {
+	return p.End.Sub(p.Start)
+}
+
+// Location returns the location used for billing a customer.
+//
+// The customer timezone is used if set, the site timezone otherwise.
+func Location(customerTimezone, siteTimezone string) (*time.Location, error) {
+	name := customerTimezone
+	if name == "" {
+		name = siteTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("period: %w", err)
+	}
+
+	return loc, nil
+}
+
+// Schedule calculates the billing periods for an anchor and interval.
+//
+// Boundaries are always calculated from the anchor, so that clamping
+// doesn't accumulate: a monthly schedule anchored on Jan 31st has
+// boundaries on Feb 28th (or 29th), Mar 31st, Apr 30th, and so on.
+type Schedule struct {
+	anchor   time.Time
+	day      int
+	interval catalog.Interval
+}
+
+// NewSchedule creates a new schedule.
+//
+// The anchor is the first period boundary. It is converted to
+// the given location, whose wall clock is used for all boundaries.
+func NewSchedule(anchor time.Time, interval catalog.Interval, loc *time.Location) Schedule {
+	anchor = anchor.In(loc)
+	return Schedule{
+		anchor:   anchor,
+		day:      anchor.Day(),
+		interval: interval,
+	}
+}
+
+// NewScheduleOnDay creates a new schedule anchored on a billing cycle day.
+//
+// The anchor is the first local midnight on or after start that falls
+// on the given day of the month. Days past the end of a month are
+// clamped to the last day of the month (e.g. 31 becomes Feb 28th),
+// while later boundaries still use the full day when possible.
+// Used for monthly and yearly intervals.
+func NewScheduleOnDay(start time.Time, day int, interval catalog.Interval, loc *time.Location) Schedule {
+	start = start.In(loc)
+	year, month, _ := start.Date()
+	anchor := onDay(year, month, day, 0, 0, 0, 0, loc)
+	if anchor.Before(start) {
+		anchor = onDay(year, month+1, day, 0, 0, 0, 0, loc)
+	}
+	return Schedule{
+		anchor:   anchor,
+		day:      day,
+		interval: interval,
+	}
+}
+
+// FromAnchor recreates a schedule from a stored anchor and billing cycle day.
+//
+// Used to restore a schedule created by NewSchedule or NewScheduleOnDay,
+// given the values returned by its Anchor and Day methods.
+func FromAnchor(anchor time.Time, day int, interval catalog.Interval, loc *time.Location) Schedule {
+	return Schedule{
+		anchor:   anchor.In(loc),
+		day:      day,
+		interval: interval,
+	}
+}
+
+// Anchor returns the first period boundary.
+func (s Schedule) Anchor() time.Time {
+	return s.anchor
+}
+
+// Day returns the billing cycle day.
+//
+// Usually matches the anchor's day, unless the anchor was clamped.
+func (s Schedule) Day() int {
+	return s.day
+}
+
+// Interval returns the interval.
+func (s Schedule) Interval() catalog.Interval {
+	return s.interval
+}
+
+// Boundary returns the nth period boundary.
+//
+// Boundary 0 is the anchor. Negative n returns boundaries before the anchor.
+func (s Schedule) Boundary(n int) time.Time {
+	a := s.anchor
+	year, month, day := a.Date()
+	hour, min, sec := a.Clock()
+	nsec := a.Nanosecond()
+	loc := a.Location()
+	count := n * s.interval.Count
+
+	switch s.interval.Unit {
+	case catalog.IntervalDay:
+		return date(year, month, day+count, hour, min, sec, nsec, loc)
+	case catalog.IntervalWeek:
+		return date(year, month, day+7*count, hour, min, sec, nsec, loc)
+	case catalog.IntervalMonth:
+		return onDay(year, month+time.Month(count), s.day, hour, min, sec, nsec, loc)
+	case catalog.IntervalYear:
+		return onDay(year+count, month, s.day, hour, min, sec, nsec, loc)
+	default:
+		panic(fmt.Sprintf("period: unknown interval unit %q", s.interval.Unit))
+	}
+}
+
+// Period returns the nth period, starting at Boundary(n).
+func (s Schedule) Period(n int) Period {
+	return Period{
+		Start: s.Boundary(n),
+		End:   s.Boundary(n + 1),
+	}
+}
+
+// PeriodAt returns the period containing the given time, and its index.
+func (s Schedule) PeriodAt(t time.Time) (Period, int) {
+	n := s.estimate(t)
+	for s.Boundary(n).After(t) {
+		n--
+	}
+	for !s.Boundary(n + 1).After(t) {
+		n++
+	}
+
+	return s.Period(n), n
+}
+
+// Next returns the first boundary after the given time.
+func (s Schedule) Next(t time.Time) time.Time {
+	p, _ := s.PeriodAt(t)
+	return p.End
+}
+
+// estimate estimates the index of the period containing t.
+//
+// The estimate is refined by PeriodAt, it only needs to be close.
+func (s Schedule) estimate(t time.Time) int {
+	var approx time.Duration
+	switch s.interval.Unit {
+	case catalog.IntervalDay:
+		approx = 24 * time.Hour
+	case catalog.IntervalWeek:
+		approx = 7 * 24 * time.Hour
+	case catalog.IntervalMonth:
+		approx = 30*24*time.Hour + 10*time.Hour
+	case catalog.IntervalYear:
+		approx = 365*24*time.Hour + 6*time.Hour
+	default:
+		panic(fmt.Sprintf("period: unknown interval unit %q", s.interval.Unit))
+	}
+	if s.interval.Count < 1 {
+		panic(fmt.Sprintf("period: invalid interval count %d", s.interval.Count))
+	}
+
+	return int(t.Sub(s.anchor) / (approx * time.Duration(s.interval.Count)))
+}
+
+// onDay returns the given date, with the day clamped to the month length.
+//
+// The year and month are normalized first, so month 13 becomes January
+// of the following year.
+func onDay(year int, month time.Month, day, hour, min, sec, nsec int, loc *time.Location) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	year, month = first.Year(), first.Month()
+	if last := daysIn(year, month); day > last {
+		day = last
+	}
+
+	return date(year, month, day, hour, min, sec, nsec, loc)
+}
+
+// date wraps time.Date to consistently handle skipped local times.
+//
+// When a local time doesn't exist because it falls into a DST gap
+// (e.g. 02:30 when clocks jump from 02:00 to 03:00), the time is moved
+// forward by the length of the gap (to 03:30).
+func date(year int, month time.Month, day, hour, min, sec, nsec int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, min, sec, nsec, loc)
+	if t.Hour() != hour {
+		_, offsetBefore := t.Zone()
+		_, offsetAfter := t.Add(2 * time.Hour).Zone()
+		t = t.Add(time.Duration(offsetAfter-offsetBefore) * time.Second)
+	}
+
+	return t
+}
+
+// daysIn returns the number of days in the given month.
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
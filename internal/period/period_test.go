@@ -0,0 +1,212 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package period_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/runbilliam/billiam/internal/catalog"
+	"github.com/runbilliam/billiam/internal/period"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestLocation(t *testing.T) {
+	tests := []struct {
+		customerTimezone string
+		siteTimezone     string
+		want             string
+		wantErr          bool
+	}{
+		{"", "Europe/Berlin", "Europe/Berlin", false},
+		{"America/New_York", "Europe/Berlin", "America/New_York", false},
+		{"Invalid/Zone", "Europe/Berlin", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			loc, err := period.Location(tt.customerTimezone, tt.siteTimezone)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && loc.String() != tt.want {
+				t.Errorf("got %v, want %v", loc, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedule_Boundary(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	newYork := mustLoadLocation(t, "America/New_York")
+	tests := []struct {
+		anchor   time.Time
+		interval catalog.Interval
+		n        int
+		want     time.Time
+	}{
+		// Anchor.
+		{time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC), catalog.Interval{Unit: catalog.IntervalMonth, Count: 1}, 0, time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC)},
+		// End of month clamping, leap year.
+		{time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC), catalog.Interval{Unit: catalog.IntervalMonth, Count: 1}, 1, time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Clamping doesn't accumulate.
+		{time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC), catalog.Interval{Unit: catalog.IntervalMonth, Count: 1}, 2, time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC), catalog.Interval{Unit: catalog.IntervalMonth, Count: 1}, 3, time.Date(2020, 4, 30, 0, 0, 0, 0, time.UTC)},
+		// End of month clamping, non-leap year.
+		{time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC), catalog.Interval{Unit: catalog.IntervalMonth, Count: 1}, 13, time.Date(2021, 2, 28, 0, 0, 0, 0, time.UTC)},
+		// Quarterly, across the year end.
+		{time.Date(2020, 11, 30, 12, 0, 0, 0, time.UTC), catalog.Interval{Unit: catalog.IntervalMonth, Count: 3}, 1, time.Date(2021, 2, 28, 12, 0, 0, 0, time.UTC)},
+		// Before the anchor.
+		{time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC), catalog.Interval{Unit: catalog.IntervalMonth, Count: 1}, -1, time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Leap day, yearly.
+		{time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC), catalog.Interval{Unit: catalog.IntervalYear, Count: 1}, 1, time.Date(2021, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC), catalog.Interval{Unit: catalog.IntervalYear, Count: 1}, 4, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Daily and weekly.
+		{time.Date(2020, 12, 30, 8, 0, 0, 0, time.UTC), catalog.Interval{Unit: catalog.IntervalDay, Count: 1}, 3, time.Date(2021, 1, 2, 8, 0, 0, 0, time.UTC)},
+		{time.Date(2020, 12, 30, 8, 0, 0, 0, time.UTC), catalog.Interval{Unit: catalog.IntervalWeek, Count: 2}, 1, time.Date(2021, 1, 13, 8, 0, 0, 0, time.UTC)},
+		// DST starts (Berlin, Mar 29th), the local time is kept.
+		{time.Date(2020, 3, 15, 0, 0, 0, 0, berlin), catalog.Interval{Unit: catalog.IntervalMonth, Count: 1}, 1, time.Date(2020, 4, 14, 22, 0, 0, 0, time.UTC)},
+		// DST ends (Berlin, Oct 25th), the local time is kept.
+		{time.Date(2020, 10, 24, 0, 0, 0, 0, berlin), catalog.Interval{Unit: catalog.IntervalDay, Count: 1}, 2, time.Date(2020, 10, 25, 23, 0, 0, 0, time.UTC)},
+		// DST starts (New York, Mar 8th), the local time is kept.
+		{time.Date(2020, 3, 7, 0, 0, 0, 0, newYork), catalog.Interval{Unit: catalog.IntervalDay, Count: 1}, 2, time.Date(2020, 3, 9, 4, 0, 0, 0, time.UTC)},
+		{time.Date(2020, 3, 1, 0, 0, 0, 0, newYork), catalog.Interval{Unit: catalog.IntervalWeek, Count: 1}, 1, time.Date(2020, 3, 8, 5, 0, 0, 0, time.UTC)},
+		{time.Date(2020, 3, 1, 0, 0, 0, 0, newYork), catalog.Interval{Unit: catalog.IntervalWeek, Count: 1}, 2, time.Date(2020, 3, 15, 4, 0, 0, 0, time.UTC)},
+		// The anchor's local time doesn't exist on the DST start day, it is moved forward.
+		{time.Date(2020, 2, 29, 2, 30, 0, 0, newYork), catalog.Interval{Unit: catalog.IntervalDay, Count: 1}, 8, time.Date(2020, 3, 8, 7, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			s := period.NewSchedule(tt.anchor, tt.interval, tt.anchor.Location())
+			got := s.Boundary(tt.n)
+			if !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want.In(tt.anchor.Location()))
+			}
+		})
+	}
+}
+
+func TestSchedule_PeriodAt(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	monthly := catalog.Interval{Unit: catalog.IntervalMonth, Count: 1}
+	tests := []struct {
+		anchor   time.Time
+		interval catalog.Interval
+		t        time.Time
+		want     period.Period
+		wantN    int
+	}{
+		{
+			time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC), monthly,
+			time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC),
+			period.Period{Start: time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC), End: time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)},
+			0,
+		},
+		{
+			time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC), monthly,
+			time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC),
+			period.Period{Start: time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC), End: time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC)},
+			1,
+		},
+		{
+			time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC), monthly,
+			time.Date(2021, 2, 27, 23, 59, 59, 0, time.UTC),
+			period.Period{Start: time.Date(2021, 1, 31, 0, 0, 0, 0, time.UTC), End: time.Date(2021, 2, 28, 0, 0, 0, 0, time.UTC)},
+			12,
+		},
+		{
+			time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC), monthly,
+			time.Date(2019, 12, 15, 0, 0, 0, 0, time.UTC),
+			period.Period{Start: time.Date(2019, 11, 30, 0, 0, 0, 0, time.UTC), End: time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC)},
+			-2,
+		},
+		// Midnight in Berlin is still the previous day in UTC.
+		{
+			time.Date(2020, 6, 1, 0, 0, 0, 0, berlin), monthly,
+			time.Date(2020, 6, 30, 22, 30, 0, 0, time.UTC),
+			period.Period{Start: time.Date(2020, 6, 30, 22, 0, 0, 0, time.UTC), End: time.Date(2020, 7, 31, 22, 0, 0, 0, time.UTC)},
+			1,
+		},
+		{
+			time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), catalog.Interval{Unit: catalog.IntervalDay, Count: 7},
+			time.Date(2020, 12, 31, 12, 0, 0, 0, time.UTC),
+			period.Period{Start: time.Date(2020, 12, 30, 0, 0, 0, 0, time.UTC), End: time.Date(2021, 1, 6, 0, 0, 0, 0, time.UTC)},
+			52,
+		},
+		{
+			time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC), catalog.Interval{Unit: catalog.IntervalYear, Count: 1},
+			time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC),
+			period.Period{Start: time.Date(2023, 2, 28, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+			3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			s := period.NewSchedule(tt.anchor, tt.interval, tt.anchor.Location())
+			got, gotN := s.PeriodAt(tt.t)
+			if !got.Start.Equal(tt.want.Start) || !got.End.Equal(tt.want.End) {
+				t.Errorf("got %v - %v, want %v - %v", got.Start, got.End, tt.want.Start, tt.want.End)
+			}
+			if gotN != tt.wantN {
+				t.Errorf("got n %v, want %v", gotN, tt.wantN)
+			}
+			if !got.Contains(tt.t) {
+				t.Errorf("period %v - %v doesn't contain %v", got.Start, got.End, tt.t)
+			}
+		})
+	}
+}
+
+func TestNewScheduleOnDay(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	monthly := catalog.Interval{Unit: catalog.IntervalMonth, Count: 1}
+	tests := []struct {
+		start      time.Time
+		day        int
+		loc        *time.Location
+		wantAnchor time.Time
+		wantNext   time.Time
+	}{
+		// Start before the day.
+		{time.Date(2020, 1, 10, 15, 0, 0, 0, time.UTC), 15, time.UTC, time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC), time.Date(2020, 2, 15, 0, 0, 0, 0, time.UTC)},
+		// Start after the day.
+		{time.Date(2020, 1, 10, 15, 0, 0, 0, time.UTC), 1, time.UTC, time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)},
+		// Start at the exact time.
+		{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), 1, time.UTC, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)},
+		// Clamped anchor, unclamped next boundary.
+		{time.Date(2020, 2, 10, 0, 0, 0, 0, time.UTC), 31, time.UTC, time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC), time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC)},
+		// Across the year end.
+		{time.Date(2020, 12, 20, 0, 0, 0, 0, time.UTC), 5, time.UTC, time.Date(2021, 1, 5, 0, 0, 0, 0, time.UTC), time.Date(2021, 2, 5, 0, 0, 0, 0, time.UTC)},
+		// The start is already the 1st in Berlin, but not in UTC.
+		{time.Date(2020, 6, 30, 23, 0, 0, 0, time.UTC), 1, berlin, time.Date(2020, 7, 31, 22, 0, 0, 0, time.UTC), time.Date(2020, 8, 31, 22, 0, 0, 0, time.UTC)},
+		{time.Date(2020, 6, 30, 21, 0, 0, 0, time.UTC), 1, berlin, time.Date(2020, 6, 30, 22, 0, 0, 0, time.UTC), time.Date(2020, 7, 31, 22, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			s := period.NewScheduleOnDay(tt.start, tt.day, monthly, tt.loc)
+			if !s.Anchor().Equal(tt.wantAnchor) {
+				t.Errorf("got anchor %v, want %v", s.Anchor(), tt.wantAnchor)
+			}
+			if got := s.Boundary(1); !got.Equal(tt.wantNext) {
+				t.Errorf("got next %v, want %v", got, tt.wantNext)
+			}
+			// Restoring the schedule produces the same boundaries.
+			restored := period.FromAnchor(s.Anchor().UTC(), s.Day(), monthly, tt.loc)
+			if got := restored.Boundary(1); !got.Equal(tt.wantNext) {
+				t.Errorf("got restored next %v, want %v", got, tt.wantNext)
+			}
+		})
+	}
+}
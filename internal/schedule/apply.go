@@ -0,0 +1,86 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/coupon"
+	"github.com/runbilliam/billiam/internal/subscription"
+)
+
+// Apply applies the phases of the subscription's active schedule
+// which start at or before the given time, usually the start of the
+// subscription's new period.
+//
+// The subscription gets the price and quantity of the latest applied
+// phase, but isn't saved, leaving that to the caller. Coupons are
+// redeemed right away, replacing the coupon of the previous phase.
+// Coupons which reached their redemption limit in the meantime are
+// skipped. Returns whether a phase was applied.
+func Apply(ctx context.Context, tx pgx.Tx, sub *subscription.Subscription, at, now time.Time) (bool, error) {
+	repo := NewRepository(tx)
+	s, err := repo.GetActive(ctx, sub.ID)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	applied := false
+	for {
+		phase, ok := s.NextPhase()
+		if !ok || phase.StartsAt.After(at) {
+			break
+		}
+		previous, _ := s.CurrentPhase()
+		if err := applyCoupon(ctx, tx, *sub, previous, phase); err != nil {
+			return false, fmt.Errorf("apply schedule %v: %w", s.ID, err)
+		}
+		sub.PriceID = phase.PriceID
+		sub.ChangeQuantity(phase.Quantity, ulid.ULID{}, now)
+		s.Advance(now)
+		applied = true
+	}
+	if !applied {
+		return false, nil
+	}
+
+	return true, repo.Update(ctx, &s)
+}
+
+// applyCoupon replaces the coupon of the previous phase with the coupon of the given phase.
+//
+// A coupon redeemed outside of the schedule is only replaced
+// if the given phase has a coupon.
+func applyCoupon(ctx context.Context, tx pgx.Tx, sub subscription.Subscription, previous, phase Phase) error {
+	if phase.CouponID == previous.CouponID {
+		return nil
+	}
+	repo := coupon.NewRepository(tx)
+	if phase.CouponID != (ulid.ULID{}) || previous.CouponID != (ulid.ULID{}) {
+		err := repo.DeleteRedemption(ctx, sub.ID)
+		if err != nil && !errors.Is(err, coupon.ErrRedemptionNotFound) {
+			return err
+		}
+	}
+	if phase.CouponID == (ulid.ULID{}) {
+		return nil
+	}
+	c, err := repo.Get(ctx, phase.CouponID)
+	if err != nil {
+		return err
+	}
+	err = repo.Redeem(ctx, &c, coupon.NewRedemption(c.ID, sub.CustomerID, sub.ID))
+	if errors.Is(err, coupon.ErrLimitReached) {
+		return nil
+	}
+
+	return err
+}
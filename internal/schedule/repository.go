@@ -0,0 +1,122 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+const scheduleColumns = `id, subscription_id, status, phases, phases_applied, created_at, updated_at, completed_at, canceled_at`
+
+// Repository loads and saves schedules.
+type Repository struct {
+	db database.Querier
+}
+
+// NewRepository creates a new schedule repository.
+func NewRepository(db database.Querier) *Repository {
+	return &Repository{db: db}
+}
+
+// GetActive gets the active schedule of the given subscription.
+func (r *Repository) GetActive(ctx context.Context, subscriptionID ulid.ULID) (Schedule, error) {
+	row := r.db.QueryRow(ctx, `
+		SELECT `+scheduleColumns+` FROM subscription_schedules
+		WHERE subscription_id = $1 AND status = $2`,
+		subscriptionID.String(), string(StatusActive),
+	)
+
+	return scanSchedule(row)
+}
+
+// Create creates the given schedule.
+//
+// Returns ErrAlreadyScheduled if the subscription already has an active schedule.
+func (r *Repository) Create(ctx context.Context, s Schedule) error {
+	phases, err := marshalPhases(s.Phases)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO subscription_schedules (`+scheduleColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		s.ID.String(), s.SubscriptionID.String(), string(s.Status), phases, s.PhasesApplied,
+		s.CreatedAt, database.NullTime(s.UpdatedAt), database.NullTime(s.CompletedAt), database.NullTime(s.CanceledAt),
+	)
+	if database.IsUniqueViolation(err) {
+		return ErrAlreadyScheduled
+	}
+
+	return err
+}
+
+// Update updates the status and progress of the given schedule.
+//
+// On success, s.UpdatedAt is set to the current time.
+func (r *Repository) Update(ctx context.Context, s *Schedule) error {
+	updatedAt := time.Now().UTC()
+	tag, err := r.db.Exec(ctx, `
+		UPDATE subscription_schedules
+		SET status = $2, phases_applied = $3, updated_at = $4, completed_at = $5, canceled_at = $6
+		WHERE id = $1`,
+		s.ID.String(), string(s.Status), s.PhasesApplied, updatedAt,
+		database.NullTime(s.CompletedAt), database.NullTime(s.CanceledAt),
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	s.UpdatedAt = updatedAt
+
+	return nil
+}
+
+// marshalPhases marshals the given phases to JSON, as an empty list if there are none.
+func marshalPhases(phases []Phase) ([]byte, error) {
+	if phases == nil {
+		phases = []Phase{}
+	}
+	return json.Marshal(phases)
+}
+
+// scanSchedule scans a schedule from the given row.
+func scanSchedule(row pgx.Row) (Schedule, error) {
+	var s Schedule
+	var id, subscriptionID, status string
+	var phases []byte
+	var updatedAt, completedAt, canceledAt *time.Time
+	err := row.Scan(&id, &subscriptionID, &status, &phases, &s.PhasesApplied,
+		&s.CreatedAt, &updatedAt, &completedAt, &canceledAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Schedule{}, ErrNotFound
+		}
+		return Schedule{}, err
+	}
+	if s.ID, err = ulid.Parse(id); err != nil {
+		return Schedule{}, err
+	}
+	if s.SubscriptionID, err = ulid.Parse(subscriptionID); err != nil {
+		return Schedule{}, err
+	}
+	if err := json.Unmarshal(phases, &s.Phases); err != nil {
+		return Schedule{}, err
+	}
+	s.Status = Status(status)
+	s.UpdatedAt = database.TimeValue(updatedAt)
+	s.CompletedAt = database.TimeValue(completedAt)
+	s.CanceledAt = database.TimeValue(canceledAt)
+
+	return s, nil
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package schedule provides subscription schedules, used to
+// change a subscription's price, quantity and coupon ahead of time.
+package schedule
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/period"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+// MaxPhases is the maximum number of phases in a schedule.
+const MaxPhases = 10
+
+// ErrNotFound is returned when a schedule could not be found.
+var ErrNotFound = errors.New("schedule not found")
+
+// ErrAlreadyScheduled is returned when a subscription already has an active schedule.
+var ErrAlreadyScheduled = errors.New("subscription already has a schedule")
+
+// Status represents a schedule status.
+type Status string
+
+// Schedule statuses.
+const (
+	// StatusActive is used for schedules with phases left to apply.
+	StatusActive Status = "active"
+	// StatusCompleted is used for schedules whose last phase was applied.
+	StatusCompleted Status = "completed"
+	// StatusCanceled is used for schedules canceled before their last phase.
+	StatusCanceled Status = "canceled"
+)
+
+// IsValid returns whether s is a known status.
+func (s Status) IsValid() bool {
+	return s == StatusActive || s == StatusCompleted || s == StatusCanceled
+}
+
+// Schedule represents a series of phases applied to a subscription.
+//
+// Phases start at the subscription's period boundaries, the first
+// one at the end of the current period. Each phase lasts a number
+// of periods, except the last one, which lasts until the subscription
+// ends. The schedule is completed once its last phase is applied.
+type Schedule struct {
+	ID             ulid.ULID `json:"id"`
+	SubscriptionID ulid.ULID `json:"subscription_id"`
+	Status         Status    `json:"status"`
+	Phases         []Phase   `json:"phases"`
+	// PhasesApplied is the number of phases applied so far.
+	PhasesApplied int       `json:"phases_applied"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	CompletedAt   time.Time `json:"completed_at"`
+	CanceledAt    time.Time `json:"canceled_at"`
+}
+
+// Phase represents a schedule phase.
+type Phase struct {
+	PriceID  ulid.ULID `json:"price_id"`
+	Quantity int64     `json:"quantity"`
+	// CouponID is the coupon redeemed when the phase starts, replacing
+	// the coupon of the previous phase. Zero for phases without a coupon.
+	CouponID ulid.ULID `json:"coupon_id"`
+	// Iterations is the number of billing periods the phase lasts.
+	// Zero for the last phase.
+	Iterations int `json:"iterations"`
+	// StartsAt is the start of the phase's first period, see SetStartDates.
+	StartsAt time.Time `json:"starts_at"`
+}
+
+// New creates a new schedule for the given subscription.
+func New(subscriptionID ulid.ULID) Schedule {
+	now := time.Now().UTC()
+	s := Schedule{
+		ID:             ulid.MustNew(ulid.Timestamp(now), rand.Reader),
+		SubscriptionID: subscriptionID,
+		Status:         StatusActive,
+		CreatedAt:      now,
+	}
+
+	return s
+}
+
+// SetStartDates sets the start date of each phase, using the
+// subscription's billing schedule.
+//
+// The first phase starts at the given time, usually the end of the
+// subscription's current period, and each following phase starts
+// once the previous one has lasted its number of periods.
+func (s *Schedule) SetStartDates(billing period.Schedule, start time.Time) {
+	for i := range s.Phases {
+		s.Phases[i].StartsAt = start.UTC()
+		_, n := billing.PeriodAt(start)
+		start = billing.Boundary(n + s.Phases[i].Iterations)
+	}
+}
+
+// NextPhase returns the next phase to be applied, if any.
+func (s Schedule) NextPhase() (Phase, bool) {
+	if s.Status != StatusActive || s.PhasesApplied >= len(s.Phases) {
+		return Phase{}, false
+	}
+	return s.Phases[s.PhasesApplied], true
+}
+
+// CurrentPhase returns the most recently applied phase, if any.
+func (s Schedule) CurrentPhase() (Phase, bool) {
+	if s.PhasesApplied == 0 {
+		return Phase{}, false
+	}
+	return s.Phases[s.PhasesApplied-1], true
+}
+
+// Advance marks the next phase as applied, completing
+// the schedule once its last phase is applied.
+func (s *Schedule) Advance(now time.Time) {
+	if _, ok := s.NextPhase(); !ok {
+		return
+	}
+	s.PhasesApplied++
+	if s.PhasesApplied == len(s.Phases) {
+		s.Status = StatusCompleted
+		s.CompletedAt = now.UTC()
+	}
+}
+
+// Cancel cancels the schedule, leaving the subscription
+// on its current price, quantity and coupon.
+func (s *Schedule) Cancel(now time.Time) {
+	s.Status = StatusCanceled
+	s.CanceledAt = now.UTC()
+}
+
+// Validate validates the schedule.
+func (s Schedule) Validate() validation.Errors {
+	errs := validation.Errors{}
+	if s.ID == (ulid.ULID{}) {
+		errs.Add("id", validation.Required("ID is required."))
+	}
+	if s.SubscriptionID == (ulid.ULID{}) {
+		errs.Add("subscription_id", validation.Required("Subscription is required."))
+	}
+	if s.Status == "" {
+		errs.Add("status", validation.Required("Status is required."))
+	} else if !s.Status.IsValid() {
+		errs.Add("status", validation.InvalidChoice("Invalid status."))
+	}
+	if len(s.Phases) == 0 {
+		errs.Add("phases", validation.Required("Phases are required."))
+	} else if len(s.Phases) > MaxPhases {
+		errs.Add("phases", validation.InvalidValue(fmt.Sprintf("A schedule can have at most %d phases.", MaxPhases)))
+	}
+	if s.CreatedAt.IsZero() {
+		errs.Add("created_at", validation.Required("CreatedAt is required."))
+	}
+
+	for i, p := range s.Phases {
+		path := "phases." + strconv.Itoa(i)
+		last := i == len(s.Phases)-1
+		if p.PriceID == (ulid.ULID{}) {
+			errs.Add(path+".price_id", validation.Required("Price is required."))
+		}
+		if p.Quantity < 1 {
+			errs.Add(path+".quantity", validation.InvalidValue("Quantity must be at least 1."))
+		}
+		if last && p.Iterations != 0 {
+			errs.Add(path+".iterations", validation.InvalidValue("The last phase lasts until the subscription ends."))
+		} else if !last && p.Iterations < 1 {
+			errs.Add(path+".iterations", validation.InvalidValue("Iterations must be at least 1."))
+		}
+	}
+	if s.PhasesApplied < 0 || s.PhasesApplied > len(s.Phases) {
+		errs.Add("phases_applied", validation.InvalidValue("Invalid number of applied phases."))
+	}
+
+	return errs
+}
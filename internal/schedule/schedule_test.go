@@ -0,0 +1,117 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/catalog"
+	"github.com/runbilliam/billiam/internal/period"
+	"github.com/runbilliam/billiam/internal/schedule"
+	"github.com/runbilliam/billiam/internal/testutil"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+func TestSchedule_SetStartDates(t *testing.T) {
+	loc, _ := time.LoadLocation("America/New_York")
+	interval := catalog.Interval{Unit: catalog.IntervalMonth, Count: 1}
+	billing := period.NewSchedule(time.Date(2020, 1, 31, 0, 0, 0, 0, loc), interval, loc)
+	s := schedule.New(testutil.NewID())
+	s.Phases = []schedule.Phase{
+		{PriceID: testutil.NewID(), Quantity: 1, Iterations: 3},
+		{PriceID: testutil.NewID(), Quantity: 1, Iterations: 1},
+		{PriceID: testutil.NewID(), Quantity: 1},
+	}
+	s.SetStartDates(billing, time.Date(2020, 2, 29, 0, 0, 0, 0, loc))
+
+	// Boundaries are clamped to the end of shorter months.
+	wantStarts := []time.Time{
+		time.Date(2020, 2, 29, 0, 0, 0, 0, loc),
+		time.Date(2020, 5, 31, 0, 0, 0, 0, loc),
+		time.Date(2020, 6, 30, 0, 0, 0, 0, loc),
+	}
+	for i, want := range wantStarts {
+		if !s.Phases[i].StartsAt.Equal(want) {
+			t.Errorf("phase %v: got %v, want %v", i, s.Phases[i].StartsAt, want)
+		}
+	}
+}
+
+func TestSchedule_Advance(t *testing.T) {
+	now := time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC)
+	s := schedule.New(testutil.NewID())
+	s.Phases = []schedule.Phase{
+		{PriceID: testutil.NewID(), Quantity: 1, Iterations: 1},
+		{PriceID: testutil.NewID(), Quantity: 5},
+	}
+	if _, ok := s.CurrentPhase(); ok {
+		t.Error("expected no current phase")
+	}
+	for i := range s.Phases {
+		next, ok := s.NextPhase()
+		if !ok || next.PriceID != s.Phases[i].PriceID {
+			t.Errorf("got %v, want phase %v", next, i)
+		}
+		s.Advance(now)
+		current, _ := s.CurrentPhase()
+		if current.PriceID != s.Phases[i].PriceID {
+			t.Errorf("got %v, want phase %v", current, i)
+		}
+	}
+	if s.Status != schedule.StatusCompleted || !s.CompletedAt.Equal(now) {
+		t.Errorf("got %v at %v, want %v at %v", s.Status, s.CompletedAt, schedule.StatusCompleted, now)
+	}
+	if _, ok := s.NextPhase(); ok {
+		t.Error("expected no next phase")
+	}
+	// Completed schedules don't advance further.
+	s.Advance(now)
+	if s.PhasesApplied != 2 {
+		t.Errorf("got %v, want 2", s.PhasesApplied)
+	}
+
+	s = schedule.New(testutil.NewID())
+	s.Phases = []schedule.Phase{{PriceID: testutil.NewID(), Quantity: 1}}
+	s.Cancel(now)
+	if _, ok := s.NextPhase(); ok {
+		t.Error("expected no next phase for a canceled schedule")
+	}
+}
+
+func TestSchedule_Validate(t *testing.T) {
+	tests := []struct {
+		modify   func(s *schedule.Schedule)
+		wantPath string
+		wantCode string
+	}{
+		{func(s *schedule.Schedule) { s.Phases = nil }, "phases", validation.CodeRequired},
+		{func(s *schedule.Schedule) { s.Phases = make([]schedule.Phase, schedule.MaxPhases+1) }, "phases", validation.CodeInvalidValue},
+		{func(s *schedule.Schedule) { s.Phases[0].PriceID = ulid.ULID{} }, "phases.0.price_id", validation.CodeRequired},
+		{func(s *schedule.Schedule) { s.Phases[1].Quantity = 0 }, "phases.1.quantity", validation.CodeInvalidValue},
+		{func(s *schedule.Schedule) { s.Phases[0].Iterations = 0 }, "phases.0.iterations", validation.CodeInvalidValue},
+		{func(s *schedule.Schedule) { s.Phases[1].Iterations = 2 }, "phases.1.iterations", validation.CodeInvalidValue},
+		{func(s *schedule.Schedule) { s.Status = "paused" }, "status", validation.CodeInvalidChoice},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			s := schedule.New(testutil.NewID())
+			s.Phases = []schedule.Phase{
+				{PriceID: testutil.NewID(), Quantity: 1, Iterations: 3},
+				{PriceID: testutil.NewID(), Quantity: 2},
+			}
+			if errs := s.Validate(); !errs.IsEmpty() {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			tt.modify(&s)
+			errs := s.Validate()
+			err, ok := errs.Get(tt.wantPath).(validation.Error)
+			if !ok || err.Code != tt.wantCode {
+				t.Errorf("%v: got %#v, want a %v error", tt.wantPath, errs.Get(tt.wantPath), tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package sequence provides gapless document numbering.
+//
+// Numbers are formatted using a pattern such as "INV-{YYYY}-{00000}",
+// and allocated from sequences stored in the database.
+package sequence
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Reset represents a sequence reset policy.
+type Reset string
+
+// Reset policies.
+const (
+	// ResetYearly restarts the sequence at 1 each year.
+	ResetYearly Reset = "yearly"
+	// ResetNever never restarts the sequence.
+	ResetNever Reset = "never"
+)
+
+// IsValid returns whether the reset policy is known.
+func (r Reset) IsValid() bool {
+	return r == ResetYearly || r == ResetNever
+}
+
+// placeholderRe matches pattern placeholders.
+var placeholderRe = regexp.MustCompile(`\{(YYYY|YY|0+)\}`)
+
+// Format formats a number using the given pattern.
+//
+// Supported placeholders:
+//  - {YYYY}: The four digit year.
+//  - {YY}: The two digit year.
+//  - {00000}: The number, zero padded to the number of zeroes.
+func Format(pattern string, year int, n int64) string {
+	return placeholderRe.ReplaceAllStringFunc(pattern, func(placeholder string) string {
+		switch placeholder {
+		case "{YYYY}":
+			return fmt.Sprintf("%04d", year)
+		case "{YY}":
+			return fmt.Sprintf("%02d", year%100)
+		default:
+			width := len(placeholder) - 2
+			s := strconv.FormatInt(n, 10)
+			if len(s) < width {
+				s = strings.Repeat("0", width-len(s)) + s
+			}
+			return s
+		}
+	})
+}
+
+// HasNumber returns whether the pattern contains a number placeholder.
+func HasNumber(pattern string) bool {
+	for _, placeholder := range placeholderRe.FindAllString(pattern, -1) {
+		if placeholder[1] == '0' {
+			return true
+		}
+	}
+	return false
+}
+
+// HasYear returns whether the pattern contains a year placeholder.
+func HasYear(pattern string) bool {
+	for _, placeholder := range placeholderRe.FindAllString(pattern, -1) {
+		if placeholder[1] == 'Y' {
+			return true
+		}
+	}
+	return false
+}
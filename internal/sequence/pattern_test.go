@@ -0,0 +1,62 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package sequence_test
+
+import (
+	"testing"
+
+	"github.com/runbilliam/billiam/internal/sequence"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		pattern string
+		year    int
+		n       int64
+		want    string
+	}{
+		{"INV-{YYYY}-{00000}", 2020, 1, "INV-2020-00001"},
+		{"INV-{YYYY}-{00000}", 2021, 12345, "INV-2021-12345"},
+		{"INV-{YYYY}-{00000}", 2021, 1234567, "INV-2021-1234567"},
+		{"{YY}/{000}", 2009, 42, "09/042"},
+		{"{0}", 2020, 7, "7"},
+		{"INV{0000}", 2020, 15, "INV0015"},
+		{"{YYYY}{YYYY}-{00}", 2020, 3, "20202020-03"},
+		{"{YYY}-{00}", 2020, 3, "{YYY}-03"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got := sequence.Format(tt.pattern, tt.year, tt.n)
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasNumber(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		wantNumber bool
+		wantYear   bool
+	}{
+		{"INV-{YYYY}-{00000}", true, true},
+		{"INV-{00000}", true, false},
+		{"INV-{YY}", false, true},
+		{"INV-{YYY}-{0A}", false, false},
+		{"", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			if got := sequence.HasNumber(tt.pattern); got != tt.wantNumber {
+				t.Errorf("HasNumber(%q): got %v, want %v", tt.pattern, got, tt.wantNumber)
+			}
+			if got := sequence.HasYear(tt.pattern); got != tt.wantYear {
+				t.Errorf("HasYear(%q): got %v, want %v", tt.pattern, got, tt.wantYear)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package sequence
+
+import (
+	"context"
+
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+// Store allocates sequence values.
+type Store struct {
+	db database.Querier
+}
+
+// NewStore creates a new sequence store.
+func NewStore(db database.Querier) *Store {
+	return &Store{db: db}
+}
+
+// Next allocates the next value of the given sequence.
+//
+// Sequences reset yearly are keyed by year, while sequences that
+// never reset use year 0. The first allocated value is 1.
+//
+// The sequence row stays locked until the end of the current transaction,
+// so concurrent allocations (from any process) wait for it to commit or
+// roll back. A rolled back allocation is released, keeping the sequence
+// gapless. Next must therefore be called inside the same transaction
+// that stores the allocated number.
+func (s *Store) Next(ctx context.Context, name string, reset Reset, year int) (int64, error) {
+	if reset != ResetYearly {
+		year = 0
+	}
+	var n int64
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO sequences (name, year, last_value) VALUES ($1, $2, 1)
+		ON CONFLICT (name, year) DO UPDATE SET last_value = sequences.last_value + 1
+		RETURNING last_value`,
+		name, year,
+	).Scan(&n)
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
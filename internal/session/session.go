@@ -0,0 +1,144 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package session provides server-side sessions for logged in users.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+// ErrNotFound is returned when a session could not be found, or has expired.
+var ErrNotFound = errors.New("session not found")
+
+// Session represents a logged in user's session.
+//
+// The session token is only known to the client. The session ID is
+// the token's SHA-256 hash, so that leaked IDs can't be used to log in.
+type Session struct {
+	ID        string
+	UserID    ulid.ULID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// New creates a new session for the given user.
+//
+// Returns the session and its token.
+func New(userID ulid.ULID, lifetime time.Duration) (Session, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return Session{}, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	now := time.Now().UTC()
+	s := Session{
+		ID:        hashToken(token),
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(lifetime),
+	}
+
+	return s, token, nil
+}
+
+// IsExpired returns whether the session has expired.
+func (s Session) IsExpired() bool {
+	return !time.Now().Before(s.ExpiresAt)
+}
+
+// Store loads and saves sessions.
+type Store struct {
+	db database.Querier
+}
+
+// NewStore creates a new session store.
+func NewStore(db database.Querier) *Store {
+	return &Store{db: db}
+}
+
+// Get gets the session with the given token.
+//
+// Expired sessions are treated as missing.
+func (s *Store) Get(ctx context.Context, token string) (Session, error) {
+	var sess Session
+	var userID string
+	err := s.db.QueryRow(ctx, `
+		SELECT id, user_id, created_at, expires_at
+		FROM sessions WHERE id = $1 AND expires_at > now()`,
+		hashToken(token),
+	).Scan(&sess.ID, &userID, &sess.CreatedAt, &sess.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Session{}, ErrNotFound
+		}
+		return Session{}, err
+	}
+	sess.UserID, err = ulid.Parse(userID)
+	if err != nil {
+		return Session{}, err
+	}
+
+	return sess, nil
+}
+
+// Create creates the given session.
+func (s *Store) Create(ctx context.Context, sess Session) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO sessions (id, user_id, created_at, expires_at)
+		VALUES ($1, $2, $3, $4)`,
+		sess.ID, sess.UserID.String(), sess.CreatedAt, sess.ExpiresAt,
+	)
+
+	return err
+}
+
+// Extend sets a new expiry time for the session with the given ID.
+func (s *Store) Extend(ctx context.Context, id string, expiresAt time.Time) error {
+	_, err := s.db.Exec(ctx, `UPDATE sessions SET expires_at = $2 WHERE id = $1`, id, expiresAt)
+
+	return err
+}
+
+// Delete deletes the session with the given token.
+func (s *Store) Delete(ctx context.Context, token string) error {
+	_, err := s.db.Exec(ctx, `DELETE FROM sessions WHERE id = $1`, hashToken(token))
+
+	return err
+}
+
+// DeleteByUser deletes all sessions of the given user.
+func (s *Store) DeleteByUser(ctx context.Context, userID ulid.ULID) error {
+	_, err := s.db.Exec(ctx, `DELETE FROM sessions WHERE user_id = $1`, userID.String())
+
+	return err
+}
+
+// DeleteExpired deletes expired sessions.
+//
+// Returns the number of deleted sessions.
+func (s *Store) DeleteExpired(ctx context.Context) (int64, error) {
+	tag, err := s.db.Exec(ctx, `DELETE FROM sessions WHERE expires_at <= now()`)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// hashToken returns the hex-encoded SHA-256 hash of the given token.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -4,18 +4,42 @@
 package settings
 
 import (
+	"fmt"
+	"strconv"
+
 	"github.com/bojanz/currency"
 
+	"github.com/runbilliam/billiam/internal/sequence"
+	"github.com/runbilliam/billiam/internal/subscription"
 	"github.com/runbilliam/billiam/pkg/timezone"
 	"github.com/runbilliam/billiam/pkg/validation"
 )
 
+// MaxDunningRetries is the maximum number of payment retries.
+const MaxDunningRetries = 10
+
 // Settings represent the site configuration.
 type Settings struct {
 	Version  int    `json:"version"`
 	SiteName string `json:"site_name"`
 	Timezone string `json:"timezone"`
 	Currency string `json:"currency"`
+	// TaxRate is the default tax rate, as a percentage (e.g. "20").
+	TaxRate string `json:"tax_rate"`
+	// InvoiceNumberPattern is the pattern used for invoice numbers,
+	// e.g. "INV-{YYYY}-{00000}". See sequence.Format.
+	InvoiceNumberPattern string         `json:"invoice_number_pattern"`
+	InvoiceNumberReset   sequence.Reset `json:"invoice_number_reset"`
+	// CreditNoteNumberPattern is the pattern used for credit note numbers,
+	// e.g. "CN-{YYYY}-{00000}". See sequence.Format.
+	CreditNoteNumberPattern string         `json:"credit_note_number_pattern"`
+	CreditNoteNumberReset   sequence.Reset `json:"credit_note_number_reset"`
+	// DunningSchedule lists the days after the first failed payment
+	// on which the payment is retried, e.g. [1, 3, 5, 7].
+	DunningSchedule []int `json:"dunning_schedule"`
+	// DunningFinalStatus is the status of subscriptions whose payment
+	// retries have been exhausted, either canceled or unpaid.
+	DunningFinalStatus subscription.Status `json:"dunning_final_status"`
 }
 
 // New creates new settings.
@@ -25,6 +49,16 @@ func New() Settings {
 		SiteName: "Billiam",
 		Timezone: "Europe/Berlin",
 		Currency: "EUR",
+		TaxRate:  "0",
+
+		InvoiceNumberPattern: "INV-{YYYY}-{00000}",
+		InvoiceNumberReset:   sequence.ResetYearly,
+
+		CreditNoteNumberPattern: "CN-{YYYY}-{00000}",
+		CreditNoteNumberReset:   sequence.ResetYearly,
+
+		DunningSchedule:    []int{1, 3, 5, 7},
+		DunningFinalStatus: subscription.StatusCanceled,
 	}
 
 	return s
@@ -46,6 +80,58 @@ func (s Settings) Validate() validation.Errors {
 		errs.Add("currency", validation.Required("Currency is required."))
 	}
 
+	if s.InvoiceNumberPattern == "" {
+		errs.Add("invoice_number_pattern", validation.Required("Invoice number pattern is required."))
+	}
+	if s.InvoiceNumberReset == "" {
+		errs.Add("invoice_number_reset", validation.Required("Invoice number reset is required."))
+	}
+	if s.CreditNoteNumberPattern == "" {
+		errs.Add("credit_note_number_pattern", validation.Required("Credit note number pattern is required."))
+	}
+	if s.CreditNoteNumberReset == "" {
+		errs.Add("credit_note_number_reset", validation.Required("Credit note number reset is required."))
+	}
+	if s.DunningFinalStatus == "" {
+		errs.Add("dunning_final_status", validation.Required("Dunning final status is required."))
+	}
+
+	if s.TaxRate != "" {
+		rate, err := strconv.ParseFloat(s.TaxRate, 64)
+		if err != nil || rate < 0 || rate > 100 {
+			errs.Add("tax_rate", validation.InvalidValue("Tax rate must be a percentage between 0 and 100."))
+		}
+	}
+	if s.InvoiceNumberPattern != "" && !sequence.HasNumber(s.InvoiceNumberPattern) {
+		errs.Add("invoice_number_pattern", validation.InvalidValue("Invoice number pattern must contain a number placeholder, e.g. {00000}."))
+	}
+	if s.InvoiceNumberReset != "" && !s.InvoiceNumberReset.IsValid() {
+		errs.Add("invoice_number_reset", validation.InvalidChoice("Invalid invoice number reset."))
+	}
+	if s.InvoiceNumberReset == sequence.ResetYearly && !sequence.HasYear(s.InvoiceNumberPattern) {
+		errs.Add("invoice_number_pattern", validation.InvalidValue("Invoice number pattern must contain a year placeholder when reset yearly."))
+	}
+	if s.CreditNoteNumberPattern != "" && !sequence.HasNumber(s.CreditNoteNumberPattern) {
+		errs.Add("credit_note_number_pattern", validation.InvalidValue("Credit note number pattern must contain a number placeholder, e.g. {00000}."))
+	}
+	if s.CreditNoteNumberReset != "" && !s.CreditNoteNumberReset.IsValid() {
+		errs.Add("credit_note_number_reset", validation.InvalidChoice("Invalid credit note number reset."))
+	}
+	if s.CreditNoteNumberReset == sequence.ResetYearly && !sequence.HasYear(s.CreditNoteNumberPattern) {
+		errs.Add("credit_note_number_pattern", validation.InvalidValue("Credit note number pattern must contain a year placeholder when reset yearly."))
+	}
+	if len(s.DunningSchedule) > MaxDunningRetries {
+		errs.Add("dunning_schedule", validation.InvalidValue(fmt.Sprintf("Dunning schedule can have at most %d retries.", MaxDunningRetries)))
+	}
+	for i, day := range s.DunningSchedule {
+		if day < 1 || (i > 0 && day <= s.DunningSchedule[i-1]) {
+			errs.Add("dunning_schedule", validation.InvalidValue("Dunning schedule days must be positive and increasing."))
+			break
+		}
+	}
+	if s.DunningFinalStatus != "" && s.DunningFinalStatus != subscription.StatusCanceled && s.DunningFinalStatus != subscription.StatusUnpaid {
+		errs.Add("dunning_final_status", validation.InvalidChoice("Invalid dunning final status."))
+	}
 	if !timezone.IsValid(s.Timezone) {
 		errs.Add("timezone", validation.InvalidChoice("Invalid timezone."))
 	}
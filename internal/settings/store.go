@@ -0,0 +1,127 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package settings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/runbilliam/billiam/internal/sequence"
+	"github.com/runbilliam/billiam/internal/subscription"
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+// ErrNotFound is returned when the settings have not been created yet.
+var ErrNotFound = errors.New("settings not found")
+
+// ErrExists is returned when attempting to create existing settings.
+var ErrExists = errors.New("settings already exist")
+
+// ConflictError is returned when the settings could not be updated
+// because they were modified in the meantime.
+type ConflictError struct {
+	Version int
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("settings were modified, version %v is out of date", e.Version)
+}
+
+// Store loads and saves the settings.
+//
+// The settings are stored as a single row.
+type Store struct {
+	db database.Querier
+}
+
+// NewStore creates a new settings store.
+func NewStore(db database.Querier) *Store {
+	return &Store{db: db}
+}
+
+// Get gets the settings.
+func (s *Store) Get(ctx context.Context) (Settings, error) {
+	var st Settings
+	var reset, creditNoteReset, finalStatus string
+	err := s.db.QueryRow(ctx, `
+		SELECT version, site_name, timezone, currency, tax_rate, invoice_number_pattern, invoice_number_reset,
+			credit_note_number_pattern, credit_note_number_reset, dunning_schedule, dunning_final_status
+		FROM settings WHERE id = 1`,
+	).Scan(&st.Version, &st.SiteName, &st.Timezone, &st.Currency, &st.TaxRate,
+		&st.InvoiceNumberPattern, &reset, &st.CreditNoteNumberPattern, &creditNoteReset,
+		&st.DunningSchedule, &finalStatus)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Settings{}, ErrNotFound
+		}
+		return Settings{}, err
+	}
+	st.InvoiceNumberReset = sequence.Reset(reset)
+	st.CreditNoteNumberReset = sequence.Reset(creditNoteReset)
+	st.DunningFinalStatus = subscription.Status(finalStatus)
+
+	return st, nil
+}
+
+// Create creates the settings.
+//
+// Returns ErrExists if the settings have already been created.
+func (s *Store) Create(ctx context.Context, st Settings) error {
+	tag, err := s.db.Exec(ctx, `
+		INSERT INTO settings (id, version, site_name, timezone, currency, tax_rate,
+			invoice_number_pattern, invoice_number_reset, credit_note_number_pattern, credit_note_number_reset,
+			dunning_schedule, dunning_final_status)
+		VALUES (1, $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (id) DO NOTHING`,
+		st.Version, st.SiteName, st.Timezone, st.Currency, st.TaxRate,
+		st.InvoiceNumberPattern, string(st.InvoiceNumberReset), st.CreditNoteNumberPattern,
+		string(st.CreditNoteNumberReset), st.DunningSchedule, string(st.DunningFinalStatus),
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrExists
+	}
+
+	return nil
+}
+
+// Update updates the settings.
+//
+// The update only succeeds if the stored version matches st.Version,
+// otherwise a *ConflictError is returned. On success, st.Version is
+// incremented.
+func (s *Store) Update(ctx context.Context, st *Settings) error {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE settings
+		SET version = version + 1, site_name = $2, timezone = $3, currency = $4, tax_rate = $5,
+			invoice_number_pattern = $6, invoice_number_reset = $7, credit_note_number_pattern = $8,
+			credit_note_number_reset = $9, dunning_schedule = $10, dunning_final_status = $11
+		WHERE id = 1 AND version = $1`,
+		st.Version, st.SiteName, st.Timezone, st.Currency, st.TaxRate,
+		st.InvoiceNumberPattern, string(st.InvoiceNumberReset), st.CreditNoteNumberPattern,
+		string(st.CreditNoteNumberReset), st.DunningSchedule, string(st.DunningFinalStatus),
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		var exists bool
+		err := s.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM settings WHERE id = 1)`).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrNotFound
+		}
+		return &ConflictError{Version: st.Version}
+	}
+	st.Version++
+
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package subscription
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+// MaxCancelFeedbackLength is the maximum length of cancellation feedback, in characters.
+const MaxCancelFeedbackLength = 2000
+
+// CancelReason represents the reason a subscription was canceled.
+type CancelReason string
+
+// Cancellation reasons.
+const (
+	CancelTooExpensive      CancelReason = "too_expensive"
+	CancelMissingFeatures   CancelReason = "missing_features"
+	CancelSwitchedService   CancelReason = "switched_service"
+	CancelUnused            CancelReason = "unused"
+	CancelCustomerService   CancelReason = "customer_service"
+	CancelTooComplex        CancelReason = "too_complex"
+	CancelLowQuality        CancelReason = "low_quality"
+	CancelTrialNotConverted CancelReason = "trial_not_converted"
+	CancelOther             CancelReason = "other"
+)
+
+// GetCancelReasons returns all known cancellation reasons.
+func GetCancelReasons() []CancelReason {
+	return []CancelReason{
+		CancelTooExpensive,
+		CancelMissingFeatures,
+		CancelSwitchedService,
+		CancelUnused,
+		CancelCustomerService,
+		CancelTooComplex,
+		CancelLowQuality,
+		CancelTrialNotConverted,
+		CancelOther,
+	}
+}
+
+// IsValid returns whether r is a known cancellation reason.
+func (r CancelReason) IsValid() bool {
+	for _, reason := range GetCancelReasons() {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// Cancellation represents a request to cancel a subscription.
+type Cancellation struct {
+	Reason CancelReason
+	// Feedback is optional free-text feedback from the customer.
+	Feedback string
+}
+
+// Validate validates the cancellation.
+func (c Cancellation) Validate() validation.Errors {
+	errs := validation.Errors{}
+	if c.Reason == "" {
+		errs.Add("reason", validation.Required("Reason is required."))
+	} else if !c.Reason.IsValid() {
+		errs.Add("reason", validation.InvalidChoice("Invalid reason."))
+	}
+	if utf8.RuneCountInString(c.Feedback) > MaxCancelFeedbackLength {
+		errs.Add("feedback", validation.InvalidValue(fmt.Sprintf("Feedback can't be longer than %d characters.", MaxCancelFeedbackLength)))
+	}
+
+	return errs
+}
+
+// Cancel cancels the subscription immediately, recording the reason.
+func (s *Subscription) Cancel(c Cancellation, now time.Time) validation.Errors {
+	if errs := s.TransitionTo(StatusCanceled, now); !errs.IsEmpty() {
+		return errs
+	}
+	s.CancelAtPeriodEnd = false
+	s.CancelReason = c.Reason
+	s.CancelFeedback = c.Feedback
+	if s.CancelRequestedAt.IsZero() {
+		s.CancelRequestedAt = now.UTC()
+	}
+
+	return validation.Errors{}
+}
+
+// CancelAtEnd schedules the subscription to be canceled
+// at the end of its current period, recording the reason.
+//
+// Until then, the cancellation can be undone, see Reactivate.
+// Errors are reported on the "status" path.
+func (s *Subscription) CancelAtEnd(c Cancellation, now time.Time) validation.Errors {
+	errs := validation.Errors{}
+	if !s.Status.CanTransitionTo(StatusCanceled) || s.Status == StatusIncomplete || s.Status == StatusUnpaid {
+		errs.Add("status", validation.InvalidValue(fmt.Sprintf("Can't cancel a %s subscription at period end.", s.Status)))
+		return errs
+	}
+	s.CancelAtPeriodEnd = true
+	s.CancelReason = c.Reason
+	s.CancelFeedback = c.Feedback
+	s.CancelRequestedAt = now.UTC()
+
+	return errs
+}
+
+// Reactivate undoes a cancellation scheduled for the end of the period.
+//
+// Errors are reported on the "status" path.
+func (s *Subscription) Reactivate() validation.Errors {
+	errs := validation.Errors{}
+	if s.Status == StatusCanceled || !s.CancelAtPeriodEnd {
+		errs.Add("status", validation.InvalidValue("Only subscriptions scheduled for cancellation can be reactivated."))
+		return errs
+	}
+	s.CancelAtPeriodEnd = false
+	s.CancelReason = ""
+	s.CancelFeedback = ""
+	s.CancelRequestedAt = time.Time{}
+
+	return errs
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package subscription
+
+import (
+	"time"
+
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+// PauseBehavior represents what happens to the invoices
+// generated while a subscription is paused.
+type PauseBehavior string
+
+// Pause behaviors.
+const (
+	// PauseVoid finalizes and voids the invoices.
+	PauseVoid PauseBehavior = "void"
+	// PauseMarkUncollectible finalizes the invoices and marks them
+	// as uncollectible, so that they can still be paid later.
+	PauseMarkUncollectible PauseBehavior = "mark_uncollectible"
+	// PauseKeepAsDraft keeps the invoices as drafts.
+	PauseKeepAsDraft PauseBehavior = "keep_as_draft"
+)
+
+// IsValid returns whether b is a known pause behavior.
+func (b PauseBehavior) IsValid() bool {
+	return b == PauseVoid || b == PauseMarkUncollectible || b == PauseKeepAsDraft
+}
+
+// ResumeBehavior represents what happens to the billing
+// cycle when a subscription is resumed.
+type ResumeBehavior string
+
+// Resume behaviors.
+const (
+	// ResumeKeepCycle keeps the billing cycle. The next invoice is
+	// generated at the end of the current period.
+	ResumeKeepCycle ResumeBehavior = "keep_cycle"
+	// ResumeReanchor anchors the billing cycle at the time of resumption,
+	// starting and invoicing a new period right away.
+	ResumeReanchor ResumeBehavior = "reanchor"
+)
+
+// IsValid returns whether b is a known resume behavior.
+func (b ResumeBehavior) IsValid() bool {
+	return b == ResumeKeepCycle || b == ResumeReanchor
+}
+
+// Pause pauses the subscription.
+//
+// Only active and past due subscriptions can be paused. The subscription
+// is resumed automatically at the given time, unless it is zero.
+// Errors are reported on the "status" and "resumes_at" paths.
+func (s *Subscription) Pause(behavior PauseBehavior, resumesAt time.Time, resumeBehavior ResumeBehavior, now time.Time) validation.Errors {
+	errs := validation.Errors{}
+	if s.Status != StatusActive && s.Status != StatusPastDue {
+		errs.Add("status", validation.InvalidValue("Only active and past due subscriptions can be paused."))
+		return errs
+	}
+	if !resumesAt.IsZero() && !resumesAt.After(now) {
+		errs.Add("resumes_at", validation.InvalidValue("Resume time must be in the future."))
+		return errs
+	}
+	if errs := s.TransitionTo(StatusPaused, now); !errs.IsEmpty() {
+		return errs
+	}
+	s.PauseBehavior = behavior
+	s.ResumeBehavior = resumeBehavior
+	s.PausedAt = now.UTC()
+	s.ResumesAt = time.Time{}
+	if !resumesAt.IsZero() {
+		s.ResumesAt = resumesAt.UTC()
+	}
+
+	return errs
+}
+
+// Resume transitions the paused subscription back to active,
+// clearing its pause.
+//
+// The billing cycle is left unchanged, re-anchoring it
+// is up to the caller, see ResumeBehavior.
+func (s *Subscription) Resume(now time.Time) validation.Errors {
+	if s.Status != StatusPaused {
+		errs := validation.Errors{}
+		errs.Add("status", validation.InvalidValue("Only paused subscriptions can be resumed."))
+		return errs
+	}
+	if errs := s.TransitionTo(StatusActive, now); !errs.IsEmpty() {
+		return errs
+	}
+	s.PauseBehavior = ""
+	s.ResumeBehavior = ""
+	s.PausedAt = time.Time{}
+	s.ResumesAt = time.Time{}
+
+	return validation.Errors{}
+}
+
+// ResumeIsDue returns whether the paused subscription
+// is due to be resumed automatically.
+func (s Subscription) ResumeIsDue(now time.Time) bool {
+	return s.Status == StatusPaused && !s.ResumesAt.IsZero() && !s.ResumesAt.After(now)
+}
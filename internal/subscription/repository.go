@@ -0,0 +1,422 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+const subscriptionColumns = `id, version, customer_id, price_id, quantity, status, current_period_start, current_period_end,
+	billing_cycle_anchor, billing_cycle_day, created_at, updated_at, status_changed_at, canceled_at,
+	trial_start, trial_end, trial_end_notified_at, metered_price_ids,
+	pause_behavior, resume_behavior, paused_at, resumes_at,
+	cancel_at_period_end, cancel_reason, cancel_feedback, cancel_requested_at`
+
+// ConflictError is returned when a subscription could not be updated
+// because it was modified in the meantime.
+type ConflictError struct {
+	ID      ulid.ULID
+	Version int
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("subscription %v was modified, version %v is out of date", e.ID, e.Version)
+}
+
+// Repository loads and saves subscriptions.
+type Repository struct {
+	db database.Querier
+}
+
+// NewRepository creates a new subscription repository.
+func NewRepository(db database.Querier) *Repository {
+	return &Repository{db: db}
+}
+
+// Get gets the subscription with the given ID.
+func (r *Repository) Get(ctx context.Context, id ulid.ULID) (Subscription, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions WHERE id = $1`, id.String())
+
+	return scanSubscription(row)
+}
+
+// ListByCustomer lists the subscriptions of the given customer, ordered by creation time.
+func (r *Repository) ListByCustomer(ctx context.Context, customerID ulid.ULID) ([]Subscription, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+subscriptionColumns+` FROM subscriptions
+		WHERE customer_id = $1 ORDER BY id`,
+		customerID.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSubscriptions(rows)
+}
+
+// ListStatusChanges lists the status changes of the given subscription, oldest first.
+func (r *Repository) ListStatusChanges(ctx context.Context, id ulid.ULID) ([]StatusChange, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT from_status, to_status, changed_at
+		FROM subscription_status_changes
+		WHERE subscription_id = $1 ORDER BY id`,
+		id.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []StatusChange
+	for rows.Next() {
+		change := StatusChange{SubscriptionID: id}
+		var from *string
+		if err := rows.Scan(&from, &change.To, &change.ChangedAt); err != nil {
+			return nil, err
+		}
+		if from != nil {
+			change.From = Status(*from)
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+// Create creates the given subscription.
+//
+// Pending status changes are persisted along with the subscription.
+func (r *Repository) Create(ctx context.Context, s *Subscription) error {
+	meteredPriceIDs, err := marshalIDs(s.MeteredPriceIDs)
+	if err != nil {
+		return err
+	}
+	return database.WithTx(ctx, r.db, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO subscriptions (`+subscriptionColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18,
+				$19, $20, $21, $22, $23, $24, $25, $26)`,
+			s.ID.String(), s.Version, s.CustomerID.String(), s.PriceID.String(), s.Quantity, string(s.Status),
+			database.NullTime(s.CurrentPeriodStart), database.NullTime(s.CurrentPeriodEnd),
+			database.NullTime(s.BillingCycleAnchor), s.BillingCycleDay,
+			s.CreatedAt, database.NullTime(s.UpdatedAt), s.StatusChangedAt, database.NullTime(s.CanceledAt),
+			database.NullTime(s.TrialStart), database.NullTime(s.TrialEnd), database.NullTime(s.TrialEndNotifiedAt),
+			meteredPriceIDs, nullString(string(s.PauseBehavior)), nullString(string(s.ResumeBehavior)),
+			database.NullTime(s.PausedAt), database.NullTime(s.ResumesAt),
+			s.CancelAtPeriodEnd, nullString(string(s.CancelReason)), nullString(s.CancelFeedback),
+			database.NullTime(s.CancelRequestedAt),
+		)
+		if err != nil {
+			return err
+		}
+		return r.saveChanges(ctx, tx, s)
+	})
+}
+
+// Update updates the given subscription.
+//
+// The update only succeeds if the stored version matches s.Version,
+// otherwise a *ConflictError is returned. On success, s.Version is
+// incremented and s.UpdatedAt is set to the current time.
+// Pending status changes are persisted along with the subscription.
+func (r *Repository) Update(ctx context.Context, s *Subscription) error {
+	meteredPriceIDs, err := marshalIDs(s.MeteredPriceIDs)
+	if err != nil {
+		return err
+	}
+	updatedAt := time.Now().UTC()
+	err = database.WithTx(ctx, r.db, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE subscriptions
+			SET version = version + 1, price_id = $3, status = $4, current_period_start = $5,
+				current_period_end = $6, billing_cycle_anchor = $7, billing_cycle_day = $8,
+				updated_at = $9, status_changed_at = $10, canceled_at = $11, trial_start = $12, trial_end = $13,
+				trial_end_notified_at = $14, metered_price_ids = $15, quantity = $16,
+				pause_behavior = $17, resume_behavior = $18, paused_at = $19, resumes_at = $20,
+				cancel_at_period_end = $21, cancel_reason = $22, cancel_feedback = $23, cancel_requested_at = $24
+			WHERE id = $1 AND version = $2`,
+			s.ID.String(), s.Version, s.PriceID.String(), string(s.Status),
+			database.NullTime(s.CurrentPeriodStart), database.NullTime(s.CurrentPeriodEnd),
+			database.NullTime(s.BillingCycleAnchor), s.BillingCycleDay, updatedAt, s.StatusChangedAt, database.NullTime(s.CanceledAt),
+			database.NullTime(s.TrialStart), database.NullTime(s.TrialEnd), database.NullTime(s.TrialEndNotifiedAt),
+			meteredPriceIDs, s.Quantity, nullString(string(s.PauseBehavior)), nullString(string(s.ResumeBehavior)),
+			database.NullTime(s.PausedAt), database.NullTime(s.ResumesAt),
+			s.CancelAtPeriodEnd, nullString(string(s.CancelReason)), nullString(s.CancelFeedback),
+			database.NullTime(s.CancelRequestedAt),
+		)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			var exists bool
+			err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM subscriptions WHERE id = $1)`, s.ID.String()).Scan(&exists)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return ErrNotFound
+			}
+			return &ConflictError{ID: s.ID, Version: s.Version}
+		}
+		return r.saveChanges(ctx, tx, s)
+	})
+	if err != nil {
+		return err
+	}
+	s.Version++
+	s.UpdatedAt = updatedAt
+
+	return nil
+}
+
+// ListDue lists the subscriptions whose current period ended before the given time,
+// and the paused subscriptions due to be resumed.
+//
+// Only trialing, active, past due and paused subscriptions are listed.
+// Subscriptions whose renewal failed are left out until their next
+// renewal attempt, see MarkRenewalFailed, as are the given skipped
+// subscriptions. The rows are locked until the end of the transaction,
+// skipping rows already locked by another process, so that each
+// subscription is renewed only once.
+func (r *Repository) ListDue(ctx context.Context, now time.Time, skip []ulid.ULID, limit int) ([]Subscription, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+subscriptionColumns+` FROM subscriptions
+		WHERE ((status IN ($1, $2, $3, $4) AND current_period_end <= $5) OR (status = $4 AND resumes_at <= $5))
+			AND (next_renewal_attempt_at IS NULL OR next_renewal_attempt_at <= $5) AND id <> ALL($6)
+		ORDER BY LEAST(current_period_end, resumes_at) LIMIT $7
+		FOR UPDATE SKIP LOCKED`,
+		string(StatusTrialing), string(StatusActive), string(StatusPastDue), string(StatusPaused), now,
+		stringIDs(skip), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSubscriptions(rows)
+}
+
+// MarkRenewalFailed records a failed renewal of the given subscription,
+// postponing the next renewal attempt until the given time.
+//
+// Skips the version check, since the failed renewal was rolled back.
+func (r *Repository) MarkRenewalFailed(ctx context.Context, id ulid.ULID, failedAt, retryAt time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE subscriptions SET renewal_failed_at = $2, next_renewal_attempt_at = $3
+		WHERE id = $1`,
+		id.String(), failedAt.UTC(), retryAt.UTC(),
+	)
+
+	return err
+}
+
+// ClearRenewalFailure clears the failed renewal of the given
+// subscription, if any, see MarkRenewalFailed.
+func (r *Repository) ClearRenewalFailure(ctx context.Context, id ulid.ULID) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE subscriptions SET renewal_failed_at = NULL, next_renewal_attempt_at = NULL
+		WHERE id = $1 AND renewal_failed_at IS NOT NULL`,
+		id.String(),
+	)
+
+	return err
+}
+
+// ListTrialsEnding lists the trialing subscriptions whose trial ends
+// before the given time, and whose customer hasn't been notified yet,
+// leaving out the given skipped subscriptions.
+//
+// The rows are locked until the end of the transaction, skipping rows
+// already locked by another process.
+func (r *Repository) ListTrialsEnding(ctx context.Context, before time.Time, skip []ulid.ULID, limit int) ([]Subscription, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+subscriptionColumns+` FROM subscriptions
+		WHERE status = $1 AND trial_end <= $2 AND trial_end_notified_at IS NULL AND id <> ALL($3)
+		ORDER BY trial_end LIMIT $4
+		FOR UPDATE SKIP LOCKED`,
+		string(StatusTrialing), before, stringIDs(skip), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSubscriptions(rows)
+}
+
+// ListQuantityChanges lists the quantity changes of the given subscription, oldest first.
+func (r *Repository) ListQuantityChanges(ctx context.Context, id ulid.ULID) ([]QuantityChange, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT from_quantity, to_quantity, changed_by, changed_at
+		FROM subscription_quantity_changes
+		WHERE subscription_id = $1 ORDER BY id`,
+		id.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []QuantityChange
+	for rows.Next() {
+		change := QuantityChange{SubscriptionID: id}
+		var changedBy *string
+		if err := rows.Scan(&change.From, &change.To, &changedBy, &change.ChangedAt); err != nil {
+			return nil, err
+		}
+		if changedBy != nil {
+			if change.ChangedBy, err = ulid.Parse(*changedBy); err != nil {
+				return nil, err
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+// saveChanges persists and clears the subscription's pending status and quantity changes.
+func (r *Repository) saveChanges(ctx context.Context, tx pgx.Tx, s *Subscription) error {
+	for _, change := range s.changes {
+		var from *string
+		if change.From != "" {
+			f := string(change.From)
+			from = &f
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO subscription_status_changes (subscription_id, from_status, to_status, changed_at)
+			VALUES ($1, $2, $3, $4)`,
+			change.SubscriptionID.String(), from, string(change.To), change.ChangedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	s.changes = nil
+	for _, change := range s.quantityChanges {
+		var changedBy *string
+		if change.ChangedBy != (ulid.ULID{}) {
+			id := change.ChangedBy.String()
+			changedBy = &id
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO subscription_quantity_changes (subscription_id, from_quantity, to_quantity, changed_by, changed_at)
+			VALUES ($1, $2, $3, $4, $5)`,
+			change.SubscriptionID.String(), change.From, change.To, changedBy, change.ChangedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	s.quantityChanges = nil
+
+	return nil
+}
+
+// nullString returns nil for empty strings, to store them as NULL.
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// stringIDs converts the given IDs to strings, for use with
+// array parameters. Never nil, since NULL arrays match nothing.
+func stringIDs(ids []ulid.ULID) []string {
+	s := make([]string, 0, len(ids))
+	for _, id := range ids {
+		s = append(s, id.String())
+	}
+	return s
+}
+
+// marshalIDs marshals the given IDs to JSON, as an empty list if there are none.
+func marshalIDs(ids []ulid.ULID) ([]byte, error) {
+	if ids == nil {
+		ids = []ulid.ULID{}
+	}
+	return json.Marshal(ids)
+}
+
+// scanSubscriptions scans subscriptions from the given rows.
+func scanSubscriptions(rows pgx.Rows) ([]Subscription, error) {
+	defer rows.Close()
+
+	var subscriptions []Subscription
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, s)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// scanSubscription scans a subscription from the given row.
+func scanSubscription(row pgx.Row) (Subscription, error) {
+	var s Subscription
+	var id, customerID, priceID, status string
+	var periodStart, periodEnd, anchor, updatedAt, canceledAt, trialStart, trialEnd, notifiedAt, pausedAt, resumesAt, cancelRequestedAt *time.Time
+	var pauseBehavior, resumeBehavior, cancelReason, cancelFeedback *string
+	var meteredPriceIDs []byte
+	err := row.Scan(&id, &s.Version, &customerID, &priceID, &s.Quantity, &status, &periodStart, &periodEnd,
+		&anchor, &s.BillingCycleDay, &s.CreatedAt, &updatedAt, &s.StatusChangedAt, &canceledAt,
+		&trialStart, &trialEnd, &notifiedAt, &meteredPriceIDs,
+		&pauseBehavior, &resumeBehavior, &pausedAt, &resumesAt,
+		&s.CancelAtPeriodEnd, &cancelReason, &cancelFeedback, &cancelRequestedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Subscription{}, ErrNotFound
+		}
+		return Subscription{}, err
+	}
+	if s.ID, err = ulid.Parse(id); err != nil {
+		return Subscription{}, err
+	}
+	if s.CustomerID, err = ulid.Parse(customerID); err != nil {
+		return Subscription{}, err
+	}
+	if s.PriceID, err = ulid.Parse(priceID); err != nil {
+		return Subscription{}, err
+	}
+	if err := json.Unmarshal(meteredPriceIDs, &s.MeteredPriceIDs); err != nil {
+		return Subscription{}, err
+	}
+	s.Status = Status(status)
+	s.CurrentPeriodStart = database.TimeValue(periodStart)
+	s.CurrentPeriodEnd = database.TimeValue(periodEnd)
+	s.BillingCycleAnchor = database.TimeValue(anchor)
+	s.UpdatedAt = database.TimeValue(updatedAt)
+	s.CanceledAt = database.TimeValue(canceledAt)
+	s.TrialStart = database.TimeValue(trialStart)
+	s.TrialEnd = database.TimeValue(trialEnd)
+	s.TrialEndNotifiedAt = database.TimeValue(notifiedAt)
+	if pauseBehavior != nil {
+		s.PauseBehavior = PauseBehavior(*pauseBehavior)
+	}
+	if resumeBehavior != nil {
+		s.ResumeBehavior = ResumeBehavior(*resumeBehavior)
+	}
+	s.PausedAt = database.TimeValue(pausedAt)
+	s.ResumesAt = database.TimeValue(resumesAt)
+	if cancelReason != nil {
+		s.CancelReason = CancelReason(*cancelReason)
+	}
+	if cancelFeedback != nil {
+		s.CancelFeedback = *cancelFeedback
+	}
+	s.CancelRequestedAt = database.TimeValue(cancelRequestedAt)
+
+	return s, nil
+}
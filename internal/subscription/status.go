@@ -0,0 +1,71 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package subscription
+
+// Status represents a subscription status.
+type Status string
+
+// Subscription statuses.
+const (
+	// StatusIncomplete is used for subscriptions whose first payment hasn't succeeded yet.
+	StatusIncomplete Status = "incomplete"
+	// StatusTrialing is used for subscriptions in a free trial.
+	StatusTrialing Status = "trialing"
+	// StatusActive is used for paid up subscriptions.
+	StatusActive Status = "active"
+	// StatusPastDue is used for subscriptions whose latest payment has failed.
+	StatusPastDue Status = "past_due"
+	// StatusUnpaid is used for subscriptions whose payment retries have been
+	// exhausted. They are no longer renewed, but can be reactivated once paid.
+	StatusUnpaid Status = "unpaid"
+	// StatusPaused is used for subscriptions that are temporarily not billed.
+	StatusPaused Status = "paused"
+	// StatusCanceled is used for subscriptions that have ended. Final.
+	StatusCanceled Status = "canceled"
+)
+
+// transitions maps each status to the statuses it can transition to.
+var transitions = map[Status][]Status{
+	StatusIncomplete: {StatusActive, StatusTrialing, StatusCanceled},
+	StatusTrialing:   {StatusActive, StatusPastDue, StatusPaused, StatusCanceled},
+	StatusActive:     {StatusPastDue, StatusPaused, StatusCanceled},
+	StatusPastDue:    {StatusActive, StatusUnpaid, StatusPaused, StatusCanceled},
+	StatusUnpaid:     {StatusActive, StatusCanceled},
+	StatusPaused:     {StatusActive, StatusCanceled},
+	StatusCanceled:   {},
+}
+
+// GetStatuses returns all known statuses.
+func GetStatuses() []Status {
+	return []Status{
+		StatusIncomplete,
+		StatusTrialing,
+		StatusActive,
+		StatusPastDue,
+		StatusUnpaid,
+		StatusPaused,
+		StatusCanceled,
+	}
+}
+
+// IsValid returns whether the status is known.
+func (s Status) IsValid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// IsFinal returns whether the status can't be transitioned out of.
+func (s Status) IsFinal() bool {
+	return s.IsValid() && len(transitions[s]) == 0
+}
+
+// CanTransitionTo returns whether s can transition to the given status.
+func (s Status) CanTransitionTo(to Status) bool {
+	for _, status := range transitions[s] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,300 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package subscription provides subscriptions and their lifecycle.
+package subscription
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/catalog"
+	"github.com/runbilliam/billiam/internal/period"
+	"github.com/runbilliam/billiam/pkg/timezone"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+// ErrNotFound is returned when a subscription could not be found.
+var ErrNotFound = errors.New("subscription not found")
+
+// Subscription represents a customer's subscription to a price.
+type Subscription struct {
+	ID         ulid.ULID `json:"id"`
+	Version    int       `json:"version"`
+	CustomerID ulid.ULID `json:"customer_id"`
+	PriceID    ulid.ULID `json:"price_id"`
+	// Quantity is the number of units charged for, e.g. seats.
+	Quantity int64 `json:"quantity"`
+	// MeteredPriceIDs lists the metered prices whose usage is billed
+	// at the end of each period, in addition to the price.
+	MeteredPriceIDs    []ulid.ULID `json:"metered_price_ids"`
+	Status             Status      `json:"status"`
+	CurrentPeriodStart time.Time   `json:"current_period_start"`
+	CurrentPeriodEnd   time.Time   `json:"current_period_end"`
+	// BillingCycleAnchor is the first period boundary, used with
+	// BillingCycleDay to restore the subscription's period.Schedule.
+	BillingCycleAnchor time.Time `json:"billing_cycle_anchor"`
+	BillingCycleDay    int       `json:"billing_cycle_day"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+	// StatusChangedAt is the time of the most recent status change.
+	StatusChangedAt time.Time `json:"status_changed_at"`
+	CanceledAt      time.Time `json:"canceled_at"`
+	// TrialStart and TrialEnd are set for subscriptions which started with
+	// a free trial. The trial is the subscription's first period.
+	TrialStart time.Time `json:"trial_start"`
+	TrialEnd   time.Time `json:"trial_end"`
+	// TrialEndNotifiedAt is the time the customer was notified of the trial ending.
+	TrialEndNotifiedAt time.Time `json:"trial_end_notified_at"`
+	// PauseBehavior and ResumeBehavior are set for paused subscriptions.
+	PauseBehavior  PauseBehavior  `json:"pause_behavior"`
+	ResumeBehavior ResumeBehavior `json:"resume_behavior"`
+	PausedAt       time.Time      `json:"paused_at"`
+	// ResumesAt is the time the paused subscription is resumed automatically.
+	// Zero for subscriptions paused indefinitely.
+	ResumesAt time.Time `json:"resumes_at"`
+	// CancelAtPeriodEnd is set for subscriptions which are canceled
+	// at the end of their current period, unless reactivated.
+	CancelAtPeriodEnd bool `json:"cancel_at_period_end"`
+	// CancelReason and CancelFeedback are set once cancellation is requested.
+	CancelReason      CancelReason `json:"cancel_reason"`
+	CancelFeedback    string       `json:"cancel_feedback"`
+	CancelRequestedAt time.Time    `json:"cancel_requested_at"`
+
+	// changes holds status changes not yet persisted by the repository.
+	changes []StatusChange
+	// quantityChanges holds quantity changes not yet persisted by the repository.
+	quantityChanges []QuantityChange
+}
+
+// StatusChange represents a change of a subscription's status.
+type StatusChange struct {
+	SubscriptionID ulid.ULID `json:"subscription_id"`
+	// From is empty for the initial status.
+	From      Status    `json:"from"`
+	To        Status    `json:"to"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// QuantityChange represents a change of a subscription's quantity.
+type QuantityChange struct {
+	SubscriptionID ulid.ULID `json:"subscription_id"`
+	From           int64     `json:"from"`
+	To             int64     `json:"to"`
+	// ChangedBy is the ID of the user who made the change.
+	// Zero for changes not made by a user.
+	ChangedBy ulid.ULID `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// New creates a new subscription with the given initial status.
+func New(customerID, priceID ulid.ULID, status Status) Subscription {
+	now := time.Now().UTC()
+	s := Subscription{
+		ID:              ulid.MustNew(ulid.Timestamp(now), rand.Reader),
+		Version:         1,
+		CustomerID:      customerID,
+		PriceID:         priceID,
+		Quantity:        1,
+		Status:          status,
+		CreatedAt:       now,
+		StatusChangedAt: now,
+	}
+	s.changes = append(s.changes, StatusChange{
+		SubscriptionID: s.ID,
+		To:             status,
+		ChangedAt:      now,
+	})
+
+	return s
+}
+
+// TransitionTo transitions the subscription to the given status.
+//
+// Only defined transitions are allowed, others are reported
+// as errors on the "status" path. The change is recorded and
+// persisted on the next repository save.
+func (s *Subscription) TransitionTo(to Status, now time.Time) validation.Errors {
+	errs := validation.Errors{}
+	if !to.IsValid() {
+		errs.Add("status", validation.InvalidChoice("Invalid status."))
+		return errs
+	}
+	if !s.Status.CanTransitionTo(to) {
+		errs.Add("status", validation.InvalidValue(fmt.Sprintf("Can't transition from %s to %s.", s.Status, to)))
+		return errs
+	}
+	now = now.UTC()
+	s.changes = append(s.changes, StatusChange{
+		SubscriptionID: s.ID,
+		From:           s.Status,
+		To:             to,
+		ChangedAt:      now,
+	})
+	s.Status = to
+	s.StatusChangedAt = now
+	if to == StatusCanceled {
+		s.CanceledAt = now
+	}
+
+	return errs
+}
+
+// ChangeQuantity changes the subscription's quantity.
+//
+// The change is recorded along with the user who made it,
+// and persisted on the next repository save.
+func (s *Subscription) ChangeQuantity(quantity int64, changedBy ulid.ULID, now time.Time) {
+	if quantity == s.Quantity {
+		return
+	}
+	s.quantityChanges = append(s.quantityChanges, QuantityChange{
+		SubscriptionID: s.ID,
+		From:           s.Quantity,
+		To:             quantity,
+		ChangedBy:      changedBy,
+		ChangedAt:      now.UTC(),
+	})
+	s.Quantity = quantity
+}
+
+// StartTrial starts a free trial of the given number of days.
+//
+// The trial becomes the current period. It is computed on the wall
+// clock of the given location, usually the customer's timezone, so
+// that it ends at the same local time of day as it started.
+func (s *Subscription) StartTrial(now time.Time, days int, loc *time.Location) {
+	s.TrialStart = now.UTC()
+	s.TrialEnd = timezone.AddDays(now, days, loc).UTC()
+	s.CurrentPeriodStart = s.TrialStart
+	s.CurrentPeriodEnd = s.TrialEnd
+}
+
+// EndTrial ends the free trial, anchoring the subscription's
+// billing schedule at the end of the trial.
+//
+// Returns the first paid period.
+func (s *Subscription) EndTrial(interval catalog.Interval, loc *time.Location) period.Period {
+	s.SetSchedule(period.NewSchedule(s.TrialEnd, interval, loc), s.TrialEnd)
+
+	return period.Period{Start: s.CurrentPeriodStart, End: s.CurrentPeriodEnd}
+}
+
+// ConvertTrial converts the trial into a paid subscription, once it ends.
+//
+// The trial is only converted if the customer has a payment method,
+// otherwise the subscription is canceled. Returns the first paid
+// period, which is empty for canceled subscriptions.
+func (s *Subscription) ConvertTrial(hasPaymentMethod bool, interval catalog.Interval, loc *time.Location, now time.Time) (period.Period, validation.Errors) {
+	if !hasPaymentMethod {
+		return period.Period{}, s.Cancel(Cancellation{Reason: CancelTrialNotConverted}, now)
+	}
+	if errs := s.TransitionTo(StatusActive, now); !errs.IsEmpty() {
+		return period.Period{}, errs
+	}
+
+	return s.EndTrial(interval, loc), validation.Errors{}
+}
+
+// Schedule returns the billing schedule for the given price interval.
+//
+// Periods are calculated on the wall clock of the given location,
+// usually the customer's timezone. Subscriptions without a billing
+// cycle anchor are anchored at the start of their current period.
+func (s Subscription) Schedule(interval catalog.Interval, loc *time.Location) period.Schedule {
+	if s.BillingCycleAnchor.IsZero() {
+		return period.NewSchedule(s.CurrentPeriodStart, interval, loc)
+	}
+	return period.FromAnchor(s.BillingCycleAnchor, s.BillingCycleDay, interval, loc)
+}
+
+// SetSchedule anchors the subscription to the given schedule,
+// and starts the period containing the given time.
+func (s *Subscription) SetSchedule(schedule period.Schedule, now time.Time) {
+	p, _ := schedule.PeriodAt(now)
+	s.BillingCycleAnchor = schedule.Anchor().UTC()
+	s.BillingCycleDay = schedule.Day()
+	s.CurrentPeriodStart = p.Start.UTC()
+	s.CurrentPeriodEnd = p.End.UTC()
+}
+
+// Renew advances the subscription to its next period.
+func (s *Subscription) Renew(schedule period.Schedule) period.Period {
+	p, _ := schedule.PeriodAt(s.CurrentPeriodEnd)
+	s.CurrentPeriodStart = p.Start.UTC()
+	s.CurrentPeriodEnd = p.End.UTC()
+
+	return p
+}
+
+// PendingChanges returns the status changes not yet persisted.
+func (s Subscription) PendingChanges() []StatusChange {
+	return s.changes
+}
+
+// PendingQuantityChanges returns the quantity changes not yet persisted.
+func (s Subscription) PendingQuantityChanges() []QuantityChange {
+	return s.quantityChanges
+}
+
+// Validate validates the subscription.
+func (s Subscription) Validate() validation.Errors {
+	errs := validation.Errors{}
+	if s.ID == (ulid.ULID{}) {
+		errs.Add("id", validation.Required("ID is required."))
+	}
+	if s.Version == 0 {
+		errs.Add("version", validation.Required("Version is required."))
+	}
+	if s.CustomerID == (ulid.ULID{}) {
+		errs.Add("customer_id", validation.Required("Customer is required."))
+	}
+	if s.PriceID == (ulid.ULID{}) {
+		errs.Add("price_id", validation.Required("Price is required."))
+	}
+	if s.Status == "" {
+		errs.Add("status", validation.Required("Status is required."))
+	}
+	if s.CreatedAt.IsZero() {
+		errs.Add("created_at", validation.Required("CreatedAt is required."))
+	}
+
+	if s.Status != "" && !s.Status.IsValid() {
+		errs.Add("status", validation.InvalidChoice("Invalid status."))
+	}
+	if s.Quantity < 1 {
+		errs.Add("quantity", validation.InvalidValue("Quantity must be at least 1."))
+	}
+	if !s.CurrentPeriodEnd.IsZero() && !s.CurrentPeriodEnd.After(s.CurrentPeriodStart) {
+		errs.Add("current_period_end", validation.InvalidValue("Period end must be after period start."))
+	}
+	if !s.TrialEnd.IsZero() && !s.TrialEnd.After(s.TrialStart) {
+		errs.Add("trial_end", validation.InvalidValue("Trial end must be after trial start."))
+	}
+	if s.Status == StatusPaused {
+		if !s.PauseBehavior.IsValid() {
+			errs.Add("pause_behavior", validation.InvalidChoice("Invalid pause behavior."))
+		}
+		if !s.ResumeBehavior.IsValid() {
+			errs.Add("resume_behavior", validation.InvalidChoice("Invalid resume behavior."))
+		}
+	}
+	if !s.ResumesAt.IsZero() && !s.ResumesAt.After(s.PausedAt) {
+		errs.Add("resumes_at", validation.InvalidValue("Resume time must be after pause time."))
+	}
+	if s.CancelReason != "" && !s.CancelReason.IsValid() {
+		errs.Add("cancel_reason", validation.InvalidChoice("Invalid cancellation reason."))
+	}
+	if s.CancelAtPeriodEnd && s.CancelReason == "" {
+		errs.Add("cancel_reason", validation.Required("Cancellation reason is required."))
+	}
+	if s.BillingCycleDay < 0 || s.BillingCycleDay > 31 {
+		errs.Add("billing_cycle_day", validation.InvalidValue("Billing cycle day must be between 1 and 31."))
+	}
+
+	return errs
+}
@@ -0,0 +1,374 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package subscription_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runbilliam/billiam/internal/catalog"
+	"github.com/runbilliam/billiam/internal/period"
+	"github.com/runbilliam/billiam/internal/subscription"
+	"github.com/runbilliam/billiam/internal/testutil"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+func TestStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from subscription.Status
+		to   subscription.Status
+		want bool
+	}{
+		{subscription.StatusIncomplete, subscription.StatusActive, true},
+		{subscription.StatusIncomplete, subscription.StatusPastDue, false},
+		{subscription.StatusTrialing, subscription.StatusActive, true},
+		{subscription.StatusActive, subscription.StatusPastDue, true},
+		{subscription.StatusActive, subscription.StatusTrialing, false},
+		{subscription.StatusActive, subscription.StatusIncomplete, false},
+		{subscription.StatusPastDue, subscription.StatusActive, true},
+		{subscription.StatusPastDue, subscription.StatusUnpaid, true},
+		{subscription.StatusActive, subscription.StatusUnpaid, false},
+		{subscription.StatusUnpaid, subscription.StatusActive, true},
+		{subscription.StatusUnpaid, subscription.StatusPastDue, false},
+		{subscription.StatusPaused, subscription.StatusActive, true},
+		{subscription.StatusPaused, subscription.StatusPastDue, false},
+		{subscription.StatusCanceled, subscription.StatusActive, false},
+		{subscription.StatusActive, subscription.StatusActive, false},
+		{subscription.Status("invalid"), subscription.StatusActive, false},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got := tt.from.CanTransitionTo(tt.to)
+			if got != tt.want {
+				t.Errorf("%v -> %v: got %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscription_TransitionTo(t *testing.T) {
+	s := subscription.New(testutil.NewID(), testutil.NewID(), subscription.StatusIncomplete)
+	changes := s.PendingChanges()
+	if len(changes) != 1 || changes[0].From != "" || changes[0].To != subscription.StatusIncomplete {
+		t.Errorf("unexpected initial changes: %+v", changes)
+	}
+
+	now := time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC)
+	errs := s.TransitionTo(subscription.StatusActive, now)
+	if !errs.IsEmpty() {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if s.Status != subscription.StatusActive {
+		t.Errorf("got %v, want %v", s.Status, subscription.StatusActive)
+	}
+	if !s.StatusChangedAt.Equal(now) {
+		t.Errorf("got %v, want %v", s.StatusChangedAt, now)
+	}
+
+	// Invalid transition.
+	errs = s.TransitionTo(subscription.StatusIncomplete, now)
+	err, ok := errs.Get("status").(validation.Error)
+	if !ok || err.Code != validation.CodeInvalidValue {
+		t.Errorf("got %#v, want an invalid value error", errs.Get("status"))
+	}
+	if s.Status != subscription.StatusActive {
+		t.Errorf("got %v, want %v", s.Status, subscription.StatusActive)
+	}
+
+	// Unknown status.
+	errs = s.TransitionTo(subscription.Status("unknown"), now)
+	err, ok = errs.Get("status").(validation.Error)
+	if !ok || err.Code != validation.CodeInvalidChoice {
+		t.Errorf("got %#v, want an invalid choice error", errs.Get("status"))
+	}
+
+	canceledAt := now.Add(time.Hour)
+	s.TransitionTo(subscription.StatusCanceled, canceledAt)
+	if !s.CanceledAt.Equal(canceledAt) {
+		t.Errorf("got %v, want %v", s.CanceledAt, canceledAt)
+	}
+	changes = s.PendingChanges()
+	if len(changes) != 3 {
+		t.Fatalf("got %v changes, want 3", len(changes))
+	}
+	if changes[2].From != subscription.StatusActive || changes[2].To != subscription.StatusCanceled {
+		t.Errorf("unexpected change: %+v", changes[2])
+	}
+}
+
+func TestSubscription_ChangeQuantity(t *testing.T) {
+	s := subscription.New(testutil.NewID(), testutil.NewID(), subscription.StatusActive)
+	if s.Quantity != 1 {
+		t.Errorf("got %v, want 1", s.Quantity)
+	}
+	now := time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC)
+	userID := testutil.NewID()
+	s.ChangeQuantity(5, userID, now)
+	// Unchanged quantities aren't recorded.
+	s.ChangeQuantity(5, userID, now)
+	if s.Quantity != 5 {
+		t.Errorf("got %v, want 5", s.Quantity)
+	}
+	changes := s.PendingQuantityChanges()
+	if len(changes) != 1 {
+		t.Fatalf("got %v changes, want 1", len(changes))
+	}
+	want := subscription.QuantityChange{SubscriptionID: s.ID, From: 1, To: 5, ChangedBy: userID, ChangedAt: now}
+	if changes[0] != want {
+		t.Errorf("got %+v, want %+v", changes[0], want)
+	}
+
+	s.ChangeQuantity(0, userID, now)
+	err, ok := s.Validate().Get("quantity").(validation.Error)
+	if !ok || err.Code != validation.CodeInvalidValue {
+		t.Errorf("got %#v, want an invalid value error", s.Validate().Get("quantity"))
+	}
+}
+
+func TestSubscription_Renew(t *testing.T) {
+	loc, _ := time.LoadLocation("America/New_York")
+	interval := catalog.Interval{Unit: catalog.IntervalMonth, Count: 1}
+	start := time.Date(2020, 1, 31, 0, 0, 0, 0, loc)
+	s := subscription.New(testutil.NewID(), testutil.NewID(), subscription.StatusActive)
+	s.SetSchedule(period.NewSchedule(start, interval, loc), start)
+	if s.BillingCycleDay != 31 {
+		t.Errorf("got %v, want 31", s.BillingCycleDay)
+	}
+
+	wantStarts := []time.Time{
+		time.Date(2020, 2, 29, 0, 0, 0, 0, loc),
+		time.Date(2020, 3, 31, 0, 0, 0, 0, loc),
+		time.Date(2020, 4, 30, 0, 0, 0, 0, loc),
+	}
+	for _, want := range wantStarts {
+		p := s.Renew(s.Schedule(interval, loc))
+		if !p.Start.Equal(want) || !s.CurrentPeriodStart.Equal(want) {
+			t.Errorf("got %v, want %v", p.Start, want)
+		}
+		if !s.CurrentPeriodEnd.Equal(p.End) {
+			t.Errorf("got %v, want %v", s.CurrentPeriodEnd, p.End)
+		}
+	}
+}
+
+func TestSubscription_Trial(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Berlin")
+	interval := catalog.Interval{Unit: catalog.IntervalMonth, Count: 1}
+	// The trial spans the DST change on October 25th.
+	start := time.Date(2020, 10, 20, 10, 30, 0, 0, loc)
+	s := subscription.New(testutil.NewID(), testutil.NewID(), subscription.StatusTrialing)
+	s.StartTrial(start, 14, loc)
+
+	wantEnd := time.Date(2020, 11, 3, 10, 30, 0, 0, loc)
+	if !s.TrialEnd.Equal(wantEnd) || !s.CurrentPeriodEnd.Equal(wantEnd) {
+		t.Errorf("got %v, want %v", s.TrialEnd, wantEnd)
+	}
+	if !s.TrialStart.Equal(start) || !s.CurrentPeriodStart.Equal(start) {
+		t.Errorf("got %v, want %v", s.TrialStart, start)
+	}
+	if errs := s.Validate(); !errs.IsEmpty() {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+
+	// The billing schedule is anchored at the end of the trial.
+	p := s.EndTrial(interval, loc)
+	if !p.Start.Equal(wantEnd) || !s.CurrentPeriodStart.Equal(wantEnd) {
+		t.Errorf("got %v, want %v", p.Start, wantEnd)
+	}
+	wantPeriodEnd := time.Date(2020, 12, 3, 10, 30, 0, 0, loc)
+	if !p.End.Equal(wantPeriodEnd) || !s.CurrentPeriodEnd.Equal(wantPeriodEnd) {
+		t.Errorf("got %v, want %v", p.End, wantPeriodEnd)
+	}
+	if s.BillingCycleDay != 3 {
+		t.Errorf("got %v, want 3", s.BillingCycleDay)
+	}
+}
+
+func TestSubscription_ConvertTrial(t *testing.T) {
+	interval := catalog.Interval{Unit: catalog.IntervalMonth, Count: 1}
+	start := time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC)
+	trialEnd := time.Date(2020, 11, 15, 10, 0, 0, 0, time.UTC)
+
+	// Trials are converted when the customer has a payment method.
+	s := subscription.New(testutil.NewID(), testutil.NewID(), subscription.StatusTrialing)
+	s.StartTrial(start, 14, time.UTC)
+	p, errs := s.ConvertTrial(true, interval, time.UTC, trialEnd)
+	if !errs.IsEmpty() {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if s.Status != subscription.StatusActive {
+		t.Errorf("got %v, want %v", s.Status, subscription.StatusActive)
+	}
+	wantEnd := time.Date(2020, 12, 15, 10, 0, 0, 0, time.UTC)
+	if !p.Start.Equal(trialEnd) || !p.End.Equal(wantEnd) {
+		t.Errorf("got %v to %v, want %v to %v", p.Start, p.End, trialEnd, wantEnd)
+	}
+	// Created, converted.
+	if changes := s.PendingChanges(); len(changes) != 2 {
+		t.Errorf("got %v changes, want 2", len(changes))
+	}
+
+	// Other trials are canceled.
+	s = subscription.New(testutil.NewID(), testutil.NewID(), subscription.StatusTrialing)
+	s.StartTrial(start, 14, time.UTC)
+	p, errs = s.ConvertTrial(false, interval, time.UTC, trialEnd)
+	if !errs.IsEmpty() {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if s.Status != subscription.StatusCanceled || s.CancelReason != subscription.CancelTrialNotConverted {
+		t.Errorf("got %v (%v), want %v (%v)", s.Status, s.CancelReason, subscription.StatusCanceled, subscription.CancelTrialNotConverted)
+	}
+	if p != (period.Period{}) {
+		t.Errorf("got %v, want an empty period", p)
+	}
+
+	// Only trials can be converted.
+	s = subscription.New(testutil.NewID(), testutil.NewID(), subscription.StatusActive)
+	if _, errs := s.ConvertTrial(true, interval, time.UTC, trialEnd); errs.Get("status") == nil {
+		t.Errorf("expected a status error, got %v", errs)
+	}
+}
+
+func TestSubscription_Pause(t *testing.T) {
+	now := time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC)
+	trialing := subscription.New(testutil.NewID(), testutil.NewID(), subscription.StatusTrialing)
+	if errs := trialing.Pause(subscription.PauseVoid, time.Time{}, subscription.ResumeKeepCycle, now); errs.Get("status") == nil {
+		t.Errorf("expected a status error, got %v", errs)
+	}
+	s := subscription.New(testutil.NewID(), testutil.NewID(), subscription.StatusActive)
+	if errs := s.Pause(subscription.PauseVoid, now, subscription.ResumeKeepCycle, now); errs.Get("resumes_at") == nil {
+		t.Errorf("expected a resumes_at error, got %v", errs)
+	}
+
+	resumesAt := now.Add(72 * time.Hour)
+	if errs := s.Pause(subscription.PauseMarkUncollectible, resumesAt, subscription.ResumeReanchor, now); !errs.IsEmpty() {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if s.Status != subscription.StatusPaused {
+		t.Errorf("got %v, want %v", s.Status, subscription.StatusPaused)
+	}
+	if !s.PausedAt.Equal(now) || !s.ResumesAt.Equal(resumesAt) {
+		t.Errorf("got %v to %v, want %v to %v", s.PausedAt, s.ResumesAt, now, resumesAt)
+	}
+	if errs := s.Validate(); !errs.IsEmpty() {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	if s.ResumeIsDue(resumesAt.Add(-time.Second)) {
+		t.Error("expected the resume to not be due yet")
+	}
+	if !s.ResumeIsDue(resumesAt) {
+		t.Error("expected the resume to be due")
+	}
+
+	s.PauseBehavior = "refund"
+	if err, ok := s.Validate().Get("pause_behavior").(validation.Error); !ok || err.Code != validation.CodeInvalidChoice {
+		t.Errorf("got %#v, want an invalid choice error", s.Validate().Get("pause_behavior"))
+	}
+}
+
+func TestSubscription_Resume(t *testing.T) {
+	now := time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC)
+	s := subscription.New(testutil.NewID(), testutil.NewID(), subscription.StatusActive)
+	if errs := s.Resume(now); errs.Get("status") == nil {
+		t.Errorf("expected a status error, got %v", errs)
+	}
+	s.Pause(subscription.PauseKeepAsDraft, now.Add(time.Hour), subscription.ResumeKeepCycle, now)
+	if errs := s.Resume(now.Add(time.Minute)); !errs.IsEmpty() {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if s.Status != subscription.StatusActive {
+		t.Errorf("got %v, want %v", s.Status, subscription.StatusActive)
+	}
+	if s.PauseBehavior != "" || s.ResumeBehavior != "" || !s.PausedAt.IsZero() || !s.ResumesAt.IsZero() {
+		t.Errorf("got %+v, want the pause to be cleared", s)
+	}
+	// Created, paused, resumed.
+	if changes := s.PendingChanges(); len(changes) != 3 {
+		t.Errorf("got %v changes, want 3", len(changes))
+	}
+}
+
+func TestCancellation_Validate(t *testing.T) {
+	tests := []struct {
+		c        subscription.Cancellation
+		wantPath string
+		wantCode string
+	}{
+		{subscription.Cancellation{}, "reason", validation.CodeRequired},
+		{subscription.Cancellation{Reason: "bored"}, "reason", validation.CodeInvalidChoice},
+		{subscription.Cancellation{Reason: subscription.CancelOther, Feedback: strings.Repeat("é", subscription.MaxCancelFeedbackLength+1)}, "feedback", validation.CodeInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			errs := tt.c.Validate()
+			err, ok := errs.Get(tt.wantPath).(validation.Error)
+			if !ok || err.Code != tt.wantCode {
+				t.Errorf("%v: got %#v, want a %v error", tt.wantPath, errs.Get(tt.wantPath), tt.wantCode)
+			}
+		})
+	}
+
+	c := subscription.Cancellation{Reason: subscription.CancelTooExpensive, Feedback: strings.Repeat("é", subscription.MaxCancelFeedbackLength)}
+	if errs := c.Validate(); !errs.IsEmpty() {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
+
+func TestSubscription_Cancel(t *testing.T) {
+	now := time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC)
+	c := subscription.Cancellation{Reason: subscription.CancelSwitchedService, Feedback: "Found a cheaper option."}
+	s := subscription.New(testutil.NewID(), testutil.NewID(), subscription.StatusActive)
+	if errs := s.Cancel(c, now); !errs.IsEmpty() {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if s.Status != subscription.StatusCanceled || !s.CanceledAt.Equal(now) {
+		t.Errorf("got %v at %v, want %v at %v", s.Status, s.CanceledAt, subscription.StatusCanceled, now)
+	}
+	if s.CancelReason != c.Reason || s.CancelFeedback != c.Feedback || !s.CancelRequestedAt.Equal(now) {
+		t.Errorf("got %v %q at %v, want %v %q at %v", s.CancelReason, s.CancelFeedback, s.CancelRequestedAt, c.Reason, c.Feedback, now)
+	}
+	if errs := s.Cancel(c, now); errs.Get("status") == nil {
+		t.Errorf("expected a status error, got %v", errs)
+	}
+	if errs := s.CancelAtEnd(c, now); errs.Get("status") == nil {
+		t.Errorf("expected a status error, got %v", errs)
+	}
+}
+
+func TestSubscription_CancelAtEnd(t *testing.T) {
+	now := time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC)
+	c := subscription.Cancellation{Reason: subscription.CancelUnused}
+	s := subscription.New(testutil.NewID(), testutil.NewID(), subscription.StatusActive)
+	if errs := s.Reactivate(); errs.Get("status") == nil {
+		t.Errorf("expected a status error, got %v", errs)
+	}
+	if errs := s.CancelAtEnd(c, now); !errs.IsEmpty() {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if s.Status != subscription.StatusActive || !s.CancelAtPeriodEnd || s.CancelReason != c.Reason {
+		t.Errorf("got %v, %v, %v, want an active subscription scheduled for cancellation", s.Status, s.CancelAtPeriodEnd, s.CancelReason)
+	}
+	if errs := s.Validate(); !errs.IsEmpty() {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+
+	if errs := s.Reactivate(); !errs.IsEmpty() {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if s.CancelAtPeriodEnd || s.CancelReason != "" || !s.CancelRequestedAt.IsZero() {
+		t.Errorf("got %+v, want the cancellation to be cleared", s)
+	}
+
+	// The reason is kept once the subscription is canceled at period end.
+	s.CancelAtEnd(c, now)
+	s.Cancel(c, now.AddDate(0, 0, 20))
+	if s.CancelAtPeriodEnd || s.CancelReason != c.Reason || !s.CancelRequestedAt.Equal(now) {
+		t.Errorf("got %v, %v at %v, want %v at %v", s.CancelAtPeriodEnd, s.CancelReason, s.CancelRequestedAt, c.Reason, now)
+	}
+	if errs := s.Reactivate(); errs.Get("status") == nil {
+		t.Errorf("expected a status error, got %v", errs)
+	}
+}
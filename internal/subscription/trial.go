@@ -0,0 +1,73 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+	"github.com/rs/zerolog"
+
+	"github.com/runbilliam/billiam/internal/event"
+	"github.com/runbilliam/billiam/internal/worker"
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+// TrialReminderPeriod is how long before the end of a trial the customer is notified.
+const TrialReminderPeriod = 3 * 24 * time.Hour
+
+// TrialNotifier notifies customers of trials ending soon.
+type TrialNotifier struct {
+	db     database.Querier
+	logger *zerolog.Logger
+}
+
+// NewTrialNotifier creates a new trial notifier.
+func NewTrialNotifier(db database.Querier, logger *zerolog.Logger) *TrialNotifier {
+	return &TrialNotifier{db: db, logger: logger}
+}
+
+// Run records a trial_will_end event for each trial ending within
+// TrialReminderPeriod, once per subscription.
+//
+// A subscription which fails with an error is logged, and retried on
+// the next run. Returns the number of recorded events.
+func (n *TrialNotifier) Run(ctx context.Context, now time.Time) (int, error) {
+	next := func(tx pgx.Tx, failed []ulid.ULID) (ulid.ULID, error) {
+		subs, err := NewRepository(tx).ListTrialsEnding(ctx, now.Add(TrialReminderPeriod), failed, 1)
+		if err != nil || len(subs) == 0 {
+			return ulid.ULID{}, err
+		}
+		s := subs[0]
+		e := event.New(event.TypeTrialWillEnd, s.CustomerID)
+		e.SubscriptionID = s.ID
+		e.Data["trial_end"] = s.TrialEnd
+		e.CreatedAt = now.UTC()
+		if err := event.NewRepository(tx).Create(ctx, e); err != nil {
+			return s.ID, fmt.Errorf("notify subscription %v: %w", s.ID, err)
+		}
+		s.TrialEndNotifiedAt = now.UTC()
+		if err := NewRepository(tx).Update(ctx, &s); err != nil {
+			return s.ID, fmt.Errorf("notify subscription %v: %w", s.ID, err)
+		}
+		n.logger.Info().
+			Str("subscription_id", s.ID.String()).
+			Str("customer_id", s.CustomerID.String()).
+			Time("trial_end", s.TrialEnd).
+			Msg("Trial ending soon")
+		return s.ID, nil
+	}
+	fail := func(id ulid.ULID, err error) error {
+		n.logger.Error().
+			Err(err).
+			Str("subscription_id", id.String()).
+			Msg("Trial notification failed")
+		return nil
+	}
+
+	return worker.EachTx(ctx, n.db, next, fail)
+}
@@ -0,0 +1,29 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package testutil provides helpers shared by the tests of other packages.
+package testutil
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+// NewID returns a new random ID.
+func NewID() ulid.ULID {
+	return ulid.MustNew(ulid.Now(), rand.Reader)
+}
+
+// AssertError checks that the given errors contain an error
+// with the given code on the given path.
+func AssertError(t *testing.T, errs validation.Errors, path, code string) {
+	t.Helper()
+	err, ok := errs.Get(path).(validation.Error)
+	if !ok || err.Code != code {
+		t.Errorf("%v: got %#v, want a %v error", path, errs.Get(path), code)
+	}
+}
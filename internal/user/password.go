@@ -0,0 +1,178 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package user
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/argon2"
+
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+// ErrInvalidHash is returned when a password hash could not be decoded.
+var ErrInvalidHash = errors.New("invalid password hash")
+
+// PasswordParams are the argon2id hashing parameters.
+type PasswordParams struct {
+	// Memory is the amount of memory used, in KiB.
+	Memory uint32
+	// Iterations is the number of passes over the memory.
+	Iterations uint32
+	// Threads is the degree of parallelism.
+	Threads uint8
+	// SaltLength is the length of the random salt, in bytes.
+	SaltLength uint32
+	// KeyLength is the length of the derived key, in bytes.
+	KeyLength uint32
+}
+
+// DefaultPasswordParams are the default hashing parameters,
+// as recommended by RFC 9106 for memory-constrained environments.
+var DefaultPasswordParams = PasswordParams{
+	Memory:     64 * 1024,
+	Iterations: 3,
+	Threads:    2,
+	SaltLength: 16,
+	KeyLength:  32,
+}
+
+// PasswordPolicy defines the requirements for new passwords.
+type PasswordPolicy struct {
+	// MinLength is the minimum number of characters.
+	MinLength int
+	// MinClasses is the minimum number of character classes
+	// (lowercase, uppercase, digits, symbols) that must be used.
+	MinClasses int
+}
+
+// DefaultPasswordPolicy is the default password policy.
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:  10,
+	MinClasses: 2,
+}
+
+// maxPasswordLength limits the cost of hashing user-provided passwords.
+const maxPasswordLength = 1024
+
+// Check checks whether the given password satisfies the policy.
+//
+// Errors are reported under the "password" path.
+func (p PasswordPolicy) Check(password string) validation.Errors {
+	errs := validation.Errors{}
+	if password == "" {
+		errs.Add("password", validation.Required("Password is required."))
+		return errs
+	}
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		errs.Add("password", validation.TooWeak(fmt.Sprintf("Password must be at least %d characters long.", p.MinLength)))
+	}
+	if length > maxPasswordLength {
+		errs.Add("password", validation.InvalidValue(fmt.Sprintf("Password must be at most %d characters long.", maxPasswordLength)))
+	}
+	if countClasses(password) < p.MinClasses {
+		errs.Add("password", validation.TooWeak(fmt.Sprintf("Password must contain at least %d of: lowercase letters, uppercase letters, digits, symbols.", p.MinClasses)))
+	}
+
+	return errs
+}
+
+// HashPassword hashes the given password using argon2id.
+//
+// The hash is encoded in the PHC string format, e.g.
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>.
+func HashPassword(password string, params PasswordParams) (string, error) {
+	salt := make([]byte, params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Threads, params.KeyLength)
+	hash := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Iterations, params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+
+	return hash, nil
+}
+
+// VerifyPassword checks whether the given password matches the hash.
+//
+// The comparison is done in constant time.
+func VerifyPassword(password, hash string) (bool, error) {
+	params, salt, key, err := decodeHash(hash)
+	if err != nil {
+		return false, err
+	}
+	otherKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Threads, params.KeyLength)
+
+	return subtle.ConstantTimeCompare(key, otherKey) == 1, nil
+}
+
+// NeedsRehash returns whether the hash was created using different parameters.
+//
+// Used to transparently upgrade hashes on login.
+func NeedsRehash(hash string, params PasswordParams) bool {
+	hashParams, _, _, err := decodeHash(hash)
+	if err != nil {
+		return true
+	}
+
+	return hashParams != params
+}
+
+// decodeHash decodes a PHC-formatted argon2id hash.
+func decodeHash(hash string) (params PasswordParams, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return PasswordParams{}, nil, nil, ErrInvalidHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return PasswordParams{}, nil, nil, ErrInvalidHash
+	}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Threads)
+	if err != nil {
+		return PasswordParams{}, nil, nil, ErrInvalidHash
+	}
+	salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return PasswordParams{}, nil, nil, ErrInvalidHash
+	}
+	key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return PasswordParams{}, nil, nil, ErrInvalidHash
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
+
+// countClasses counts the character classes used by the given password.
+func countClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+
+	return lower + upper + digit + symbol
+}
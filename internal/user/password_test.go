@@ -0,0 +1,109 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package user_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/runbilliam/billiam/internal/user"
+	"github.com/runbilliam/billiam/pkg/validation"
+)
+
+// testParams are cheap parameters, to keep tests fast.
+var testParams = user.PasswordParams{
+	Memory:     1024,
+	Iterations: 1,
+	Threads:    1,
+	SaltLength: 16,
+	KeyLength:  32,
+}
+
+func TestHashPassword(t *testing.T) {
+	hash, err := user.HashPassword("correct horse", testParams)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("unexpected hash format: %v", hash)
+	}
+	otherHash, _ := user.HashPassword("correct horse", testParams)
+	if hash == otherHash {
+		t.Error("hashes are identical, salt not applied")
+	}
+}
+
+func TestVerifyPassword(t *testing.T) {
+	hash, _ := user.HashPassword("correct horse", testParams)
+	tests := []struct {
+		password string
+		hash     string
+		want     bool
+		wantErr  bool
+	}{
+		{"correct horse", hash, true, false},
+		{"battery staple", hash, false, false},
+		{"", hash, false, false},
+		{"correct horse", "", false, true},
+		{"correct horse", "$2a$10$invalid", false, true},
+		{"correct horse", "$argon2id$v=19$m=1024,t=1,p=1$!!!$!!!", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got, err := user.VerifyPassword(tt.password, tt.hash)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	hash, _ := user.HashPassword("correct horse", testParams)
+	if user.NeedsRehash(hash, testParams) {
+		t.Error("got true, want false")
+	}
+	params := testParams
+	params.Iterations = 2
+	if !user.NeedsRehash(hash, params) {
+		t.Error("got false, want true")
+	}
+	if !user.NeedsRehash("invalid", testParams) {
+		t.Error("got false, want true")
+	}
+}
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	policy := user.PasswordPolicy{MinLength: 8, MinClasses: 3}
+	tests := []struct {
+		password string
+		want     []string
+	}{
+		{"", []string{validation.CodeRequired}},
+		{"short", []string{validation.CodeTooWeak, validation.CodeTooWeak}},
+		{"Sh0rt", []string{validation.CodeTooWeak}},
+		{"lowercaseonly", []string{validation.CodeTooWeak}},
+		{"Lowercase1", nil},
+		{"lower case 1", nil},
+		{"Šđćčž123", nil},
+		{strings.Repeat("Aa1", 400), []string{validation.CodeInvalidValue}},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			errs := policy.Check(tt.password)
+			var got []string
+			for _, err := range errs["password"] {
+				got = append(got, err.(validation.Error).Code)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
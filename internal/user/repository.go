@@ -0,0 +1,221 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+const userColumns = `id, version, email, password, timezone, active, created_at, updated_at, login_at`
+
+// ErrEmailInUse is returned when saving a user whose email is already in use.
+var ErrEmailInUse = errors.New("email already in use")
+
+// ErrInvalidCredentials is returned when authentication fails.
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// ConflictError is returned when a user could not be updated
+// because it was modified in the meantime.
+type ConflictError struct {
+	ID      ulid.ULID
+	Version int
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("user %v was modified, version %v is out of date", e.ID, e.Version)
+}
+
+// Repository loads and saves users.
+type Repository struct {
+	db database.Querier
+}
+
+// NewRepository creates a new user repository.
+func NewRepository(db database.Querier) *Repository {
+	return &Repository{db: db}
+}
+
+// Get gets the user with the given ID.
+func (r *Repository) Get(ctx context.Context, id ulid.ULID) (User, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id.String())
+
+	return scanUser(row)
+}
+
+// GetByEmail gets the user with the given email.
+//
+// Emails are compared case-insensitively.
+func (r *Repository) GetByEmail(ctx context.Context, email string) (User, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE lower(email) = lower($1)`, email)
+
+	return scanUser(row)
+}
+
+// List lists all users, ordered by email.
+func (r *Repository) List(ctx context.Context) ([]User, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+userColumns+` FROM users ORDER BY lower(email)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
+
+// Create creates the given user.
+//
+// The user's password is expected to already be hashed.
+// Returns ErrEmailInUse if another user has the same email.
+func (r *Repository) Create(ctx context.Context, u User) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO users (`+userColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		u.ID.String(), u.Version, u.Email, u.Password, u.Timezone, u.Active,
+		u.CreatedAt, database.NullTime(u.UpdatedAt), database.NullTime(u.LoginAt),
+	)
+	if database.IsUniqueViolation(err) {
+		return ErrEmailInUse
+	}
+
+	return err
+}
+
+// Update updates the given user.
+//
+// The update only succeeds if the stored version matches u.Version,
+// otherwise a *ConflictError is returned. On success, u.Version is
+// incremented and u.UpdatedAt is set to the current time.
+func (r *Repository) Update(ctx context.Context, u *User) error {
+	updatedAt := time.Now().UTC()
+	tag, err := r.db.Exec(ctx, `
+		UPDATE users
+		SET version = version + 1, email = $3, password = $4, timezone = $5,
+			active = $6, updated_at = $7, login_at = $8
+		WHERE id = $1 AND version = $2`,
+		u.ID.String(), u.Version, u.Email, u.Password, u.Timezone,
+		u.Active, updatedAt, database.NullTime(u.LoginAt),
+	)
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return ErrEmailInUse
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		var exists bool
+		err := r.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, u.ID.String()).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrNotFound
+		}
+		return &ConflictError{ID: u.ID, Version: u.Version}
+	}
+	u.Version++
+	u.UpdatedAt = updatedAt
+
+	return nil
+}
+
+// Delete deletes the user with the given ID.
+func (r *Repository) Delete(ctx context.Context, id ulid.ULID) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM users WHERE id = $1`, id.String())
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Authenticate finds the active user with the given email and password.
+//
+// If the user's password hash was created with different parameters,
+// it is transparently rehashed using the given parameters.
+// On success, the user's LoginAt is set to the current time.
+// Returns ErrInvalidCredentials if the email or password don't match.
+func (r *Repository) Authenticate(ctx context.Context, email, password string, params PasswordParams) (User, error) {
+	u, err := r.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			// Hash anyway, to avoid revealing whether the user exists.
+			HashPassword(password, params)
+			return User{}, ErrInvalidCredentials
+		}
+		return User{}, err
+	}
+	ok, err := VerifyPassword(password, u.Password)
+	if err != nil {
+		return User{}, err
+	}
+	if !ok || !u.Active {
+		return User{}, ErrInvalidCredentials
+	}
+	previous := u.Password
+	if NeedsRehash(u.Password, params) {
+		hash, err := HashPassword(password, params)
+		if err != nil {
+			return User{}, err
+		}
+		u.Password = hash
+	}
+	u.LoginAt = time.Now().UTC()
+	// Logins skip the version check, so that they don't conflict with
+	// each other or with profile edits. The rehashed password is only
+	// stored if the password wasn't changed in the meantime.
+	_, err = r.db.Exec(ctx, `
+		UPDATE users
+		SET login_at = $2, password = CASE WHEN password = $4 THEN $3 ELSE password END
+		WHERE id = $1`,
+		u.ID.String(), u.LoginAt, u.Password, previous,
+	)
+	if err != nil {
+		return User{}, err
+	}
+
+	return u, nil
+}
+
+// scanUser scans a user from the given row.
+func scanUser(row pgx.Row) (User, error) {
+	var u User
+	var id string
+	var updatedAt, loginAt *time.Time
+	err := row.Scan(&id, &u.Version, &u.Email, &u.Password, &u.Timezone,
+		&u.Active, &u.CreatedAt, &updatedAt, &loginAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return User{}, ErrNotFound
+		}
+		return User{}, err
+	}
+	u.ID, err = ulid.Parse(id)
+	if err != nil {
+		return User{}, err
+	}
+	u.UpdatedAt = database.TimeValue(updatedAt)
+	u.LoginAt = database.TimeValue(loginAt)
+
+	return u, nil
+}
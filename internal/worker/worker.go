@@ -0,0 +1,169 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package worker runs periodic background jobs.
+//
+// Multiple billiam processes can share a database. Each job run takes
+// a Postgres advisory lock, so that only one process runs a given job
+// at a time, while the others skip it until the next tick.
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/oklog/ulid/v2"
+	"github.com/rs/zerolog"
+
+	"github.com/runbilliam/billiam/pkg/database"
+)
+
+// Job represents a periodic background job.
+type Job struct {
+	// Name identifies the job. Also used as the advisory lock key.
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Worker runs jobs.
+type Worker struct {
+	db     *pgxpool.Pool
+	logger *zerolog.Logger
+	jobs   []Job
+}
+
+// New creates a new worker.
+func New(db *pgxpool.Pool, logger *zerolog.Logger) *Worker {
+	return &Worker{db: db, logger: logger}
+}
+
+// Add adds a job to the worker.
+func (w *Worker) Add(job Job) {
+	w.jobs = append(w.jobs, job)
+}
+
+// Start runs the jobs until the given context is canceled.
+//
+// Each job runs immediately, and then once per its interval.
+func (w *Worker) Start(ctx context.Context) {
+	done := make(chan struct{})
+	for _, job := range w.jobs {
+		go func(job Job) {
+			defer func() { done <- struct{}{} }()
+			ticker := time.NewTicker(job.Interval)
+			defer ticker.Stop()
+			for {
+				w.run(ctx, job)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(job)
+	}
+	for range w.jobs {
+		<-done
+	}
+}
+
+// run runs the given job, if no other process is running it.
+func (w *Worker) run(ctx context.Context, job Job) {
+	conn, err := w.db.Acquire(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			w.logger.Error().Err(err).Str("job", job.Name).Msg("Could not acquire a connection")
+		}
+		return
+	}
+	defer conn.Release()
+
+	// Advisory locks are held by the connection, which is kept for the
+	// duration of the job and then explicitly unlocked.
+	var locked bool
+	err = conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, job.Name).Scan(&locked)
+	if err != nil {
+		if ctx.Err() == nil {
+			w.logger.Error().Err(err).Str("job", job.Name).Msg("Could not acquire the job lock")
+		}
+		return
+	}
+	if !locked {
+		return
+	}
+	defer func() {
+		// Unlock even if the context was canceled in the meantime.
+		_, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, job.Name)
+		if err != nil {
+			w.logger.Error().Err(err).Str("job", job.Name).Msg("Could not release the job lock")
+		}
+	}()
+
+	start := time.Now()
+	if err := job.Run(ctx); err != nil && ctx.Err() == nil {
+		w.logger.Error().Err(err).Str("job", job.Name).Msg("Job failed")
+		return
+	}
+	w.logger.Debug().Str("job", job.Name).Dur("duration", time.Since(start)).Msg("Job finished")
+}
+
+// Each handles due items one at a time, until none are left.
+//
+// The next function handles the next due item, leaving out the given
+// failed items, and returns its ID. The zero ID means that no items
+// are left. An error returned along with an ID is a failure of that
+// item alone: it is passed to fail (which usually records when to
+// retry it), and the item is left out for the rest of the run, so that
+// it can't block the items after it. Errors returned without an ID or
+// by fail, such as database errors, and context cancellation stop the run.
+func Each(ctx context.Context, next func(failed []ulid.ULID) (ulid.ULID, error), fail func(id ulid.ULID, err error) error) error {
+	failed := []ulid.ULID{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		id, err := next(failed)
+		switch {
+		case id == (ulid.ULID{}):
+			return err
+		case err == nil:
+			continue
+		case ctx.Err() != nil:
+			return err
+		}
+		if err := fail(id, err); err != nil {
+			return err
+		}
+		failed = append(failed, id)
+	}
+}
+
+// EachTx is like Each, but handles each item in its own transaction.
+//
+// The next function lists the next due item inside the given transaction,
+// locking it with FOR UPDATE SKIP LOCKED, so that items being handled by
+// another process are skipped. The item stays locked while it's handled.
+// The transaction is committed before the next item is handled, and
+// rolled back if the item failed, before fail is called.
+//
+// Returns the number of handled items, not counting the failed ones.
+func EachTx(ctx context.Context, db database.Beginner, next func(tx pgx.Tx, failed []ulid.ULID) (ulid.ULID, error), fail func(id ulid.ULID, err error) error) (int, error) {
+	count := 0
+	err := Each(ctx, func(failed []ulid.ULID) (ulid.ULID, error) {
+		var id ulid.ULID
+		err := database.WithTx(ctx, db, func(tx pgx.Tx) error {
+			var err error
+			id, err = next(tx, failed)
+			return err
+		})
+		if err == nil && id != (ulid.ULID{}) {
+			count++
+		}
+		return id, err
+	}, fail)
+
+	return count, err
+}
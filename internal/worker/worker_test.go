@@ -0,0 +1,89 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/runbilliam/billiam/internal/testutil"
+	"github.com/runbilliam/billiam/internal/worker"
+)
+
+func TestEach(t *testing.T) {
+	// The first due subscription always fails to renew,
+	// the later ones are still renewed.
+	due := []ulid.ULID{testutil.NewID(), testutil.NewID(), testutil.NewID()}
+	failing := due[0]
+	var renewed, failed []ulid.ULID
+	next := func(skip []ulid.ULID) (ulid.ULID, error) {
+		for _, id := range due {
+			if contains(skip, id) || contains(renewed, id) {
+				continue
+			}
+			if id == failing {
+				return id, errors.New("price not found")
+			}
+			renewed = append(renewed, id)
+			return id, nil
+		}
+		return ulid.ULID{}, nil
+	}
+	fail := func(id ulid.ULID, err error) error {
+		failed = append(failed, id)
+		return nil
+	}
+	if err := worker.Each(context.Background(), next, fail); err != nil {
+		t.Fatal(err)
+	}
+	if len(renewed) != 2 || renewed[0] != due[1] || renewed[1] != due[2] {
+		t.Errorf("got %v, want %v", renewed, due[1:])
+	}
+	if len(failed) != 1 || failed[0] != failing {
+		t.Errorf("got %v, want [%v]", failed, failing)
+	}
+
+	// Errors without an ID stop the run.
+	listErr := errors.New("connection refused")
+	err := worker.Each(context.Background(), func(skip []ulid.ULID) (ulid.ULID, error) {
+		return ulid.ULID{}, listErr
+	}, fail)
+	if err != listErr {
+		t.Errorf("got %v, want %v", err, listErr)
+	}
+
+	// Errors from fail stop the run.
+	failErr := errors.New("connection refused")
+	err = worker.Each(context.Background(), func(skip []ulid.ULID) (ulid.ULID, error) {
+		return failing, errors.New("price not found")
+	}, func(id ulid.ULID, err error) error {
+		return failErr
+	})
+	if err != failErr {
+		t.Errorf("got %v, want %v", err, failErr)
+	}
+
+	// Canceled contexts stop the run.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = worker.Each(ctx, func(skip []ulid.ULID) (ulid.ULID, error) {
+		t.Error("unexpected call")
+		return ulid.ULID{}, nil
+	}, fail)
+	if err != context.Canceled {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
+
+func contains(ids []ulid.ULID, id ulid.ULID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
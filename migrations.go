@@ -21,18 +21,210 @@ var Migrations = func() http.FileSystem {
 	fs := vfsgen۰FS{
 		"/": &vfsgen۰DirInfo{
 			name:    "/",
-			modTime: time.Date(2020, 8, 7, 21, 44, 38, 734522581, time.UTC),
+			modTime: time.Date(2026, 10, 17, 6, 51, 28, 891478117, time.UTC),
 		},
 		"/001_create_schema.sql": &vfsgen۰CompressedFileInfo{
 			name:             "001_create_schema.sql",
 			modTime:          time.Date(2020, 8, 9, 17, 56, 53, 444422435, time.UTC),
 			uncompressedSize: 405,
 
-			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x6c\xd0\xbd\x6a\xeb\x30\x18\xc6\xf1\x5d\x57\xf1\x8c\x09\x1c\x73\x68\x87\x2e\x99\x14\xfb\x4d\x2b\xea\xaf\xca\x32\x24\x5d\x8a\x12\xab\x45\xe0\x58\xc6\x72\x1c\xe8\xd5\x17\xbb\x4e\x69\x68\xb4\xfe\x7f\x3c\x88\x37\x94\xc4\x15\x41\xf1\x75\x4c\x38\x79\xd3\x79\x2c\x18\x00\x5b\xe1\xf2\xc2\x27\x2e\x17\xf7\x0f\x4b\xe4\x52\x24\x5c\xee\xf0\x4c\xbb\x7f\xa3\x19\x4c\xe7\xad\x6b\x46\x23\x52\x45\x8f\x24\x91\x66\x0a\x69\x19\xc7\x88\x68\xc3\xcb\x58\xe1\x6e\x92\xe6\xa8\x6d\xfd\xbd\xa6\x68\xab\x50\xa6\xe2\xa5\xa4\x1f\x3d\x99\x56\x7b\x7f\x76\x5d\x75\x31\x57\xb1\xb7\x47\xf3\xe9\x1a\x73\x33\xea\x43\x6f\x07\x33\xad\xaf\xb3\x2c\x26\x9e\xfe\xfd\xc7\xbb\xae\xbd\x99\xf4\xa1\x33\xba\x37\xd5\x9b\xee\xa1\x44\x42\x85\xe2\x49\xae\x5e\xaf\x17\x4f\x6d\x75\xc3\x4c\xa9\x76\x1f\xb6\x19\x03\x7e\x27\xb6\x5c\x31\x16\x04\x41\x30\xcf\x43\xef\xdd\x60\xf0\x1f\x55\xe7\x5a\xec\x4d\xed\xce\x18\x33\x63\x91\xcc\xf2\xf9\xdc\x62\x03\xda\x8a\x42\x15\xf3\xe1\x43\x5e\x84\x3c\xa2\x15\xfb\x0a\x00\x00\xff\xff\x45\xcf\x9b\x7e\x95\x01\x00\x00"),
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x6c\xd0\xcf\x4a\xc3\x40\x10\xc7\xf1\xfb\x3e\xc5\xef\xd8\x82\x41\xf4\xe0\xa5\xa7\x6d\x32\xd5\xc5\xfc\x73\xb3\x81\xd6\x8b\x6c\x9b\x55\x16\xd2\x6c\xc8\xa6\x29\xf8\xf4\x92\x90\x8a\xc5\xce\xf5\xf3\x65\x18\x26\x94\xc4\x15\x41\xf1\x75\x4c\x38\x79\xd3\x79\x2c\x18\x00\x5b\xe1\x32\xe1\x0b\x97\x8b\xc7\xa7\x25\x72\x29\x12\x2e\x77\x78\xa5\xdd\x1d\x03\x30\x98\xce\x5b\xd7\x00\x80\x48\x15\x3d\x93\x44\x9a\x29\xa4\x65\x1c\x23\xa2\x0d\x2f\x63\x85\x87\xa9\x34\x47\x6d\x6b\x4c\xa3\x68\xab\x50\xa6\xe2\xad\xa4\xdf\x7a\x6a\x5a\xed\xfd\xd9\x75\xd5\xa5\xb9\xc2\xde\x1e\xcd\xb7\x6b\xcc\x4d\xd4\x87\xde\x0e\x23\x01\xeb\x2c\x8b\x89\xa7\xff\xef\xf8\xd4\xb5\x37\x53\x7d\xe8\x8c\xee\x4d\xf5\xa1\x7b\x28\x91\x50\xa1\x78\x92\xab\xf7\xeb\x8d\xa7\xb6\xba\xd1\x4c\x54\xbb\x2f\xdb\x8c\x80\xbf\xc4\x96\x2b\xc6\x82\x20\x08\xe6\xf5\xd0\x7b\x37\x18\xdc\xa3\xea\x5c\x8b\xbd\xa9\xdd\x19\x23\x33\x16\xc9\x2c\x9f\xdf\x2d\x36\xa0\xad\x28\x54\x31\x3f\x3e\xe4\x45\xc8\x23\x5a\xb1\x9f\x01\x00\x45\xcf\x9b\x7e\x95\x01\x00\x00"),
+		},
+		"/002_create_settings.sql": &vfsgen۰CompressedFileInfo{
+			name:             "002_create_settings.sql",
+			modTime:          time.Date(2026, 10, 17, 4, 16, 18, 197488447, time.UTC),
+			uncompressedSize: 287,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x6c\xcf\x41\x4b\xc3\x40\x14\x04\xe0\xfb\xfb\x15\x73\x6c\xc1\x20\x3d\x17\x0f\xeb\xe6\x55\x43\x63\x5a\x36\xaf\xd0\x9e\x24\x4d\x1e\xb2\x60\x77\x65\xb3\x56\xf4\xd7\x8b\xa5\x58\x91\xce\xf5\x9b\x39\x8c\x75\x6c\x84\x21\xe6\xbe\x66\x8c\x9a\xb3\x0f\x2f\x23\x26\x04\xc0\x0f\x38\xa7\x6a\x84\x1f\xd8\x61\xed\xaa\x27\xe3\x76\x58\xf2\x0e\x25\x2f\xcc\xa6\x16\xcc\x60\x1f\xd9\x2e\x31\xf1\x03\xee\x30\x9b\xde\x10\x80\xa3\xa6\xd1\xc7\xf0\x67\xda\xac\x04\xcd\xa6\xae\x2f\xbb\x53\x71\xf4\x59\x9f\x43\x77\x50\x08\x6f\xe5\xb7\x75\xb2\xec\x0f\xfa\x15\x83\xe2\x8a\xf5\xef\x29\x69\xe8\x3f\xff\x19\x4d\xe7\x44\x45\x51\x14\xe8\x93\x76\x59\xd1\xed\xe3\x51\x71\x8b\x21\xc5\x37\xec\xf5\x35\x7e\xe0\x87\x89\x4a\xb7\x5a\x9f\x5f\x57\x0b\xf0\xb6\x6a\xa5\xbd\xfc\xb7\xa6\xb5\xa6\xe4\x39\x7d\x0f\x00\x29\x3b\xce\x78\x1f\x01\x00\x00"),
+		},
+		"/003_index_users_email.sql": &vfsgen۰CompressedFileInfo{
+			name:             "003_index_users_email.sql",
+			modTime:          time.Date(2026, 10, 17, 4, 17, 15, 837905226, time.UTC),
+			uncompressedSize: 253,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x7c\xce\xc1\x8a\x83\x30\x18\x04\xe0\x7b\x9e\x62\x8e\x7a\x08\xfb\x00\x39\x65\x4d\x16\x02\x12\x77\x63\x04\x6f\xa2\xeb\x7f\x90\x5a\x52\x62\x5b\xdb\xb7\x2f\x55\x7b\x68\x0b\xbd\xfe\xf3\x33\xf3\xc9\xdc\x6b\x07\x2f\xbf\x73\x8d\xd3\x44\x71\x82\x72\xc5\x2f\xb2\xc2\x96\xde\x49\x63\xfd\x7a\x6d\x68\xdf\x0e\x63\xb3\xa3\xab\x60\x99\xd3\xd2\x6b\x54\xd6\xfc\x55\x1a\xc6\x2a\x5d\x3f\x3d\x0d\xfd\x05\x85\xdd\xda\x92\x31\xcc\x14\x93\x25\x49\x53\xc1\x18\xe7\x9c\xe3\x3f\x52\x7b\x24\xb4\x5d\x38\x13\xbe\xd0\xc7\x70\x40\x47\x63\x98\x71\x8f\x19\x5b\x0c\x6b\xb3\xf9\x81\xae\x4d\xe9\xcb\xd7\x0d\xc1\xde\xed\x52\xa9\x0f\xf4\x07\x79\xd3\x08\x76\x1b\x00\x56\xc5\xdb\x29\xfd\x00\x00\x00"),
+		},
+		"/004_create_sessions.sql": &vfsgen۰CompressedFileInfo{
+			name:             "004_create_sessions.sql",
+			modTime:          time.Date(2026, 10, 17, 4, 18, 56, 923640568, time.UTC),
+			uncompressedSize: 404,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x74\x90\x41\x4b\xc4\x30\x10\x85\xef\xf9\x15\xef\xd8\x82\x45\x10\xd9\x4b\x4f\x31\x9d\xc5\x60\x9b\x96\x24\xc2\xae\x97\xd2\x35\x39\x04\xc4\x2e\xcd\xaa\xfb\xf3\xa5\x25\xb6\x07\xd9\x5c\xdf\x37\x6f\xf2\x8d\xd0\xc4\x2d\xc1\xf2\xa7\x9a\x10\x7d\x8c\x61\xfc\x8c\xc8\x18\x80\xe0\xf0\xf7\xc4\x33\xd7\xd9\xee\x31\x47\xa7\x65\xc3\xf5\x11\x2f\x74\xbc\x9b\x99\xaf\xe8\xa7\x3e\xb8\x95\x79\xd8\xe5\x50\xad\x85\x7a\xad\x6b\x68\xda\x93\x26\x25\xc8\x2c\x5c\x44\x16\x5c\x8e\x56\xa1\xa2\x9a\x2c\x41\x70\x23\x78\x45\x4b\xd1\xfb\xe4\x87\x8b\x77\xfd\x70\x81\x95\x0d\x19\xcb\x9b\xce\xbe\xad\x5d\x0b\xe3\xaf\xe7\x30\xf9\x78\x8b\x61\x79\xc9\x92\x8e\x54\x15\x1d\x56\x9d\x3e\xfd\xb2\x0f\xee\x3a\xaf\xdf\x34\x53\x70\x73\x70\xdb\xf8\x7f\x76\xcb\xf2\x92\xb1\xa2\x28\x8a\x24\x81\xe1\x34\x7e\x7b\xdc\xc3\x4d\xe3\x19\x27\xff\x31\xfe\x60\x8e\x19\xab\x74\xdb\xa5\x53\xcb\x3d\xe8\x20\x8d\x35\x5b\x63\x3a\x47\xc9\x7e\x07\x00\x77\xb1\x19\xae\x94\x01\x00\x00"),
+		},
+		"/005_create_customers.sql": &vfsgen۰CompressedFileInfo{
+			name:             "005_create_customers.sql",
+			modTime:          time.Date(2026, 10, 17, 4, 20, 45, 112478974, time.UTC),
+			uncompressedSize: 666,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8c\x92\x4d\x6f\xa3\x30\x10\x86\xef\xfe\x15\xef\x2d\x41\x5a\xb4\xda\x3d\xec\x25\x27\x07\x9c\x2d\x2d\x81\x08\x1c\x29\x69\x55\x21\x07\xcf\x01\x09\x70\x64\xc8\x57\xab\xfe\xf7\xaa\x94\xa6\xa9\x14\x95\xce\xf5\x7d\x34\xe3\xf1\x3c\x5e\x22\xb8\x14\x90\x7c\x1a\x0a\xe4\xbb\xa6\x35\x15\xd9\x06\x63\x06\xa0\xd0\xf8\x28\xef\x86\x27\xe3\xbf\xff\x1c\x2c\x92\x60\xce\x93\x35\xee\xc4\xfa\x17\x03\xb0\x27\xdb\x14\xa6\x06\x80\x20\x92\xe2\xbf\x48\x10\xc5\x12\xd1\x32\x0c\xe1\x8b\x19\x5f\x86\x12\x7f\x3a\x92\x2a\x55\x94\xe8\x4a\x8a\x95\x3c\x63\x5d\x58\xab\x8a\x70\x25\x3c\xf7\x18\x8d\x3a\x2e\x37\xd5\x56\xd5\xa7\x41\x4e\x69\x6d\xa9\x69\x00\xe0\x36\x8d\xa3\xe9\x15\xf0\xf9\xe5\x1d\x6d\xd5\x31\x2b\xf4\xb7\xe8\xc3\x63\x3f\x7d\x67\x2d\xd5\xf9\x69\x68\x7a\x5b\x54\xf4\x64\x6a\x1a\xe2\x4a\x93\xab\x92\x86\xb7\xae\xa8\x55\x5a\xb5\xea\x07\xdb\xe4\x96\x54\x4b\x3a\x53\x2d\x64\x30\x17\xa9\xe4\xf3\x85\xbc\xff\xfa\xd9\xbb\xad\xbe\xc2\x30\x67\xc2\x7a\x1b\x82\xc8\x17\xab\x4f\x1b\xb2\xee\x74\x59\xa1\x8f\x88\xa3\x4b\x49\x4a\x73\x20\x3b\xee\x52\xc7\x99\x30\xe6\xba\xae\xdb\xbf\x00\x6a\x63\xf6\x84\xdf\xd0\xd6\x6c\xb1\xa1\xd2\x1c\xf0\x16\x33\xe6\x27\xf1\xa2\xf7\x2d\x98\x41\xac\x82\x54\xa6\x17\x4d\x3d\x9e\x7a\xdc\x17\x13\xf6\x3a\x00\x12\x41\x3b\x8a\x9a\x02\x00\x00"),
+		},
+		"/006_create_catalog.sql": &vfsgen۰CompressedFileInfo{
+			name:             "006_create_catalog.sql",
+			modTime:          time.Date(2026, 10, 17, 4, 21, 58, 731035280, time.UTC),
+			uncompressedSize: 957,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x7c\x92\x51\x6b\xdb\x30\x1c\xc4\xdf\xfd\x29\xee\xcd\x31\x24\x6c\xdd\x60\x30\xca\x0a\x9e\xfc\xcf\x2a\xea\x38\x41\x56\x20\xdd\x8b\x51\x2d\x41\x45\x1b\x3b\x28\x76\xba\x7c\xfb\x61\xc7\x71\xed\xac\x8b\x5e\x6c\xb8\xe3\x38\x9d\x7e\x4c\x50\x28\x09\x32\xfc\x19\x13\x76\xae\xd4\x75\x5e\xed\x31\xf1\x00\x58\x8d\xfe\xb0\xfb\x50\x4c\xbe\x7c\x0b\xb0\x12\x7c\x11\x8a\x47\x3c\xd0\xe3\xd4\x03\x70\x30\x6e\x6f\xcb\x02\xcd\xe1\x89\xa4\x5f\x24\x90\x2c\x25\x92\x75\x1c\x23\xa2\x79\xb8\x8e\x25\x6e\x5a\x6b\xa1\xb6\xe6\x9c\x27\x69\x23\x7b\x5f\xab\x6a\xb3\xcf\x9d\xdd\x55\x4d\xd8\x48\xed\x53\x7c\xbf\x35\xe6\xce\xa8\xca\xe8\x4c\x55\x80\xe4\x0b\x4a\x65\xb8\x58\xc9\xdf\xe3\xb4\x7a\xa7\x3f\x32\xb5\x9a\x72\xf9\xb3\x3d\x9c\xc4\x81\xe6\x05\xb7\x9e\x77\x31\x87\xcd\xcd\x07\x63\x5c\xdb\xa3\x9b\x30\xb3\x7a\xec\xeb\x6f\x23\x68\x4e\x82\x12\x46\xe9\x60\x6e\xab\x03\x2c\x13\x44\x14\x93\x24\x08\x4a\xa5\xe0\x4c\x9e\x56\xb3\xf9\xcb\x60\xb9\xab\xd3\xa8\x6d\x59\x17\x55\x5f\x32\x59\x2f\x48\x70\x36\xb9\xf9\x3e\xc5\xb0\x02\xbb\x27\xf6\x80\x49\xe7\xbe\xfb\x81\xcf\xc1\x69\xd9\xda\x39\x53\xe4\xc7\xe1\x1d\xbf\x06\xe3\x61\x6d\x51\x19\x77\x50\xaf\x59\x5d\xd8\xea\xb2\x4e\x17\x3c\xf6\xf0\x04\x13\x5f\xab\xa3\x3f\x85\xff\x66\xcc\x4b\xf3\xdd\x96\x45\xf5\xdc\xfc\x1c\x8d\x72\x7e\x10\x8c\xa3\xf3\xb6\xd7\x3f\x34\x5d\xa6\x9f\x6c\x77\x7d\xfb\x01\x17\xb8\x82\xc6\xf0\xf9\x81\x4b\x02\x3a\x00\x78\x12\xd1\xa6\x03\x20\x7b\x7f\xd3\xcc\xea\x3f\xcd\x43\x9d\xc9\x78\x57\x1a\x78\x66\xb3\xd9\xac\xab\x01\xf5\x54\x1e\x0c\x3e\x41\xbb\x72\x87\x27\xf3\x5a\xbe\xa1\x91\x3d\x2f\x12\xcb\x55\xc7\x17\x9f\x83\x36\x3c\x95\xe9\x39\x8f\x85\x29\x0b\x23\xba\xfd\x9f\xa9\xc3\xa5\xb7\xfd\x1d\x00\x5a\x79\x2f\x8b\xbd\x03\x00\x00"),
+		},
+		"/007_create_subscriptions.sql": &vfsgen۰CompressedFileInfo{
+			name:             "007_create_subscriptions.sql",
+			modTime:          time.Date(2026, 10, 17, 4, 23, 2, 687717748, time.UTC),
+			uncompressedSize: 1335,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x84\x53\xc1\x8e\x9b\x30\x14\xbc\xf3\x15\xef\x96\x20\x6d\x54\xb5\x87\x5e\x72\x62\xe1\x65\xd7\x5a\x42\x22\xe3\x95\xb2\xbd\x58\x0e\x76\xb7\x96\x12\x8c\x6c\x93\xf6\xf3\x2b\x58\x9a\x05\x42\x28\x27\x0b\xcf\x8c\xfd\x66\xc6\x31\xc5\x88\x21\xb0\xe8\x31\x45\x70\xf5\xd1\x15\x56\x57\x5e\x9b\xd2\xc1\x32\x00\x00\x2d\xe1\xf6\x8b\x9f\x23\xba\xfc\xf6\x3d\x84\x3d\x25\xdb\x88\xbe\xc1\x0b\xbe\x3d\x34\xe8\x8b\xb2\x4e\x9b\x72\x88\x26\x19\xc3\x27\xa4\x90\xed\x18\x64\xaf\x69\x0a\x09\x6e\xa2\xd7\x94\xc1\xd7\x96\x53\xd4\xce\x9b\xb3\xb2\x5c\xcb\x89\x13\xae\x24\x8a\x1b\xa4\x98\xc5\x98\x5f\x19\x0e\x96\x5a\x86\xb0\xcb\x20\xc1\x14\x19\x02\xc5\x9c\x51\x12\xb3\x56\xb7\xb2\xba\x50\x7c\x74\xff\x59\xdd\x96\x31\x2f\xea\xbc\xf0\xb5\x1b\xd9\xc1\xf0\xc0\x3e\x05\xe3\x67\x8c\x5f\x60\xd9\x21\x49\x06\xcb\x85\x2e\x0b\x73\xae\x4e\xca\xab\xc5\x03\x2c\xbc\xd5\xe2\xa4\xcb\xf7\x66\x2d\x0a\xaf\x2f\xed\xdf\x4a\x38\xcf\x65\xdd\xad\x6b\xa7\x64\xb3\x2a\x44\x59\xa8\x93\x92\x8b\x30\xec\xcc\xb2\x56\x95\x9e\x57\xca\x6a\x23\xb9\xf3\xc2\x7a\x60\x64\x8b\x39\x8b\xb6\x7b\xf6\x63\x0a\xa4\x4a\x09\x70\x0b\xb2\x4a\x78\x25\xb9\xf0\xfd\x49\x3e\x41\xd7\x81\x5a\x74\x5d\xc9\x59\x74\xcf\x1c\x5e\xfc\x12\xe5\xfb\x15\x7b\x57\xf2\xdf\x68\x03\xcd\x1e\x3a\x08\xd7\x41\xd7\x4e\x92\x25\x78\x18\xb6\x93\xf7\x6a\xc3\xb5\xfc\xd3\x04\x36\xaa\x6f\x0f\x31\x2f\xd5\xdd\x7b\x5a\xe5\x63\x33\x5c\x07\xc1\xdd\xa7\xc2\x07\x83\xdf\x79\x38\x8f\xe4\x29\x47\x4a\xa2\xf4\xe6\xd1\x0c\xa4\xb4\x9c\xef\xe8\xe8\x76\xc3\xaa\xc6\x51\x1e\x47\x09\xb6\xaa\x3f\xad\x39\xf3\x5e\x5d\x9b\x92\xb6\x1b\xde\xf4\x7f\x8f\xda\xfb\x91\xcc\x20\xc0\xe9\x08\x67\xd3\x19\x19\xc2\x47\x13\x4e\x39\x7d\xe3\xe1\x88\xd3\x04\xb0\x5a\xad\x56\x5d\x6f\x41\x1c\xcd\x45\xc1\x17\x90\xd6\x54\x70\x54\x27\xf3\x1b\x9a\xed\x20\x48\xe8\x6e\xdf\x65\x44\x36\x80\x07\x92\xb3\x7c\xf6\xa4\xce\xb4\xf5\xff\x99\x3d\xec\xdf\x01\x00\x0b\x01\x92\x70\x37\x05\x00\x00"),
+		},
+		"/008_create_invoices.sql": &vfsgen۰CompressedFileInfo{
+			name:             "008_create_invoices.sql",
+			modTime:          time.Date(2026, 10, 17, 5, 23, 29, 285643504, time.UTC),
+			uncompressedSize: 4597,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xa4\x58\x5d\x6f\xa4\x36\x14\x7d\xe7\x57\xdc\x87\x54\x33\x23\x4d\xd2\xdd\x56\x5a\xa9\x9a\x6e\x25\x02\x9e\x09\x5d\x02\xa9\x61\x94\x6c\xab\x0a\x79\xc0\x3b\xb1\x96\x31\x2c\x98\x74\xd3\x5f\x5f\xf1\x0d\xc6\x4c\xd2\xdd\xbc\xa0\x8c\xcf\x39\xbe\xb6\xef\x3d\xd7\xb2\x6e\xfb\x08\x83\xaf\x5f\xdb\x08\x72\x2a\x04\xe3\xc7\x1c\x74\xd3\x04\xc3\xb5\xf7\xb7\x0e\x08\xf2\x35\xc8\x88\xa0\xe0\xa3\x07\x1f\x1c\xd7\x07\x67\x6f\xdb\x60\xa2\xad\xbe\xb7\x7d\x58\xbc\x59\x6c\x34\x6d\x24\x52\x1c\xf2\x30\x63\xa9\x60\x09\x1f\x29\x1d\x58\x1c\x33\x7e\x0c\xc2\xe7\x30\xa6\x01\xe1\xe1\x63\x92\x81\x6f\xdd\x22\xcf\xd7\x6f\xef\xfc\x3f\x37\xdf\x22\x13\x91\x67\xb0\x1c\x1f\xed\x10\x9e\x06\xf7\x66\xa3\x19\x18\xe9\x3e\x02\xcb\x31\xd1\xc3\x58\x33\x08\x8b\x2c\xa3\x5c\x04\x29\xcd\x58\x12\x05\x94\x47\x01\x8b\xbe\x82\xeb\x48\x73\x2f\xa7\xc0\xd5\x46\x6b\x95\xeb\x68\x19\x7f\x4a\x58\x48\x73\x58\x6a\x00\xc0\x22\x50\xfe\x19\x37\x3a\x5e\xfe\xf4\x6e\x05\x77\xd8\xba\xd5\xf1\x47\xf8\x80\x3e\xae\x35\x00\x78\xa2\x59\xce\x12\x3e\x21\xcc\xae\xec\x6d\x45\x0b\x8b\x5c\x24\x27\x9a\x05\x2c\x52\xcf\xd3\xf1\x30\xda\x22\x8c\x1c\x03\x79\x1d\x29\x87\x25\x8b\x56\xe5\x72\x4d\x64\x23\x1f\x01\x46\x9e\x8f\x2d\xc3\xaf\xa4\x87\x5b\x30\x94\xef\xa4\x07\x8a\xd2\x76\x9d\x51\xe5\xc5\xe9\x40\xb3\xe9\xc6\x54\xb9\xb5\x77\xac\x3f\xf6\xa8\x9e\x5d\x10\x51\xe4\x33\xb8\x6e\x51\xc6\x0d\x32\x3e\xc0\xb2\x01\x5b\x0e\x2c\x17\x51\x46\x3e\x89\xc5\x1a\x16\x49\x4a\x79\xf9\x4d\x09\x8b\xca\xef\x53\x52\x7f\x0b\x1e\x26\x71\x4c\x43\xc1\x0e\x31\x5d\xac\x56\xcd\x3e\x96\x07\x1c\x3e\x2b\xcf\xeb\xe7\x7e\x1b\x2b\xb0\x20\x5f\xa9\x22\x34\xf8\xdd\x73\x9d\x6b\x45\x81\xfc\xf5\xf7\xa2\xdd\x51\x91\x08\x12\xcb\x3c\x67\x7f\x8b\xb0\x65\x2c\xdf\xfe\xb2\x7e\x27\x4d\x15\xb1\x3c\x4c\x0a\x2e\x02\x89\x78\x86\x52\x96\xab\x6a\x9a\x73\x14\x05\xfc\x05\x4a\x53\x09\xb9\x20\x99\x18\x9d\x4f\x5f\xcd\x43\x1c\xe5\x52\x41\xc8\xb8\x30\xa3\x44\xd0\x28\x20\x62\x16\x37\x0e\xa0\x48\xa3\x97\x08\x15\xee\x13\xe3\x24\x66\xff\x4e\x90\x93\x40\x09\x9b\x8a\x29\x70\x65\x1a\x29\xa6\x9d\xe0\x4e\x24\xfb\x4c\xa3\x60\x94\x6d\x25\x6b\x80\xd3\x56\x92\x3b\xb5\x1e\x12\x0c\xca\xba\x75\xa4\xde\x5f\x06\x83\xb3\x02\x52\xf1\x4e\x45\x24\xc0\xbc\x50\x55\x5a\x0a\x7e\xf5\xfb\x9c\x0b\x06\x31\xe3\x4a\x2b\x9c\xf5\xbf\x96\xc8\xa2\x21\x4a\xe5\x5e\x7d\x0c\x63\x9b\x31\x74\xcf\xd0\xcd\xda\x3d\xd2\x24\x67\xa2\xb5\x53\xd9\x42\xeb\x94\x7f\x4e\xa9\xda\x54\xea\xba\xa3\xdd\xee\xa8\x86\xd3\xac\x8b\x55\x6d\x88\x69\xa6\x0a\x71\xe4\x84\x5f\x0a\xc2\x05\x13\xb5\xe5\x5c\x5b\x3b\xcb\x91\x26\x29\x38\x13\x01\x39\x95\xe5\x7f\xb6\x14\x5b\xc8\x6b\x8c\xa1\xea\xe3\x93\x25\xf7\x56\xb5\x98\x16\xf7\xd9\x8a\x7e\x45\x36\xd7\xb9\x10\xf4\x07\x2c\x25\x53\x9b\x2b\x3d\x60\xdd\x9d\x5f\x99\x5f\x97\x97\xb0\x6d\x6b\xb8\x3f\x7c\x92\x51\x60\xa7\x53\x21\xc8\x21\xa6\x6b\x48\x78\xfc\x0c\xe2\x91\xb2\xac\xed\x1c\x4b\xc2\xa3\xf2\x17\x38\x11\x11\x3e\x32\x7e\x2c\x85\x04\x3b\xd1\x5c\x90\x53\x9a\xaf\x20\x24\x1c\xc2\x47\xc2\x8f\xf4\x0a\x10\xff\x94\x64\x21\x8d\xe0\x91\x96\xba\x1c\x48\x14\x55\x01\x80\x48\x2a\x91\x8c\x56\x21\x25\xd9\xf3\xba\xd2\x49\xe0\x58\x90\x2c\x02\x72\x24\x8c\xe7\x02\x4e\x84\x17\x24\x6e\xf4\xf2\xab\x76\x23\xb6\x7b\xc7\xf0\xad\x41\xe1\x04\xe1\x23\x0d\x3f\x07\x5d\xe4\xcb\x32\x6b\xfc\x3d\x76\x3c\x10\x19\x3b\x1e\x69\x06\xba\x07\x17\x17\xda\x35\xda\x59\x8e\x06\x00\xd6\x16\xfc\x5d\xe0\xde\xc1\x7b\x58\xd4\x69\xb4\x00\xff\x06\x55\x63\xf5\xb0\x6b\x9b\x57\xcd\xa2\x7f\xfd\x0d\x9a\x06\x38\xc4\x00\x00\xd6\x2d\x0f\x01\x7a\x30\xd0\x5d\x15\xcf\xa2\x09\x08\x7e\x00\x96\xf7\x1e\xb9\x58\x57\x6a\x2c\xda\x34\x54\xe4\x98\x60\x6d\xdb\xff\xea\x50\x4b\xc8\x46\x1b\x0f\xce\x86\xa1\x3b\x66\x6d\x03\x00\xe0\xa0\xfb\xab\xe1\x85\xc5\xf2\xc0\xb4\x3c\xdf\x72\x0c\x1f\xb6\xd8\xbd\xad\x24\x86\x00\x17\x0f\x98\xf2\x7d\x44\xc9\x96\x41\x23\x85\xe6\xee\xa1\x24\x36\x63\x23\x7c\x77\x29\x98\x09\xb4\x19\x1d\x71\xea\xbb\x81\x92\x50\x0f\xc9\x6b\xaa\x7b\xef\xdc\x62\xea\xd1\x11\x47\xba\x12\x28\x99\x12\x46\x8e\xf0\x1c\xb5\x1f\x1e\xb3\xce\x30\xa6\xe8\x91\x81\x28\x49\x23\x84\x8a\x5b\xfa\xcb\x39\x66\x39\x3e\xe2\x8d\x1a\xbd\x92\x39\x44\x68\x00\xb0\x1a\x16\xc9\x37\x55\xc8\xa0\x02\x9a\xda\x70\xd0\xfd\x46\x43\x8e\xb9\xd1\x2e\x2e\xc0\xd6\x9d\xdd\x5e\xdf\x21\x48\xe3\xf4\x98\x7f\x89\x07\xdd\x12\x5b\xbb\xb2\x27\x75\xbe\xd0\x39\x02\x5c\xa3\xad\x8b\x11\xec\xef\xcc\x12\xe8\xe2\xb6\x79\x0c\x5c\x44\x03\x80\xad\x8b\x01\xe9\xc6\x0d\x60\xf7\x1e\xd0\x03\x32\xf6\xaf\xf3\x9b\x3e\x06\x19\xdc\x18\xf5\x6b\x1d\xca\x44\x86\xad\x63\x34\x6c\xde\x4d\xf9\x97\xbd\x65\xd3\x3b\x98\x87\x6c\x64\xf8\xd0\x5d\xd2\x7d\x57\x26\x54\x27\x34\x5c\xdc\xfd\x0d\xc2\x08\x58\x04\xef\xc1\x70\x75\x1b\x79\x06\x5a\x96\x67\x3c\xec\x11\xd5\x31\x74\xff\xaf\x5a\x1b\x92\xa4\xd5\x8e\xf8\xfa\xc3\xfe\x5f\xd3\x8f\x0d\xf1\x9c\x6d\xcf\x3b\xe9\x77\xe5\x51\xdb\x6b\xe5\x64\xb2\x1c\x0f\x61\x1f\x5c\x7c\x2e\xad\x6a\xf2\xab\x73\x6b\x2e\x5d\xaa\x96\x7d\x79\xd9\xdc\xe8\x81\x1c\x92\x27\x0a\x3f\x42\x94\x25\x29\x1c\x68\x9c\xfc\x03\xe5\xb0\xa6\x99\xd8\xbd\x6b\x6e\x8d\xd6\x16\xd0\x83\xe5\xf9\xde\x58\xbb\xbd\xd2\x6d\xce\x62\x65\x58\x17\xe7\x8c\xaa\x2a\xe2\x17\x88\x4a\xce\xfc\x53\x45\xa5\xd6\xbc\x55\xf4\x5a\x93\x57\x8b\xef\x95\xa8\xdf\x4f\x24\x95\xf6\xfd\x46\x2d\xd0\x5e\x01\x37\xda\x7f\x03\x00\x03\x09\x3d\x89\xf5\x11\x00\x00"),
+		},
+		"/009_create_sequences.sql": &vfsgen۰CompressedFileInfo{
+			name:             "009_create_sequences.sql",
+			modTime:          time.Date(2026, 10, 17, 5, 26, 2, 331432075, time.UTC),
+			uncompressedSize: 698,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xa4\x92\xcf\x6f\x9b\x30\x14\xc7\xef\xfe\x2b\xbe\x37\x88\x14\xba\xde\x73\x72\xc1\xed\xac\x52\x52\x81\x33\x95\x53\xe4\x90\xb7\x25\x12\xc5\x9d\x31\x54\x55\x94\xff\x7d\x32\x90\x6d\x99\x92\xc3\xd4\x77\x43\x8f\xcf\xf7\x87\x9e\x79\xaa\x44\x0e\xc5\xef\x52\x81\x96\x9c\xdb\x37\x3f\x5a\xf0\x24\x41\xbc\x4c\x57\x4f\x19\xf6\x4d\x6f\xf6\x15\xad\x9b\xee\x75\x43\x76\xfd\xa6\x9d\x23\xdb\x40\x89\x17\x85\x6c\xa9\x90\xad\xd2\x14\x89\xb8\xe7\xab\x54\x21\x90\xd9\xb7\xe8\x50\x96\x65\x79\x8c\x0e\xb7\x7e\x8e\xc1\x82\xfd\xa7\x85\xa5\x96\xdc\x35\x83\x0f\xd2\xb6\xfe\x08\x18\x80\xf8\xab\x88\x1f\x11\x5e\xa4\x65\x86\xf0\xf4\xef\x1c\x41\x43\x3d\xd9\x60\x36\x5b\x30\x16\x45\x10\xba\xda\xc1\x9a\x77\xec\x4c\xbd\x6d\xe1\x76\x84\x5a\xb7\x0e\xba\xae\x4d\xa5\x1d\x6d\xd1\xeb\xba\x23\x98\xef\xd0\x68\xe9\x67\x47\x4d\x45\x37\x9e\x2c\xa6\x0f\x0f\x69\x87\x41\x16\xa3\x63\xd7\x12\xbc\x21\x6e\x6f\x58\x9c\x0b\xae\xc4\xef\xc6\x27\x26\xf4\xa9\x1b\xfd\x4a\x18\xe7\xac\xe2\xdc\x2f\x07\x81\x71\x64\xa6\xc4\x83\xc8\xcf\xf7\x3e\xe6\x7a\x0c\x77\x27\x1f\x64\xf6\x0f\xfe\x9c\xcb\x27\x9e\x97\x78\x14\x25\x42\x6f\x34\x1f\x14\x67\x6c\x2c\x1e\x45\xa8\x2c\x69\x47\xd0\x1b\xd3\x13\xbe\x60\x6b\xcd\x1b\x36\x54\x9b\x77\xf8\x35\x63\x49\xbe\x7c\x9e\x72\xcb\x7b\x88\x17\x59\xa8\xe2\xaf\x06\x31\x2f\x62\x9e\x88\x2b\x27\x1d\xe0\xe9\xa6\x7f\xe8\x4b\xf7\xf9\x8c\xc0\xf4\x02\x17\xec\xd7\x00\x26\x67\x32\x48\xba\x02\x00\x00"),
+		},
+		"/010_create_payments.sql": &vfsgen۰CompressedFileInfo{
+			name:             "010_create_payments.sql",
+			modTime:          time.Date(2026, 10, 17, 5, 36, 55, 578067657, time.UTC),
+			uncompressedSize: 3015,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xdc\x56\x5d\x8f\xa3\x36\x14\x7d\xe7\x57\xdc\xb7\x04\x29\x69\xbb\xdd\x7e\xa8\x42\x7d\x60\x89\xa7\x83\x96\x90\x29\x10\x75\xb6\x55\x85\x1c\x7c\x93\x58\x05\x83\xb0\x99\x9d\xa8\xea\x7f\xaf\x20\x7c\x86\x90\x66\xfa\xb8\x3c\x45\xe1\xf8\x70\x8f\x7d\x8e\xef\x5d\x2e\xc1\x43\x95\x73\x94\x70\xa4\x59\x86\x02\xd4\x11\xe1\xc0\x5f\x50\x80\x28\x92\x1d\xe6\x90\xee\x81\xd1\x93\x04\xba\x57\x98\x57\xaf\xf7\x3c\x97\x0a\xf6\x94\xc7\xc8\x80\x2a\x85\x49\xa6\xbe\xd2\x4c\x27\x20\x1e\x04\xe6\x07\x87\x80\x44\xa5\xb8\x38\x48\x30\x57\x2b\xb0\x36\xce\x76\xed\x02\x2b\x84\xe0\xe2\x10\xca\xe8\x88\xac\x88\x11\x6c\x37\x20\xbf\x10\xef\x8f\x3f\xc1\xdd\x04\xe0\x6e\x1d\x07\x56\xe4\xc1\xdc\x3a\x01\xcc\xfe\x7e\xb7\x78\xbf\xf8\x7e\xf1\xe3\x3f\x33\xe3\x6e\xe2\x3d\x17\x34\x0e\xa5\xa2\xaa\x90\x10\x90\xe7\xe0\x0a\x6f\x44\x45\x84\x31\xb2\x99\x06\x00\xd6\x23\xb1\x3e\xc2\xfc\xea\x7a\xdb\x85\x79\x87\x5e\xc0\xac\x10\x19\xe5\x6c\xa6\xeb\x86\x36\xac\xa8\xd8\xc9\x28\xe7\x99\xe2\xa9\x90\xb0\xf2\x36\x4f\x60\x6d\x5c\x3f\xf0\x4c\xdb\x0d\x86\x6f\x6b\xee\x30\x3a\x62\xf4\x97\x71\x83\xe5\x2c\xee\x0e\x92\x9e\x8a\x7e\xdd\x5c\x44\x69\x92\xc5\xa8\xb0\xac\x5c\xe5\x9c\xc6\x5c\x1c\xca\xdf\x34\x52\xfc\xa5\xfa\x37\xa3\x52\x85\xac\xc0\x9e\xb6\xea\xdf\x42\x9e\xf5\xb6\xda\x47\x8a\xb9\x78\x49\x79\x84\x83\x33\xa8\x5d\x10\x46\x69\x21\x54\x73\xb2\xe3\xfd\xff\xc6\xf8\x4f\x26\x81\xaf\x2a\xcc\xe8\x29\x41\xa1\xc2\x86\x96\x2a\x08\xec\x35\xf1\x03\x73\xfd\x14\xfc\x6e\x68\x96\x47\xcc\x80\x80\xed\xae\xc8\x73\xcb\x12\x4e\x2c\x0d\x39\x7b\x85\x8d\xdb\x7d\x6d\x3e\x01\xd4\x35\x00\xf8\xed\x91\x78\x64\xb2\x0a\xdb\x6f\x45\x19\x5a\x53\xc6\x59\x4c\x83\x4e\x50\x1d\x53\x26\x61\xae\x01\x00\x67\x70\xf9\x58\x8f\xa6\x37\xff\xf6\x07\x1d\x9e\x3c\x7b\x6d\x7a\x9f\xe0\x23\xf9\xb4\x28\xb1\x51\x21\x55\x9a\x60\x1e\x72\x36\xc2\x36\x1f\x05\x8f\x3c\x10\x8f\xb8\x16\xf1\x5b\xbc\x84\x39\x67\x7a\xa9\x70\x45\x1c\x12\x10\xb0\x4c\xdf\x32\x57\xa4\x22\x3d\x50\x85\x9f\xe9\xa9\x5f\xc0\x20\x1a\x15\x28\xc7\x24\x55\x18\x72\x76\x07\xa8\x5f\xe5\x44\xc8\x66\x15\x5e\x9d\x32\xbc\x90\x3e\x26\xdd\xe5\x54\xb0\x1b\xa0\x4b\xd2\x98\x4a\xf5\xdd\x1b\xf0\xf8\x9a\x85\x49\x2a\xd4\xb1\xc3\x4f\x9b\xb3\x5d\x71\x42\x9a\xc3\xdd\x2b\xb8\x0c\x19\xee\x69\x11\xab\x76\xc5\x87\xcd\xc6\x21\xa6\x3b\x5e\xf1\x60\x3a\xfe\xf9\x60\xa2\x1c\xa9\x42\x56\x9a\xaa\x79\x7a\x16\x6f\x57\x6a\xfa\x85\xdb\x2f\x6c\xd6\x3f\x8f\xc6\xe8\x23\x27\xf6\x30\x1d\xdd\xd6\xb5\x7f\xdd\x4e\xb1\xd6\x82\xee\x62\xac\x23\xd3\x6d\xc3\x54\x32\xea\x1c\x4d\x46\x63\x32\x19\x75\x72\xef\x0d\x46\x17\xf4\x61\x2e\x3c\xe2\x07\x9e\x6d\x05\x15\xe7\x50\x52\x49\xdd\x72\xf6\xa8\x46\xc2\x87\x8c\x3e\xe9\x79\xb9\xee\x97\x37\x8c\x33\x91\xc8\x9b\x0e\x1e\x67\xf3\x26\xbc\x6e\x03\x93\xec\x57\xba\x85\x2c\xa2\x08\x91\x9d\xaf\xfd\x73\x57\x9f\xe9\x7a\xc5\x46\x93\xea\x3e\xef\x3d\xee\x76\x4d\x3c\xdb\x9a\xbf\xfb\x69\xd1\xdb\xfd\xfa\xfe\xca\x73\x14\xd1\xe9\xe2\x40\xdf\x5f\xc0\x18\x46\x31\x17\x18\x46\x29\xc3\x3b\x04\x25\x28\x25\x3d\xe0\xbd\xdb\x35\x8e\xd5\xb5\x54\x55\xd0\x3a\x00\xf3\xce\x5d\x8b\xfa\x0c\xf5\x32\x75\xda\x72\xb9\x5c\xd6\x7c\x40\x77\xe9\x0b\xc2\xd7\xc0\xf2\x34\x83\x1d\xc6\xe9\x67\x28\x5f\x6b\x5a\xd5\xf0\xcf\x36\xb7\x1f\x80\x3c\xdb\x7e\xe0\x8f\x0d\x5f\xdf\xc8\xc6\x6d\x78\x63\xb2\x16\x7d\xb5\x57\xd6\x23\x46\xd5\x2c\x3b\x8e\x89\x86\xf5\x26\x8e\x41\x13\x37\xb4\xed\xd3\xaa\xcc\xf0\x70\x34\x29\x1d\x5f\x9b\xe7\xe7\xde\x10\x51\xdf\x01\xdd\x9b\x7a\xa4\x30\xbe\xb8\x59\x69\x62\x42\xba\x3a\xa4\x5e\xdf\xe6\x6b\xf3\xe6\xff\x22\x68\x26\x69\x43\xfb\x77\x00\x03\xe7\x95\xc2\xc7\x0b\x00\x00"),
+		},
+		"/011_create_credit_notes.sql": &vfsgen۰CompressedFileInfo{
+			name:             "011_create_credit_notes.sql",
+			modTime:          time.Date(2026, 10, 17, 5, 40, 41, 649789434, time.UTC),
+			uncompressedSize: 3348,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xac\x57\x5d\xaf\xa2\x48\x10\x7d\xe7\x57\xd4\xc3\xdd\xa0\x89\xee\x67\x32\xc9\xc6\x27\x2e\x96\x5e\x76\x14\xef\x36\x90\xd5\xdd\x6c\x48\x0b\x35\x5e\xb2\xda\xb8\x4d\xeb\x8c\x99\xcc\x7f\xdf\x34\x02\x17\x1c\x44\x77\x66\x7c\xed\xd3\x87\xaa\x53\xa7\x0e\x62\xcd\x7c\x64\xe0\x5b\x8f\x33\x84\x8c\x94\x4a\xc4\x26\x03\x6b\x3c\x06\x7b\x31\x0b\xe6\x2e\x44\x92\xe2\x44\x85\x22\x55\x14\x8a\xc3\x6e\x4d\x32\xdc\x73\xa5\x48\x0a\xf0\x71\xe9\x83\xbb\xf0\xc1\x0d\x66\x33\x18\xe3\xc4\x0a\x66\x3e\x98\xb6\x3b\xfc\xb8\x5a\xad\x56\x9f\x86\x1f\x7f\xd4\xbf\x4f\xe6\xc8\xf8\x82\xa7\x48\xca\x48\x5d\x7b\xc6\x89\xb8\xdc\x9e\x4c\x03\x00\xec\x27\xb4\xdf\x42\xef\x2a\x83\xe3\x42\xaf\xc4\x0f\xc0\x14\x74\x24\x69\xf6\xfb\x23\xc3\xb0\x19\x5a\x3e\x16\x55\xd5\xee\x67\xd0\xd3\xc4\x49\x0c\xd5\xcf\x7e\xb2\x58\xef\xe7\x37\x7d\x78\x66\xce\xdc\x62\x2b\x78\x8b\xab\x41\x0e\x12\xc7\x34\x89\x28\x4c\xe2\x1a\xa8\xaa\x97\xe1\x04\x19\xba\x36\x7a\x25\x30\x83\x5e\x12\xf7\x61\xe1\xc2\x18\x67\xe8\x23\x30\xf4\x7c\xe6\xd8\x7e\xce\x16\x1d\x32\x95\xee\x48\x6a\xba\x4e\xb6\x12\xd8\x4d\x77\xd6\xe1\xdc\x41\x53\xc8\xc0\x75\x7e\x0f\x30\x07\xed\x48\xbd\xa4\x71\x1b\xa8\x50\xb6\x00\xe4\x3a\x4a\x7a\x77\x10\xb1\xd6\xb1\x2a\x75\xcd\xb7\x5c\x44\x64\xf6\xfb\x05\xdd\x2e\x05\x68\xa3\xab\x86\x67\x16\xbd\x4a\x49\x22\x3a\x55\xf2\xfe\xf2\xda\x6a\x0e\x50\xfc\x03\x65\x05\xd3\x6f\xde\xc2\x7d\x6c\xa1\xfa\xeb\xef\x33\x59\x76\x58\xab\x54\xf1\xad\xc6\xba\xc1\x1c\x99\x63\xf7\x7e\xfa\x75\xf0\xe6\x73\xca\xb0\xc4\x75\xc1\x4a\xaa\x6e\xd8\x59\x8c\x7c\xf4\x37\x5a\x95\xc4\x15\xc5\x21\x57\x00\xbe\x33\x47\xcf\xb7\xe6\xcf\xfe\x9f\x15\xde\xe8\x8f\x4a\x33\x3a\xee\x18\x97\x0d\x33\x86\xaf\x16\x0b\x93\xf8\x83\x9e\x75\xd3\xab\xaf\xe7\x9d\x34\x35\x6f\xb5\xf3\xd4\x00\x5d\xdb\x11\x6e\x13\xd1\xb6\x22\x9d\x6b\x52\xbf\x7f\x73\x55\x2e\xda\xbb\xee\xef\xb2\x73\x5d\xd1\xcd\x95\xa9\x83\xbb\x69\xf7\x69\x96\xa8\x24\x15\x65\x57\x8e\xeb\xe3\x14\x59\x73\xf8\x31\x65\x91\x4c\xf6\x15\xae\x61\x80\x1c\xc1\x77\xe9\x41\xa8\x57\x71\x6e\xf8\x52\x72\x45\xb7\x16\xa7\xcb\x28\xe7\xc6\xc2\xa6\xd2\x2d\x93\x2e\x05\x68\x02\x07\x55\xd7\x7a\xf6\xc3\x21\x20\x8f\x5e\x40\xa6\xef\x8b\xab\x19\xf4\xce\x80\x23\x15\x8d\xf5\x21\x95\x10\xd3\x3a\x3f\x13\xb4\xe1\xf5\x33\xcd\xa0\x5e\xa8\xca\x29\x33\x83\x22\x27\x20\x11\xf9\xc9\x26\x39\x92\xa8\x42\xe0\xfb\x0b\xbb\x5d\x84\x4b\xa8\x24\x17\x19\x8f\x74\x7d\xed\xd6\xbb\xee\xbc\x5a\xa4\x02\x7c\x8b\x54\x55\xa7\x3d\xd5\x9e\x7c\x73\xf0\x1d\x73\xaf\x67\xe0\xb5\x18\xbc\x58\x9d\xaa\x81\xaf\x59\x98\x42\xbc\x36\xae\xbb\xde\x53\x17\xe6\xbf\x3b\xfc\xe0\xde\xfc\xeb\x9a\x7f\x6b\x92\x75\x1b\xa6\x76\x63\x50\x89\x5e\x18\xdd\xce\xc5\x83\xb3\x78\x5c\xc4\x95\x51\x1b\x14\x5c\x12\xe4\x7f\x1d\x60\x97\xc6\xc9\xbb\x84\xe2\xb3\xfd\xb7\xa4\x28\xae\xdc\x3b\x09\x5c\xdb\x77\x74\x3d\x2f\x14\xfd\x13\xf2\xfd\x9e\x44\x1c\xa6\x62\x7b\xea\x69\x91\xfd\x80\xb9\x1e\x28\x99\x6c\x36\x24\xc1\xf2\xe0\xe1\xc1\x78\xc4\xa9\xe3\x6a\x9d\x98\xe5\x78\x08\xb8\xb4\xf1\x39\xa7\x30\xbf\xd3\xeb\x97\x41\xc4\x85\x69\x2a\x58\x53\xf5\x64\x73\x00\xfe\x34\xcc\x37\x25\x74\xad\x39\x8e\x0c\x74\xc7\x23\xe3\xe1\x01\x66\x96\x3b\x0d\xac\x29\xc2\x7e\xbb\xdf\x64\xff\x6e\x6b\x31\xce\x9c\xa9\xce\xb0\xc6\x2b\xa1\x56\x1f\x3c\xe2\x64\xc1\x10\x82\xe7\xb1\x86\x2f\x58\x39\xf7\x8b\xd7\x84\xae\x74\xb2\x60\x80\x96\xfd\x04\x6c\xf1\x07\xe0\x12\xed\xe0\x46\xeb\xa3\x8e\x2a\x8a\xd8\xfa\xff\xa5\x9c\x2f\x7e\xa3\x7a\x3a\xfd\x76\x6f\x6d\x5d\x24\x5f\x58\xa7\x31\x1c\x0e\x87\xc5\x0a\x01\x5f\xa7\x47\x82\x1f\x20\x96\xe9\x1e\xd6\xb4\x4d\xdf\x83\x3e\x36\x8c\x31\x5b\x3c\x17\xc9\xe9\x4c\x00\x97\x8e\xe7\x7b\x37\x56\xc2\xb6\x3c\xdb\x1a\xe3\xe8\xca\xdd\xcf\x5e\x17\x77\xe3\x2f\xa1\x55\x7f\x35\x74\x5b\xa7\xad\x5f\x08\x39\x43\xf1\x89\xd0\x5e\x5d\xfd\xaf\xfe\xd7\x92\x14\xdf\x35\x23\xe3\xbf\x01\x00\xc9\xe1\x4e\x3e\x14\x0d\x00\x00"),
+		},
+		"/012_create_coupons.sql": &vfsgen۰CompressedFileInfo{
+			name:             "012_create_coupons.sql",
+			modTime:          time.Date(2026, 10, 17, 5, 45, 25, 12771328, time.UTC),
+			uncompressedSize: 1491,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8c\x93\x41\x6f\x9b\x4e\x10\xc5\xef\x7c\x8a\xb9\xd9\x48\xb1\xfe\xf9\x47\x6a\x2f\x69\x0e\x04\x4f\x1a\x1a\x0c\xe9\xb2\x56\x93\x56\x15\x22\xec\xa4\x5a\x29\xb0\xab\x05\x52\xf7\xdb\x57\x6c\xc0\x06\x07\xdb\xdd\x23\xfb\x9b\xc7\xdb\x37\x33\x3e\x43\x8f\x23\x70\xef\x3a\x44\xc8\x55\xa3\x55\x59\xc1\xdc\x01\x00\x29\x60\x7c\xfc\x5b\x8f\xcd\x2f\x3e\xba\x70\xcf\x82\x95\xc7\x1e\xe1\x0e\x1f\xcf\x1c\x00\x78\x25\x53\x49\x55\xee\xc8\x20\xe2\xf8\x19\x19\x44\x31\x87\x68\x1d\x86\xb0\xc4\x1b\x6f\x1d\x72\xf8\xdf\xf2\xb9\x12\x34\x52\xe6\xf8\xc0\xb7\xb0\x45\xca\xac\x38\x85\xd4\x7f\xf4\x11\x04\xfc\x5b\xf4\xef\x60\x6e\xa9\x20\x82\xf9\x4c\x93\xc9\xa9\xac\x67\x67\x30\x7b\x96\x1b\x12\x33\xd7\xb5\x3a\xdd\xf7\x54\x3d\x3f\x83\x3d\xd1\x7a\x85\x2c\xf0\xe7\x1f\xce\xe0\xe2\x0d\xc9\x0a\xd5\x94\x75\xb5\x43\xbe\x24\x71\x74\xfd\xfe\x79\xb3\x1f\x3f\x67\xb6\x40\x34\x26\xab\x87\x91\x4c\x7a\xdb\x52\xd6\x9f\x2a\x73\x6a\xcd\x19\xd2\x94\xd5\xb2\xfc\x65\x9d\x2a\x43\xaf\x64\x7a\xaf\x7d\x45\xaa\xc9\x48\x25\xaa\xc3\x49\x9f\x5b\xbe\xc8\x36\xa9\x21\x41\x85\x6e\xab\x2a\x38\xc5\xd7\xb2\xa0\xca\x56\x50\x41\x02\x4e\xf2\xda\x28\xd1\xe4\x75\x2a\x45\xf5\x6f\xc1\xd0\x46\x4b\x43\x55\x9a\xd5\x7d\x30\xc1\x0a\x13\xee\xad\xee\xf9\xf7\xb7\xd9\x30\x94\xd5\x24\x26\x81\x71\xff\x1b\x2d\x0e\x92\x16\xe8\x52\xde\xcf\xe0\x0a\xce\x21\x66\xfb\x4f\xfd\x74\xb5\x1f\x96\xeb\xb8\x97\x4e\xb7\x1e\xeb\x28\xf8\xba\x46\x08\xa2\x25\x3e\xf4\x5b\x92\xb6\x63\x9c\x4a\xb1\x81\x38\xda\x6d\x4e\x18\x7f\x43\x36\x6f\xaf\x5c\xf7\xd2\x71\x26\xd6\x6b\x64\x66\x72\xd3\x0e\x2e\x5a\x27\x20\xc5\x3e\xb8\x0d\x9c\xe1\x0d\x32\x8c\x7c\x4c\x76\x96\xa4\x70\x5b\x8b\x4b\x0c\x91\x23\x30\x4c\x38\x0b\x7c\xfe\x26\xd8\x54\xb5\x2a\xc8\xf4\x92\xc7\x05\x3b\xf8\xb8\x64\xd5\x3c\x55\xb9\x91\xf6\x7d\xad\xec\x7b\xc9\x2e\xcc\x81\xf2\xb0\xe6\x9d\xba\xef\x25\xbe\xb7\xc4\x7e\x57\xdb\xb1\x4f\x33\xad\x5f\x24\x89\x53\xe3\xb9\x3f\x4c\x53\xb3\x34\xe8\xf2\xb0\xbd\xc3\x2e\xa5\xdb\xdc\xc7\xed\x1e\x77\x72\x0b\xb5\x7d\x5f\x2c\x16\x8b\xee\xf7\x90\x3d\xa9\x57\x82\xff\x40\x18\xa5\xe1\x89\x5e\xd4\x6f\x68\xaf\x1d\x67\xc9\xe2\xfb\x6e\x34\x82\x1b\xc0\x87\x20\xe1\xc9\x94\x74\x97\xc0\xe5\xb1\x82\x01\xf5\x77\x00\x66\x19\x62\x29\xd3\x05\x00\x00"),
+		},
+		"/013_add_trials.sql": &vfsgen۰CompressedFileInfo{
+			name:             "013_add_trials.sql",
+			modTime:          time.Date(2026, 10, 17, 5, 49, 12, 682642785, time.UTC),
+			uncompressedSize: 1163,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xa4\x94\x4d\x6f\x9b\x4c\x14\x85\xf7\xfc\x8a\xb3\x33\x48\xaf\xf5\x46\x5d\x74\x83\x52\x69\x02\xd7\x35\x0d\x06\x6b\x18\xab\x4e\x37\x08\x9b\x69\x35\x52\x0a\x16\x33\x4e\x1b\x55\xfd\xef\x15\x9f\x1e\xbb\xa9\xa5\x36\x6c\xef\x39\xcf\xfd\x64\x58\x2c\x88\x43\xb0\xbb\x98\x70\x68\xd4\x5e\x6a\xb0\x30\x44\x90\xc6\x9b\x55\x02\xd3\xa8\xe2\x31\x2f\x8b\x67\x8d\x28\x11\xf4\x9e\x38\x92\x54\x20\xd9\xc4\x31\x42\x5a\xb0\x4d\x2c\x70\x83\x60\x49\xc1\x3d\x5c\x4b\xfc\xee\x16\x37\x9e\xef\x38\x36\x5d\x1f\x77\x7a\xdf\xa8\x83\x51\x75\xf5\x42\x12\x6d\x8a\xc6\x40\x44\x2b\xca\x04\x5b\xad\xc5\x27\xff\x6f\xdc\xb2\x2a\x5f\xe3\xcd\xab\xda\xa8\xcf\x4a\x96\x79\x71\x51\x43\xc0\x89\x09\x42\x94\x84\xb4\x3d\x07\xe5\x27\xb7\x2a\xbf\x23\x4d\x2e\xf2\xb8\x53\xdc\xc3\xc7\x25\x71\x82\x36\x85\x39\x6a\xdc\x62\xd6\x85\x54\xf5\x65\xe6\x3b\x63\x86\xbe\x54\xf9\x24\x2b\xa3\xe1\x3a\x00\x54\x89\xb3\x2f\x58\x32\xee\xbe\x79\xeb\x61\xcd\xa3\x15\xe3\x0f\xb8\xa7\x87\xff\x5a\xa1\x79\x3e\x48\x5b\x28\x68\x2b\xa6\x3d\x75\x8a\xfd\x51\x9b\xfa\xab\x6c\x72\x55\x9e\xa3\x46\x15\x38\x2d\x88\x53\x12\x50\x36\x89\x35\x5c\x55\x7a\x6d\x63\x21\xc5\x24\x08\x01\xcb\x02\x16\x52\x47\xb4\x7b\x6d\xa9\x13\xd1\x02\x5d\xcc\xe3\xcf\xb0\xb2\x30\x85\xdd\xc0\x87\x2c\x4d\xee\x7e\xbf\xb4\xd9\x8f\x9f\xb3\xbe\x9d\x46\x16\xa6\x5f\x56\xf7\x59\x1b\x9b\x5c\x8e\x77\xb1\xbc\x7e\xb4\xb9\x35\x8a\x71\x6f\xe3\xd0\xad\x50\x7b\xbc\xf3\xf9\x7c\x3e\xa4\x42\xb1\xab\x9f\x24\xfe\x47\xd9\xd4\x07\xec\xe4\x63\xfd\x0d\x6d\xd8\x71\x42\x9e\xae\x87\xdd\x45\x0b\xd0\x36\xca\x44\x36\x02\x87\x16\xfd\x5e\xd4\x57\x71\x12\x5d\x39\xa6\x6b\xe7\xdb\xb1\x86\xfb\x3d\xc1\x5e\xbc\xe4\x57\x60\xfe\xd5\xda\xfd\xc5\xe7\xe6\xe1\x55\xb9\xe6\x6a\xdf\x0c\xdf\xf9\x35\x00\x8f\x69\x35\xa4\x8b\x04\x00\x00"),
+		},
+		"/014_create_usage.sql": &vfsgen۰CompressedFileInfo{
+			name:             "014_create_usage.sql",
+			modTime:          time.Date(2026, 10, 17, 5, 52, 9, 401830316, time.UTC),
+			uncompressedSize: 1726,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xb4\x54\x4d\x6f\xda\x40\x14\xbc\xfb\x57\xcc\x0d\x2c\x41\x5a\xf5\xd0\x0b\x6a\x25\x63\x36\x89\x1b\x63\xa8\xbd\x48\x49\xab\xca\x5a\xec\x27\xb2\x2a\xfe\x88\x77\x9d\x26\xff\xbe\x32\x06\x7f\x94\x8f\xb4\x87\x72\x41\xf2\x9b\x9d\x7d\x6f\x66\xf6\xd9\x3e\xb3\x38\x03\xb7\xa6\x2e\x43\x42\x9a\x0a\x85\xa1\x01\x40\xc6\x68\x7e\xf6\xad\xe5\x0f\x3f\x7c\x34\xb1\xf4\x9d\xb9\xe5\x3f\xe0\x8e\x3d\x8c\x0c\x00\x3f\xe9\xb5\x01\x71\x76\xcf\xe1\x2d\x38\xbc\x95\xeb\x62\xe5\x39\x5f\x57\x6c\x07\x4a\x45\x42\x27\x41\xbb\xaa\xd8\x6c\x0a\xda\x08\x2d\xb3\xb4\x5f\x85\x7d\xcb\xec\x3b\x0c\xbb\x00\xc7\xc3\x70\xa0\xca\x64\x30\xc2\x20\x11\x2f\xd5\xdf\x56\x28\x3d\x30\xcd\x1d\x57\x54\x90\xd0\x14\x87\x42\x03\xdc\x99\xb3\x80\x5b\xf3\x25\xff\xd6\x50\x1a\xe6\xc4\x30\x2c\x97\x33\x7f\x3f\x70\x5e\xc8\x88\x14\xac\xd9\x0c\xf6\xc2\x5d\xcd\xbd\x5a\x82\x50\xc6\xed\xcc\x3e\xbb\x66\x3e\xf3\x6c\x16\x34\xfa\xc8\xd8\xc4\xc2\xc3\x8c\xb9\x8c\x33\xf8\x2c\xe0\xbe\x63\xf3\x49\x8f\x5a\x95\x6b\x15\x15\x32\xaf\xfa\x3e\xbe\x81\xe2\x70\x77\x77\x28\x63\x85\x2f\xc1\xc2\x9b\xb6\x73\xcf\xd8\xb5\xb5\x72\x39\x06\xdf\x7f\x0c\x26\x86\x31\x1e\x63\x29\x0a\x2d\x2b\x22\x8a\xb1\x7e\x45\x92\xa5\xfa\x71\x04\x45\x54\x93\x5d\xb1\x54\x95\x05\x35\x28\x75\x65\xf4\x6c\x2d\x95\xd8\x50\x48\xcf\x94\xea\x13\xe6\xf6\x0c\xee\x59\xd3\x68\x71\x0e\xf5\xf7\xda\xd4\xf6\x94\x4a\x67\x49\x4b\x79\x91\xef\x00\x3e\xa2\xb4\xad\xc0\xb6\x66\x75\xb4\x9e\x4a\x91\x6a\xa9\x9b\x10\x4e\x9d\x1b\xc7\x3b\x8e\x50\x03\xfb\xfc\x09\xef\xeb\xa8\x68\x99\x90\xd2\x22\xc9\x0f\xc1\x3c\x11\x97\x51\x2d\x15\x25\x79\xa6\x29\x8d\x5e\xc3\x2a\xee\xc7\x09\xee\xa6\xee\x22\x55\xe7\xf5\x54\x43\x8d\xda\x26\x4c\xc3\xc4\xd2\xf2\xb9\xc3\x9d\x85\x87\xe9\x03\x7c\xcb\xbb\x61\x18\xb6\x80\xc9\xc1\x52\xc7\x9b\xb1\xfb\x9e\xa5\xe1\xc1\xa7\x50\xc6\x2f\x95\x50\x7d\xbf\x0f\xd5\x51\x57\xff\xee\xdd\x75\xc6\x56\xa9\x7c\x2a\x09\x51\x96\x2a\x5d\x08\x59\x1d\xcd\x52\xe4\x9d\xe4\x69\xb1\xde\x92\x42\x52\x2a\x0d\x99\x46\xdb\x32\x26\xe8\x47\x6a\x31\xd5\x3a\x18\x55\x5c\x2a\xeb\xca\x56\x7d\x56\x10\x05\x21\xa6\xb8\xcc\xb7\x32\xaa\xe4\x82\x4c\x21\xa0\x28\x17\x85\xd0\x54\x93\x9f\xcf\x6d\xb8\xe3\x18\xfe\x9f\x14\xbd\x6d\x71\xdd\xc3\x85\x97\xf0\x6f\x99\xea\x05\xa1\xe7\xca\x1f\xad\x98\x46\xed\xce\x78\xbc\x4f\x19\xc4\x3a\x7b\x26\xbc\x43\x5c\x64\x39\xd6\xb4\xcd\x7e\xa1\x2a\x1b\xc6\xcc\x5f\x2c\xf7\xba\x39\xd7\x60\xf7\x4e\xc0\x83\x63\x05\xf7\x63\x4f\xde\x84\x77\xa0\xe7\xb7\xda\x8e\x64\xbf\xd6\x5a\x96\xa3\x05\x37\x39\xb5\x73\x2f\x9c\x0d\x65\x7c\xa6\xc1\xfd\x92\x69\x5a\xfb\x3d\x00\x0a\x25\xe4\x9a\xbe\x06\x00\x00"),
+		},
+		"/015_add_pricing_models.sql": &vfsgen۰CompressedFileInfo{
+			name:             "015_add_pricing_models.sql",
+			modTime:          time.Date(2026, 10, 17, 5, 57, 3, 344268692, time.UTC),
+			uncompressedSize: 627,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x94\x92\x51\x6b\xfa\x30\x14\xc5\xdf\xfb\x29\xce\x5b\x15\xfe\xe5\xef\xbb\x6c\x50\x6d\xdc\xba\x65\xe9\xd0\x08\xc2\x18\x12\xdb\x3b\x09\xd6\xa6\xa4\xb1\xc2\x3e\xfd\x68\x9d\x6c\x85\xd2\xe1\x5b\x9b\x7b\xee\xef\x9c\x13\x12\x72\xc9\x96\x90\xe1\x8c\x33\x94\x56\xa7\x54\x21\x8c\x22\xcc\x13\xbe\x7e\x11\x38\x9a\x8c\x72\x48\xb6\x91\x10\x89\x84\x58\x73\x8e\x88\x2d\xc2\x35\x97\xf0\x3f\x72\xe5\x7c\x0f\xc0\xfc\x91\xcd\x9f\x31\xba\x88\x63\x81\xd1\x65\xf4\x0f\x7e\xa9\xd2\x83\xda\x53\xf3\xb9\xb7\x2a\x3b\x29\x47\x59\xf3\x53\x9b\xfc\x74\x6c\x8f\x2b\xa7\xb4\xad\x1c\x95\xfe\x78\x3c\xf5\x86\xd3\x7c\xd3\xb6\x95\xfe\x24\xcc\xe2\x87\x58\xf4\xc4\x9a\x5c\xe3\x74\xd4\xf7\x77\x98\xfc\xc9\x77\x9a\x6c\x85\xa7\x55\x22\x66\x3d\x75\xdf\xde\xfd\x2e\x40\x17\xb5\xd1\x29\x6d\x73\x5d\x74\x39\x3b\x4b\xea\x90\x99\x73\x31\xcc\xf2\x82\x20\x08\x90\x5a\x52\x8e\xa0\x76\xa6\x26\xfc\x47\x66\x4d\x89\x1d\xe5\xe6\x8c\x66\xec\x0d\x38\x46\xcb\xe4\xf5\x6a\x19\x2f\xc0\x36\xf1\x4a\xae\x7e\xcc\x7b\xdb\xf6\xef\xb4\xc5\x6f\xd0\xff\xbe\xda\x1b\xd6\xda\x07\x32\xf5\xbe\x06\x00\x12\x9f\x5d\x2a\x73\x02\x00\x00"),
+		},
+		"/016_create_invoice_pending_lines.sql": &vfsgen۰CompressedFileInfo{
+			name:             "016_create_invoice_pending_lines.sql",
+			modTime:          time.Date(2026, 10, 17, 5, 59, 34, 298275304, time.UTC),
+			uncompressedSize: 999,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x84\x93\x5f\x6f\xda\x30\x14\xc5\xdf\xf3\x29\xee\x5b\x41\x22\x4c\xdb\xa4\x4a\x13\x4f\x21\x5c\xba\x6c\x21\x20\xc7\x48\x74\xd3\x14\x99\xf8\x8e\x59\x6b\xed\xcc\x31\xff\xbe\xfd\x94\x94\x50\x92\x82\xea\xa7\xc8\xf7\xe7\x7b\x8e\xaf\x4f\x7c\x1f\x62\xa5\xa9\x84\xbd\x50\x4e\xe9\x0d\x38\x03\x6b\x02\x21\x25\xc9\xea\xdb\xfd\x21\xd0\x74\x70\xa0\xf4\xce\xa8\x9c\xc0\xfc\x06\x01\xe5\x76\x5d\xe6\x56\x15\x4e\x19\x3d\xf0\x7c\x1f\x68\xb8\x19\x42\x61\x8d\x15\xd5\x56\x39\x04\x46\xcf\x66\x47\x12\x8c\xce\xa9\x39\x2b\x87\x5e\xc8\x30\xe0\x08\x3c\x18\xc7\xd8\x6c\x67\x05\x69\xa9\xf4\x26\x7b\xaa\x8d\xf4\x3c\x00\x50\x12\x5a\x2b\xfc\x1a\xb0\xde\xa7\xfb\x3e\x2c\x58\x34\x0b\xd8\x23\x7c\xc7\xc7\x81\x07\xd0\x72\x92\x29\xf9\x0a\x26\x73\x0e\xc9\x32\x8e\x81\xe1\x14\x19\x26\x21\xa6\x2d\xb8\x84\x9e\x92\x7d\x98\x27\x30\xc1\x18\x39\x42\x18\xa4\x61\x30\xc1\xba\x6b\x61\x4a\x55\x41\x8d\x7c\x94\x70\x7c\x40\x76\x6e\x5a\x43\xee\x58\xd0\xa5\x47\x8e\x2b\xde\x26\x24\x9d\xe5\x6e\x10\x85\xad\x06\xf0\x7a\xdb\xb3\xfd\x0b\xd7\x35\xf3\xc6\x2e\xc3\x94\xb3\x28\xe4\x75\x9b\x7f\x5b\xa1\x9d\x72\xc7\xa6\xcd\x38\x7a\x88\x92\x8e\xd4\x56\x2b\x97\x89\x67\xb3\xd5\x0e\xaa\x95\x2c\x67\xc8\xa2\xb0\xf7\xf1\xcb\xe0\xbe\xdf\x46\x2f\xa8\xf7\xd0\x7c\x6b\x2d\xe9\xfc\xd8\xba\xc0\xe7\x0e\xe4\xc4\x21\xb3\xc2\xd1\xd5\x49\xc1\x04\xa7\xc1\x32\xe6\x70\x77\xf7\x32\x12\xb2\xca\xc8\xac\x74\xc2\xba\x1a\x8e\x66\x98\xf2\x60\xb6\xe0\x3f\x2e\xeb\xa4\x4f\x43\xeb\xd6\xd7\x96\xc4\x5f\x69\xf6\xcd\xe3\x7d\x4b\xe7\xc9\xf8\x8a\xda\xcf\x5f\x2f\x7a\xb9\x25\xe1\x48\x66\xc2\xbd\xe9\x77\x3e\xe5\xf5\x47\x4d\x74\xa3\x64\x82\xab\xeb\xd1\xcd\x3a\x61\xcc\x94\x3c\x54\x2f\x76\x23\xe7\x1d\xba\x3f\xf2\x3c\xdf\xf7\xfd\x93\x21\x10\x6b\xb3\x23\xf8\x00\xd2\x9a\x02\xd6\xf4\x64\xf6\x50\x95\x3d\x6f\xc2\xe6\x8b\xd3\x1f\x14\x4d\x01\x57\x51\xca\xd3\x1b\x1a\xa7\x4c\x8f\xbc\xff\x03\x00\xe2\xe1\x99\xb3\xe7\x03\x00\x00"),
+		},
+		"/017_add_subscription_quantity.sql": &vfsgen۰CompressedFileInfo{
+			name:             "017_add_subscription_quantity.sql",
+			modTime:          time.Date(2026, 10, 17, 6, 1, 8, 19319401, time.UTC),
+			uncompressedSize: 1027,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x94\x93\x4b\x6f\xdb\x30\x10\x84\xef\xfc\x15\x73\xb4\x80\x1a\x4d\x7a\xe8\xc5\x68\x01\x9a\x5a\x27\x44\x68\xc9\xa0\x68\x20\xe9\x45\xd0\xab\x2d\x81\x46\x72\x25\xb9\x75\xff\x7d\x21\x3f\xa2\x47\x04\x0b\xd6\x55\xb3\xdf\xcc\xee\x72\xb9\x32\xa4\x61\xf8\x52\x11\x76\xa5\x4d\xb2\x0a\xdc\x75\x21\x7c\xb5\x5d\x7b\x78\xb5\x79\xf8\x7b\x1f\xe5\xb5\xad\xff\x61\x29\x1f\xa4\x67\xe0\xf9\x06\xde\x56\x29\xb8\xb4\xe2\x5b\x65\x70\x07\xf1\x48\xe2\x09\xb3\x9e\xfa\xeb\x17\xdc\x39\x0b\x36\xc1\x8f\x0e\xb7\xf0\xa3\xc3\x55\x7e\xb5\x8f\xab\xa4\xb4\xbb\xda\x16\x79\xcf\x66\xd2\xe2\xfe\x62\xd1\xc5\xdf\x3b\x0b\xc6\x84\x26\x6e\x68\xc4\xe0\x2d\x49\x98\xfc\x8c\xf2\x1f\x59\x85\x19\x03\x60\x53\xf4\xbe\xa5\x7c\x08\x48\x4b\xae\xb0\xd1\x72\xcd\xf5\x0b\x9e\xe8\xe5\x43\xa3\xec\xc1\x6c\x0a\xf1\xc8\xf5\xec\xd3\x67\xa7\x4d\xa7\x69\x45\x9a\x3c\x41\xc1\xa0\xb5\x99\x4d\x1d\xf8\x1e\x5c\x52\x64\x08\x82\x07\x82\xbb\x74\xa4\x7e\x2f\x8b\xd7\x76\x48\x18\x76\x7c\xd4\xd4\x45\x57\x31\xae\x39\x35\x95\x86\xf1\x49\xd2\xa6\xeb\x84\xda\x57\x59\xf9\x2e\x4c\x40\x23\x94\xa8\x3e\x51\x8c\x5c\x53\x60\xf8\x7a\x63\xbe\xbd\xd9\x31\x67\x71\x99\xb2\xf4\x5c\x7a\xbe\x3e\xe5\x70\x30\xb6\xd0\xa6\x87\xc6\x7d\x62\x35\x83\xaa\x66\xb3\xf3\xf9\x7c\x8e\xa4\xcc\xa2\x3a\x43\x14\x17\x7f\x32\x7c\x44\x5a\x16\x3b\xc4\xd9\xaf\xe2\x2f\x9a\xdf\x8c\xb9\xda\xdf\x9c\x97\x2f\x57\xa0\x67\x19\x98\x60\xc2\xeb\xbc\x8d\x6b\x4f\xf3\x48\x3d\xbf\xcd\x16\x7b\x21\x8d\x1e\xcd\x78\x49\xf7\x22\x6e\x29\xeb\x1c\xea\x82\xfd\x1f\x00\x3d\xa7\x45\xf0\x03\x04\x00\x00"),
+		},
+		"/018_add_subscription_pauses.sql": &vfsgen۰CompressedFileInfo{
+			name:             "018_add_subscription_pauses.sql",
+			modTime:          time.Date(2026, 10, 17, 6, 4, 35, 480520927, time.UTC),
+			uncompressedSize: 652,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x9c\xd0\xd1\x6a\x83\x30\x18\x05\xe0\x7b\x9f\xe2\xdc\xd9\xc2\x64\x0f\xe0\x95\xd3\x8c\xc9\xb4\x2d\x35\x83\xb2\x1b\x49\xe2\x3f\x1a\x6a\x8d\x24\xd1\xb1\xb7\x1f\x75\xac\x94\x8e\x95\xb6\xb7\x39\x1c\xbe\xf3\x27\x29\x38\x5b\x83\x27\x4f\x05\x83\x1b\xa4\x53\x56\xf7\x5e\x9b\xce\x21\xc9\x32\xa4\xcb\xe2\xad\x5c\xa0\x17\x83\xa3\x5a\xd2\x56\x8c\xda\x58\x70\xb6\xe1\x48\x5f\x58\xfa\x8a\xd9\x59\x94\x2f\x30\x0b\x47\xa3\x9b\xf0\x01\xe1\x5e\xd8\x5d\x3d\x74\xca\xb4\x2d\x29\xaf\x65\x4b\x87\xd7\x1d\x51\x5f\x0b\x57\x37\x56\x7c\xf8\x70\x3e\x8f\x83\xab\x36\x58\x72\xc3\xfe\x9f\x11\xe7\xd9\xb4\x62\x72\xd4\x97\xfa\x51\x2d\x89\x4e\x6d\x8d\xbd\x1e\x9c\x2e\x6b\x6a\xe1\xc1\xf3\x92\x55\x3c\x29\x57\xfc\xfd\xa6\xb1\xee\x4f\x39\x88\xa2\x28\x82\xb2\x24\x3c\x41\x48\x33\x12\x1e\xd1\x58\xd3\x43\x52\x6b\x3e\x71\x88\x83\x0b\x44\xb6\x5e\xae\x7e\x8d\xfc\x19\x6c\x93\x57\xbc\x3a\xd1\xe2\x9b\xbb\xc7\x2b\xe3\x3b\xd9\xe3\xaf\xdf\x69\x9f\xf4\xbf\x07\x00\x97\x46\x4b\xc2\x8c\x02\x00\x00"),
+		},
+		"/019_create_subscription_schedules.sql": &vfsgen۰CompressedFileInfo{
+			name:             "019_create_subscription_schedules.sql",
+			modTime:          time.Date(2026, 10, 17, 6, 7, 31, 179823725, time.UTC),
+			uncompressedSize: 852,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x94\x93\xc1\x8f\x9a\x40\x14\xc6\xef\xf3\x57\x7c\x37\x21\x59\xda\xa6\x87\x5e\x4c\x0f\xb3\xf0\xac\x74\x71\xb4\xc3\x98\xba\xbd\x90\x91\x99\xc4\x49\x58\x21\x32\xda\xf6\xbf\x6f\x54\xa8\xb2\x0d\x69\xd6\x9b\x79\xbf\xfc\xde\x07\xdf\x23\x96\xc4\x15\x41\xf1\xc7\x8c\xd0\x1e\xb7\x6d\x79\x70\x8d\x77\xf5\xbe\x68\xcb\x9d\x35\xc7\xca\xb6\x08\x18\x00\x67\x30\xf8\xc5\x73\x2e\x83\x8f\x9f\x42\xac\x64\xba\xe0\xf2\x19\x4f\xf4\xfc\x70\x06\x07\x12\x67\x6e\xa0\x58\x2a\x88\x75\x96\x41\xd2\x8c\x24\x89\x98\xf2\x01\xdc\x22\x70\x26\xc4\x52\x20\xa1\x8c\x14\x21\xe6\x79\xcc\x13\xba\x5a\xbd\xf6\xc7\xf6\xb6\x5e\xd1\x46\xdd\x8c\xf1\x9c\xe2\x27\x04\x1d\x94\x0a\x04\x13\x5d\x7a\x77\xb2\x93\x07\x4c\xca\xfa\xa5\xa9\xac\xb7\xe6\xf2\x47\xef\x4b\x5b\x59\x33\x09\xc3\x8b\xb7\xd9\xe9\xd6\xde\x79\xbf\xe6\x4b\xf1\xf8\x57\x7c\x87\x14\xba\x69\x2a\x67\x0d\x90\x0a\x45\x5f\x48\xde\xb6\x27\x34\xe3\xeb\x4c\xe1\xc3\x05\x2f\x0f\x56\x7b\x6b\x0a\xed\xbb\xa4\xe9\x82\x72\xc5\x17\x2b\xf5\x63\xe8\x3d\x36\x66\x0c\xbc\x8a\xfa\xdc\x1d\xf1\xcf\xbc\x7b\x94\x5e\x70\x37\x67\xe1\x94\x75\xcd\xa6\x22\xa1\xcd\x48\xb3\xc5\xab\xae\x0a\x67\x7e\x9d\xdf\xff\xd8\x1d\xbc\xc2\xc3\x29\x8b\x22\xf0\x01\x7e\x4e\x85\x7a\x5f\xfd\xc6\x4e\x9f\x2c\xea\xbd\xc5\xb5\x09\xf4\x1e\x68\x0f\x0d\xef\x5e\xec\xbb\x3e\xe3\x5a\xa4\xdf\xd6\xff\x89\x7a\xb5\xbc\x35\x21\xbe\xcf\x49\x52\x7f\x3d\x9f\xd1\x9f\xc5\x94\xb1\x28\x8a\xa2\xae\x2c\xe8\x6d\x7d\xb2\x78\x0f\x73\xa8\x1b\x6c\x6d\x55\xff\xc4\x79\xcc\x58\x22\x97\xab\xee\xe3\x48\x67\xa0\x4d\x9a\xab\x7c\x6c\x79\x77\xae\x53\xf6\x67\x00\x01\x0c\xcb\xf6\x54\x03\x00\x00"),
+		},
+		"/020_add_subscription_cancellations.sql": &vfsgen۰CompressedFileInfo{
+			name:             "020_add_subscription_cancellations.sql",
+			modTime:          time.Date(2026, 10, 17, 6, 10, 3, 18471686, time.UTC),
+			uncompressedSize: 945,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xa4\x93\x41\x8f\xda\x30\x10\x85\xef\xf9\x15\xef\x16\x90\x36\xed\x0f\xe0\x94\x25\x46\x1b\x35\x24\x2b\x62\x54\xd4\x8b\x65\x9c\x61\xb1\x1a\xec\xac\xed\x00\xfd\xf7\x55\xd2\x6d\x0b\x55\xb5\x12\xec\x31\x33\x2f\x9f\xdf\xbc\xb1\xd3\x82\xb3\x15\x78\xfa\x58\x30\xf8\x7e\xeb\x95\xd3\x5d\xd0\xd6\x78\xa4\x59\x86\x79\x55\xac\x97\x25\x94\x34\x8a\x5a\x21\x83\xe8\xc8\x69\xdb\x08\x32\x0d\x1e\xab\xaa\x60\x69\x89\xb2\xe2\x28\xd7\x45\x81\x8c\x2d\xd2\x75\xc1\xb1\x48\x8b\x9a\xcd\xa2\x5b\xc8\x8e\xa4\xb7\x06\x9c\x6d\x38\xe6\x4f\x6c\xfe\x05\x93\xeb\x4e\x5e\x62\x12\x07\x6b\x05\x9d\x3b\x32\x5e\x1f\x29\x7e\x40\x7c\xd0\xde\x6b\xf3\x22\x76\x24\x43\xef\xc8\x0f\x35\x7f\xd2\x41\xed\xa9\x11\x9e\xdc\x51\xab\x51\xd7\x9b\xde\x53\x13\x3f\x44\x00\x62\xd5\xfb\x60\x0f\xe4\x2e\x05\x03\x59\xd9\x43\xd7\xd2\x79\xf8\x6c\xed\x49\xbc\xf6\xb2\xd5\xe1\xc7\xd8\x75\x5a\xb6\xc2\xd8\x20\x94\x35\x47\x72\x61\x60\x21\xb6\x61\x4f\x2e\x9e\x4e\x6f\x1b\x75\x47\xd4\x6c\xa5\xfa\x3e\x0e\x7b\x6b\x4a\xaf\x3d\xf9\x40\x8d\x90\x01\x3c\x5f\xb2\x9a\xa7\xcb\x67\xfe\x6d\x16\x25\x09\xd6\x9e\x1a\xec\xac\x83\xda\xf7\xce\xc0\x51\x67\x5d\xd0\xe6\xe5\x53\x34\x5f\xb1\x94\x33\xe4\x65\xc6\x36\xd7\x67\x88\x5f\xe0\x11\x28\x74\x73\x46\x55\xfe\x63\x62\x72\xa1\x98\xe2\xeb\x13\x5b\x31\x5c\x94\x90\xd7\x7f\xf6\x3f\x8b\xa2\x24\x49\x12\x28\x47\x32\x10\xe4\xd6\x1e\x09\x9f\xd1\x38\xdb\x61\x4b\xad\x3d\x61\x68\x47\x51\xb6\xaa\x9e\xdf\xcc\xe4\x0b\xb0\x4d\x5e\xf3\xfa\x7d\x5b\xef\xa5\x34\xd2\xde\x62\xfa\x8b\xfb\x4f\x60\x77\x43\x7e\x2f\xec\x03\x2e\x86\x2b\x7c\xf7\xef\x57\xaf\x6e\x16\xfd\x1c\x00\x33\xb7\xc6\xda\xb1\x03\x00\x00"),
+		},
+		"/021_create_customer_balances.sql": &vfsgen۰CompressedFileInfo{
+			name:             "021_create_customer_balances.sql",
+			modTime:          time.Date(2026, 10, 17, 6, 15, 15, 329912700, time.UTC),
+			uncompressedSize: 3652,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xec\x96\x5f\x6f\xab\x36\x18\xc6\xef\xf9\x14\xcf\x45\xa7\x04\x89\x74\xdd\x26\x4d\x9a\x58\x8f\x44\xc1\x49\xd1\x21\x26\x02\x47\xa7\xdd\x0d\x72\xc0\x6d\xd9\x49\x80\x61\xd3\xae\xfb\xf4\x13\x7f\x92\x42\x4a\xb2\x9d\x73\xdd\xdc\xe1\xf7\xf9\x3d\x7e\x1d\xfb\x7d\xed\xd9\x0c\xec\x49\x40\x56\x3b\xe4\x0f\x10\x3c\x7e\x42\x5c\x49\x95\xef\x44\x39\x91\xd8\xf0\x2d\xcf\x62\x01\x55\xf2\x4c\xf2\x58\xa5\x79\x26\x0d\x14\xa2\x44\x5c\x95\xa5\xc8\xe2\xd7\x4b\x6d\x36\x83\x97\xc7\x5f\x45\x82\x97\xa7\x74\x2b\xc0\x8b\x62\xfb\x9a\x66\x8f\x88\x4b\x91\xa4\xca\x80\xcc\xa1\x9e\xb8\x42\x9c\x67\x2d\xa5\x50\x94\x79\x2c\xa4\x14\x12\x31\xcf\x26\xaa\xf6\x90\x85\xc8\x12\xa8\x3a\x17\xbe\x13\x1d\x0c\xf5\x92\xc6\xe2\x52\xb3\x03\x62\x31\x02\x66\xdd\x78\xe4\x90\x5f\xd4\x65\x27\x31\xd5\x80\xb7\xe1\x34\x81\x7d\x6b\x05\xd3\x9f\x7f\xd5\x41\x7d\x06\xba\xf6\x3c\x04\x64\x4e\x02\x42\x6d\x12\x1e\x84\x12\xd3\x34\xd1\xe1\x53\x38\xc4\x23\x8c\x20\x20\x21\x0b\x5c\x9b\x19\xad\x5d\xbb\x40\x00\xad\xdd\x2f\x6f\x6e\x8d\x80\xef\xf2\x2a\x53\x68\x7e\x74\xbd\x24\x81\x6b\x4f\x7f\xfa\xcd\xe8\x4f\x6a\xdf\x12\xfb\x33\xa6\x9d\xf2\xd3\x35\xae\xf4\x06\xad\x8a\x84\x2b\x91\x44\x5c\x01\xcc\x5d\x92\x90\x59\xcb\x15\xfb\x63\xe8\xbf\x0a\xdc\xa5\x15\xdc\xe3\x33\xb9\xc7\xb4\xb7\x38\xe3\x90\x9a\xae\xe9\xa6\xe6\xd2\x90\x04\x0c\x2e\x65\xfe\xd8\x3f\x33\x0a\x1a\x5d\xf2\x46\x2f\x13\xbd\x9e\x33\x24\x1e\xb1\x19\x4e\x40\xe1\x7a\xd9\xad\x45\x37\xb0\xb4\xee\xa6\x71\x29\xf6\x34\xe6\x81\xbf\x7c\x97\x40\xd4\x3f\x38\xf5\x04\x8b\xc0\x5f\xaf\x70\x73\x3f\x3e\x85\xa9\x69\x96\xc7\x48\x70\x62\xa3\x07\x6e\xb0\x1c\x07\xb6\x4f\x43\x16\x58\x2e\x65\xe7\xc5\x91\x7a\x2d\x44\x14\x3f\x89\xf8\xab\x06\xec\xf7\xa5\x1e\x84\x4b\x31\x9d\xb4\xa7\x2d\xca\x72\x25\x26\x06\x26\x45\x99\x97\xbc\x06\xeb\x0f\x9e\xfc\x59\x49\xb5\x13\x99\xaa\xbf\xd2\xec\x39\x4f\x63\x11\x15\xfc\x75\x3f\x94\x3f\x8b\x72\xff\xa9\xeb\x47\x6b\xe8\xf4\xfb\x74\xbd\xf5\x92\x76\x67\x3b\xaa\x0b\x25\x15\xc9\xa9\xc3\xe3\x90\xb9\xb5\xf6\x18\xae\xcc\xff\x34\x6c\x37\x25\x4a\x2a\x31\x34\x33\xb5\xf5\xca\xb1\x58\x0f\x0a\x09\xeb\xab\xaf\xb1\x68\x2a\x2b\x64\x53\x95\x2b\xbe\x35\x70\xa5\x9f\x9a\xae\x19\x7c\x3f\x61\xed\xb8\xcf\xd9\xd4\xf6\x95\xea\x07\x08\xc8\xca\xb3\x6c\x82\xf9\x9a\xda\xcc\xf5\xe9\xc1\xaa\xdd\x87\x28\xdd\xed\x2a\xc5\x37\x5b\x31\xd5\x11\x10\xb6\x0e\x68\x08\x55\xa6\x8f\x8f\xa2\x84\x15\xe2\xe2\x42\xbb\x21\x0b\x97\x6a\x00\xdc\x39\xd8\x22\xf2\x57\xb8\xc6\xa4\xad\xd5\x09\xd8\x2d\x69\x62\x6d\xd8\xf7\x9c\x4b\xa9\xb8\xaa\x24\x7e\xff\x84\x49\x52\xf2\x07\x35\xd0\x00\x08\x2c\x37\x24\x20\x77\x36\x59\x35\xf9\xec\xf7\x12\x3f\x20\x95\x78\x48\x33\xbe\x4d\xff\x11\xc9\xc4\x68\xdc\xd2\xc4\xec\x50\x42\x1d\xb8\xf3\xfd\x57\x9b\x6a\x2d\x31\xb5\x61\xf0\x64\x1a\x16\x75\xda\x16\x05\x80\x92\x2f\x97\xfd\x4e\xe5\x86\x70\xdc\x90\xb9\xd4\x66\x6d\x09\xd5\x16\x7d\x81\x1f\xf4\x48\x59\x6d\x64\x5c\xa6\x45\x7d\x36\x4f\xd2\xc7\xa2\x81\x43\x56\xed\x36\xa2\x1c\x07\xbb\xd8\x40\x7f\x68\x83\x27\x12\xed\xa2\x03\x46\xf1\xbf\x85\x1c\x07\xda\xd0\xf1\x9a\x9a\xb3\x77\x72\x31\x6d\x74\xc0\x24\xa9\x8c\x9b\x13\x78\x86\x3c\xd2\x1c\x67\x78\x0e\x7d\x0b\x0f\xa9\x33\xc4\x7b\xf5\x51\x95\x8f\xff\x7f\x43\xcd\x80\xef\xd5\xd8\x28\xdb\x8b\x0f\xb8\x42\x94\x69\x9e\x44\x52\xf1\x52\x8d\x93\x03\xc5\x18\x5b\xdf\xc4\xe7\xc8\x3a\x3e\xe0\x0e\xc5\x53\x5f\x69\xa3\x64\x5f\x51\x83\x7a\xbf\x38\xbf\xab\x32\x7b\x95\xd7\xd5\x24\x25\x5f\x4c\x8d\x50\xc7\xd4\x2e\x2e\xe0\x59\x74\xb1\xb6\x16\x04\xc5\xb6\x78\x94\x7f\x6d\x4d\x4d\x9b\xcd\x66\x33\xb4\x77\x16\xf8\x26\x7f\x16\xf8\x11\x49\x99\x17\xd8\x88\x6d\xfe\x82\x3a\xfc\xd1\xc0\x3e\x1a\xd8\x47\x03\xfb\x68\x24\x67\x1b\xc9\xe8\xfb\xc8\x09\xfc\xd5\xfe\x79\xe4\xce\x41\xee\xdc\x90\x85\xbd\x87\xd2\x37\x61\xc3\x7b\xc1\xfc\x86\x57\x71\xe7\x77\x78\x16\xf7\x3c\xff\xe7\x03\xd9\xd4\x1a\x8f\x76\xb2\xd3\xb8\x84\x6d\x85\xb6\xe5\x10\x53\xfb\x77\x00\x40\xf6\x61\x3a\x44\x0e\x00\x00"),
+		},
+		"/022_add_subscription_renewal_failures.sql": &vfsgen۰CompressedFileInfo{
+			name:             "022_add_subscription_renewal_failures.sql",
+			modTime:          time.Date(2026, 10, 17, 6, 25, 14, 965948344, time.UTC),
+			uncompressedSize: 433,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x94\xd0\xc1\x4a\xc4\x30\x10\x06\xe0\x7b\x9e\xe2\xbf\x79\x31\xfa\x00\x3d\x55\xdb\x85\x42\xeb\x2e\xdb\x08\xe2\xa5\x4c\xdb\xa9\x0d\xc6\xa4\x24\xa3\xeb\xe3\x8b\x0b\x3d\x48\x51\xdc\xe3\x30\xfc\xdf\x3f\x8c\xd6\xd8\x91\x75\x3c\x22\xb2\xe7\x13\xb9\x04\x8a\x8c\xc8\x12\x2d\x8f\x70\x24\x1c\xaf\x91\x02\x64\x26\x41\xf0\x8c\x89\xac\xb3\xfe\x45\x69\x8d\xf4\xde\xa7\x21\xda\x45\x6c\xf0\x18\xc8\x5f\x09\x7a\x17\x86\x57\xc8\xcc\xab\x87\x30\x9d\xc7\x20\x33\xc7\x74\xa3\xf2\xda\x94\x47\x98\xfc\xae\x2e\x7f\xe4\x13\xf2\xa2\xc0\xfd\xbe\x7e\x6c\x1e\xd6\x6c\x37\x9d\x4f\xeb\x48\x60\xaa\xa6\x6c\x4d\xde\x1c\xcc\x73\xf6\x3f\xc3\xf3\xa7\x74\x2b\x44\x22\xfc\xb6\xc8\x46\x52\x5a\x6b\x8d\x21\x32\x09\x83\xfa\xf0\xc1\xb8\xc5\x18\xc3\x82\x9e\x5d\x38\xe1\x7b\xad\xfe\xe8\x2b\x8e\xfb\xc3\x5a\x58\xed\x50\x3e\x55\xad\x69\x7f\xab\xce\x2e\x86\x36\x7f\xc8\xd4\xd7\x00\xe3\x3b\xb5\x12\xb1\x01\x00\x00"),
+		},
+		"/023_add_credit_note_refund_status.sql": &vfsgen۰CompressedFileInfo{
+			name:             "023_add_credit_note_refund_status.sql",
+			modTime:          time.Date(2026, 10, 17, 6, 28, 46, 17624816, time.UTC),
+			uncompressedSize: 1848,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xc4\x54\xc1\x92\xa2\x48\x10\xbd\xf3\x15\xef\xd0\x1b\x68\xc4\xe8\x7e\x00\x31\x07\x1a\x52\x9b\x58\x06\x0c\xc4\x68\x6f\x04\x52\xa9\xb2\x8d\x94\x5b\x55\x8e\xd3\x7f\xbf\x51\x25\xb3\x6a\x4f\xf4\xec\x4e\x5f\xf6\x46\x51\x95\x2f\xf3\xe5\x7b\x99\x93\x09\x0a\xde\x9e\x7a\xa1\x51\x2b\xc6\xa1\x16\x8c\x7a\x6b\x58\xc1\xec\x19\x8d\x62\xd1\x1a\xf4\xd2\x30\x5a\x0d\x6d\xa4\x62\xf1\x09\x5a\xc2\xec\x6b\x83\x1a\xdb\xba\xed\x4e\x8a\xbd\xc9\x04\x1b\x36\x67\xe6\xde\xc5\x99\xb3\x44\x53\xf7\xbe\x41\x27\x35\xc3\xa8\xba\x79\x81\xdc\xa2\x86\x72\xc9\xa6\x58\x70\x2f\xda\x7e\x37\x9c\x5d\x72\x0b\xa2\xd8\xa8\x96\x05\xce\xad\xd9\x3b\x24\x5d\x1f\x18\xad\xe0\xc3\x51\x1a\xee\x9b\x57\xbc\xf0\x2b\x4e\xbd\x69\x3b\x7b\xfd\x0a\x7d\x6a\x1a\x66\x01\xa9\x5c\x2d\x53\x2f\x4c\x4b\x2a\x50\x86\x8f\x29\x0d\xe5\x57\xb6\x7c\x8d\x30\x8e\x11\xe5\xe9\xea\x4b\x36\x24\xad\xb4\xa9\xcd\x49\xa3\xa4\x75\x89\x2c\x2f\x91\xad\xd2\x14\x31\xcd\xc2\x55\x5a\xc2\xf7\x3d\x00\xd1\x13\x45\x7f\x60\x74\x1f\x90\x64\x18\xf9\xfe\x27\xf8\xc7\x0b\x09\xfb\x39\xd4\xc1\xc2\x1e\x6c\x25\x2c\xfc\xf1\x38\xf8\x95\x72\x0e\xac\x75\xbd\xe3\x77\xeb\x09\xbc\xa8\xa0\xb0\x24\x24\x59\x4c\xeb\x3b\xb4\x6a\xa8\xa4\x1a\xa0\x5a\xf1\x0d\x79\x76\x9f\x70\xd4\x8a\x31\x9e\x9f\xa8\xa0\x37\xfc\x3f\x5f\x89\x04\x9e\x15\x21\xba\xaa\x7e\x71\x85\x36\x6d\xd7\xa1\xe7\xaf\xac\x20\xb8\x63\x63\x4d\x50\xf7\x02\xb2\xef\x5e\x9d\x4c\xf2\x64\x1a\x79\x70\x12\x3a\x99\x8f\x77\xf2\x5a\x2f\x60\xc3\x50\xdc\x48\x25\x58\x4c\xbf\x33\x99\xad\xb2\xa8\x4c\x6c\xa5\x7b\x6e\x5e\xaa\x9b\x7a\xab\xd3\x51\xd4\x86\x47\x63\x14\x54\xae\x8a\x6c\x09\xa3\xda\xdd\x8e\x15\xc2\x25\x1e\x1e\xbc\x47\x9a\x27\x99\x55\x28\x99\xa1\x9c\x57\xf9\xc2\xd2\x58\x2d\xe2\xb0\x24\x1f\x61\x16\x23\x4f\xe3\xe9\xbb\x3c\x6d\x20\xe0\xde\x8d\x8c\xac\xfe\xd4\xb2\xdf\x8c\x32\x7a\x1e\x63\x02\xff\x2e\xca\xbf\xf9\xd3\x8a\xdb\xd3\x20\x97\x3f\x1e\xc0\x00\x7c\xbe\x41\xcb\xd3\xf8\x43\x68\x28\x9f\x28\x1b\x20\x2f\xd4\x91\xd1\x73\x60\xff\x50\x16\x23\x99\xb9\xcf\x22\x4c\x96\x04\x5a\x47\xb4\x70\xfd\xf3\x7f\x83\x92\x67\xed\x86\xce\x37\xb6\xd7\x07\x29\xda\x6d\xeb\xfc\x58\xce\x2b\x67\xc0\x2a\x0b\xbf\x50\xe0\x51\x16\x07\xde\xc3\x03\xd2\x30\x9b\xaf\xc2\x39\xe1\xd8\x1d\x77\xfa\xaf\x2e\xf0\xbc\xb8\xc8\x17\x28\x8b\x64\x3e\xa7\xe2\xde\x60\xf5\xd1\xf6\xae\x72\x82\xbf\x71\x56\x60\x55\xa7\x6f\xad\x36\xb7\x03\x7d\xe6\xef\xeb\x64\xc3\x5b\xa9\xd8\xda\xa4\x55\xb7\x1b\x65\x78\x73\x59\x2a\x53\xef\x22\xde\x1d\x32\x96\x54\xfe\x68\xd6\xeb\xa8\x0d\x76\x3e\xb0\xd9\x4b\x61\xaf\x2e\x6f\xaf\x93\xf2\xaf\x54\x1e\x69\x96\x17\x84\x21\x77\x5e\x20\xa6\x94\x4a\x7a\x4b\xd1\xb6\x7c\x96\x17\xa0\x30\x7a\x42\x91\x3f\x83\xd6\x14\xad\xfe\x9b\x81\xdd\x4c\x4d\x26\x16\xaf\x36\x8c\x7a\x23\xbf\x32\x7e\x87\x50\xf2\x88\x0d\x77\xf2\x0c\x7b\xfd\xa6\xf7\xc9\x0c\xb4\x4e\x96\xe5\xf2\x17\x54\xf8\x9f\x38\xdf\x40\x5b\xb2\x8e\xc6\x3f\x4f\x6e\x78\xfc\xa4\x41\x2e\xe6\xb2\xd5\xde\x21\xfe\xe3\x7e\xfb\xc9\x6a\x75\x70\xc3\x6e\xbd\xe2\xdd\x0f\xda\x47\xc3\x2f\x36\x0c\xbc\xbf\x07\x00\x55\x56\xf4\xb8\x38\x07\x00\x00"),
+		},
+		"/024_add_credit_note_refund_amounts.sql": &vfsgen۰CompressedFileInfo{
+			name:             "024_add_credit_note_refund_amounts.sql",
+			modTime:          time.Date(2026, 10, 17, 6, 30, 57, 533968707, time.UTC),
+			uncompressedSize: 516,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8c\x90\xcf\x6a\xf2\x40\x14\xc5\xf7\x79\x8a\xb3\xf3\xfb\xc0\xe9\x9f\x4d\xa1\x88\x8b\xd4\x8c\x36\x10\xa3\x4c\x26\xb4\xbb\x30\x3a\x57\x13\x88\x99\x90\x5c\x2b\xbe\x7d\x49\x9a\x52\x14\x2a\xdd\xcd\x9c\x73\xef\xe1\x77\xae\x10\x50\xb4\x3b\x56\xb6\x85\x69\x08\x65\x71\x28\x98\x2c\xd8\x81\x73\x82\x39\xb8\x63\xc5\xa8\x4d\x61\xc1\x79\xe3\x8e\xfb\xbc\xd7\xf7\x86\xe9\x64\xce\x63\x4f\x88\xfe\xdf\x50\xcb\x70\xbb\xfe\xbd\x6d\xc8\x16\x8c\xca\x31\x81\x1d\x9b\x12\x45\x3b\x88\x3f\xc1\x1b\x53\x9a\x6a\x4b\x77\x9e\x1f\x69\xa9\xa0\xfd\x97\x48\x0e\x43\x59\xb7\xd9\xc2\x0f\x02\xcc\x56\x51\xba\x8c\xd1\xf4\x80\xd9\x00\x13\xa7\x4b\xa9\xc2\xd9\xbf\xc7\xe7\xf1\xd3\x7f\xc4\x2b\x8d\x38\x8d\x22\x04\x72\xee\xa7\x91\xc6\xc3\xe4\xf7\xcc\x20\x4c\x7a\x51\xab\x70\xb1\x90\xea\xc2\xcc\x4c\x5d\x53\x65\x33\x57\x95\xe7\x89\x97\xae\x03\x5f\x5f\x6d\x27\x52\x5f\xa1\x4c\x87\x82\x6f\xaf\x52\x49\x1c\x88\x73\x67\x31\xc5\xe8\x6b\x6a\x74\x83\x44\xc6\x7f\x04\xf1\x84\x10\xa2\xf3\x0d\x13\xcc\xc6\x7d\x10\xee\x61\x1b\x57\x63\x43\xa5\x3b\xa1\xb3\xbd\x1b\x8d\xd5\x6a\xfd\x7d\xc6\x70\x0e\xf9\x1e\x26\x3a\xb9\x6c\x31\xf1\x3e\x07\x00\x28\x0c\x82\xfc\x04\x02\x00\x00"),
+		},
+		"/025_add_pending_payment_attempts.sql": &vfsgen۰CompressedFileInfo{
+			name:             "025_add_pending_payment_attempts.sql",
+			modTime:          time.Date(2026, 10, 17, 6, 51, 28, 894041903, time.UTC),
+			uncompressedSize: 654,
+
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xd4\x90\xcd\x6a\xc3\x30\x10\x84\xef\x7a\x8a\xb9\x39\x81\x28\x7d\x80\xd0\x83\x1b\x1b\x12\x1a\x92\xe0\xb8\xf4\x18\x64\x69\x6b\x8b\x38\x92\x91\xd6\x35\x79\xfb\x62\x92\x96\x42\x7f\xe8\xb5\xc7\x9d\x1d\x66\xbf\x1d\x29\xb1\x57\x97\x33\x39\x8e\x18\x1a\xab\x1b\xa8\x40\x88\x6c\xdb\x16\x15\x59\x57\xa3\x0b\x5e\x53\x8c\x64\x30\xa1\x79\x3d\x47\xa5\xdc\x09\x86\x2a\xcb\x71\x3a\x9a\x85\x94\x08\xa4\x7d\x30\x64\xa0\x22\x3a\x72\xc6\xba\x7a\x06\xe5\x0c\xfa\xce\x28\x26\x03\xef\x34\x81\x1b\x42\xad\x98\x06\x75\xc1\xc9\xf9\x21\x8e\x8a\x0d\xf0\x3d\x6b\x7f\xa6\xb9\x48\x37\x65\x5e\xa0\x4c\x1f\x36\x39\xba\x2b\xd5\x51\x31\xd3\xb9\xe3\x88\xac\xd8\xed\xb1\xdc\x6d\x0f\x65\x91\xae\xb7\xe5\x17\xc3\x31\xb2\xe2\x3e\x1e\x75\x43\xfa\xb4\xf8\x3d\x2b\xcd\xb2\x3f\x47\x09\x00\xcb\x55\xbe\x7c\xc4\xe4\x2a\x63\xbd\xc5\x24\xb9\xbd\x99\xcc\x90\xc4\x5e\x6b\x22\x43\x66\x1c\x5e\x94\x6d\xc9\x24\xd3\xe9\x42\x08\x29\xa5\x84\x0e\xa4\x98\xa0\x2a\xff\x4a\xb8\x83\x09\xbe\x43\x45\xad\x1f\x30\xae\x85\x78\xda\x67\x69\xf9\x0d\xe3\x21\x2f\x71\x3b\x78\xff\x11\x8b\xe7\x55\x5e\xe4\x9f\xf4\x77\x8c\xc5\xbf\x2a\xef\xa7\xc6\xde\x06\x00\x17\x2a\xac\x74\x8e\x02\x00\x00"),
 		},
 	}
 	fs["/"].(*vfsgen۰DirInfo).entries = []os.FileInfo{
 		fs["/001_create_schema.sql"].(os.FileInfo),
+		fs["/002_create_settings.sql"].(os.FileInfo),
+		fs["/003_index_users_email.sql"].(os.FileInfo),
+		fs["/004_create_sessions.sql"].(os.FileInfo),
+		fs["/005_create_customers.sql"].(os.FileInfo),
+		fs["/006_create_catalog.sql"].(os.FileInfo),
+		fs["/007_create_subscriptions.sql"].(os.FileInfo),
+		fs["/008_create_invoices.sql"].(os.FileInfo),
+		fs["/009_create_sequences.sql"].(os.FileInfo),
+		fs["/010_create_payments.sql"].(os.FileInfo),
+		fs["/011_create_credit_notes.sql"].(os.FileInfo),
+		fs["/012_create_coupons.sql"].(os.FileInfo),
+		fs["/013_add_trials.sql"].(os.FileInfo),
+		fs["/014_create_usage.sql"].(os.FileInfo),
+		fs["/015_add_pricing_models.sql"].(os.FileInfo),
+		fs["/016_create_invoice_pending_lines.sql"].(os.FileInfo),
+		fs["/017_add_subscription_quantity.sql"].(os.FileInfo),
+		fs["/018_add_subscription_pauses.sql"].(os.FileInfo),
+		fs["/019_create_subscription_schedules.sql"].(os.FileInfo),
+		fs["/020_add_subscription_cancellations.sql"].(os.FileInfo),
+		fs["/021_create_customer_balances.sql"].(os.FileInfo),
+		fs["/022_add_subscription_renewal_failures.sql"].(os.FileInfo),
+		fs["/023_add_credit_note_refund_status.sql"].(os.FileInfo),
+		fs["/024_add_credit_note_refund_amounts.sql"].(os.FileInfo),
+		fs["/025_add_pending_payment_attempts.sql"].(os.FileInfo),
 	}
 
 	return fs
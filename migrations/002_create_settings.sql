@@ -0,0 +1,11 @@
+CREATE TABLE settings (
+   id        INTEGER PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+   version   INTEGER NOT NULL DEFAULT 1,
+   site_name TEXT NOT NULL,
+   timezone  TEXT NOT NULL,
+   currency  TEXT NOT NULL
+);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS settings CASCADE;
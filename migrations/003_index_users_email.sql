@@ -0,0 +1,7 @@
+ALTER TABLE users DROP CONSTRAINT users_email_key;
+CREATE UNIQUE INDEX users_email_idx ON users (lower(email));
+
+---- create above / drop below ----
+
+DROP INDEX IF EXISTS users_email_idx;
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
@@ -0,0 +1,12 @@
+CREATE TABLE sessions (
+   id         CHAR(64) PRIMARY KEY,
+   user_id    CHAR(26) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+   created_at TIMESTAMPTZ NOT NULL,
+   expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+CREATE INDEX sessions_expires_at_idx ON sessions (expires_at);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS sessions CASCADE;
@@ -0,0 +1,20 @@
+CREATE TABLE customers (
+   id         CHAR(26) PRIMARY KEY,
+   version    INTEGER NOT NULL DEFAULT 1,
+   email      TEXT NOT NULL,
+   name       TEXT NOT NULL DEFAULT '',
+   company    TEXT NOT NULL DEFAULT '',
+   address    JSONB NOT NULL DEFAULT '{}',
+   tax_ids    JSONB NOT NULL DEFAULT '[]',
+   currency   TEXT NOT NULL DEFAULT '',
+   timezone   TEXT NOT NULL DEFAULT '',
+   locale     TEXT NOT NULL DEFAULT '',
+   metadata   JSONB NOT NULL DEFAULT '{}',
+   created_at TIMESTAMPTZ NOT NULL,
+   updated_at TIMESTAMPTZ
+);
+CREATE INDEX customers_email_idx ON customers (lower(email));
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS customers CASCADE;
@@ -0,0 +1,27 @@
+CREATE TABLE products (
+   id          CHAR(26) PRIMARY KEY,
+   version     INTEGER NOT NULL DEFAULT 1,
+   name        TEXT NOT NULL,
+   description TEXT NOT NULL DEFAULT '',
+   created_at  TIMESTAMPTZ NOT NULL,
+   updated_at  TIMESTAMPTZ,
+   archived_at TIMESTAMPTZ
+);
+
+CREATE TABLE prices (
+   id             CHAR(26) PRIMARY KEY,
+   product_id     CHAR(26) NOT NULL REFERENCES products (id) ON DELETE RESTRICT,
+   nickname       TEXT NOT NULL DEFAULT '',
+   amount         NUMERIC(19, 6) NOT NULL CHECK (amount >= 0),
+   currency       CHAR(3) NOT NULL,
+   interval_unit  TEXT NOT NULL CHECK (interval_unit IN ('day', 'week', 'month', 'year')),
+   interval_count INTEGER NOT NULL CHECK (interval_count > 0),
+   created_at     TIMESTAMPTZ NOT NULL,
+   archived_at    TIMESTAMPTZ
+);
+CREATE INDEX prices_product_id_idx ON prices (product_id);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS prices CASCADE;
+DROP TABLE IF EXISTS products CASCADE;
@@ -0,0 +1,29 @@
+CREATE TABLE subscriptions (
+   id                   CHAR(26) PRIMARY KEY,
+   version              INTEGER NOT NULL DEFAULT 1,
+   customer_id          CHAR(26) NOT NULL REFERENCES customers (id) ON DELETE RESTRICT,
+   price_id             CHAR(26) NOT NULL REFERENCES prices (id) ON DELETE RESTRICT,
+   status               TEXT NOT NULL CHECK (status IN ('incomplete', 'trialing', 'active', 'past_due', 'paused', 'canceled')),
+   current_period_start TIMESTAMPTZ,
+   current_period_end   TIMESTAMPTZ,
+   created_at           TIMESTAMPTZ NOT NULL,
+   updated_at           TIMESTAMPTZ,
+   status_changed_at    TIMESTAMPTZ NOT NULL,
+   canceled_at          TIMESTAMPTZ
+);
+CREATE INDEX subscriptions_customer_id_idx ON subscriptions (customer_id);
+CREATE INDEX subscriptions_status_idx ON subscriptions (status);
+
+CREATE TABLE subscription_status_changes (
+   id              BIGSERIAL PRIMARY KEY,
+   subscription_id CHAR(26) NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+   from_status     TEXT,
+   to_status       TEXT NOT NULL,
+   changed_at      TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX subscription_status_changes_subscription_id_idx ON subscription_status_changes (subscription_id);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS subscription_status_changes CASCADE;
+DROP TABLE IF EXISTS subscriptions CASCADE;
@@ -0,0 +1,110 @@
+ALTER TABLE settings ADD COLUMN tax_rate TEXT NOT NULL DEFAULT '0';
+
+ALTER TABLE subscriptions ADD COLUMN billing_cycle_anchor TIMESTAMPTZ;
+ALTER TABLE subscriptions ADD COLUMN billing_cycle_day INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX subscriptions_current_period_end_idx ON subscriptions (current_period_end);
+
+CREATE TABLE invoices (
+   id                      CHAR(26) PRIMARY KEY,
+   version                 INTEGER NOT NULL DEFAULT 1,
+   customer_id             CHAR(26) NOT NULL REFERENCES customers (id) ON DELETE RESTRICT,
+   subscription_id         CHAR(26) REFERENCES subscriptions (id) ON DELETE RESTRICT,
+   number                  TEXT UNIQUE,
+   status                  TEXT NOT NULL CHECK (status IN ('draft', 'open', 'paid', 'void', 'uncollectible')),
+   currency                CHAR(3) NOT NULL,
+   taxes                   JSONB NOT NULL DEFAULT '[]',
+   subtotal                NUMERIC(19,6) NOT NULL,
+   discount_total          NUMERIC(19,6) NOT NULL,
+   tax_total               NUMERIC(19,6) NOT NULL,
+   total                   NUMERIC(19,6) NOT NULL,
+   period_start            TIMESTAMPTZ,
+   period_end              TIMESTAMPTZ,
+   created_at              TIMESTAMPTZ NOT NULL,
+   updated_at              TIMESTAMPTZ,
+   finalized_at            TIMESTAMPTZ,
+   paid_at                 TIMESTAMPTZ,
+   voided_at               TIMESTAMPTZ,
+   marked_uncollectible_at TIMESTAMPTZ
+);
+CREATE INDEX invoices_customer_id_idx ON invoices (customer_id);
+CREATE INDEX invoices_subscription_id_idx ON invoices (subscription_id);
+CREATE INDEX invoices_status_idx ON invoices (status);
+
+CREATE TABLE invoice_lines (
+   id           CHAR(26) PRIMARY KEY,
+   invoice_id   CHAR(26) NOT NULL REFERENCES invoices (id) ON DELETE CASCADE,
+   position     INTEGER NOT NULL,
+   type         TEXT NOT NULL,
+   description  TEXT NOT NULL,
+   price_id     CHAR(26) REFERENCES prices (id) ON DELETE RESTRICT,
+   quantity     BIGINT NOT NULL,
+   unit_amount  NUMERIC(19,6) NOT NULL,
+   amount       NUMERIC(19,6) NOT NULL,
+   tax_rate     TEXT NOT NULL DEFAULT '',
+   period_start TIMESTAMPTZ,
+   period_end   TIMESTAMPTZ
+);
+CREATE INDEX invoice_lines_invoice_id_idx ON invoice_lines (invoice_id, position);
+
+-- Finalized invoices are immutable, only their status (and the matching
+-- timestamps) can change. Enforced here in addition to the repository,
+-- to guard against manual changes.
+CREATE FUNCTION invoices_check_immutable() RETURNS trigger AS $$
+BEGIN
+   IF TG_OP = 'DELETE' THEN
+      IF OLD.status <> 'draft' THEN
+         RAISE EXCEPTION 'invoice % is finalized', OLD.id;
+      END IF;
+      RETURN OLD;
+   END IF;
+   IF OLD.status <> 'draft' AND (
+      NEW.customer_id IS DISTINCT FROM OLD.customer_id OR
+      NEW.subscription_id IS DISTINCT FROM OLD.subscription_id OR
+      NEW.number IS DISTINCT FROM OLD.number OR
+      NEW.currency IS DISTINCT FROM OLD.currency OR
+      NEW.taxes IS DISTINCT FROM OLD.taxes OR
+      NEW.subtotal IS DISTINCT FROM OLD.subtotal OR
+      NEW.discount_total IS DISTINCT FROM OLD.discount_total OR
+      NEW.tax_total IS DISTINCT FROM OLD.tax_total OR
+      NEW.total IS DISTINCT FROM OLD.total OR
+      NEW.period_start IS DISTINCT FROM OLD.period_start OR
+      NEW.period_end IS DISTINCT FROM OLD.period_end OR
+      NEW.finalized_at IS DISTINCT FROM OLD.finalized_at
+   ) THEN
+      RAISE EXCEPTION 'invoice % is finalized', OLD.id;
+   END IF;
+   RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER invoices_immutable BEFORE UPDATE OR DELETE ON invoices
+   FOR EACH ROW EXECUTE FUNCTION invoices_check_immutable();
+
+CREATE FUNCTION invoice_lines_check_immutable() RETURNS trigger AS $$
+DECLARE
+   invoice_status TEXT;
+BEGIN
+   SELECT status INTO invoice_status FROM invoices
+   WHERE id = COALESCE(NEW.invoice_id, OLD.invoice_id);
+   IF invoice_status <> 'draft' THEN
+      RAISE EXCEPTION 'invoice % is finalized', COALESCE(NEW.invoice_id, OLD.invoice_id);
+   END IF;
+   IF TG_OP = 'DELETE' THEN
+      RETURN OLD;
+   END IF;
+   RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER invoice_lines_immutable BEFORE INSERT OR UPDATE OR DELETE ON invoice_lines
+   FOR EACH ROW EXECUTE FUNCTION invoice_lines_check_immutable();
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS invoice_lines CASCADE;
+DROP TABLE IF EXISTS invoices CASCADE;
+DROP FUNCTION IF EXISTS invoice_lines_check_immutable();
+DROP FUNCTION IF EXISTS invoices_check_immutable();
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS billing_cycle_day;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS billing_cycle_anchor;
+ALTER TABLE settings DROP COLUMN IF EXISTS tax_rate;
@@ -0,0 +1,18 @@
+ALTER TABLE settings ADD COLUMN invoice_number_pattern TEXT NOT NULL DEFAULT 'INV-{YYYY}-{00000}';
+ALTER TABLE settings ADD COLUMN invoice_number_reset TEXT NOT NULL DEFAULT 'yearly'
+   CHECK (invoice_number_reset IN ('yearly', 'never'));
+
+-- Each row holds the last allocated value of a sequence.
+-- Sequences that never reset use year 0.
+CREATE TABLE sequences (
+   name       TEXT NOT NULL,
+   year       INTEGER NOT NULL,
+   last_value BIGINT NOT NULL,
+   PRIMARY KEY (name, year)
+);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS sequences CASCADE;
+ALTER TABLE settings DROP COLUMN IF EXISTS invoice_number_reset;
+ALTER TABLE settings DROP COLUMN IF EXISTS invoice_number_pattern;
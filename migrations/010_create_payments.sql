@@ -0,0 +1,59 @@
+-- Retries happen the given number of days after the first failed attempt.
+ALTER TABLE settings ADD COLUMN dunning_schedule INTEGER[] NOT NULL DEFAULT '{1,3,5,7}';
+ALTER TABLE settings ADD COLUMN dunning_final_status TEXT NOT NULL DEFAULT 'canceled'
+   CHECK (dunning_final_status IN ('canceled', 'unpaid'));
+
+ALTER TABLE subscriptions DROP CONSTRAINT subscriptions_status_check;
+ALTER TABLE subscriptions ADD CONSTRAINT subscriptions_status_check
+   CHECK (status IN ('incomplete', 'trialing', 'active', 'past_due', 'unpaid', 'paused', 'canceled'));
+
+ALTER TABLE invoices ADD COLUMN attempt_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN next_payment_attempt_at TIMESTAMPTZ;
+CREATE INDEX invoices_next_payment_attempt_at_idx ON invoices (next_payment_attempt_at)
+   WHERE next_payment_attempt_at IS NOT NULL;
+
+CREATE TABLE payment_methods (
+   id                 CHAR(26) PRIMARY KEY,
+   customer_id        CHAR(26) NOT NULL REFERENCES customers (id) ON DELETE CASCADE,
+   gateway            TEXT NOT NULL,
+   remote_id          TEXT NOT NULL,
+   remote_customer_id TEXT NOT NULL DEFAULT '',
+   type               TEXT NOT NULL,
+   brand              TEXT NOT NULL DEFAULT '',
+   last4              TEXT NOT NULL DEFAULT '',
+   exp_month          INTEGER NOT NULL DEFAULT 0,
+   exp_year           INTEGER NOT NULL DEFAULT 0,
+   is_default         BOOLEAN NOT NULL DEFAULT FALSE,
+   created_at         TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX payment_methods_customer_id_idx ON payment_methods (customer_id);
+CREATE UNIQUE INDEX payment_methods_default_idx ON payment_methods (customer_id) WHERE is_default;
+
+CREATE TABLE payment_attempts (
+   id                CHAR(26) PRIMARY KEY,
+   invoice_id        CHAR(26) NOT NULL REFERENCES invoices (id) ON DELETE RESTRICT,
+   payment_method_id CHAR(26) REFERENCES payment_methods (id) ON DELETE SET NULL,
+   number            INTEGER NOT NULL,
+   gateway           TEXT NOT NULL DEFAULT '',
+   remote_id         TEXT NOT NULL DEFAULT '',
+   status            TEXT NOT NULL CHECK (status IN ('succeeded', 'failed')),
+   amount            NUMERIC(19,6) NOT NULL,
+   currency          CHAR(3) NOT NULL,
+   decline_code      TEXT NOT NULL DEFAULT '',
+   message           TEXT NOT NULL DEFAULT '',
+   created_at        TIMESTAMPTZ NOT NULL,
+   UNIQUE (invoice_id, number)
+);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS payment_attempts CASCADE;
+DROP TABLE IF EXISTS payment_methods CASCADE;
+ALTER TABLE invoices DROP COLUMN IF EXISTS next_payment_attempt_at;
+ALTER TABLE invoices DROP COLUMN IF EXISTS attempt_count;
+UPDATE subscriptions SET status = 'past_due' WHERE status = 'unpaid';
+ALTER TABLE subscriptions DROP CONSTRAINT subscriptions_status_check;
+ALTER TABLE subscriptions ADD CONSTRAINT subscriptions_status_check
+   CHECK (status IN ('incomplete', 'trialing', 'active', 'past_due', 'paused', 'canceled'));
+ALTER TABLE settings DROP COLUMN IF EXISTS dunning_final_status;
+ALTER TABLE settings DROP COLUMN IF EXISTS dunning_schedule;
@@ -0,0 +1,70 @@
+ALTER TABLE settings ADD COLUMN credit_note_number_pattern TEXT NOT NULL DEFAULT 'CN-{YYYY}-{00000}';
+ALTER TABLE settings ADD COLUMN credit_note_number_reset TEXT NOT NULL DEFAULT 'yearly'
+   CHECK (credit_note_number_reset IN ('yearly', 'never'));
+
+CREATE TABLE credit_notes (
+   id          CHAR(26) PRIMARY KEY,
+   invoice_id  CHAR(26) NOT NULL REFERENCES invoices (id) ON DELETE RESTRICT,
+   customer_id CHAR(26) NOT NULL REFERENCES customers (id) ON DELETE RESTRICT,
+   number      TEXT NOT NULL UNIQUE,
+   method      TEXT NOT NULL CHECK (method IN ('refund', 'customer_balance')),
+   memo        TEXT NOT NULL DEFAULT '',
+   currency    CHAR(3) NOT NULL,
+   taxes       JSONB NOT NULL DEFAULT '[]',
+   subtotal    NUMERIC(19,6) NOT NULL,
+   tax_total   NUMERIC(19,6) NOT NULL,
+   total       NUMERIC(19,6) NOT NULL,
+   refund_id   TEXT NOT NULL DEFAULT '',
+   created_at  TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX credit_notes_invoice_id_idx ON credit_notes (invoice_id);
+CREATE INDEX credit_notes_customer_id_idx ON credit_notes (customer_id);
+
+CREATE TABLE credit_note_lines (
+   id              CHAR(26) PRIMARY KEY,
+   credit_note_id  CHAR(26) NOT NULL REFERENCES credit_notes (id) ON DELETE RESTRICT,
+   invoice_line_id CHAR(26) NOT NULL REFERENCES invoice_lines (id) ON DELETE RESTRICT,
+   position        INTEGER NOT NULL,
+   description     TEXT NOT NULL,
+   amount          NUMERIC(19,6) NOT NULL,
+   tax_rate        TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX credit_note_lines_credit_note_id_idx ON credit_note_lines (credit_note_id, position);
+
+-- Each row credits (positive amount) or debits (negative amount)
+-- the customer's balance in the given currency.
+CREATE TABLE customer_balance_transactions (
+   id             CHAR(26) PRIMARY KEY,
+   customer_id    CHAR(26) NOT NULL REFERENCES customers (id) ON DELETE RESTRICT,
+   type           TEXT NOT NULL,
+   amount         NUMERIC(19,6) NOT NULL,
+   currency       CHAR(3) NOT NULL,
+   credit_note_id CHAR(26) REFERENCES credit_notes (id) ON DELETE RESTRICT,
+   invoice_id     CHAR(26) REFERENCES invoices (id) ON DELETE RESTRICT,
+   description    TEXT NOT NULL DEFAULT '',
+   created_at     TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX customer_balance_transactions_customer_id_idx ON customer_balance_transactions (customer_id, currency);
+
+-- Credit notes and balance transactions are never modified or deleted.
+CREATE FUNCTION check_append_only() RETURNS trigger AS $$
+BEGIN
+   RAISE EXCEPTION '% rows can''t be modified', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER credit_notes_append_only BEFORE UPDATE OR DELETE ON credit_notes
+   FOR EACH ROW EXECUTE FUNCTION check_append_only();
+CREATE TRIGGER credit_note_lines_append_only BEFORE UPDATE OR DELETE ON credit_note_lines
+   FOR EACH ROW EXECUTE FUNCTION check_append_only();
+CREATE TRIGGER customer_balance_transactions_append_only BEFORE UPDATE OR DELETE ON customer_balance_transactions
+   FOR EACH ROW EXECUTE FUNCTION check_append_only();
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS customer_balance_transactions CASCADE;
+DROP TABLE IF EXISTS credit_note_lines CASCADE;
+DROP TABLE IF EXISTS credit_notes CASCADE;
+DROP FUNCTION IF EXISTS check_append_only();
+ALTER TABLE settings DROP COLUMN IF EXISTS credit_note_number_reset;
+ALTER TABLE settings DROP COLUMN IF EXISTS credit_note_number_pattern;
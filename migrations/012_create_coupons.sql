@@ -0,0 +1,34 @@
+CREATE TABLE coupons (
+   id               CHAR(26) PRIMARY KEY,
+   version          INTEGER NOT NULL DEFAULT 1,
+   code             TEXT NOT NULL,
+   name             TEXT NOT NULL,
+   type             TEXT NOT NULL CHECK (type IN ('percent', 'fixed')),
+   percent_off      NUMERIC(5, 2),
+   amounts_off      JSONB NOT NULL DEFAULT '[]',
+   duration         TEXT NOT NULL CHECK (duration IN ('once', 'repeating', 'forever')),
+   duration_periods INTEGER NOT NULL DEFAULT 0,
+   max_redemptions  INTEGER NOT NULL DEFAULT 0,
+   times_redeemed   INTEGER NOT NULL DEFAULT 0,
+   product_ids      JSONB NOT NULL DEFAULT '[]',
+   expires_at       TIMESTAMPTZ,
+   created_at       TIMESTAMPTZ NOT NULL,
+   updated_at       TIMESTAMPTZ,
+   CHECK (max_redemptions = 0 OR times_redeemed <= max_redemptions)
+);
+CREATE UNIQUE INDEX coupons_code_idx ON coupons (LOWER(code));
+
+CREATE TABLE coupon_redemptions (
+   id              CHAR(26) PRIMARY KEY,
+   coupon_id       CHAR(26) NOT NULL REFERENCES coupons (id) ON DELETE RESTRICT,
+   customer_id     CHAR(26) NOT NULL REFERENCES customers (id) ON DELETE RESTRICT,
+   subscription_id CHAR(26) NOT NULL UNIQUE REFERENCES subscriptions (id) ON DELETE CASCADE,
+   periods_applied INTEGER NOT NULL DEFAULT 0,
+   created_at      TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX coupon_redemptions_coupon_id_idx ON coupon_redemptions (coupon_id);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS coupon_redemptions CASCADE;
+DROP TABLE IF EXISTS coupons CASCADE;
@@ -0,0 +1,25 @@
+ALTER TABLE prices ADD COLUMN trial_days INTEGER NOT NULL DEFAULT 0 CHECK (trial_days >= 0);
+
+ALTER TABLE subscriptions ADD COLUMN trial_start TIMESTAMPTZ;
+ALTER TABLE subscriptions ADD COLUMN trial_end TIMESTAMPTZ;
+ALTER TABLE subscriptions ADD COLUMN trial_end_notified_at TIMESTAMPTZ;
+CREATE INDEX subscriptions_trial_end_idx ON subscriptions (trial_end) WHERE status = 'trialing';
+
+CREATE TABLE events (
+   id              CHAR(26) PRIMARY KEY,
+   type            TEXT NOT NULL,
+   customer_id     CHAR(26) NOT NULL REFERENCES customers (id) ON DELETE CASCADE,
+   subscription_id CHAR(26) REFERENCES subscriptions (id) ON DELETE CASCADE,
+   data            JSONB NOT NULL DEFAULT '{}',
+   created_at      TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX events_customer_id_idx ON events (customer_id);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS events CASCADE;
+DROP INDEX IF EXISTS subscriptions_trial_end_idx;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS trial_end_notified_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS trial_end;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS trial_start;
+ALTER TABLE prices DROP COLUMN IF EXISTS trial_days;
@@ -0,0 +1,41 @@
+CREATE TABLE meters (
+   id          CHAR(26) PRIMARY KEY,
+   key         TEXT NOT NULL UNIQUE,
+   name        TEXT NOT NULL,
+   aggregation TEXT NOT NULL CHECK (aggregation IN ('sum', 'max', 'last')),
+   created_at  TIMESTAMPTZ NOT NULL
+);
+
+ALTER TABLE prices ADD COLUMN meter_id CHAR(26) REFERENCES meters (id) ON DELETE RESTRICT;
+ALTER TABLE subscriptions ADD COLUMN metered_price_ids JSONB NOT NULL DEFAULT '[]';
+
+-- Partitioned by month, see meter.EnsurePartitions.
+CREATE TABLE usage_events (
+   id              CHAR(26) NOT NULL,
+   meter_id        CHAR(26) NOT NULL REFERENCES meters (id) ON DELETE RESTRICT,
+   customer_id     CHAR(26) NOT NULL REFERENCES customers (id) ON DELETE CASCADE,
+   quantity        BIGINT NOT NULL CHECK (quantity >= 0),
+   timestamp       TIMESTAMPTZ NOT NULL,
+   idempotency_key TEXT NOT NULL,
+   created_at      TIMESTAMPTZ NOT NULL,
+   PRIMARY KEY (id, timestamp)
+) PARTITION BY RANGE (timestamp);
+CREATE INDEX usage_events_meter_id_idx ON usage_events (meter_id, customer_id, timestamp);
+
+-- Unique constraints on partitioned tables must include the partition key,
+-- so idempotency keys are deduplicated in a separate table.
+CREATE TABLE usage_event_keys (
+   customer_id     CHAR(26) NOT NULL REFERENCES customers (id) ON DELETE CASCADE,
+   idempotency_key TEXT NOT NULL,
+   event_id        CHAR(26) NOT NULL,
+   timestamp       TIMESTAMPTZ NOT NULL,
+   PRIMARY KEY (customer_id, idempotency_key)
+);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS usage_event_keys CASCADE;
+DROP TABLE IF EXISTS usage_events CASCADE;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS metered_price_ids;
+ALTER TABLE prices DROP COLUMN IF EXISTS meter_id;
+DROP TABLE IF EXISTS meters CASCADE;
@@ -0,0 +1,12 @@
+ALTER TABLE prices ADD COLUMN model TEXT NOT NULL DEFAULT 'flat'
+   CHECK (model IN ('flat', 'package', 'graduated', 'volume', 'stairstep'));
+ALTER TABLE prices ADD COLUMN package_size BIGINT NOT NULL DEFAULT 0 CHECK (package_size >= 0);
+ALTER TABLE prices ADD COLUMN tiers JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE invoice_lines ADD COLUMN breakdown JSONB NOT NULL DEFAULT '[]';
+
+---- create above / drop below ----
+
+ALTER TABLE invoice_lines DROP COLUMN IF EXISTS breakdown;
+ALTER TABLE prices DROP COLUMN IF EXISTS tiers;
+ALTER TABLE prices DROP COLUMN IF EXISTS package_size;
+ALTER TABLE prices DROP COLUMN IF EXISTS model;
@@ -0,0 +1,24 @@
+-- Lines waiting to be added to the next invoice of a subscription,
+-- e.g. prorations. Removed once invoiced.
+CREATE TABLE invoice_pending_lines (
+   id              CHAR(26) PRIMARY KEY,
+   subscription_id CHAR(26) NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+   position        INTEGER NOT NULL,
+   type            TEXT NOT NULL,
+   description     TEXT NOT NULL,
+   price_id        CHAR(26) REFERENCES prices (id) ON DELETE RESTRICT,
+   quantity        BIGINT NOT NULL,
+   unit_amount     NUMERIC(19,6) NOT NULL,
+   amount          NUMERIC(19,6) NOT NULL,
+   currency        CHAR(3) NOT NULL,
+   tax_rate        TEXT NOT NULL DEFAULT '',
+   period_start    TIMESTAMPTZ,
+   period_end      TIMESTAMPTZ,
+   breakdown       JSONB NOT NULL DEFAULT '[]',
+   created_at      TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX invoice_pending_lines_subscription_id_idx ON invoice_pending_lines (subscription_id);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS invoice_pending_lines CASCADE;
@@ -0,0 +1,20 @@
+ALTER TABLE prices ADD COLUMN min_quantity BIGINT NOT NULL DEFAULT 0 CHECK (min_quantity >= 0);
+ALTER TABLE prices ADD COLUMN max_quantity BIGINT NOT NULL DEFAULT 0 CHECK (max_quantity >= 0);
+ALTER TABLE subscriptions ADD COLUMN quantity BIGINT NOT NULL DEFAULT 1 CHECK (quantity >= 1);
+
+CREATE TABLE subscription_quantity_changes (
+   id              BIGSERIAL PRIMARY KEY,
+   subscription_id CHAR(26) NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+   from_quantity   BIGINT NOT NULL,
+   to_quantity     BIGINT NOT NULL,
+   changed_by      CHAR(26) REFERENCES users (id) ON DELETE SET NULL,
+   changed_at      TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX subscription_quantity_changes_subscription_id_idx ON subscription_quantity_changes (subscription_id);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS subscription_quantity_changes CASCADE;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS quantity;
+ALTER TABLE prices DROP COLUMN IF EXISTS max_quantity;
+ALTER TABLE prices DROP COLUMN IF EXISTS min_quantity;
@@ -0,0 +1,11 @@
+ALTER TABLE subscriptions ADD COLUMN pause_behavior TEXT CHECK (pause_behavior IN ('void', 'mark_uncollectible', 'keep_as_draft'));
+ALTER TABLE subscriptions ADD COLUMN resume_behavior TEXT CHECK (resume_behavior IN ('keep_cycle', 'reanchor'));
+ALTER TABLE subscriptions ADD COLUMN paused_at TIMESTAMPTZ;
+ALTER TABLE subscriptions ADD COLUMN resumes_at TIMESTAMPTZ;
+
+---- create above / drop below ----
+
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS resumes_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS paused_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS resume_behavior;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS pause_behavior;
@@ -0,0 +1,18 @@
+CREATE TABLE subscription_schedules (
+   id              CHAR(26) PRIMARY KEY,
+   subscription_id CHAR(26) NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+   status          TEXT NOT NULL CHECK (status IN ('active', 'completed', 'canceled')),
+   phases          JSONB NOT NULL,
+   phases_applied  INTEGER NOT NULL DEFAULT 0,
+   created_at      TIMESTAMPTZ NOT NULL,
+   updated_at      TIMESTAMPTZ,
+   completed_at    TIMESTAMPTZ,
+   canceled_at     TIMESTAMPTZ
+);
+CREATE INDEX subscription_schedules_subscription_id_idx ON subscription_schedules (subscription_id);
+-- A subscription can only have one active schedule at a time.
+CREATE UNIQUE INDEX subscription_schedules_active_idx ON subscription_schedules (subscription_id) WHERE status = 'active';
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS subscription_schedules CASCADE;
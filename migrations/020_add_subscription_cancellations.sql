@@ -0,0 +1,15 @@
+ALTER TABLE subscriptions ADD COLUMN cancel_at_period_end BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE subscriptions ADD COLUMN cancel_reason TEXT CHECK (cancel_reason IN ('too_expensive', 'missing_features', 'switched_service', 'unused',
+   'customer_service', 'too_complex', 'low_quality', 'trial_not_converted', 'other'));
+ALTER TABLE subscriptions ADD COLUMN cancel_feedback TEXT;
+ALTER TABLE subscriptions ADD COLUMN cancel_requested_at TIMESTAMPTZ;
+-- Used for churn reporting.
+CREATE INDEX subscriptions_canceled_at_idx ON subscriptions (canceled_at) WHERE canceled_at IS NOT NULL;
+
+---- create above / drop below ----
+
+DROP INDEX IF EXISTS subscriptions_canceled_at_idx;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS cancel_requested_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS cancel_feedback;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS cancel_reason;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS cancel_at_period_end;
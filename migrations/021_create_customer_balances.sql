@@ -0,0 +1,85 @@
+-- The sum of each customer's balance transactions, per currency.
+-- Locked while applying credit, so that concurrent processes can't
+-- spend the same credit twice.
+CREATE TABLE customer_balances (
+   customer_id CHAR(26) NOT NULL REFERENCES customers (id) ON DELETE RESTRICT,
+   currency    CHAR(3) NOT NULL,
+   amount      NUMERIC(19,6) NOT NULL CHECK (amount >= 0),
+   updated_at  TIMESTAMPTZ NOT NULL,
+   PRIMARY KEY (customer_id, currency)
+);
+INSERT INTO customer_balances (customer_id, currency, amount, updated_at)
+   SELECT customer_id, currency, SUM(amount), MAX(created_at) FROM customer_balance_transactions
+   GROUP BY customer_id, currency;
+
+ALTER TABLE customer_balance_transactions ADD CONSTRAINT customer_balance_transactions_type_check
+   CHECK (type IN ('credit_note', 'proration', 'adjustment', 'invoice_payment', 'overpayment'));
+
+ALTER TABLE invoices ADD COLUMN credit_applied NUMERIC(19,6) NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN amount_due NUMERIC(19,6);
+UPDATE invoices SET amount_due = GREATEST(total, 0);
+ALTER TABLE invoices ALTER COLUMN amount_due SET NOT NULL;
+
+CREATE OR REPLACE FUNCTION invoices_check_immutable() RETURNS trigger AS $$
+BEGIN
+   IF TG_OP = 'DELETE' THEN
+      IF OLD.status <> 'draft' THEN
+         RAISE EXCEPTION 'invoice % is finalized', OLD.id;
+      END IF;
+      RETURN OLD;
+   END IF;
+   IF OLD.status <> 'draft' AND (
+      NEW.customer_id IS DISTINCT FROM OLD.customer_id OR
+      NEW.subscription_id IS DISTINCT FROM OLD.subscription_id OR
+      NEW.number IS DISTINCT FROM OLD.number OR
+      NEW.currency IS DISTINCT FROM OLD.currency OR
+      NEW.taxes IS DISTINCT FROM OLD.taxes OR
+      NEW.subtotal IS DISTINCT FROM OLD.subtotal OR
+      NEW.discount_total IS DISTINCT FROM OLD.discount_total OR
+      NEW.tax_total IS DISTINCT FROM OLD.tax_total OR
+      NEW.total IS DISTINCT FROM OLD.total OR
+      NEW.credit_applied IS DISTINCT FROM OLD.credit_applied OR
+      NEW.amount_due IS DISTINCT FROM OLD.amount_due OR
+      NEW.period_start IS DISTINCT FROM OLD.period_start OR
+      NEW.period_end IS DISTINCT FROM OLD.period_end OR
+      NEW.finalized_at IS DISTINCT FROM OLD.finalized_at
+   ) THEN
+      RAISE EXCEPTION 'invoice % is finalized', OLD.id;
+   END IF;
+   RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+---- create above / drop below ----
+
+CREATE OR REPLACE FUNCTION invoices_check_immutable() RETURNS trigger AS $$
+BEGIN
+   IF TG_OP = 'DELETE' THEN
+      IF OLD.status <> 'draft' THEN
+         RAISE EXCEPTION 'invoice % is finalized', OLD.id;
+      END IF;
+      RETURN OLD;
+   END IF;
+   IF OLD.status <> 'draft' AND (
+      NEW.customer_id IS DISTINCT FROM OLD.customer_id OR
+      NEW.subscription_id IS DISTINCT FROM OLD.subscription_id OR
+      NEW.number IS DISTINCT FROM OLD.number OR
+      NEW.currency IS DISTINCT FROM OLD.currency OR
+      NEW.taxes IS DISTINCT FROM OLD.taxes OR
+      NEW.subtotal IS DISTINCT FROM OLD.subtotal OR
+      NEW.discount_total IS DISTINCT FROM OLD.discount_total OR
+      NEW.tax_total IS DISTINCT FROM OLD.tax_total OR
+      NEW.total IS DISTINCT FROM OLD.total OR
+      NEW.period_start IS DISTINCT FROM OLD.period_start OR
+      NEW.period_end IS DISTINCT FROM OLD.period_end OR
+      NEW.finalized_at IS DISTINCT FROM OLD.finalized_at
+   ) THEN
+      RAISE EXCEPTION 'invoice % is finalized', OLD.id;
+   END IF;
+   RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+ALTER TABLE invoices DROP COLUMN IF EXISTS amount_due;
+ALTER TABLE invoices DROP COLUMN IF EXISTS credit_applied;
+ALTER TABLE customer_balance_transactions DROP CONSTRAINT IF EXISTS customer_balance_transactions_type_check;
+DROP TABLE IF EXISTS customer_balances CASCADE;
@@ -0,0 +1,9 @@
+-- Failed renewals are retried later, so that one failing
+-- subscription can't block the renewal of the others.
+ALTER TABLE subscriptions ADD COLUMN renewal_failed_at TIMESTAMPTZ;
+ALTER TABLE subscriptions ADD COLUMN next_renewal_attempt_at TIMESTAMPTZ;
+
+---- create above / drop below ----
+
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS next_renewal_attempt_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS renewal_failed_at;
@@ -0,0 +1,36 @@
+-- Refunds are made after the credit note is stored, so that a failure
+-- between the two can't lose track of a refund. Pending refunds are
+-- retried with the same idempotency key until they succeed or fail.
+ALTER TABLE credit_notes ADD COLUMN refund_status TEXT NOT NULL DEFAULT ''
+   CHECK (refund_status IN ('', 'pending', 'succeeded', 'failed'));
+ALTER TABLE credit_notes ADD COLUMN refund_message TEXT NOT NULL DEFAULT '';
+CREATE INDEX credit_notes_pending_refund_idx ON credit_notes (id) WHERE refund_status = 'pending';
+
+-- Credit notes are still never deleted, and only the outcome
+-- of a pending refund can be recorded.
+CREATE FUNCTION check_credit_note_update() RETURNS trigger AS $$
+BEGIN
+   IF TG_OP = 'UPDATE' AND OLD.refund_status = 'pending'
+      AND (to_jsonb(NEW) - 'refund_status' - 'refund_id' - 'refund_message')
+         = (to_jsonb(OLD) - 'refund_status' - 'refund_id' - 'refund_message') THEN
+      RETURN NEW;
+   END IF;
+   RAISE EXCEPTION '% rows can''t be modified', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER credit_notes_append_only ON credit_notes;
+-- Existing refunds were made before their credit notes were stored.
+UPDATE credit_notes SET refund_status = 'succeeded' WHERE method = 'refund';
+CREATE TRIGGER credit_notes_append_only BEFORE UPDATE OR DELETE ON credit_notes
+   FOR EACH ROW EXECUTE FUNCTION check_credit_note_update();
+
+---- create above / drop below ----
+
+DROP TRIGGER IF EXISTS credit_notes_append_only ON credit_notes;
+CREATE TRIGGER credit_notes_append_only BEFORE UPDATE OR DELETE ON credit_notes
+   FOR EACH ROW EXECUTE FUNCTION check_append_only();
+DROP FUNCTION IF EXISTS check_credit_note_update();
+DROP INDEX IF EXISTS credit_notes_pending_refund_idx;
+ALTER TABLE credit_notes DROP COLUMN IF EXISTS refund_message;
+ALTER TABLE credit_notes DROP COLUMN IF EXISTS refund_status;
@@ -0,0 +1,10 @@
+-- Refunds are limited to the amount paid through the gateway,
+-- the rest of the credit note total is credited to the balance.
+ALTER TABLE credit_notes ADD COLUMN refund_amount NUMERIC(19,6) NOT NULL DEFAULT 0;
+ALTER TABLE credit_notes DISABLE TRIGGER credit_notes_append_only;
+UPDATE credit_notes SET refund_amount = total WHERE method = 'refund';
+ALTER TABLE credit_notes ENABLE TRIGGER credit_notes_append_only;
+
+---- create above / drop below ----
+
+ALTER TABLE credit_notes DROP COLUMN IF EXISTS refund_amount;
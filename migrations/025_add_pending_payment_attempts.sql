@@ -0,0 +1,12 @@
+-- Payments which are still being processed (e.g. bank debits) are
+-- recorded as pending, and updated once the gateway knows their outcome.
+ALTER TABLE payment_attempts DROP CONSTRAINT payment_attempts_status_check;
+ALTER TABLE payment_attempts ADD CONSTRAINT payment_attempts_status_check
+   CHECK (status IN ('pending', 'succeeded', 'failed'));
+
+---- create above / drop below ----
+
+UPDATE payment_attempts SET status = 'failed' WHERE status = 'pending';
+ALTER TABLE payment_attempts DROP CONSTRAINT payment_attempts_status_check;
+ALTER TABLE payment_attempts ADD CONSTRAINT payment_attempts_status_check
+   CHECK (status IN ('succeeded', 'failed'));
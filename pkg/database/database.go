@@ -0,0 +1,88 @@
+// Copyright (c) 2020 Bojan Zivanovic and contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package database provides PostgreSQL helpers.
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// codeUniqueViolation is the PostgreSQL error code for unique violations.
+const codeUniqueViolation = "23505"
+
+// codeCheckViolation is the PostgreSQL error code for check constraint violations.
+const codeCheckViolation = "23514"
+
+// Querier executes queries.
+//
+// Implemented by *pgxpool.Pool, *pgxpool.Conn and pgx.Tx, allowing
+// repositories to work both inside and outside of transactions.
+// Repositories needing to make several writes atomically can pass
+// the Querier to WithTx, which starts a savepoint inside an
+// existing transaction.
+type Querier interface {
+	Beginner
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// Beginner starts transactions.
+//
+// Implemented by *pgxpool.Pool and pgx.Tx (which starts a savepoint).
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// WithTx runs the given function inside a transaction.
+//
+// The transaction is committed if the function returns nil,
+// and rolled back otherwise.
+func WithTx(ctx context.Context, db Beginner, fn func(tx pgx.Tx) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// IsUniqueViolation returns whether the given error is a unique violation.
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == codeUniqueViolation
+}
+
+// IsCheckViolation returns whether the given error is a violation
+// of the given check constraint.
+func IsCheckViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == codeCheckViolation && pgErr.ConstraintName == constraint
+}
+
+// NullTime converts a zero time to nil, for use with nullable columns.
+func NullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// TimeValue converts a nil time to the zero time.
+func TimeValue(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
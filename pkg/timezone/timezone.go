@@ -1,11 +1,12 @@
 // Copyright (c) 2020 Bojan Zivanovic and contributors
 // SPDX-License-Identifier: Apache-2.0
 
-// Package timezone provides a list of timezones.
+// Package timezone provides a list of timezones and wall clock helpers.
 package timezone
 
 import (
 	"sort"
+	"time"
 )
 
 // GetNames returns a list of timezone names.
@@ -27,3 +28,12 @@ func IsValid(name string) bool {
 
 	return names[i] == name
 }
+
+// AddDays adds the given number of days to t on the wall clock of the given location.
+//
+// The local time of day is kept across DST transitions, so a 14 day
+// trial starting at 10:00 ends at 10:00, even if the day it ends on
+// is 23 or 25 hours apart from the day it started on.
+func AddDays(t time.Time, days int, loc *time.Location) time.Time {
+	return t.In(loc).AddDate(0, 0, days)
+}
@@ -5,6 +5,7 @@ package timezone_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/runbilliam/billiam/pkg/timezone"
 )
@@ -39,3 +40,27 @@ func TestIsValid(t *testing.T) {
 		})
 	}
 }
+
+func TestAddDays(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	tests := []struct {
+		t    time.Time
+		days int
+		want time.Time
+	}{
+		{time.Date(2020, 11, 1, 10, 0, 0, 0, berlin), 14, time.Date(2020, 11, 15, 10, 0, 0, 0, berlin)},
+		// Across the DST change on October 25th, the local time is kept.
+		{time.Date(2020, 10, 20, 10, 0, 0, 0, berlin), 7, time.Date(2020, 10, 27, 10, 0, 0, 0, berlin)},
+		// The given time is converted to the location first.
+		{time.Date(2020, 3, 28, 23, 30, 0, 0, time.UTC), 1, time.Date(2020, 3, 30, 0, 30, 0, 0, berlin)},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got := timezone.AddDays(tt.t, tt.days, berlin)
+			if !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -8,6 +8,9 @@ import "regexp"
 // https://html.spec.whatwg.org/multipage/forms.html#valid-e-mail-address
 var rxEmail = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
 
+// Unicode locale identifier in canonical form, e.g. "en", "fr-CA", "sr-Latn-RS", "es-419".
+var rxLocale = regexp.MustCompile("^[a-z]{2,3}(?:-[A-Z][a-z]{3})?(?:-(?:[A-Z]{2}|[0-9]{3}))?$")
+
 // CheckEmail checks whether the given email is valid.
 //
 // An empty email is considered valid.
@@ -17,3 +20,14 @@ func CheckEmail(email string) bool {
 	}
 	return len(email) <= 254 && rxEmail.MatchString(email)
 }
+
+// CheckLocale checks whether the given locale ID is valid.
+//
+// The locale ID must be in canonical form ("sr-Latn-RS", not "sr_latn_rs").
+// An empty locale ID is considered valid.
+func CheckLocale(locale string) bool {
+	if locale == "" {
+		return true
+	}
+	return rxLocale.MatchString(locale)
+}
@@ -49,3 +49,34 @@ func TestCheckEmail(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   bool
+	}{
+		{"", true},
+		{"e", false},
+		{"EN", false},
+		{"en_US", false},
+		{"en-us", false},
+		{"sr-latn-rs", false},
+		{"en-US-", false},
+
+		{"en", true},
+		{"fil", true},
+		{"fr-CA", true},
+		{"es-419", true},
+		{"sr-Latn", true},
+		{"sr-Latn-RS", true},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got := validation.CheckLocale(tt.locale)
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -13,6 +13,12 @@ const (
 	CodeInvalidChoice = "invalid_choice"
 	// CodeNotUnique is used for values that are not unique (e.g. email in use).
 	CodeNotUnique = "not_unique"
+	// CodeTooWeak is used for values that don't meet strength requirements (e.g. passwords).
+	CodeTooWeak = "too_weak"
+	// CodeExpired is used for values that are no longer valid (e.g. expired coupons).
+	CodeExpired = "expired"
+	// CodeLimitReached is used for values that have been used up (e.g. fully redeemed coupons).
+	CodeLimitReached = "limit_reached"
 )
 
 // Error represents a validation error.
@@ -45,6 +51,21 @@ func NotUnique(message string) Error {
 	return Error{CodeNotUnique, message}
 }
 
+// TooWeak creates a too weak error.
+func TooWeak(message string) Error {
+	return Error{CodeTooWeak, message}
+}
+
+// Expired creates an expired error.
+func Expired(message string) Error {
+	return Error{CodeExpired, message}
+}
+
+// LimitReached creates a limit reached error.
+func LimitReached(message string) Error {
+	return Error{CodeLimitReached, message}
+}
+
 // Errors maps a field path to a list of errors.
 type Errors map[string][]error
 
@@ -4,21 +4,27 @@
 package setup
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
+	"sync/atomic"
 
 	"github.com/aymerick/raymond"
 	"github.com/bojanz/currency"
 	"github.com/go-chi/chi"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/rs/zerolog"
 	"github.com/shurcooL/httpfs/filter"
 	"github.com/shurcooL/httpfs/vfsutil"
 
+	"github.com/runbilliam/billiam/auth"
 	"github.com/runbilliam/billiam/internal/settings"
 	"github.com/runbilliam/billiam/internal/user"
+	"github.com/runbilliam/billiam/pkg/database"
 	"github.com/runbilliam/billiam/pkg/timezone"
 	"github.com/runbilliam/billiam/pkg/validation"
 )
@@ -33,19 +39,28 @@ type data struct {
 
 // Handler handles setup routes.
 type Handler struct {
-	logger *zerolog.Logger
+	logger         *zerolog.Logger
+	db             *pgxpool.Pool
+	passwordParams user.PasswordParams
+	passwordPolicy user.PasswordPolicy
+	// installed is set to 1 once setup has been completed.
+	installed int32
 }
 
 // NewHandler creates a new setup handler.
-func NewHandler(logger *zerolog.Logger) *Handler {
+func NewHandler(logger *zerolog.Logger, db *pgxpool.Pool, passwordParams user.PasswordParams, passwordPolicy user.PasswordPolicy) *Handler {
 	h := Handler{
-		logger: logger,
+		logger:         logger,
+		db:             db,
+		passwordParams: passwordParams,
+		passwordPolicy: passwordPolicy,
 	}
 	return &h
 }
 
 // Routes attaches setup routes to the router.
 func (h *Handler) Routes(r chi.Router) {
+	r.Use(h.redirectIfInstalled)
 	r.Get("/", h.Setup)
 	r.Post("/", h.SubmitSetup)
 	r.Get("/assets/*", h.ServeAssets)
@@ -74,6 +89,9 @@ func (h *Handler) SubmitSetup(w http.ResponseWriter, r *http.Request) {
 	u.Timezone = r.PostFormValue("timezone")
 	u.Active = true
 	errs := u.Validate()
+	if errs.Get("password") == nil {
+		errs.Merge("", h.passwordPolicy.Check(u.Password))
+	}
 
 	s := settings.New()
 	s.Timezone = r.PostFormValue("timezone")
@@ -92,8 +110,35 @@ func (h *Handler) SubmitSetup(w http.ResponseWriter, r *http.Request) {
 		h.render(w, "setup.html.hbs", data)
 		return
 	}
+	hash, err := user.HashPassword(u.Password, h.passwordParams)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	u.Password = hash
+
+	ctx := r.Context()
+	err = database.WithTx(ctx, h.db, func(tx pgx.Tx) error {
+		// Settings are created first, so that concurrent submits
+		// fail on the settings row instead of the users table.
+		if err := settings.NewStore(tx).Create(ctx, s); err != nil {
+			return err
+		}
+		return user.NewRepository(tx).Create(ctx, u)
+	})
+	if errors.Is(err, settings.ErrExists) {
+		// A concurrent submit completed setup first, and this
+		// submit's user was rolled back along with the settings.
+		atomic.StoreInt32(&h.installed, 1)
+		http.Redirect(w, r, auth.LoginPath+"?notice="+auth.NoticeSetupCompleted, http.StatusSeeOther)
+		return
+	} else if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	atomic.StoreInt32(&h.installed, 1)
 
-	w.Write([]byte("Setup complete!"))
+	http.Redirect(w, r, auth.LoginPath, http.StatusSeeOther)
 }
 
 // ServeAssets serves assets.
@@ -108,6 +153,39 @@ func (h *Handler) ServeAssets(w http.ResponseWriter, r *http.Request) {
 	fsHandler.ServeHTTP(w, r)
 }
 
+// redirectIfInstalled prevents access to setup once it has been completed.
+func (h *Handler) redirectIfInstalled(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		installed, err := h.isInstalled(r)
+		if err != nil {
+			h.handleError(w, err)
+			return
+		}
+		if installed {
+			http.Redirect(w, r, auth.LoginPath, http.StatusSeeOther)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isInstalled returns whether setup has been completed.
+func (h *Handler) isInstalled(r *http.Request) (bool, error) {
+	if atomic.LoadInt32(&h.installed) == 1 {
+		return true, nil
+	}
+	_, err := settings.NewStore(h.db).Get(r.Context())
+	if err != nil {
+		if errors.Is(err, settings.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	atomic.StoreInt32(&h.installed, 1)
+
+	return true, nil
+}
+
 func (h *Handler) render(w http.ResponseWriter, filename string, data interface{}) {
 	b, err := vfsutil.ReadFile(Assets, "templates/"+filename)
 	if err != nil {